@@ -0,0 +1,75 @@
+package main
+
+import "fmt"
+
+// attestationField holds the attestation of a result.
+const attestationField = "attestation"
+
+// Attestation records, in the result of a task, what exactly produced its
+// output: the model the provider reports having served, the API version, the
+// parameters the provider was sent and the hashes of the prompt inputs of the
+// operator. It is part of the canonical result and so of the result digest
+// operators sign, which settles disputes about which model an operator ran
+// from the result itself:
+//
+//	"attestation": {
+//	  "provider":             "openai",
+//	  "model":                "gpt-4o-mini-2024-07-18",
+//	  "parameters":           {"model": "gpt-4o-mini", "max_tokens": 1024, "temperature": 0.2},
+//	  "prompt_template_hash": "0x…", // keccak256 of the template source
+//	  "system_prompt_hash":   "0x…",
+//	  "examples_hash":        "0x…"
+//	}
+//
+// Tasks whose output comes from several provider calls attest the last one.
+type Attestation struct {
+	Provider   string                `json:"provider"`
+	Model      string                `json:"model"`
+	APIVersion string                `json:"api_version,omitempty"`
+	Parameters *CompletionParameters `json:"parameters,omitempty"`
+
+	PromptTemplateHash string `json:"prompt_template_hash,omitempty"`
+	SystemPromptHash   string `json:"system_prompt_hash,omitempty"`
+	ExamplesHash       string `json:"examples_hash,omitempty"`
+}
+
+// newAttestation returns the attestation of a completion served by provider,
+// or nil when the completion was not produced by a provider.
+func newAttestation(provider Provider, completion *CompletionResponse) *Attestation {
+	if completion.Parameters == nil {
+		return nil
+	}
+	servedBy := completion.Provider
+	if servedBy == "" {
+		servedBy = provider.Name()
+	}
+	model := completion.Model
+	if model == "" {
+		model = completion.Parameters.Model
+	}
+	return &Attestation{
+		Provider:   servedBy,
+		Model:      model,
+		APIVersion: completion.APIVersion,
+		Parameters: completion.Parameters,
+	}
+}
+
+// validateAttestation checks the attestation of a result.
+func validateAttestation(v interface{}) error {
+	attestation, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s field must be an object", attestationField)
+	}
+	for _, field := range []string{"provider", "model"} {
+		if s, ok := attestation[field].(string); !ok || s == "" {
+			return fmt.Errorf("%s.%s field must be a non-empty string", attestationField, field)
+		}
+	}
+	if params, exists := attestation["parameters"]; exists {
+		if _, ok := params.(map[string]interface{}); !ok {
+			return fmt.Errorf("%s.parameters field must be an object", attestationField)
+		}
+	}
+	return nil
+}
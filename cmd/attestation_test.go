@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_newAttestation(t *testing.T) {
+	params := &CompletionParameters{Model: "gpt-4o-mini", MaxTokens: 100, Temperature: 0.2}
+
+	tests := []struct {
+		name       string
+		completion *CompletionResponse
+		want       *Attestation
+	}{
+		{name: "not from a provider", completion: &CompletionResponse{Output: "4", Model: "stub-model"}},
+		{
+			name:       "reported model",
+			completion: &CompletionResponse{Model: "gpt-4o-mini-2024-07-18", APIVersion: "2024-06-01", Parameters: params},
+			want:       &Attestation{Provider: "stub", Model: "gpt-4o-mini-2024-07-18", APIVersion: "2024-06-01", Parameters: params},
+		},
+		{
+			name:       "requested model",
+			completion: &CompletionResponse{Parameters: params},
+			want:       &Attestation{Provider: "stub", Model: "gpt-4o-mini", Parameters: params},
+		},
+		{
+			name:       "served by another provider",
+			completion: &CompletionResponse{Model: "claude-3-5-haiku-20241022", Provider: "anthropic", Parameters: params},
+			want:       &Attestation{Provider: "anthropic", Model: "claude-3-5-haiku-20241022", Parameters: params},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := json.Marshal(newAttestation(&stubProvider{}, tt.completion))
+			want, _ := json.Marshal(tt.want)
+			if string(got) != string(want) {
+				t.Errorf("expected %s, got %s", want, got)
+			}
+		})
+	}
+}
+
+func Test_validateAttestation(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		wantErr bool
+	}{
+		{name: "valid", value: map[string]interface{}{"provider": "openai", "model": "gpt-4o-mini", "parameters": map[string]interface{}{"max_tokens": 100.0}}},
+		{name: "not an object", value: "openai", wantErr: true},
+		{name: "missing model", value: map[string]interface{}{"provider": "openai"}, wantErr: true},
+		{name: "empty provider", value: map[string]interface{}{"provider": "", "model": "m"}, wantErr: true},
+		{name: "invalid parameters", value: map[string]interface{}{"provider": "openai", "model": "m", "parameters": 1.0}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateAttestation(tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_HandleTaskAttestation(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), NewSystemPromptProvider(NewMockProvider(&MockConfig{}), "Answer briefly."), nil)
+	req := &performerV1.TaskRequest{
+		TaskId:  []byte("task-1"),
+		Payload: []byte(`{"template":"qa","template_version":1,"variables":{"context":"Paris is the capital of France.","question":"What is the capital of France?"},"temperature":0.5}`),
+	}
+	if err := tw.ValidateTask(req); err != nil {
+		t.Fatalf("ValidateTask failed: %v", err)
+	}
+	resp, err := tw.HandleTask(req)
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+
+	var result struct {
+		Attestation Attestation `json:"attestation"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	got := result.Attestation
+	if got.Provider != "mock" || got.Model != defaultMockModel || got.Parameters == nil || got.Parameters.Temperature != 0.5 {
+		t.Errorf("unexpected attestation: %s", resp.Result)
+	}
+	if got.PromptTemplateHash != mustBuiltinPromptTemplates().Hash("qa", 1) || got.SystemPromptHash == "" {
+		t.Errorf("unexpected prompt hashes: %s", resp.Result)
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+)
+
+// Sources of audited results.
+const (
+	auditSourceHandled = "handled"
+	auditSourceCache   = "cache"
+)
+
+// maxAuditRecordSize bounds the records read back from an audit log file.
+const maxAuditRecordSize = 1 << 20
+
+// AuditRecord is an entry of the audit log. Each record carries the hash of
+// the one before it, so removing or altering a record breaks the chain.
+type AuditRecord struct {
+	Seq    uint64    `json:"seq"`
+	Time   time.Time `json:"time"`
+	TaskID string    `json:"task_id"`
+	// PayloadHash is the keccak256 hash of the task payload as received.
+	PayloadHash string `json:"payload_hash"`
+	Provider    string `json:"provider,omitempty"`
+	Model       string `json:"model,omitempty"`
+	// Source tells whether the result was handled or served from the
+	// result cache.
+	Source string `json:"source,omitempty"`
+	// ResultHash is the keccak256 hash of the canonical result.
+	ResultHash string `json:"result_hash,omitempty"`
+	Verified   *bool  `json:"verified,omitempty"`
+	ErrorCode  string `json:"error_code,omitempty"`
+	// Rejection is why the task failed validation, if it did.
+	Rejection string `json:"rejection,omitempty"`
+	PrevHash  string `json:"prev_hash"`
+	Hash      string `json:"hash,omitempty"`
+}
+
+// hash is the keccak256 hash of the record without its own hash.
+func (r AuditRecord) hash() string {
+	r.Hash = ""
+	body, _ := json.Marshal(r)
+	return crypto.Keccak256Hash(body).Hex()
+}
+
+// AuditLog appends a hash-chained record of every task received, its result
+// and verification verdict to a JSON lines file and posts it to a webhook,
+// as evidence in disputes over the results of the performer.
+type AuditLog struct {
+	logger  *zap.Logger
+	webhook string
+	client  *http.Client
+	now     func() time.Time
+	// posts tracks the records being posted to the webhook.
+	posts sync.WaitGroup
+
+	mu   sync.Mutex
+	file io.Writer
+	seq  uint64
+	prev string
+}
+
+// auditLogFromEnv appends the audit log to AUDIT_LOG_FILE and posts its
+// records to AUDIT_LOG_URL, and returns nil when neither is set. The chain of
+// an existing file is continued.
+func auditLogFromEnv(logger *zap.Logger) (*AuditLog, error) {
+	path, webhook := getenv("AUDIT_LOG_FILE"), getenv("AUDIT_LOG_URL")
+	if path == "" && webhook == "" {
+		return nil, nil
+	}
+	var file io.Writer
+	var last *AuditRecord
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open AUDIT_LOG_FILE: %w", err)
+		}
+		if last, err = VerifyAuditLog(f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("AUDIT_LOG_FILE %s: %w", path, err)
+		}
+		file = f
+	}
+	a := NewAuditLog(logger, file, webhook)
+	if last != nil {
+		a.seq, a.prev = last.Seq, last.Hash
+	}
+	return a, nil
+}
+
+func NewAuditLog(logger *zap.Logger, file io.Writer, webhook string) *AuditLog {
+	return &AuditLog{
+		logger:  logger,
+		webhook: webhook,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		now:     time.Now,
+		file:    file,
+		prev:    common.Hash{}.Hex(),
+	}
+}
+
+// RecordResult audits the canonical result of t, from source.
+func (a *AuditLog) RecordResult(t *performerV1.TaskRequest, source string, resultBytes []byte) {
+	var result struct {
+		Verified struct {
+			Passed *bool `json:"passed"`
+		} `json:"verified"`
+		ErrorCode string `json:"error_code"`
+		Metadata  struct {
+			Provider string `json:"provider"`
+			Model    string `json:"model"`
+		} `json:"metadata"`
+	}
+	_ = json.Unmarshal(resultBytes, &result)
+	a.append(AuditRecord{
+		TaskID:      hexutil.Encode(t.TaskId),
+		PayloadHash: crypto.Keccak256Hash(t.Payload).Hex(),
+		Provider:    result.Metadata.Provider,
+		Model:       result.Metadata.Model,
+		Source:      source,
+		ResultHash:  crypto.Keccak256Hash(resultBytes).Hex(),
+		Verified:    result.Verified.Passed,
+		ErrorCode:   result.ErrorCode,
+	})
+}
+
+// RecordRejection audits t failing validation with cause.
+func (a *AuditLog) RecordRejection(t *performerV1.TaskRequest, cause error) {
+	a.append(AuditRecord{
+		TaskID:      hexutil.Encode(t.TaskId),
+		PayloadHash: crypto.Keccak256Hash(t.Payload).Hex(),
+		Rejection:   cause.Error(),
+	})
+}
+
+func (a *AuditLog) append(record AuditRecord) {
+	a.mu.Lock()
+	record.Seq = a.seq + 1
+	record.Time = a.now().UTC()
+	record.PrevHash = a.prev
+	record.Hash = record.hash()
+	line, _ := json.Marshal(record)
+	if a.file != nil {
+		if _, err := a.file.Write(append(line, '\n')); err != nil {
+			a.mu.Unlock()
+			auditLogErrors.WithLabelValues("file").Inc()
+			a.logger.Sugar().Errorw("Failed to write audit log", zap.Uint64("seq", record.Seq), zap.Error(err))
+			return
+		}
+	}
+	a.seq, a.prev = record.Seq, record.Hash
+	a.mu.Unlock()
+
+	if a.webhook != "" {
+		a.posts.Add(1)
+		go func() {
+			defer a.posts.Done()
+			a.post(record.Seq, line)
+		}()
+	}
+}
+
+// Flush waits for the records being posted to the webhook, until ctx is
+// done, and syncs the audit log file.
+func (a *AuditLog) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		a.posts.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("audit records still being posted: %w", ctx.Err())
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if f, ok := a.file.(interface{ Sync() error }); ok {
+		return f.Sync()
+	}
+	return nil
+}
+
+func (a *AuditLog) post(seq uint64, line []byte) {
+	resp, err := a.client.Post(a.webhook, "application/json", bytes.NewReader(line))
+	if err != nil {
+		auditLogErrors.WithLabelValues("webhook").Inc()
+		a.logger.Sugar().Errorw("Failed to post audit record", zap.Uint64("seq", seq), zap.Error(err))
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		auditLogErrors.WithLabelValues("webhook").Inc()
+		a.logger.Sugar().Errorw("Audit webhook rejected the record", zap.Uint64("seq", seq), zap.Int("status", resp.StatusCode))
+	}
+}
+
+// VerifyAuditLog checks the hash chain of the audit log in r and returns its
+// last record, or nil when it is empty.
+func VerifyAuditLog(r io.Reader) (*AuditRecord, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxAuditRecordSize)
+	var last *AuditRecord
+	prev := common.Hash{}.Hex()
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("malformed audit record after %s: %w", prev, err)
+		}
+		if record.PrevHash != prev {
+			return nil, fmt.Errorf("audit record %d does not follow %s", record.Seq, prev)
+		}
+		if record.Hash != record.hash() {
+			return nil, fmt.Errorf("audit record %d was altered", record.Seq)
+		}
+		if last != nil && record.Seq != last.Seq+1 {
+			return nil, fmt.Errorf("audit record %d follows record %d", record.Seq, last.Seq)
+		}
+		prev, last = record.Hash, &record
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("audit record after %s exceeds %d bytes", prev, maxAuditRecordSize)
+		}
+		return nil, err
+	}
+	return last, nil
+}
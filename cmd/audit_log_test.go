@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_VerifyAuditLog(t *testing.T) {
+	var buf bytes.Buffer
+	a := NewAuditLog(zap.NewNop(), &buf, "")
+	for _, id := range []string{"task-1", "task-2", "task-3"} {
+		a.RecordResult(&performerV1.TaskRequest{TaskId: []byte(id), Payload: []byte("What is 2+2?")}, auditSourceHandled, []byte(`{"llm_output":"4"}`))
+	}
+	lines := strings.SplitAfter(strings.TrimSuffix(buf.String(), "\n"), "\n")
+
+	tests := []struct {
+		name    string
+		log     string
+		wantSeq uint64
+		wantErr bool
+	}{
+		{name: "intact", log: buf.String(), wantSeq: 3},
+		{name: "empty", log: ""},
+		{name: "record removed", log: lines[0] + lines[2], wantErr: true},
+		{name: "records reordered", log: lines[1] + lines[0] + lines[2], wantErr: true},
+		{name: "record altered", log: lines[0] + strings.Replace(lines[1], "task", "fake", 1) + lines[2], wantErr: true},
+		{name: "first record removed", log: lines[1] + lines[2], wantErr: true},
+		{name: "malformed", log: lines[0] + "{\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			last, err := VerifyAuditLog(strings.NewReader(tt.log))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			var seq uint64
+			if last != nil {
+				seq = last.Seq
+			}
+			if seq != tt.wantSeq {
+				t.Errorf("expected last record %d, got %d", tt.wantSeq, seq)
+			}
+		})
+	}
+}
+
+func Test_auditLogFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	t.Setenv("AUDIT_LOG_FILE", path)
+	t.Setenv("AUDIT_LOG_URL", "")
+	task := &performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")}
+
+	// A restarted performer continues the chain of the existing file.
+	for i := 0; i < 2; i++ {
+		a, err := auditLogFromEnv(zap.NewNop())
+		if err != nil {
+			t.Fatalf("auditLogFromEnv failed: %v", err)
+		}
+		a.RecordResult(task, auditSourceHandled, []byte(`{"llm_output":"4"}`))
+		a.file.(io.Closer).Close()
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open audit log: %v", err)
+	}
+	defer f.Close()
+	last, err := VerifyAuditLog(f)
+	if err != nil {
+		t.Fatalf("VerifyAuditLog failed: %v", err)
+	}
+	if last == nil || last.Seq != 2 {
+		t.Errorf("expected 2 chained records, got %+v", last)
+	}
+
+	if err := os.WriteFile(path, []byte("not a record\n"), 0o600); err != nil {
+		t.Fatalf("failed to corrupt audit log: %v", err)
+	}
+	if _, err := auditLogFromEnv(zap.NewNop()); err == nil {
+		t.Errorf("expected a corrupted audit log to be refused")
+	}
+}
+
+func Test_AuditLogWebhook(t *testing.T) {
+	received := make(chan AuditRecord, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var record AuditRecord
+		if err := json.NewDecoder(r.Body).Decode(&record); err != nil {
+			t.Errorf("failed to decode audit record: %v", err)
+		}
+		received <- record
+	}))
+	defer srv.Close()
+
+	a := NewAuditLog(zap.NewNop(), nil, srv.URL)
+	a.RecordRejection(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("x")}, errors.New("task payload cannot be empty"))
+
+	record := <-received
+	if record.Seq != 1 || record.Rejection != "task payload cannot be empty" || record.Hash != record.hash() {
+		t.Errorf("unexpected audit record %+v", record)
+	}
+}
+
+func Test_AuditLogFlush(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	a := NewAuditLog(zap.NewNop(), nil, srv.URL)
+	a.RecordRejection(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("x")}, errors.New("task payload cannot be empty"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := a.Flush(ctx); err == nil {
+		t.Errorf("expected flush to time out while the record is being posted")
+	}
+	release <- struct{}{}
+	if err := a.Flush(context.Background()); err != nil {
+		t.Errorf("unexpected flush error: %v", err)
+	}
+}
+
+func Test_TaskWorkerAuditLog(t *testing.T) {
+	var buf bytes.Buffer
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "4"}, nil)
+	tw.SetAuditLog(NewAuditLog(zap.NewNop(), &buf, ""))
+
+	if err := tw.ValidateTask(&performerV1.TaskRequest{TaskId: []byte("task-1")}); err == nil {
+		t.Fatalf("expected a task without payload to be invalid")
+	}
+	if _, err := tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte("task-2"), Payload: []byte("What is 2+2?")}); err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+
+	var records []AuditRecord
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record AuditRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("malformed audit record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 audit records, got %d", len(records))
+	}
+	if records[0].Rejection == "" {
+		t.Errorf("expected the invalid task to be recorded as rejected, got %+v", records[0])
+	}
+	if r := records[1]; r.Verified == nil || !*r.Verified || r.Provider == "" || r.ResultHash == "" || r.Source != auditSourceHandled {
+		t.Errorf("expected the handled task to be recorded with its verdict, got %+v", r)
+	}
+}
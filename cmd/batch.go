@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const defaultBatchMaxSize = 16
+
+// BatchCompleter is implemented by providers that can serve several prompts
+// sharing the same model and sampling parameters in a single request.
+type BatchCompleter interface {
+	CompleteBatch(ctx context.Context, reqs []*CompletionRequest) ([]*CompletionResponse, error)
+}
+
+// BatchConfig configures request coalescing.
+type BatchConfig struct {
+	// Window is how long the first request of a batch waits for others to
+	// arrive. Zero disables batching.
+	Window time.Duration
+	// MaxSize dispatches a batch as soon as it holds this many requests.
+	MaxSize int
+}
+
+func batchConfigFromEnv() (*BatchConfig, error) {
+	window, err := envDuration("BATCH_WINDOW")
+	if err != nil {
+		return nil, err
+	}
+	maxSize, err := envInt("BATCH_MAX_SIZE")
+	if err != nil {
+		return nil, err
+	}
+	if maxSize <= 0 {
+		maxSize = defaultBatchMaxSize
+	}
+	return &BatchConfig{Window: window, MaxSize: maxSize}, nil
+}
+
+// batchKey groups requests that can share one batch request.
+type batchKey struct {
+	model       string
+	maxTokens   int
+	temperature float64
+	seed        int64
+	pinModel    bool
+}
+
+type batchResult struct {
+	resp *CompletionResponse
+	err  error
+}
+
+type batchItem struct {
+	ctx  context.Context
+	req  *CompletionRequest
+	done chan batchResult
+}
+
+type pendingBatch struct {
+	items []*batchItem
+	timer *time.Timer
+}
+
+// BatchingProvider coalesces requests that arrive within a short window into
+// a single batch request and hands each caller its own response. Only plain
+// prompts, a single user message, are batched; conversations are served by
+// the chat endpoint of the provider. Batched requests are not streamed.
+type BatchingProvider struct {
+	Provider
+	batcher BatchCompleter
+	config  *BatchConfig
+	logger  *zap.Logger
+
+	mu      sync.Mutex
+	pending map[batchKey]*pendingBatch
+}
+
+func NewBatchingProvider(logger *zap.Logger, cfg *BatchConfig, p Provider, batcher BatchCompleter) *BatchingProvider {
+	return &BatchingProvider{
+		Provider: p,
+		batcher:  batcher,
+		config:   cfg,
+		logger:   logger,
+		pending:  map[batchKey]*pendingBatch{},
+	}
+}
+
+func (p *BatchingProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	if !isPlainPrompt(req) {
+		return p.Provider.Complete(ctx, req)
+	}
+	item := &batchItem{ctx: ctx, req: req, done: make(chan batchResult, 1)}
+	key := batchKey{model: req.Model, maxTokens: req.MaxTokens, temperature: req.Temperature, seed: req.Seed, pinModel: req.PinModel}
+
+	p.mu.Lock()
+	batch, ok := p.pending[key]
+	if !ok {
+		batch = &pendingBatch{}
+		batch.timer = time.AfterFunc(p.config.Window, func() { p.flush(key, batch) })
+		p.pending[key] = batch
+	}
+	batch.items = append(batch.items, item)
+	full := len(batch.items) >= p.config.MaxSize
+	p.mu.Unlock()
+
+	if full {
+		p.flush(key, batch)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-item.done:
+		return result.resp, result.err
+	}
+}
+
+// flush dispatches batch unless it was already dispatched.
+func (p *BatchingProvider) flush(key batchKey, batch *pendingBatch) {
+	p.mu.Lock()
+	if p.pending[key] != batch {
+		p.mu.Unlock()
+		return
+	}
+	delete(p.pending, key)
+	batch.timer.Stop()
+	p.mu.Unlock()
+
+	go p.dispatch(batch.items)
+}
+
+// isPlainPrompt reports whether req is a single user message, which the
+// batch endpoint can serve without flattening a conversation.
+func isPlainPrompt(req *CompletionRequest) bool {
+	return len(req.Messages) == 1 && req.Messages[0].Role == "user"
+}
+
+func (p *BatchingProvider) dispatch(items []*batchItem) {
+	// Callers have their own deadlines; the batch outlives any single one of
+	// them so an early cancellation doesn't fail the others, runs until the
+	// latest of them, and is cancelled once every caller has given up.
+	limit := time.Now().Add(providerTimeout)
+	var deadline time.Time
+	for _, item := range items {
+		d, ok := item.ctx.Deadline()
+		if !ok || d.After(limit) {
+			deadline = limit
+			break
+		}
+		if d.After(deadline) {
+			deadline = d
+		}
+	}
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	go func() {
+		for _, item := range items {
+			select {
+			case <-item.ctx.Done():
+			case <-ctx.Done():
+				return
+			}
+		}
+		cancel()
+	}()
+
+	reqs := make([]*CompletionRequest, len(items))
+	for i, item := range items {
+		reqs[i] = item.req
+	}
+
+	p.logger.Sugar().Debugw("Dispatching batch",
+		zap.String("provider", p.Name()),
+		zap.Int("size", len(reqs)),
+	)
+	resps, err := p.batcher.CompleteBatch(ctx, reqs)
+	if err == nil && len(resps) != len(reqs) {
+		err = fmt.Errorf("%s returned %d results for a batch of %d", p.Name(), len(resps), len(reqs))
+	}
+
+	for i, item := range items {
+		if err != nil {
+			item.done <- batchResult{err: err}
+			continue
+		}
+		item.done <- batchResult{resp: resps[i]}
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// echoBatcher answers every prompt of a batch with the prompt itself and
+// records the size of each batch it received.
+type echoBatcher struct {
+	mu      sync.Mutex
+	batches []int
+	err     error
+}
+
+func (b *echoBatcher) CompleteBatch(ctx context.Context, reqs []*CompletionRequest) ([]*CompletionResponse, error) {
+	b.mu.Lock()
+	b.batches = append(b.batches, len(reqs))
+	b.mu.Unlock()
+
+	if b.err != nil {
+		return nil, b.err
+	}
+	resps := make([]*CompletionResponse, len(reqs))
+	for i, req := range reqs {
+		resps[i] = &CompletionResponse{Output: req.Messages[0].Content}
+	}
+	return resps, nil
+}
+
+func Test_BatchingProvider(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      BatchConfig
+		requests    int
+		wantBatches []int
+	}{
+		{name: "dispatch when full", config: BatchConfig{Window: time.Minute, MaxSize: 3}, requests: 3, wantBatches: []int{3}},
+		{name: "dispatch after window", config: BatchConfig{Window: 20 * time.Millisecond, MaxSize: 10}, requests: 2, wantBatches: []int{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batcher := &echoBatcher{}
+			p := NewBatchingProvider(zap.NewNop(), &tt.config, &stubProvider{}, batcher)
+
+			var wg sync.WaitGroup
+			for i := 0; i < tt.requests; i++ {
+				wg.Add(1)
+				go func(prompt string) {
+					defer wg.Done()
+					resp, err := p.Complete(context.Background(), &CompletionRequest{
+						Messages: []ChatMessage{{Role: "user", Content: prompt}},
+					})
+					if err != nil {
+						t.Errorf("Complete failed: %v", err)
+						return
+					}
+					if resp.Output != prompt {
+						t.Errorf("expected response for %q, got %q", prompt, resp.Output)
+					}
+				}(fmt.Sprintf("prompt %d", i))
+			}
+			wg.Wait()
+
+			if fmt.Sprint(batcher.batches) != fmt.Sprint(tt.wantBatches) {
+				t.Errorf("expected batches %v, got %v", tt.wantBatches, batcher.batches)
+			}
+		})
+	}
+}
+
+func Test_BatchingProviderSeparatesParameters(t *testing.T) {
+	batcher := &echoBatcher{}
+	p := NewBatchingProvider(zap.NewNop(), &BatchConfig{Window: 20 * time.Millisecond, MaxSize: 10}, &stubProvider{}, batcher)
+
+	var wg sync.WaitGroup
+	for _, model := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(model string) {
+			defer wg.Done()
+			_, _ = p.Complete(context.Background(), &CompletionRequest{
+				Messages: []ChatMessage{{Role: "user", Content: "hi"}},
+				Model:    model,
+			})
+		}(model)
+	}
+	wg.Wait()
+
+	if len(batcher.batches) != 2 {
+		t.Errorf("expected one batch per model, got %v", batcher.batches)
+	}
+}
+
+func Test_BatchingProviderConversation(t *testing.T) {
+	batcher := &echoBatcher{}
+	p := NewBatchingProvider(zap.NewNop(), &BatchConfig{Window: time.Minute, MaxSize: 10}, &stubProvider{output: "from chat"}, batcher)
+
+	resp, err := p.Complete(context.Background(), &CompletionRequest{
+		Messages: []ChatMessage{{Role: "system", Content: "be brief"}, {Role: "user", Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if resp.Output != "from chat" || len(batcher.batches) != 0 {
+		t.Errorf("expected the conversation to bypass the batch, got %q and batches %v", resp.Output, batcher.batches)
+	}
+}
+
+// ctxBatcher reports the deadline of the batch context and how it ended.
+type ctxBatcher struct {
+	deadline chan time.Time
+	ended    chan error
+}
+
+func (b *ctxBatcher) CompleteBatch(ctx context.Context, reqs []*CompletionRequest) ([]*CompletionResponse, error) {
+	d, _ := ctx.Deadline()
+	b.deadline <- d
+	<-ctx.Done()
+	b.ended <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+func Test_BatchingProviderContext(t *testing.T) {
+	batcher := &ctxBatcher{deadline: make(chan time.Time, 1), ended: make(chan error, 1)}
+	p := NewBatchingProvider(zap.NewNop(), &BatchConfig{Window: time.Millisecond, MaxSize: 10}, &stubProvider{}, batcher)
+
+	deadline := time.Now().Add(providerTimeout / 2)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.Complete(ctx, &CompletionRequest{Messages: []ChatMessage{{Role: "user", Content: "hi"}}})
+		done <- err
+	}()
+
+	if got := <-batcher.deadline; !got.Equal(deadline) {
+		t.Errorf("expected the batch to run until the caller's deadline %v, got %v", deadline, got)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the cancellation, got %v", err)
+	}
+	select {
+	case err := <-batcher.ended:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the batch to be cancelled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("expected the batch to be cancelled once its only caller gave up")
+	}
+}
+
+func Test_BatchingProviderError(t *testing.T) {
+	batcher := &echoBatcher{err: errors.New("batch failed")}
+	p := NewBatchingProvider(zap.NewNop(), &BatchConfig{Window: time.Millisecond, MaxSize: 10}, &stubProvider{}, batcher)
+
+	if _, err := p.Complete(context.Background(), &CompletionRequest{
+		Messages: []ChatMessage{{Role: "user", Content: "hi"}},
+	}); err == nil {
+		t.Fatalf("expected batch error to be returned")
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultCircuitFailureThreshold = 5
+	defaultCircuitCooldown         = 30 * time.Second
+	defaultHealthCheckInterval     = 30 * time.Second
+)
+
+// ErrProviderUnavailable is returned without contacting the provider while its
+// circuit is open.
+var ErrProviderUnavailable = errors.New("provider unavailable")
+
+// providerBreakers holds the circuit breaker of each provider built from the
+// environment, by provider name, for the readiness checks.
+var (
+	providerBreakersMu sync.Mutex
+	providerBreakers   = map[string]*CircuitBreaker{}
+)
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreakerConfig configures the circuit breaker around a provider.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive provider faults that open
+	// the circuit.
+	FailureThreshold int
+	// Cooldown is how long the circuit stays open before a single trial request
+	// is let through.
+	Cooldown time.Duration
+	// HealthCheckInterval is how often the provider is probed in the background.
+	// Zero disables probing.
+	HealthCheckInterval time.Duration
+}
+
+func circuitBreakerConfigFromEnv() (*CircuitBreakerConfig, error) {
+	cfg := &CircuitBreakerConfig{
+		FailureThreshold:    defaultCircuitFailureThreshold,
+		Cooldown:            defaultCircuitCooldown,
+		HealthCheckInterval: defaultHealthCheckInterval,
+	}
+
+	threshold, err := envInt("CIRCUIT_FAILURE_THRESHOLD")
+	if err != nil {
+		return nil, err
+	}
+	if threshold > 0 {
+		cfg.FailureThreshold = threshold
+	}
+
+	cooldown, err := envDuration("CIRCUIT_COOLDOWN")
+	if err != nil {
+		return nil, err
+	}
+	if cooldown > 0 {
+		cfg.Cooldown = cooldown
+	}
+
+	// An explicit zero disables background probing.
+	if getenv("HEALTH_CHECK_INTERVAL") != "" {
+		if cfg.HealthCheckInterval, err = envDuration("HEALTH_CHECK_INTERVAL"); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// CircuitBreaker wraps a Provider and fast-fails requests with
+// ErrProviderUnavailable once the provider has failed repeatedly, instead of
+// letting every task wait for the full provider timeout. When the wrapped
+// provider supports probing, it is also checked periodically so an outage is
+// detected (and recovery noticed) without spending tasks on it.
+type CircuitBreaker struct {
+	Provider
+	prober ReadinessProber
+	config *CircuitBreakerConfig
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+	now      func() time.Time
+}
+
+func NewCircuitBreaker(logger *zap.Logger, cfg *CircuitBreakerConfig, p Provider, prober ReadinessProber) *CircuitBreaker {
+	cb := &CircuitBreaker{
+		Provider: p,
+		prober:   prober,
+		config:   cfg,
+		logger:   logger,
+		now:      time.Now,
+	}
+	providerCircuitState.WithLabelValues(p.Name()).Set(float64(circuitClosed))
+	return cb
+}
+
+// Start runs the background health checks until ctx is cancelled.
+func (cb *CircuitBreaker) Start(ctx context.Context) {
+	if cb.prober == nil || cb.config.HealthCheckInterval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(cb.config.HealthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				probeCtx, cancel := context.WithTimeout(ctx, providerTimeout)
+				err := cb.prober.Probe(probeCtx)
+				cancel()
+				cb.recordProbe(err)
+			}
+		}
+	}()
+}
+
+func (cb *CircuitBreaker) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	if !cb.allow() {
+		return nil, fmt.Errorf("%s: %w", cb.Name(), ErrProviderUnavailable)
+	}
+
+	resp, err := cb.Provider.Complete(ctx, req)
+	cb.record(err)
+	return resp, err
+}
+
+// allow reports whether a request may be sent, moving an open circuit to
+// half-open once the cooldown has elapsed.
+func (cb *CircuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case circuitOpen:
+		if cb.now().Sub(cb.openedAt) < cb.config.Cooldown {
+			return false
+		}
+		cb.setState(circuitHalfOpen)
+		return true
+	case circuitHalfOpen:
+		// A trial request is already in flight.
+		return false
+	default:
+		return true
+	}
+}
+
+// available reports whether a request may be sent, without moving the
+// circuit to half-open.
+func (cb *CircuitBreaker) available() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state != circuitOpen || cb.now().Sub(cb.openedAt) >= cb.config.Cooldown
+}
+
+func (cb *CircuitBreaker) record(err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if err == nil || !isProviderFault(err) {
+		cb.failures = 0
+		if cb.state != circuitClosed {
+			cb.setState(circuitClosed)
+		}
+		return
+	}
+
+	cb.failures++
+	if cb.state == circuitHalfOpen || cb.failures >= cb.config.FailureThreshold {
+		cb.open()
+	}
+}
+
+func (cb *CircuitBreaker) recordProbe(err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if err != nil {
+		if cb.state != circuitOpen {
+			cb.logger.Sugar().Warnw("Provider health check failed",
+				zap.String("provider", cb.Name()),
+				zap.Error(err),
+			)
+			cb.open()
+		}
+		return
+	}
+	if cb.state != circuitClosed {
+		cb.failures = 0
+		cb.setState(circuitClosed)
+	}
+}
+
+func (cb *CircuitBreaker) open() {
+	cb.openedAt = cb.now()
+	cb.setState(circuitOpen)
+}
+
+func (cb *CircuitBreaker) setState(s circuitState) {
+	if cb.state != s {
+		cb.logger.Sugar().Infow("Provider circuit state changed",
+			zap.String("provider", cb.Name()),
+			zap.String("from", cb.state.String()),
+			zap.String("to", s.String()),
+		)
+	}
+	cb.state = s
+	providerCircuitState.WithLabelValues(cb.Name()).Set(float64(s))
+}
+
+// isProviderFault reports whether err indicates the provider itself is
+// unhealthy: a server error or rate limit, a timeout or a transport failure.
+// Bad requests, cancelled tasks and requests rejected before reaching the
+// provider, such as ErrModelOverrideUnsupported, are not faults.
+func isProviderFault(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.StatusCode >= 500 || providerErr.StatusCode == http.StatusTooManyRequests
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// registerProviderBreaker makes the provider of cb part of the readiness
+// checks, replacing the breaker of an earlier provider of the same name.
+func registerProviderBreaker(cb *CircuitBreaker) {
+	providerBreakersMu.Lock()
+	defer providerBreakersMu.Unlock()
+	providerBreakers[cb.Name()] = cb
+}
+
+// providersReachable fails when the circuit of every provider is open, so
+// no task can be answered.
+func providersReachable(ctx context.Context) error {
+	providerBreakersMu.Lock()
+	defer providerBreakersMu.Unlock()
+
+	var open []string
+	for name, cb := range providerBreakers {
+		if cb.available() {
+			return nil
+		}
+		open = append(open, name)
+	}
+	if len(open) == 0 {
+		return nil
+	}
+	sort.Strings(open)
+	return fmt.Errorf("%w: circuits of %s are open", ErrProviderUnavailable, strings.Join(open, ", "))
+}
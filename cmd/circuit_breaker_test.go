@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+// flakyProvider fails while down is set and succeeds otherwise.
+type flakyProvider struct {
+	down  bool
+	calls int
+}
+
+func (p *flakyProvider) Name() string {
+	return "flaky"
+}
+
+func (p *flakyProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	p.calls++
+	if p.down {
+		return nil, &ProviderError{Provider: p.Name(), StatusCode: 503, Body: "unavailable"}
+	}
+	return &CompletionResponse{Output: "ok"}, nil
+}
+
+func Test_CircuitBreaker(t *testing.T) {
+	now := time.Now()
+	p := &flakyProvider{down: true}
+	cb := NewCircuitBreaker(zap.NewNop(), &CircuitBreakerConfig{FailureThreshold: 2, Cooldown: time.Minute}, p, nil)
+	cb.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if _, err := cb.Complete(context.Background(), &CompletionRequest{}); errors.Is(err, ErrProviderUnavailable) {
+			t.Fatalf("circuit opened before reaching the threshold")
+		}
+	}
+
+	_, err := cb.Complete(context.Background(), &CompletionRequest{})
+	if !errors.Is(err, ErrProviderUnavailable) {
+		t.Fatalf("expected ErrProviderUnavailable, got %v", err)
+	}
+	if p.calls != 2 {
+		t.Errorf("expected open circuit to skip the provider, got %d calls", p.calls)
+	}
+
+	// After the cooldown a failed trial request reopens the circuit.
+	now = now.Add(time.Minute)
+	if _, err := cb.Complete(context.Background(), &CompletionRequest{}); errors.Is(err, ErrProviderUnavailable) {
+		t.Fatalf("expected trial request after cooldown")
+	}
+	if _, err := cb.Complete(context.Background(), &CompletionRequest{}); !errors.Is(err, ErrProviderUnavailable) {
+		t.Fatalf("expected failed trial to reopen the circuit, got %v", err)
+	}
+
+	// A successful trial request closes it again.
+	now = now.Add(time.Minute)
+	p.down = false
+	if _, err := cb.Complete(context.Background(), &CompletionRequest{}); err != nil {
+		t.Fatalf("expected trial request to succeed, got %v", err)
+	}
+	if cb.state != circuitClosed {
+		t.Errorf("expected closed circuit, got %s", cb.state)
+	}
+}
+
+func Test_CircuitBreakerProbe(t *testing.T) {
+	cb := NewCircuitBreaker(zap.NewNop(), &CircuitBreakerConfig{FailureThreshold: 5, Cooldown: time.Minute}, &stubProvider{}, nil)
+
+	cb.recordProbe(errors.New("connection refused"))
+	if _, err := cb.Complete(context.Background(), &CompletionRequest{}); !errors.Is(err, ErrProviderUnavailable) {
+		t.Fatalf("expected failed probe to open the circuit, got %v", err)
+	}
+
+	cb.recordProbe(nil)
+	if _, err := cb.Complete(context.Background(), &CompletionRequest{}); err != nil {
+		t.Fatalf("expected healthy probe to close the circuit, got %v", err)
+	}
+}
+
+func Test_IsProviderFault(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "server error", err: &ProviderError{StatusCode: 502}, want: true},
+		{name: "rate limited", err: &ProviderError{StatusCode: 429}, want: true},
+		{name: "bad request", err: &ProviderError{StatusCode: 400}, want: false},
+		{name: "cancelled", err: fmt.Errorf("request: %w", context.Canceled), want: false},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, want: true},
+		{name: "network error", err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, want: true},
+		{name: "wrapped network error", err: fmt.Errorf("request: %w", &url.Error{Op: "Post", URL: "https://api.openai.com", Err: errors.New("connection reset")}), want: true},
+		{name: "truncated response", err: fmt.Errorf("failed to read stream: %w", io.ErrUnexpectedEOF), want: true},
+		{name: "model override unsupported", err: fmt.Errorf("azure: %w", ErrModelOverrideUnsupported), want: false},
+		{name: "local validation error", err: errors.New("messages must not be empty"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isProviderFault(tt.err); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_HandleTaskProviderUnavailable(t *testing.T) {
+	cb := NewCircuitBreaker(zap.NewNop(), &CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Minute}, &flakyProvider{down: true}, nil)
+	cb.recordProbe(errors.New("connection refused"))
+	taskWorker := NewTaskWorker(zap.NewNop(), cb, nil)
+
+	resp, err := taskWorker.HandleTask(&performerV1.TaskRequest{
+		TaskId:  []byte("test-task-id"),
+		Payload: []byte("test-data"),
+	})
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+
+	var result struct {
+		Verified  Verification `json:"verified"`
+		ErrorCode string       `json:"error_code"`
+		Retryable bool         `json:"retryable"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if result.ErrorCode != "provider_unavailable" || !result.Retryable || result.Verified.Passed {
+		t.Errorf("unexpected result: %s", resp.Result)
+	}
+}
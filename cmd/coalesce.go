@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+)
+
+// inflightCall is a provider call shared by identical requests.
+type inflightCall struct {
+	done chan struct{}
+	resp *CompletionResponse
+	err  error
+	// waiters counts the requests sharing the call besides the first.
+	waiters int
+}
+
+// CoalescingProvider makes one provider call for identical requests in flight
+// at the same time and hands each caller its own copy of the response.
+// Duplicate tasks are common when several AVS consumers ask the same
+// question. Requests are identical when their prompt, examples, model and
+// sampling parameters are; the shared call runs with the context of the
+// first request.
+type CoalescingProvider struct {
+	Provider
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	inflight map[common.Hash]*inflightCall
+}
+
+// coalescingFromEnv wraps p in a CoalescingProvider when COALESCE_REQUESTS is
+// true, and returns p unchanged otherwise.
+func coalescingFromEnv(logger *zap.Logger, p Provider) (Provider, error) {
+	v := getenv("COALESCE_REQUESTS")
+	if v == "" {
+		return p, nil
+	}
+	enabled, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for COALESCE_REQUESTS: %w", err)
+	}
+	if !enabled {
+		return p, nil
+	}
+	return NewCoalescingProvider(logger, p), nil
+}
+
+func NewCoalescingProvider(logger *zap.Logger, p Provider) *CoalescingProvider {
+	return &CoalescingProvider{Provider: p, logger: logger, inflight: map[common.Hash]*inflightCall{}}
+}
+
+func (p *CoalescingProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	key := requestHash(req)
+
+	p.mu.Lock()
+	if call, ok := p.inflight[key]; ok {
+		call.waiters++
+		p.mu.Unlock()
+		coalescedRequests.Inc()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		// The call was abandoned by the task that made it, not by this one,
+		// which makes its own.
+		if isContextError(call.err) && ctx.Err() == nil {
+			return p.Provider.Complete(ctx, req)
+		}
+		if call.err != nil {
+			return nil, call.err
+		}
+		resp := *call.resp
+		resp.Coalesced = true
+		return &resp, nil
+	}
+	call := &inflightCall{done: make(chan struct{})}
+	p.inflight[key] = call
+	p.mu.Unlock()
+
+	call.resp, call.err = p.Provider.Complete(ctx, req)
+
+	p.mu.Lock()
+	delete(p.inflight, key)
+	waiters := call.waiters
+	p.mu.Unlock()
+	close(call.done)
+
+	if waiters > 0 {
+		correlatedLogger(ctx, p.logger).Sugar().Infow("Coalesced identical requests",
+			zap.String("requestHash", key.Hex()),
+			zap.Int("requests", waiters+1),
+		)
+	}
+	if call.err != nil {
+		return nil, call.err
+	}
+	resp := *call.resp
+	return &resp, nil
+}
+
+// isContextError reports whether err is the cancellation or expiry of a
+// context.
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// requestHash is the keccak256 hash of everything that determines the
+// response to a request.
+func requestHash(req *CompletionRequest) common.Hash {
+	key, _ := json.Marshal(struct {
+		Messages    []ChatMessage
+		Examples    []ChatMessage
+		MaxTokens   int
+		Temperature float64
+		Model       string
+		Requested   [2]string
+		Critical    bool
+		Seed        int64
+		PinModel    bool
+	}{req.Messages, req.Examples, req.MaxTokens, req.Temperature, req.Model, [2]string{req.RequestedModel, req.RequestedProvider}, req.Critical, req.Seed, req.PinModel})
+	return crypto.Keccak256Hash(key)
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// gatedProvider counts its calls and answers once release is closed.
+type gatedProvider struct {
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (p *gatedProvider) Name() string { return "gated" }
+
+func (p *gatedProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	p.calls.Add(1)
+	<-p.release
+	return &CompletionResponse{Output: req.Messages[len(req.Messages)-1].Content, Model: "m"}, nil
+}
+
+// cancellableProvider gives up on a call to its provider once the context of
+// the request is done.
+type cancellableProvider struct {
+	Provider
+}
+
+func (p *cancellableProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	type result struct {
+		resp *CompletionResponse
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := p.Provider.Complete(ctx, req)
+		done <- result{resp, err}
+	}()
+	select {
+	case r := <-done:
+		return r.resp, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func Test_CoalescingProvider(t *testing.T) {
+	tests := []struct {
+		name      string
+		prompts   []string
+		wantCalls int32
+	}{
+		{name: "identical prompts", prompts: []string{"What is 2+2?", "What is 2+2?", "What is 2+2?"}, wantCalls: 1},
+		{name: "different prompts", prompts: []string{"What is 2+2?", "What is 3+3?"}, wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &gatedProvider{release: make(chan struct{})}
+			p := NewCoalescingProvider(zap.NewNop(), inner)
+
+			var wg sync.WaitGroup
+			resps := make([]*CompletionResponse, len(tt.prompts))
+			for i, prompt := range tt.prompts {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					resp, err := p.Complete(context.Background(), &CompletionRequest{Messages: []ChatMessage{{Role: "user", Content: prompt}}})
+					if err != nil {
+						t.Errorf("Complete failed: %v", err)
+						return
+					}
+					resps[i] = resp
+				}()
+			}
+			// Wait for every request to be in flight before answering.
+			for {
+				p.mu.Lock()
+				waiting := 0
+				for _, call := range p.inflight {
+					waiting += call.waiters + 1
+				}
+				p.mu.Unlock()
+				if waiting == len(tt.prompts) {
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+			close(inner.release)
+			wg.Wait()
+
+			if got := inner.calls.Load(); got != tt.wantCalls {
+				t.Errorf("expected %d provider calls, got %d", tt.wantCalls, got)
+			}
+			for i, resp := range resps {
+				if resp == nil || resp.Output != tt.prompts[i] {
+					t.Fatalf("request %d got response %+v", i, resp)
+				}
+				for j := 0; j < i; j++ {
+					if resps[j] == resp {
+						t.Errorf("requests %d and %d share a response", j, i)
+					}
+				}
+			}
+		})
+	}
+}
+
+func Test_CoalescingProviderLeaderCancelled(t *testing.T) {
+	inner := &gatedProvider{release: make(chan struct{})}
+	p := NewCoalescingProvider(zap.NewNop(), &cancellableProvider{inner})
+	req := &CompletionRequest{Messages: []ChatMessage{{Role: "user", Content: "What is 2+2?"}}}
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := p.Complete(leaderCtx, req)
+		leaderErr <- err
+	}()
+	for inner.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	followerResp := make(chan *CompletionResponse, 1)
+	go func() {
+		resp, err := p.Complete(context.Background(), req)
+		if err != nil {
+			t.Errorf("follower Complete failed: %v", err)
+		}
+		followerResp <- resp
+	}()
+	for {
+		p.mu.Lock()
+		waiters := 0
+		for _, call := range p.inflight {
+			waiters += call.waiters
+		}
+		p.mu.Unlock()
+		if waiters == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancelLeader()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the leader to be cancelled, got %v", err)
+	}
+	close(inner.release)
+	if resp := <-followerResp; resp == nil || resp.Output != "What is 2+2?" {
+		t.Errorf("unexpected follower response %+v", resp)
+	}
+	if got := inner.calls.Load(); got != 2 {
+		t.Errorf("expected the follower to make its own call, got %d calls", got)
+	}
+}
+
+func Test_requestHash(t *testing.T) {
+	base := CompletionRequest{Messages: []ChatMessage{{Role: "user", Content: "hi"}}, MaxTokens: 10}
+
+	tests := []struct {
+		name     string
+		modify   func(r *CompletionRequest)
+		wantSame bool
+	}{
+		{name: "identical", modify: func(r *CompletionRequest) {}, wantSame: true},
+		{name: "streaming", modify: func(r *CompletionRequest) { r.Stream = true }, wantSame: true},
+		{name: "prompt", modify: func(r *CompletionRequest) { r.Messages = []ChatMessage{{Role: "user", Content: "hello"}} }},
+		{name: "model", modify: func(r *CompletionRequest) { r.Model = "gpt-4o" }},
+		{name: "temperature", modify: func(r *CompletionRequest) { r.Temperature = 0.7 }},
+		{name: "examples", modify: func(r *CompletionRequest) { r.Examples = []ChatMessage{{Role: "user", Content: "x"}} }},
+		{name: "seed", modify: func(r *CompletionRequest) { r.Seed = deterministicSeed }},
+		{name: "pinned model", modify: func(r *CompletionRequest) { r.PinModel = true }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := base
+			tt.modify(&other)
+			if same := requestHash(&base) == requestHash(&other); same != tt.wantSame {
+				t.Errorf("expected same hash %v, got %v", tt.wantSame, same)
+			}
+		})
+	}
+}
+
+func Test_coalescingFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		wantWrap bool
+		wantErr  bool
+	}{
+		{name: "unset"},
+		{name: "enabled", value: "true", wantWrap: true},
+		{name: "disabled", value: "false"},
+		{name: "invalid", value: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("COALESCE_REQUESTS", tt.value)
+			p, err := coalescingFromEnv(zap.NewNop(), &stubProvider{output: "4"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if _, ok := p.(*CoalescingProvider); !tt.wantErr && ok != tt.wantWrap {
+				t.Errorf("expected coalescing %v, got %T", tt.wantWrap, p)
+			}
+		})
+	}
+}
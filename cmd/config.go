@@ -0,0 +1,542 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the configuration file of the performer, loaded from the YAML
+// file named by the -config flag or CONFIG_FILE. Every setting is named by the
+// environment variable it can be overridden with, and some by a flag that in
+// turn overrides the environment:
+//
+//	port: 8080
+//	task_timeout: 30s
+//	provider:
+//	  name: openai
+//	  openai:
+//	    model: gpt-4o-mini
+//	limits:
+//	  max_payload_size: 8192
+//	verification:
+//	  verifiers: [non_empty, judge]
+//	env:
+//	  JUDGE_PROVIDER: anthropic
+//
+// Settings without a field of their own, such as the secret references of
+// API keys, are set by their environment variable name under env.
+//
+// Profiles let one file serve several deployment targets. The profile
+// selected by the -profile flag, PERFORMER_PROFILE or profile overrides the
+// settings it sets:
+//
+//	profile: mainnet
+//	profiles:
+//	  devnet:
+//	    dev: true
+//	  testnet:
+//	    limits:
+//	      max_payload_size: 65536
+//	  mainnet:
+//	    provider:
+//	      failover: [openai, anthropic]
+//	    verification:
+//	      verifiers: [non_empty, judge, fact_check]
+type Config struct {
+	// Profile names the profile of Profiles applied over the other settings.
+	Profile  string             `yaml:"profile" env:"PERFORMER_PROFILE" flag:"profile"`
+	Profiles map[string]*Config `yaml:"profiles"`
+
+	Port        int           `yaml:"port" env:"PERFORMER_PORT" flag:"port"`
+	TaskTimeout time.Duration `yaml:"task_timeout" env:"TASK_TIMEOUT" flag:"task-timeout"`
+	HealthPort  int           `yaml:"health_port" env:"HEALTH_PORT" flag:"health-port"`
+	MetricsPort int           `yaml:"metrics_port" env:"METRICS_PORT" flag:"metrics-port"`
+	DebugPort   int           `yaml:"debug_port" env:"DEBUG_PORT" flag:"debug-port"`
+	// Dev serves canned responses from the built-in mock provider, see
+	// MockProvider.
+	Dev *bool `yaml:"dev" env:"DEV_MODE" flag:"dev"`
+	// DryRun answers tasks with a synthetic result instead of calling the
+	// provider, see TaskWorker.dryRunResult.
+	DryRun *bool `yaml:"dry_run" env:"DRY_RUN" flag:"dry-run"`
+	// Deterministic makes operators' results reproducible, see
+	// DeterministicProvider.
+	Deterministic *bool `yaml:"deterministic" env:"DETERMINISTIC" flag:"deterministic"`
+
+	// ProviderTimeout bounds each provider request, and VerificationTimeout
+	// the verification of a result. TaskTimeout must exceed their sum.
+	ProviderTimeout     time.Duration `yaml:"provider_timeout" env:"PROVIDER_TIMEOUT" flag:"provider-timeout"`
+	VerificationTimeout time.Duration `yaml:"verification_timeout" env:"VERIFICATION_TIMEOUT" flag:"verification-timeout"`
+	ShutdownGracePeriod time.Duration `yaml:"shutdown_grace_period" env:"SHUTDOWN_GRACE_PERIOD"`
+
+	Logging      LoggingConfig      `yaml:"logging"`
+	Provider     ProviderConfig     `yaml:"provider"`
+	Limits       LimitsFileConfig   `yaml:"limits"`
+	Verification VerificationConfig `yaml:"verification"`
+
+	// Env sets any other setting by its environment variable name. The
+	// fields above take precedence over it.
+	Env map[string]string `yaml:"env"`
+}
+
+// LoggingConfig configures logging, see loggerConfigFromEnv.
+type LoggingConfig struct {
+	Level    string   `yaml:"level" env:"LOG_LEVEL" flag:"log-level"`
+	Format   string   `yaml:"format" env:"LOG_FORMAT" flag:"log-format"`
+	Sampling *bool    `yaml:"sampling" env:"LOG_SAMPLING"`
+	Output   []string `yaml:"output" env:"LOG_OUTPUT"`
+}
+
+// ProviderConfig configures the LLM providers, see NewProviderFromEnv.
+type ProviderConfig struct {
+	Name                   string        `yaml:"name" env:"LLM_PROVIDER" flag:"provider"`
+	Failover               []string      `yaml:"failover" env:"LLM_PROVIDERS"`
+	Ensemble               []string      `yaml:"ensemble" env:"LLM_ENSEMBLE"`
+	Routes                 string        `yaml:"routes" env:"LLM_ROUTES"`
+	ModelAllowlist         string        `yaml:"model_allowlist" env:"MODEL_ALLOWLIST"`
+	SecretsRefreshInterval time.Duration `yaml:"secrets_refresh_interval" env:"SECRETS_REFRESH_INTERVAL"`
+
+	Retry struct {
+		MaxAttempts int           `yaml:"max_attempts" env:"RETRY_MAX_ATTEMPTS"`
+		BaseDelay   time.Duration `yaml:"base_delay" env:"RETRY_BASE_DELAY"`
+		MaxDelay    time.Duration `yaml:"max_delay" env:"RETRY_MAX_DELAY"`
+	} `yaml:"retry"`
+	Circuit struct {
+		FailureThreshold    int           `yaml:"failure_threshold" env:"CIRCUIT_FAILURE_THRESHOLD"`
+		Cooldown            time.Duration `yaml:"cooldown" env:"CIRCUIT_COOLDOWN"`
+		HealthCheckInterval time.Duration `yaml:"health_check_interval" env:"HEALTH_CHECK_INTERVAL"`
+	} `yaml:"circuit"`
+
+	OpenAI struct {
+		Model        string `yaml:"model" env:"OPENAI_MODEL"`
+		BaseURL      string `yaml:"base_url" env:"OPENAI_BASE_URL"`
+		Organization string `yaml:"organization" env:"OPENAI_ORGANIZATION"`
+	} `yaml:"openai"`
+	Anthropic struct {
+		Model   string `yaml:"model" env:"ANTHROPIC_MODEL"`
+		BaseURL string `yaml:"base_url" env:"ANTHROPIC_BASE_URL"`
+		Version string `yaml:"version" env:"ANTHROPIC_VERSION"`
+	} `yaml:"anthropic"`
+	Ollama struct {
+		Host  string `yaml:"host" env:"OLLAMA_HOST"`
+		Model string `yaml:"model" env:"OLLAMA_MODEL"`
+	} `yaml:"ollama"`
+	Groq struct {
+		Model   string `yaml:"model" env:"GROQ_MODEL"`
+		BaseURL string `yaml:"base_url" env:"GROQ_BASE_URL"`
+	} `yaml:"groq"`
+	OpenRouter struct {
+		Model   string `yaml:"model" env:"OPENROUTER_MODEL"`
+		BaseURL string `yaml:"base_url" env:"OPENROUTER_BASE_URL"`
+	} `yaml:"openrouter"`
+	OpenAICompatible struct {
+		Model   string `yaml:"model" env:"OPENAI_COMPATIBLE_MODEL"`
+		BaseURL string `yaml:"base_url" env:"OPENAI_COMPATIBLE_BASE_URL"`
+	} `yaml:"openai_compatible"`
+	Azure struct {
+		Endpoint   string `yaml:"endpoint" env:"AZURE_OPENAI_ENDPOINT"`
+		Deployment string `yaml:"deployment" env:"AZURE_OPENAI_DEPLOYMENT"`
+		APIVersion string `yaml:"api_version" env:"AZURE_OPENAI_API_VERSION"`
+	} `yaml:"azure"`
+	Bedrock struct {
+		Region  string `yaml:"region" env:"BEDROCK_REGION"`
+		ModelID string `yaml:"model_id" env:"BEDROCK_MODEL_ID"`
+		RoleARN string `yaml:"role_arn" env:"BEDROCK_ROLE_ARN"`
+	} `yaml:"bedrock"`
+	Vertex struct {
+		Project  string `yaml:"project" env:"VERTEX_PROJECT"`
+		Location string `yaml:"location" env:"VERTEX_LOCATION"`
+		Model    string `yaml:"model" env:"VERTEX_MODEL"`
+	} `yaml:"vertex"`
+	TGI struct {
+		URL  string `yaml:"url" env:"TGI_URL"`
+		Mode string `yaml:"mode" env:"TGI_MODE"`
+	} `yaml:"tgi"`
+	Mock struct {
+		ResponsesFile string        `yaml:"responses_file" env:"MOCK_RESPONSES_FILE"`
+		Latency       time.Duration `yaml:"latency" env:"MOCK_LATENCY"`
+	} `yaml:"mock"`
+}
+
+// LimitsFileConfig configures the payload and result limits, see
+// limitsConfigFromEnv.
+type LimitsFileConfig struct {
+	MaxPayloadSize             int   `yaml:"max_payload_size" env:"MAX_PAYLOAD_SIZE" flag:"max-payload-size"`
+	MaxDecompressedPayloadSize int   `yaml:"max_decompressed_payload_size" env:"MAX_DECOMPRESSED_PAYLOAD_SIZE"`
+	MaxResultSize              int   `yaml:"max_result_size" env:"MAX_RESULT_SIZE" flag:"max-result-size"`
+	MaxPayloadTokens           int   `yaml:"max_payload_tokens" env:"MAX_PAYLOAD_TOKENS"`
+	MaxResultTokens            int   `yaml:"max_result_tokens" env:"MAX_RESULT_TOKENS"`
+	MaxContextTokens           int   `yaml:"max_context_tokens" env:"MAX_CONTEXT_TOKENS"`
+	CompressResultsOver        int   `yaml:"compress_results_over" env:"COMPRESS_RESULTS_OVER"`
+	TruncateResults            *bool `yaml:"truncate_results" env:"TRUNCATE_RESULTS"`
+}
+
+// VerificationConfig configures the verification policy, see verifierFromEnv
+// and verificationPipelinesFromEnv.
+type VerificationConfig struct {
+	Verifiers     []string `yaml:"verifiers" env:"VERIFIERS" flag:"verifiers"`
+	Substring     string   `yaml:"substring" env:"VERIFIER_SUBSTRING"`
+	Pattern       string   `yaml:"pattern" env:"VERIFIER_PATTERN"`
+	RulesFile     string   `yaml:"rules_file" env:"VERIFIER_RULES_FILE"`
+	PipelinesFile string   `yaml:"pipelines_file" env:"VERIFICATION_PIPELINES_FILE"`
+	Judge         struct {
+		Provider  string  `yaml:"provider" env:"JUDGE_PROVIDER"`
+		Model     string  `yaml:"model" env:"JUDGE_MODEL"`
+		Threshold float64 `yaml:"threshold" env:"JUDGE_THRESHOLD"`
+	} `yaml:"judge"`
+	SimilarityThreshold float64 `yaml:"similarity_threshold" env:"SIMILARITY_THRESHOLD"`
+}
+
+// settingsStore resolves the settings of the performer: flags take
+// precedence over the environment, which takes precedence over the
+// configuration file. Both are keyed by environment variable name.
+type settingsStore struct {
+	mu sync.RWMutex
+	// path is the configuration file, or "" when there is none.
+	path string
+	// profile is the profile selected by the flags or the environment, or
+	// "" to apply the one the file selects.
+	profile string
+	file    map[string]string
+	flags   map[string]string
+}
+
+// configSettings are the settings loaded by loadConfig.
+var configSettings = &settingsStore{}
+
+// getenv returns the setting named key, or "" when it is unset. It is used in
+// place of os.Getenv so that every setting can come from the configuration
+// file and flags as well.
+func getenv(key string) string {
+	configSettings.mu.RLock()
+	defer configSettings.mu.RUnlock()
+	if v, ok := configSettings.flags[key]; ok {
+		return v
+	}
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return configSettings.file[key]
+}
+
+// loadConfig loads the configuration file named by the -config flag or
+// CONFIG_FILE, if any, and the flags in args, and makes their settings
+// available through getenv.
+func loadConfig(args []string) error {
+	fs := flag.NewFlagSet("performer", flag.ContinueOnError)
+	path := fs.String("config", "", "YAML configuration `file`, overridden by the environment and flags")
+	version := fs.Bool("version", false, "print the version of the performer and exit")
+	flags := map[string]string{}
+	for _, f := range configFields(reflect.TypeOf(Config{})) {
+		if f.flag != "" {
+			fs.Var(&settingFlag{key: f.env, settings: flags, boolean: f.boolean}, f.flag, "overrides "+f.env)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %q", fs.Args())
+	}
+	if *version {
+		fmt.Fprintln(os.Stdout, currentBuildInfo())
+		return errVersionRequested
+	}
+
+	if *path == "" {
+		*path = os.Getenv("CONFIG_FILE")
+	}
+	profile, ok := flags["PERFORMER_PROFILE"]
+	if !ok {
+		profile = os.Getenv("PERFORMER_PROFILE")
+	}
+	var file map[string]string
+	switch {
+	case *path != "":
+		var err error
+		if file, err = readConfigFile(*path, profile); err != nil {
+			return err
+		}
+	case profile != "":
+		return fmt.Errorf("profile %q selected without a config file", profile)
+	}
+
+	configSettings.mu.Lock()
+	defer configSettings.mu.Unlock()
+	configSettings.path = *path
+	configSettings.profile = profile
+	configSettings.file = file
+	configSettings.flags = flags
+	return nil
+}
+
+// readConfigFile returns the settings of the configuration file at path, with
+// the named profile applied.
+func readConfigFile(path, profile string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	cfg, err := parseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	settings, err := cfg.profileSettings(profile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// configFilePath returns the configuration file loaded, or "" when there is
+// none.
+func configFilePath() string {
+	configSettings.mu.RLock()
+	defer configSettings.mu.RUnlock()
+	return configSettings.path
+}
+
+// configProfile returns the profile selected by the flags or the environment.
+func configProfile() string {
+	configSettings.mu.RLock()
+	defer configSettings.mu.RUnlock()
+	return configSettings.profile
+}
+
+// setFileSettings replaces the settings of the configuration file and
+// returns the previous ones.
+func setFileSettings(file map[string]string) map[string]string {
+	configSettings.mu.Lock()
+	defer configSettings.mu.Unlock()
+	previous := configSettings.file
+	configSettings.file = file
+	return previous
+}
+
+// performerSettingsFromEnv returns the port the performer serves tasks on and
+// how long a task may be handled for, from PERFORMER_PORT and TASK_TIMEOUT.
+func performerSettingsFromEnv() (int, time.Duration, error) {
+	port, err := envInt("PERFORMER_PORT")
+	if err != nil {
+		return 0, 0, err
+	}
+	if port < 0 || port > 65535 {
+		return 0, 0, fmt.Errorf("PERFORMER_PORT must be a valid port")
+	}
+	if port == 0 {
+		port = defaultPerformerPort
+	}
+	timeout, err := envDuration("TASK_TIMEOUT")
+	if err != nil {
+		return 0, 0, err
+	}
+	if timeout < 0 {
+		return 0, 0, fmt.Errorf("TASK_TIMEOUT must not be negative")
+	}
+	if timeout == 0 {
+		timeout = defaultTaskTimeout
+	}
+	return port, timeout, nil
+}
+
+// providerTimeoutFromEnv returns how long a provider request may take, from
+// PROVIDER_TIMEOUT.
+func providerTimeoutFromEnv() (time.Duration, error) {
+	timeout, err := envDuration("PROVIDER_TIMEOUT")
+	if err != nil {
+		return 0, err
+	}
+	if timeout < 0 {
+		return 0, fmt.Errorf("PROVIDER_TIMEOUT must not be negative")
+	}
+	if timeout == 0 {
+		timeout = defaultProviderTimeout
+	}
+	return timeout, nil
+}
+
+// verificationTimeoutFromEnv returns how long the verification of a result
+// may take, from VERIFICATION_TIMEOUT, or 0 when it is only bounded by the
+// task timeout.
+func verificationTimeoutFromEnv() (time.Duration, error) {
+	timeout, err := envDuration("VERIFICATION_TIMEOUT")
+	if err != nil {
+		return 0, err
+	}
+	if timeout < 0 {
+		return 0, fmt.Errorf("VERIFICATION_TIMEOUT must not be negative")
+	}
+	return timeout, nil
+}
+
+// validateTimeouts checks that a task has time for a provider request and the
+// verification of its result, so that tasks are not cut short by their own
+// deadline while the provider is still answering.
+func validateTimeouts() error {
+	_, task, err := performerSettingsFromEnv()
+	if err != nil {
+		return err
+	}
+	provider, err := providerTimeoutFromEnv()
+	if err != nil {
+		return err
+	}
+	verification, err := verificationTimeoutFromEnv()
+	if err != nil {
+		return err
+	}
+	if task <= provider+verification {
+		return fmt.Errorf("TASK_TIMEOUT (%s) must exceed PROVIDER_TIMEOUT (%s) plus VERIFICATION_TIMEOUT (%s)", task, provider, verification)
+	}
+	return nil
+}
+
+// parseConfig parses a YAML configuration, refusing unknown fields so that
+// misspelled settings are not silently ignored.
+func parseConfig(data []byte) (*Config, error) {
+	cfg := &Config{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	for name, profile := range cfg.Profiles {
+		if profile == nil {
+			return nil, fmt.Errorf("profile %s is empty", name)
+		}
+		if profile.Profile != "" || len(profile.Profiles) > 0 {
+			return nil, fmt.Errorf("profile %s cannot select or define profiles", name)
+		}
+	}
+	return cfg, nil
+}
+
+// profileSettings returns the settings of the configuration with the named
+// profile applied, or the profile selected by the file when name is "".
+func (c *Config) profileSettings(name string) (map[string]string, error) {
+	settings := c.settings()
+	if name == "" {
+		name = c.Profile
+	}
+	if name == "" {
+		return settings, nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(c.Profiles))
+		for n := range c.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown profile %q, the profiles are %s", name, strings.Join(names, ", "))
+	}
+	for key, value := range profile.settings() {
+		settings[key] = value
+	}
+	settings["PERFORMER_PROFILE"] = name
+	return settings, nil
+}
+
+// settings returns the settings of the configuration by environment variable
+// name, leaving out the fields that are not set.
+func (c *Config) settings() map[string]string {
+	settings := map[string]string{}
+	for key, value := range c.Env {
+		settings[key] = value
+	}
+	v := reflect.ValueOf(c).Elem()
+	for _, f := range configFields(v.Type()) {
+		if value, ok := formatSetting(v.FieldByIndex(f.index)); ok {
+			settings[f.env] = value
+		}
+	}
+	return settings
+}
+
+// configField is a setting of Config.
+type configField struct {
+	index []int
+	env   string
+	flag  string
+	// boolean fields are set by their flag alone, e.g. -dev.
+	boolean bool
+}
+
+// configFields returns the fields of t tagged with an environment variable,
+// descending into nested structs.
+func configFields(t reflect.Type) []configField {
+	var fields []configField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if env := sf.Tag.Get("env"); env != "" {
+			boolean := sf.Type == reflect.TypeOf((*bool)(nil))
+			fields = append(fields, configField{index: []int{i}, env: env, flag: sf.Tag.Get("flag"), boolean: boolean})
+			continue
+		}
+		if sf.Type.Kind() == reflect.Struct {
+			for _, nested := range configFields(sf.Type) {
+				nested.index = append([]int{i}, nested.index...)
+				fields = append(fields, nested)
+			}
+		}
+	}
+	return fields
+}
+
+// formatSetting formats v as its environment variable would be written,
+// reporting false when v is the zero value.
+func formatSetting(v reflect.Value) (string, bool) {
+	if v.IsZero() {
+		return "", false
+	}
+	switch x := v.Interface().(type) {
+	case string:
+		return x, true
+	case int:
+		return strconv.Itoa(x), true
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64), true
+	case time.Duration:
+		return x.String(), true
+	case *bool:
+		return strconv.FormatBool(*x), true
+	case []string:
+		return strings.Join(x, ","), true
+	default:
+		panic(fmt.Sprintf("unsupported config field type %T", x))
+	}
+}
+
+// settingFlag is a flag setting the environment variable key.
+type settingFlag struct {
+	key      string
+	settings map[string]string
+	boolean  bool
+}
+
+func (f *settingFlag) String() string {
+	if f == nil || f.settings == nil {
+		return ""
+	}
+	return f.settings[f.key]
+}
+
+func (f *settingFlag) Set(value string) error {
+	f.settings[f.key] = value
+	return nil
+}
+
+func (f *settingFlag) IsBoolFlag() bool {
+	return f.boolean
+}
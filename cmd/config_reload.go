@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// configPollInterval is how often the configuration file is checked for
+// changes.
+const configPollInterval = 10 * time.Second
+
+// restartSettings are the settings only read at startup. Changing them in
+// the configuration file has no effect until the performer is restarted.
+var restartSettings = []string{
+	"PERFORMER_PORT",
+	"HEALTH_PORT",
+	"METRICS_PORT",
+	"DEBUG_PORT",
+	"LLM_PROVIDER",
+	"LLM_PROVIDERS",
+	"LLM_ENSEMBLE",
+	"DEV_MODE",
+	"DRY_RUN",
+	"DETERMINISTIC",
+}
+
+// ConfigReloader applies changes to the configuration file without a
+// restart: the payload and result limits, the prompt templates and few-shot
+// examples, the system prompt, the route cost table and the pattern list.
+// Other settings are only read at startup.
+type ConfigReloader struct {
+	logger       *zap.Logger
+	worker       *TaskWorker
+	systemPrompt *SystemPromptProvider
+	patterns     *PatternFilter
+
+	mu sync.Mutex
+}
+
+// NewConfigReloader returns a reloader applying changes to worker.
+// systemPrompt and patterns are nil when no system prompt or pattern list is
+// configured.
+func NewConfigReloader(logger *zap.Logger, worker *TaskWorker, systemPrompt *SystemPromptProvider, patterns *PatternFilter) *ConfigReloader {
+	return &ConfigReloader{
+		logger:       logger,
+		worker:       worker,
+		systemPrompt: systemPrompt,
+		patterns:     patterns,
+	}
+}
+
+// Reload reads the configuration file again and applies it. Every reloadable
+// setting is checked before any is applied, so an invalid file leaves the
+// running configuration untouched and Reload returns a *ConfigError.
+func (r *ConfigReloader) Reload(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	path := configFilePath()
+	if path == "" {
+		return nil
+	}
+	file, err := readConfigFile(path, configProfile())
+	if err != nil {
+		configReloads.WithLabelValues("error").Inc()
+		return &ConfigError{Problems: []string{err.Error()}}
+	}
+	before := make(map[string]string, len(restartSettings))
+	for _, key := range restartSettings {
+		before[key] = getenv(key)
+	}
+	previous := setFileSettings(file)
+
+	var problems []string
+	check := func(setting string, err error) {
+		if err != nil {
+			problems = append(problems, setting+": "+err.Error())
+		}
+	}
+	limits, err := limitsConfigFromEnv()
+	check("limits", err)
+	templates, err := NewPromptTemplates(getenv("PROMPT_TEMPLATE_DIR"))
+	check("prompt templates", err)
+	examples, err := fewShotLibraryFromEnv()
+	check("few-shot examples", err)
+	prompt, err := systemPromptFromEnv()
+	check("system prompt", err)
+	var routes []RouteSpec
+	router := currentRouter.Load()
+	if router != nil {
+		routes, err = parseRouteSpecs(getenv("LLM_ROUTES"))
+		check("LLM_ROUTES", err)
+	}
+	if len(problems) > 0 {
+		setFileSettings(previous)
+		configReloads.WithLabelValues("error").Inc()
+		return &ConfigError{Problems: problems}
+	}
+	if router != nil {
+		if err := router.SetRouteSpecs(routes); err != nil {
+			setFileSettings(previous)
+			configReloads.WithLabelValues("error").Inc()
+			return &ConfigError{Problems: []string{"LLM_ROUTES: " + err.Error()}}
+		}
+	}
+
+	r.worker.SetLimits(limits)
+	r.worker.SetPromptTemplates(templates)
+	r.worker.SetFewShotExamples(examples)
+	switch {
+	case r.systemPrompt != nil && prompt != "":
+		r.systemPrompt.SetPrompt(prompt)
+	case r.systemPrompt == nil && prompt != "":
+		r.logger.Sugar().Warnw("A system prompt was configured, it is used after a restart")
+	case r.systemPrompt != nil && prompt == "":
+		r.logger.Sugar().Warnw("The system prompt was removed, it is kept until a restart")
+	}
+	for _, key := range restartSettings {
+		if getenv(key) != before[key] {
+			r.logger.Sugar().Warnw("Setting changed, it is applied after a restart", zap.String("setting", key))
+		}
+	}
+	configReloads.WithLabelValues("success").Inc()
+	r.logger.Sugar().Infow("Reloaded configuration", zap.String("path", path))
+	return nil
+}
+
+// Watch reloads the configuration on SIGHUP and when the configuration file
+// changes, until ctx is cancelled. The pattern list is reloaded along with
+// the file; it already reloads itself on SIGHUP.
+func (r *ConfigReloader) Watch(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	modified := r.modTime()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			modified = r.modTime()
+		case <-ticker.C:
+			t := r.modTime()
+			if t.Equal(modified) {
+				continue
+			}
+			modified = t
+			if r.patterns != nil {
+				if err := r.patterns.Reload(ctx); err != nil {
+					r.logger.Sugar().Errorw("Failed to reload pattern list", zap.Error(err))
+				}
+			}
+		}
+		if err := r.Reload(ctx); err != nil {
+			r.logger.Sugar().Errorw("Failed to reload configuration, keeping the running one", zap.Error(err))
+		}
+	}
+}
+
+// modTime returns the modification time of the configuration file, or the
+// zero time when it cannot be read.
+func (r *ConfigReloader) modTime() time.Time {
+	info, err := os.Stat(configFilePath())
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_ConfigReloaderReload(t *testing.T) {
+	tests := []struct {
+		name           string
+		reloaded       string
+		wantErr        bool
+		wantPayload    int
+		wantPromptHash string
+	}{
+		{
+			name:           "applies changes",
+			reloaded:       "limits:\n  max_payload_size: 2048\nenv:\n  OPERATOR_SYSTEM_PROMPT: be brief\n",
+			wantPayload:    2048,
+			wantPromptHash: NewSystemPromptProvider(nil, "be brief").Hash(),
+		},
+		{
+			name:           "keeps the running configuration on errors",
+			reloaded:       "limits:\n  max_payload_size: 2048\nenv:\n  MAX_RESULT_SIZE: lots\n",
+			wantErr:        true,
+			wantPayload:    1024,
+			wantPromptHash: NewSystemPromptProvider(nil, "be verbose").Hash(),
+		},
+		{
+			name:           "rejects unknown settings",
+			reloaded:       "limits:\n  max_payload_bytes: 2048\n",
+			wantErr:        true,
+			wantPayload:    1024,
+			wantPromptHash: NewSystemPromptProvider(nil, "be verbose").Hash(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withConfigSettings(t)
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte("limits:\n  max_payload_size: 1024\nenv:\n  OPERATOR_SYSTEM_PROMPT: be verbose\n"), 0o600))
+			require.NoError(t, loadConfig([]string{"-config", path}))
+
+			limits, err := limitsConfigFromEnv()
+			require.NoError(t, err)
+			w := NewTaskWorker(zap.NewNop(), &stubProvider{}, nil)
+			w.SetLimits(limits)
+			sp := NewSystemPromptProvider(&stubProvider{}, "be verbose")
+			r := NewConfigReloader(zap.NewNop(), w, sp, nil)
+
+			require.NoError(t, os.WriteFile(path, []byte(tt.reloaded), 0o600))
+			err = r.Reload(context.Background())
+			if tt.wantErr {
+				var configErr *ConfigError
+				assert.True(t, errors.As(err, &configErr))
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantPayload, w.limits.Load().MaxPayloadSize)
+			assert.Equal(t, tt.wantPromptHash, sp.Hash())
+			assert.Equal(t, tt.wantPayload, mustEnvInt(t, "MAX_PAYLOAD_SIZE"))
+		})
+	}
+}
+
+func Test_RouterProviderSetRouteSpecs(t *testing.T) {
+	tests := []struct {
+		name     string
+		specs    []RouteSpec
+		wantErr  bool
+		wantCost float64
+	}{
+		{
+			name:     "updates costs",
+			specs:    []RouteSpec{{Provider: "Stub", CostPer1KTokens: 0.5, MaxPromptTokens: 100}},
+			wantCost: 0.5,
+		},
+		{
+			name:     "rejects a new provider",
+			specs:    []RouteSpec{{Provider: "openai", CostPer1KTokens: 0.5}},
+			wantErr:  true,
+			wantCost: 1,
+		},
+		{
+			name:     "rejects a new route",
+			specs:    []RouteSpec{{Provider: "stub", CostPer1KTokens: 0.5}, {Provider: "stub", CostPer1KTokens: 2}},
+			wantErr:  true,
+			wantCost: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, err := NewRouterProvider(zap.NewNop(), Route{Provider: &stubProvider{}, CostPer1KTokens: 1})
+			require.NoError(t, err)
+
+			err = router.SetRouteSpecs(tt.specs)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCost, router.selectRoute(10, false).CostPer1KTokens)
+		})
+	}
+}
+
+func mustEnvInt(t *testing.T, key string) int {
+	t.Helper()
+	v, err := envInt(key)
+	require.NoError(t, err)
+	return v
+}
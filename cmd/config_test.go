@@ -0,0 +1,290 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// withConfigSettings gives the test settings of its own, restoring the loaded
+// settings when it ends.
+func withConfigSettings(t *testing.T) {
+	saved := configSettings
+	configSettings = &settingsStore{}
+	t.Cleanup(func() { configSettings = saved })
+}
+
+func Test_parseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "empty", want: map[string]string{}},
+		{
+			name: "typed settings",
+			yaml: `
+port: 9090
+task_timeout: 30s
+logging:
+  sampling: false
+provider:
+  name: openai
+  failover: [openai, anthropic]
+  openai:
+    model: gpt-4o-mini
+  retry:
+    max_attempts: 5
+limits:
+  max_payload_size: 8192
+  truncate_results: false
+verification:
+  verifiers: [non_empty, judge]
+  judge:
+    threshold: 0.75
+`,
+			want: map[string]string{
+				"PERFORMER_PORT":     "9090",
+				"TASK_TIMEOUT":       "30s",
+				"LOG_SAMPLING":       "false",
+				"LLM_PROVIDER":       "openai",
+				"LLM_PROVIDERS":      "openai,anthropic",
+				"OPENAI_MODEL":       "gpt-4o-mini",
+				"RETRY_MAX_ATTEMPTS": "5",
+				"MAX_PAYLOAD_SIZE":   "8192",
+				"TRUNCATE_RESULTS":   "false",
+				"VERIFIERS":          "non_empty,judge",
+				"JUDGE_THRESHOLD":    "0.75",
+			},
+		},
+		{
+			name: "fields take precedence over env",
+			yaml: "provider:\n  name: anthropic\nenv:\n  LLM_PROVIDER: openai\n  OPENAI_API_KEY: vault:secret/openai#key\n",
+			want: map[string]string{"LLM_PROVIDER": "anthropic", "OPENAI_API_KEY": "vault:secret/openai#key"},
+		},
+		{name: "unknown field", yaml: "provider:\n  nmae: openai\n", wantErr: true},
+		{name: "invalid duration", yaml: "task_timeout: soon\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseConfig([]byte(tt.yaml))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if got := cfg.settings(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected settings %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_loadConfig(t *testing.T) {
+	withConfigSettings(t)
+	path := filepath.Join(t.TempDir(), "performer.yaml")
+	if err := os.WriteFile(path, []byte("port: 9090\ntask_timeout: 30s\nprovider:\n  name: openai\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("TASK_TIMEOUT", "20s")
+	t.Setenv("LLM_PROVIDER", "anthropic")
+	t.Setenv("PERFORMER_PORT", "")
+
+	if err := loadConfig([]string{"-config", path, "-provider", "ollama"}); err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "PERFORMER_PORT", want: "9090"},
+		{key: "TASK_TIMEOUT", want: "20s"},
+		{key: "LLM_PROVIDER", want: "ollama"},
+		{key: "OPENAI_MODEL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := getenv(tt.key); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	port, timeout, err := performerSettingsFromEnv()
+	if err != nil || port != 9090 || timeout != 20*time.Second {
+		t.Errorf("unexpected performer settings %d, %v, %v", port, timeout, err)
+	}
+}
+
+func Test_loadConfigErrors(t *testing.T) {
+	withConfigSettings(t)
+	t.Setenv("CONFIG_FILE", "")
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "missing file", args: []string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}},
+		{name: "unknown flag", args: []string{"-prvider", "openai"}},
+		{name: "positional argument", args: []string{"openai"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := loadConfig(tt.args); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func Test_loadConfigProfiles(t *testing.T) {
+	withConfigSettings(t)
+	t.Setenv("CONFIG_FILE", "")
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		return path
+	}
+	profiles := write("profiles.yaml", `
+profile: mainnet
+limits:
+  max_payload_size: 1024
+verification:
+  verifiers: [non_empty]
+profiles:
+  devnet:
+    dev: true
+  testnet:
+    limits:
+      max_payload_size: 65536
+  mainnet:
+    provider:
+      failover: [openai, anthropic]
+    verification:
+      verifiers: [non_empty, judge, fact_check]
+`)
+
+	tests := []struct {
+		name    string
+		path    string
+		args    []string
+		env     string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "selected by the file",
+			path: profiles,
+			want: map[string]string{"PERFORMER_PROFILE": "mainnet", "MAX_PAYLOAD_SIZE": "1024", "LLM_PROVIDERS": "openai,anthropic", "VERIFIERS": "non_empty,judge,fact_check"},
+		},
+		{
+			name: "selected by the environment",
+			path: profiles,
+			env:  "testnet",
+			want: map[string]string{"PERFORMER_PROFILE": "testnet", "MAX_PAYLOAD_SIZE": "65536", "LLM_PROVIDERS": "", "VERIFIERS": "non_empty"},
+		},
+		{
+			name: "selected by the flag",
+			path: profiles,
+			args: []string{"-profile", "devnet"},
+			env:  "testnet",
+			want: map[string]string{"PERFORMER_PROFILE": "devnet", "DEV_MODE": "true", "MAX_PAYLOAD_SIZE": "1024"},
+		},
+		{name: "unknown profile", path: profiles, env: "localnet", wantErr: true},
+		{name: "without a config file", env: "devnet", wantErr: true},
+		{name: "nested profiles", path: write("nested.yaml", "profiles:\n  devnet:\n    profile: testnet\n"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PERFORMER_PROFILE", tt.env)
+			for key := range tt.want {
+				if key != "PERFORMER_PROFILE" {
+					t.Setenv(key, "")
+				}
+			}
+			args := tt.args
+			if tt.path != "" {
+				args = append([]string{"-config", tt.path}, args...)
+			}
+			err := loadConfig(args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			for key, want := range tt.want {
+				if got := getenv(key); got != want {
+					t.Errorf("expected %s=%q, got %q", key, want, got)
+				}
+			}
+		})
+	}
+}
+
+func Test_performerSettingsFromEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		port        string
+		timeout     string
+		wantPort    int
+		wantTimeout time.Duration
+		wantErr     bool
+	}{
+		{name: "defaults", wantPort: defaultPerformerPort, wantTimeout: defaultTaskTimeout},
+		{name: "set", port: "9090", timeout: "1m", wantPort: 9090, wantTimeout: time.Minute},
+		{name: "invalid port", port: "70000", wantErr: true},
+		{name: "negative timeout", timeout: "-1s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PERFORMER_PORT", tt.port)
+			t.Setenv("TASK_TIMEOUT", tt.timeout)
+			port, timeout, err := performerSettingsFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if port != tt.wantPort || timeout != tt.wantTimeout {
+				t.Errorf("expected %d and %v, got %d and %v", tt.wantPort, tt.wantTimeout, port, timeout)
+			}
+		})
+	}
+}
+
+func Test_validateTimeouts(t *testing.T) {
+	tests := []struct {
+		name         string
+		task         string
+		provider     string
+		verification string
+		wantErr      bool
+	}{
+		{name: "defaults"},
+		{name: "room for verification", task: "30s", provider: "10s", verification: "15s"},
+		{name: "task shorter than provider", task: "5s", wantErr: true},
+		{name: "no room for verification", task: "20s", provider: "10s", verification: "10s", wantErr: true},
+		{name: "negative provider timeout", provider: "-1s", wantErr: true},
+		{name: "invalid verification timeout", verification: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TASK_TIMEOUT", tt.task)
+			t.Setenv("PROVIDER_TIMEOUT", tt.provider)
+			t.Setenv("VERIFICATION_TIMEOUT", tt.verification)
+			if err := validateTimeouts(); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// endpointSettings are the settings holding the URL of a service the
+// performer calls.
+var endpointSettings = []string{
+	"OPENAI_BASE_URL",
+	"ANTHROPIC_BASE_URL",
+	"GROQ_BASE_URL",
+	"OPENROUTER_BASE_URL",
+	"OPENAI_COMPATIBLE_BASE_URL",
+	"AZURE_OPENAI_ENDPOINT",
+	"VERTEX_BASE_URL",
+	"OLLAMA_HOST",
+	"TGI_URL",
+	"FACT_CHECK_SEARCH_URL",
+	"AUDIT_LOG_URL",
+	"COST_CAP_WEBHOOK_URL",
+	"VAULT_ADDR",
+}
+
+// portSettings are the settings holding a port the performer listens on.
+var portSettings = []string{"HEALTH_PORT", "METRICS_PORT", "DEBUG_PORT", "REVERIFY_PORT"}
+
+// providerVerifiers are the verifiers that call a provider of their own, so
+// they are only checked once the providers are built.
+var providerVerifiers = map[string]bool{
+	"similarity":       true,
+	"judge":            true,
+	"fact_check":       true,
+	"self_consistency": true,
+}
+
+// ConfigError lists every problem found in the configuration, so operators
+// can fix them all at once instead of one restart at a time.
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid configuration, %d problem(s) found:", len(e.Problems))
+	for _, problem := range e.Problems {
+		b.WriteString("\n  - ")
+		b.WriteString(problem)
+	}
+	return b.String()
+}
+
+// validateConfig checks the configuration of the performer before anything
+// is started: provider credentials, endpoint URLs, ports, limits, TLS files
+// and the other settings that can be checked without calling out to a
+// provider. It returns a *ConfigError listing all the problems found.
+func validateConfig(ctx context.Context) error {
+	var problems []string
+	check := func(setting string, err error) {
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", setting, err))
+		}
+	}
+
+	err := validateTimeouts()
+	check("performer", err)
+	_, err = shutdownGracePeriodFromEnv()
+	check("shutdown", err)
+	_, err = loggerConfigFromEnv()
+	check("logging", err)
+	_, err = dryRunFromEnv()
+	check("dry run", err)
+	_, err = deterministicFromEnv()
+	check("deterministic mode", err)
+	_, err = logRedactionFromEnv()
+	check("log redaction", err)
+	for _, key := range portSettings {
+		check(key, validatePort(key))
+	}
+	_, err = debugAddrFromEnv()
+	check("debug server", err)
+
+	names, err := providerNamesFromEnv()
+	check("providers", err)
+	for _, name := range names {
+		_, err := newNamedProviderFromEnv(ctx, name)
+		check("provider "+name, err)
+	}
+	for _, key := range endpointSettings {
+		if v := getenv(key); v != "" {
+			check(key, validateEndpoint(v))
+		}
+	}
+	_, err = transportConfigFromEnv()
+	check("HTTP transport", err)
+	_, err = retryConfigFromEnv()
+	check("retries", err)
+	_, err = circuitBreakerConfigFromEnv()
+	check("circuit breaker", err)
+	_, err = batchConfigFromEnv()
+	check("batching", err)
+
+	_, err = limitsConfigFromEnv()
+	check("limits", err)
+	_, err = payloadDecoderFromEnv()
+	check("payload encoding", err)
+	_, err = resultEncoderFromEnv()
+	check("result encoding", err)
+	_, err = tokenBudgetFromEnv()
+	check("token budget", err)
+	_, err = senderRateLimiterFromEnv()
+	check("rate limits", err)
+	_, err = workerPoolFromEnv()
+	check("worker pool", err)
+	_, err = loadShedderFromEnv()
+	check("load shedding", err)
+	_, err = idempotencyFromEnv()
+	check("idempotency", err)
+
+	for _, name := range strings.Split(getenv("VERIFIERS"), ",") {
+		if name = strings.TrimSpace(name); name != "" && !providerVerifiers[name] {
+			_, err := newVerifierFromEnv(ctx, nil, name)
+			check("verifier "+name, err)
+		}
+	}
+	_, err = outputSanitizerFromEnv()
+	check("output sanitizer", err)
+	_, err = piiRedactorFromEnv()
+	check("PII redaction", err)
+	_, err = systemPromptFromEnv()
+	check("system prompt", err)
+	_, err = fewShotLibraryFromEnv()
+	check("few-shot examples", err)
+
+	_, err = serverTLSConfigFromEnv()
+	check("TLS", err)
+
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+// providerNamesFromEnv returns the names of the providers tasks are sent to,
+// from LLM_ROUTES, LLM_ENSEMBLE, LLM_PROVIDERS or LLM_PROVIDER.
+func providerNamesFromEnv() ([]string, error) {
+	dev, err := devModeFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if dev {
+		return []string{"mock"}, nil
+	}
+	ensemble := strings.TrimSpace(getenv("LLM_ENSEMBLE"))
+	chain := strings.TrimSpace(getenv("LLM_PROVIDERS"))
+	routes := strings.TrimSpace(getenv("LLM_ROUTES"))
+
+	var names []string
+	switch {
+	case (ensemble != "" && chain != "") || (ensemble != "" && routes != "") || (chain != "" && routes != ""):
+		return nil, fmt.Errorf("only one of LLM_ENSEMBLE, LLM_PROVIDERS and LLM_ROUTES can be set")
+	case routes != "":
+		specs, err := parseRouteSpecs(routes)
+		if err != nil {
+			return nil, err
+		}
+		for _, spec := range specs {
+			names = append(names, spec.Provider)
+		}
+	case ensemble != "":
+		names = strings.Split(ensemble, ",")
+	case chain != "":
+		names = strings.Split(chain, ",")
+	default:
+		names = []string{getenv("LLM_PROVIDER")}
+	}
+	for i, name := range names {
+		names[i] = strings.TrimSpace(name)
+	}
+	return names, nil
+}
+
+// validateEndpoint checks that v is an absolute HTTP or HTTPS URL.
+func validateEndpoint(v string) error {
+	u, err := url.Parse(v)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q must be an http:// or https:// URL", v)
+	}
+	return nil
+}
+
+// validatePort checks the port setting named key, if set.
+func validatePort(key string) error {
+	port, err := envInt(key)
+	if err != nil {
+		return err
+	}
+	if port < 0 || port > 65535 {
+		return fmt.Errorf("%d is not a valid port", port)
+	}
+	return nil
+}
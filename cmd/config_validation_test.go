@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_validateConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantProblems []string
+	}{
+		{name: "valid", env: map[string]string{"LLM_PROVIDER": "ollama"}},
+		{
+			name:         "missing credentials",
+			env:          map[string]string{"LLM_PROVIDER": "azure", "AZURE_OPENAI_ENDPOINT": "https://example.openai.azure.com"},
+			wantProblems: []string{"provider azure"},
+		},
+		{
+			name: "aggregated",
+			env: map[string]string{
+				"LLM_PROVIDERS":    "ollama,azure",
+				"OPENAI_BASE_URL":  "api.openai.com/v1",
+				"MAX_PAYLOAD_SIZE": "big",
+				"METRICS_PORT":     "99999",
+				"VERIFIERS":        "non_empty,contains,judge",
+			},
+			wantProblems: []string{"METRICS_PORT", "provider azure", "OPENAI_BASE_URL", "limits", "verifier contains"},
+		},
+		{
+			name:         "conflicting provider modes",
+			env:          map[string]string{"LLM_PROVIDERS": "ollama", "LLM_ENSEMBLE": "ollama,ollama"},
+			wantProblems: []string{"providers"},
+		},
+		{
+			name:         "missing TLS key",
+			env:          map[string]string{"LLM_PROVIDER": "ollama", "PERFORMER_TLS_CERT_FILE": "/nonexistent/cert.pem"},
+			wantProblems: []string{"TLS"},
+		},
+	}
+
+	keys := []string{"LLM_PROVIDER", "LLM_PROVIDERS", "LLM_ENSEMBLE", "LLM_ROUTES", "AZURE_OPENAI_ENDPOINT", "AZURE_OPENAI_KEY", "OPENAI_BASE_URL",
+		"MAX_PAYLOAD_SIZE", "METRICS_PORT", "VERIFIERS", "VERIFIER_SUBSTRING", "PERFORMER_TLS_CERT_FILE", "PERFORMER_TLS_KEY_FILE"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range keys {
+				t.Setenv(key, tt.env[key])
+			}
+			err := validateConfig(context.Background())
+			if len(tt.wantProblems) == 0 {
+				if err != nil {
+					t.Fatalf("expected a valid configuration, got %v", err)
+				}
+				return
+			}
+			var cfgErr *ConfigError
+			if !errors.As(err, &cfgErr) {
+				t.Fatalf("expected a ConfigError, got %v", err)
+			}
+			if len(cfgErr.Problems) != len(tt.wantProblems) {
+				t.Fatalf("expected %d problems, got %v", len(tt.wantProblems), err)
+			}
+			for i, want := range tt.wantProblems {
+				if !strings.HasPrefix(cfgErr.Problems[i], want+": ") {
+					t.Errorf("expected problem %d about %s, got %q", i, want, cfgErr.Problems[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_validateEndpoint(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{url: "https://api.openai.com/v1"},
+		{url: "http://localhost:11434"},
+		{url: "api.openai.com/v1", wantErr: true},
+		{url: "ftp://example.com", wantErr: true},
+		{url: "https://", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if err := validateEndpoint(tt.url); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// correlationIDHeader carries the correlation ID of a task to providers.
+	correlationIDHeader = "X-Correlation-ID"
+	// correlationIDMetadataKey is the gRPC metadata the executor may send the
+	// correlation ID of a task in.
+	correlationIDMetadataKey = "x-correlation-id"
+	// maxCorrelationIDLength bounds the correlation IDs taken from callers.
+	maxCorrelationIDLength = 128
+)
+
+// correlationIDKey is the span attribute carrying the correlation ID.
+const correlationIDKey = attribute.Key("correlation.id")
+
+// correlationIDNamespace is the UUID namespace of the correlation IDs
+// generated from TaskIds.
+var correlationIDNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://github.com/Layr-Labs/hourglass-avs-template/correlation-id"))
+
+type correlationIDContextKey struct{}
+
+// withTaskCorrelationID returns ctx carrying the correlation ID of t: the ID
+// already in ctx, the x-correlation-id gRPC metadata of the call, the
+// "correlation_id" of the task metadata, or else one generated from the
+// TaskId. The same ID is attached to the logs, spans and provider requests of
+// the task and to its result, so a task can be followed from the aggregator
+// to the provider. Generating it from the TaskId keeps it, and the result
+// digest, the same on every operator and every redelivery of the task.
+func withTaskCorrelationID(ctx context.Context, t *performerV1.TaskRequest) context.Context {
+	if correlationID(ctx) != "" {
+		return ctx
+	}
+	id := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(correlationIDMetadataKey); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	if id == "" && len(t.Metadata) > 0 {
+		var md struct {
+			CorrelationID string `json:"correlation_id"`
+		}
+		if json.Unmarshal(t.Metadata, &md) == nil {
+			id = md.CorrelationID
+		}
+	}
+	if id = strings.TrimSpace(id); id == "" || len(id) > maxCorrelationIDLength || !isPrintableASCII(id) {
+		id = generatedCorrelationID(t)
+	}
+	return context.WithValue(ctx, correlationIDContextKey{}, id)
+}
+
+// generatedCorrelationID derives the correlation ID of a task from its TaskId,
+// falling back to a random ID for tasks without one.
+func generatedCorrelationID(t *performerV1.TaskRequest) string {
+	if len(t.TaskId) == 0 {
+		return uuid.NewString()
+	}
+	return uuid.NewSHA1(correlationIDNamespace, t.TaskId).String()
+}
+
+// correlationID returns the correlation ID in ctx, or "" when there is none.
+func correlationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDContextKey{}).(string)
+	return id
+}
+
+// correlatedLogger returns logger tagged with the correlation ID in ctx.
+func correlatedLogger(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	if id := correlationID(ctx); id != "" {
+		return logger.With(zap.String("correlationId", id))
+	}
+	return logger
+}
+
+// isPrintableASCII reports whether s can be sent as an HTTP header value.
+func isPrintableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// correlationTransport sends the correlation ID of the task of a provider
+// request in the X-Correlation-ID header.
+type correlationTransport struct {
+	base http.RoundTripper
+}
+
+func (t *correlationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if id := correlationID(req.Context()); id != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(correlationIDHeader, id)
+	}
+	return t.base.RoundTrip(req)
+}
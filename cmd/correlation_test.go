@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc/metadata"
+)
+
+func Test_withTaskCorrelationID(t *testing.T) {
+	task := &performerV1.TaskRequest{TaskId: []byte("task-1"), Metadata: []byte(`{"correlation_id":"from-metadata"}`)}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		task *performerV1.TaskRequest
+		want string
+	}{
+		{name: "already set", ctx: context.WithValue(context.Background(), correlationIDContextKey{}, "from-ctx"), task: task, want: "from-ctx"},
+		{name: "grpc metadata", ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(correlationIDMetadataKey, "from-grpc")), task: task, want: "from-grpc"},
+		{name: "task metadata", ctx: context.Background(), task: task, want: "from-metadata"},
+		{name: "generated", ctx: context.Background(), task: &performerV1.TaskRequest{TaskId: []byte("task-1")}, want: generatedCorrelationID(&performerV1.TaskRequest{TaskId: []byte("task-1")})},
+		{name: "unprintable", ctx: context.Background(), task: &performerV1.TaskRequest{TaskId: []byte("task-1"), Metadata: []byte(`{"correlation_id":"a\nb"}`)}, want: generatedCorrelationID(&performerV1.TaskRequest{TaskId: []byte("task-1")})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := correlationID(withTaskCorrelationID(tt.ctx, tt.task)); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	if a, b := generatedCorrelationID(&performerV1.TaskRequest{}), generatedCorrelationID(&performerV1.TaskRequest{}); a == "" || a == b {
+		t.Errorf("expected random IDs for tasks without a TaskId, got %q and %q", a, b)
+	}
+}
+
+func Test_correlationTransport(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(correlationIDHeader)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &correlationTransport{base: http.DefaultTransport}}
+	ctx := context.WithValue(context.Background(), correlationIDContextKey{}, "corr-1")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if got != "corr-1" {
+		t.Errorf("expected the correlation ID header, got %q", got)
+	}
+}
+
+func Test_HandleTaskCorrelationID(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	tw := NewTaskWorker(zap.New(core), &stubProvider{output: "4"}, nil)
+
+	resp, err := tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?"), Metadata: []byte(`{"correlation_id":"corr-1"}`)})
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+	var result struct {
+		Metadata map[string]interface{} `json:"metadata"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if result.Metadata["correlation_id"] != "corr-1" {
+		t.Errorf("expected the correlation ID in the result metadata, got %v", result.Metadata)
+	}
+	for _, entry := range logs.All() {
+		if entry.ContextMap()["correlationId"] != "corr-1" {
+			t.Errorf("expected %q to carry the correlation ID, got %v", entry.Message, entry.ContextMap())
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// Actions of the cost cap once spend reaches it.
+const (
+	costCapActionReject    = "reject"
+	costCapActionDowngrade = "downgrade"
+
+	defaultCostCapWindow = 24 * time.Hour
+)
+
+// ErrCostCapExceeded is matched by the CostCapError of a task refused because
+// the operator's spend reached the cost cap.
+var ErrCostCapExceeded = errors.New("cost cap exceeded")
+
+// CostCapError reports the spend of the current window and when it resets.
+type CostCapError struct {
+	Spend      float64
+	Cap        float64
+	RetryAfter time.Duration
+}
+
+func (e *CostCapError) Error() string {
+	return fmt.Sprintf("spend of $%.4f reached the cost cap of $%.2f, resets in %s", e.Spend, e.Cap, e.RetryAfter.Round(time.Second))
+}
+
+func (e *CostCapError) Is(target error) bool {
+	return target == ErrCostCapExceeded
+}
+
+func (e *CostCapError) retryAfter() time.Duration {
+	return e.RetryAfter
+}
+
+// ModelPrice is the price of a model in USD per 1000 prompt and completion
+// tokens.
+type ModelPrice struct {
+	InputPer1K  float64 `yaml:"input_per_1k"`
+	OutputPer1K float64 `yaml:"output_per_1k"`
+}
+
+// PricingTable holds the prices of models by name. The "*" entry prices
+// models without their own entry.
+type PricingTable map[string]ModelPrice
+
+// Cost returns the price of a completion of model.
+func (t PricingTable) Cost(model string, tokensIn, tokensOut int) (float64, bool) {
+	price, ok := t[model]
+	if !ok {
+		if price, ok = t["*"]; !ok {
+			return 0, false
+		}
+	}
+	return (float64(tokensIn)*price.InputPer1K + float64(tokensOut)*price.OutputPer1K) / 1000, true
+}
+
+// CostCapProvider prices every completion with the pricing table and keeps a
+// running spend per window, aligned to the UTC clock. Once the spend reaches
+// the cap, completions are rejected or, under the downgrade action, served
+// with the cheaper fallback model of the default provider until the window
+// ends. The first breach of each window is posted to the webhook, if any.
+type CostCapProvider struct {
+	Provider
+	logger   *zap.Logger
+	pricing  PricingTable
+	cap      float64
+	window   time.Duration
+	action   string
+	fallback string
+	webhook  string
+	client   *http.Client
+	now      func() time.Time
+
+	mu       sync.Mutex
+	start    time.Time
+	spend    float64
+	breached bool
+	unpriced map[string]bool
+}
+
+// costCapFromEnv wraps p with the cost cap configured by COST_CAP (USD per
+// window) and COST_PRICING_FILE (a YAML or JSON table of model prices), and
+// returns p unchanged when COST_CAP is unset. COST_CAP_WINDOW sets the window
+// (24h by default), COST_CAP_ACTION the action ("reject" by default, or
+// "downgrade" to COST_CAP_FALLBACK_MODEL) and COST_CAP_WEBHOOK_URL the URL
+// breaches are posted to.
+func costCapFromEnv(logger *zap.Logger, p Provider) (Provider, error) {
+	limit, err := envFloat("COST_CAP")
+	if err != nil {
+		return nil, err
+	}
+	if limit < 0 {
+		return nil, fmt.Errorf("COST_CAP must not be negative")
+	}
+	if limit == 0 {
+		return p, nil
+	}
+
+	path := getenv("COST_PRICING_FILE")
+	if path == "" {
+		return nil, fmt.Errorf("COST_CAP requires COST_PRICING_FILE")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing table: %w", err)
+	}
+	pricing, err := ParsePricingTable(data)
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewCostCapProvider(logger, p, pricing, limit)
+	if c.window, err = envDuration("COST_CAP_WINDOW"); err != nil {
+		return nil, err
+	}
+	if c.window == 0 {
+		c.window = defaultCostCapWindow
+	}
+	switch action := getenv("COST_CAP_ACTION"); action {
+	case "", costCapActionReject:
+	case costCapActionDowngrade:
+		c.action = action
+		if c.fallback = getenv("COST_CAP_FALLBACK_MODEL"); c.fallback == "" {
+			return nil, fmt.Errorf("COST_CAP_ACTION=%s requires COST_CAP_FALLBACK_MODEL", costCapActionDowngrade)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported COST_CAP_ACTION %q, expected %s or %s", action, costCapActionReject, costCapActionDowngrade)
+	}
+	c.webhook = getenv("COST_CAP_WEBHOOK_URL")
+	return c, nil
+}
+
+// ParsePricingTable parses a pricing table:
+//
+//	gpt-4o-mini: {input_per_1k: 0.00015, output_per_1k: 0.0006}
+//	"*": {input_per_1k: 0.005, output_per_1k: 0.015}
+func ParsePricingTable(data []byte) (PricingTable, error) {
+	var t PricingTable
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&t); err != nil {
+		return nil, fmt.Errorf("invalid pricing table: %w", err)
+	}
+	for model, price := range t {
+		if price.InputPer1K < 0 || price.OutputPer1K < 0 {
+			return nil, fmt.Errorf("price of %s must not be negative", model)
+		}
+	}
+	return t, nil
+}
+
+func NewCostCapProvider(logger *zap.Logger, p Provider, pricing PricingTable, limit float64) *CostCapProvider {
+	return &CostCapProvider{
+		Provider: p,
+		logger:   logger,
+		pricing:  pricing,
+		cap:      limit,
+		window:   defaultCostCapWindow,
+		action:   costCapActionReject,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		now:      time.Now,
+		unpriced: map[string]bool{},
+	}
+}
+
+func (c *CostCapProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	if err := c.check(); err != nil {
+		if c.action != costCapActionDowngrade {
+			return nil, err
+		}
+		downgraded := *req
+		downgraded.Model = c.fallback
+		downgraded.RequestedModel, downgraded.RequestedProvider = "", ""
+		req = &downgraded
+	}
+
+	resp, err := c.Provider.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	tokensIn, tokensOut := resp.TokensIn, resp.TokensOut
+	if tokensIn == 0 {
+		tokensIn = estimateTokens(req)
+	}
+	if tokensOut == 0 {
+		tokensOut = estimateTextTokens(resp.Output)
+	}
+	model := resp.Model
+	if model == "" {
+		model = req.Model
+	}
+	c.record(model, tokensIn, tokensOut)
+	return resp, nil
+}
+
+// check returns a CostCapError once the spend of the current window reached
+// the cap.
+func (c *CostCapProvider) check() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.roll(now)
+	if c.spend < c.cap {
+		return nil
+	}
+	return &CostCapError{Spend: c.spend, Cap: c.cap, RetryAfter: c.start.Add(c.window).Sub(now)}
+}
+
+// record adds the cost of a completion to the spend and alerts on the first
+// breach of the window.
+func (c *CostCapProvider) record(model string, tokensIn, tokensOut int) {
+	cost, ok := c.pricing.Cost(model, tokensIn, tokensOut)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !ok && !c.unpriced[model] {
+		c.unpriced[model] = true
+		c.logger.Sugar().Warnw("Model has no price, its spend is not counted", zap.String("model", model))
+	}
+	c.roll(c.now())
+	c.spend += cost
+	providerCost.WithLabelValues(model).Add(cost)
+	costCapSpend.Set(c.spend)
+
+	if c.spend >= c.cap && !c.breached {
+		c.breached = true
+		costCapBreaches.Inc()
+		c.logger.Sugar().Warnw("Cost cap reached",
+			zap.Float64("spend", c.spend),
+			zap.Float64("cap", c.cap),
+			zap.String("action", c.action),
+		)
+		if c.webhook != "" {
+			go c.alert(c.spend, c.start)
+		}
+	}
+}
+
+// roll starts a new window once the current one has passed.
+func (c *CostCapProvider) roll(now time.Time) {
+	if start := now.UTC().Truncate(c.window); !start.Equal(c.start) {
+		c.start, c.spend, c.breached = start, 0, false
+		costCapSpend.Set(0)
+	}
+}
+
+// costCapAlert is the body posted to the webhook on a breach.
+type costCapAlert struct {
+	Event       string    `json:"event"`
+	Spend       float64   `json:"spend"`
+	Cap         float64   `json:"cap"`
+	WindowStart time.Time `json:"window_start"`
+	Action      string    `json:"action"`
+}
+
+func (c *CostCapProvider) alert(spend float64, windowStart time.Time) {
+	body, _ := json.Marshal(costCapAlert{Event: "cost_cap_exceeded", Spend: spend, Cap: c.cap, WindowStart: windowStart, Action: c.action})
+	resp, err := c.client.Post(c.webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		c.logger.Sugar().Errorw("Failed to post cost cap alert", zap.Error(err))
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		c.logger.Sugar().Errorw("Cost cap webhook rejected the alert", zap.Int("status", resp.StatusCode))
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// pricedStubProvider answers with a fixed token usage and records the model
+// of the last request.
+type pricedStubProvider struct {
+	lastModel string
+}
+
+func (p *pricedStubProvider) Name() string { return "priced" }
+
+func (p *pricedStubProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	p.lastModel = req.Model
+	model := req.Model
+	if model == "" {
+		model = "large"
+	}
+	return &CompletionResponse{Output: "4", Model: model, TokensIn: 1000, TokensOut: 1000}, nil
+}
+
+func Test_PricingTableCost(t *testing.T) {
+	table := PricingTable{
+		"large": {InputPer1K: 0.01, OutputPer1K: 0.03},
+		"*":     {InputPer1K: 0.001, OutputPer1K: 0.002},
+	}
+
+	tests := []struct {
+		name   string
+		table  PricingTable
+		model  string
+		want   float64
+		wantOK bool
+	}{
+		{name: "priced model", table: table, model: "large", want: 0.04, wantOK: true},
+		{name: "default price", table: table, model: "small", want: 0.003, wantOK: true},
+		{name: "unpriced", table: PricingTable{}, model: "small"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.table.Cost(tt.model, 1000, 1000)
+			if ok != tt.wantOK || got < tt.want-1e-9 || got > tt.want+1e-9 {
+				t.Errorf("expected %v (%v), got %v (%v)", tt.want, tt.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func Test_ParsePricingTable(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    int
+		wantErr bool
+	}{
+		{name: "yaml", data: "gpt-4o: {input_per_1k: 0.0025, output_per_1k: 0.01}\n\"*\": {input_per_1k: 0.001}", want: 2},
+		{name: "json", data: `{"gpt-4o-mini": {"input_per_1k": 0.00015, "output_per_1k": 0.0006}}`, want: 1},
+		{name: "unknown field", data: `gpt-4o: {per_1k: 0.01}`, wantErr: true},
+		{name: "negative price", data: `gpt-4o: {input_per_1k: -1}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := ParsePricingTable([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && len(table) != tt.want {
+				t.Errorf("expected %d prices, got %d", tt.want, len(table))
+			}
+		})
+	}
+}
+
+func Test_CostCapProvider(t *testing.T) {
+	pricing := PricingTable{"large": {InputPer1K: 0.01, OutputPer1K: 0.03}, "small": {InputPer1K: 0.001, OutputPer1K: 0.001}}
+	start := time.Date(2024, 10, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		action     string
+		at         time.Duration
+		wantErr    bool
+		wantModel  string
+		wantBreach bool
+	}{
+		{name: "reject", action: costCapActionReject, wantErr: true},
+		{name: "downgrade", action: costCapActionDowngrade, wantModel: "small"},
+		{name: "next window", action: costCapActionReject, at: 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alerts := make(chan costCapAlert, 1)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var alert costCapAlert
+				_ = json.NewDecoder(r.Body).Decode(&alert)
+				alerts <- alert
+			}))
+			defer srv.Close()
+
+			inner := &pricedStubProvider{}
+			c := NewCostCapProvider(zap.NewNop(), inner, pricing, 0.05)
+			c.action, c.fallback, c.webhook = tt.action, "small", srv.URL
+			c.now = func() time.Time { return start }
+
+			// Two completions of $0.04 reach the cap of $0.05.
+			for i := 0; i < 2; i++ {
+				if _, err := c.Complete(context.Background(), &CompletionRequest{}); err != nil {
+					t.Fatalf("completion %d failed: %v", i, err)
+				}
+			}
+			select {
+			case alert := <-alerts:
+				if alert.Event != "cost_cap_exceeded" || alert.Cap != 0.05 {
+					t.Errorf("unexpected alert %+v", alert)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("expected a webhook alert")
+			}
+
+			c.now = func() time.Time { return start.Add(tt.at) }
+			_, err := c.Complete(context.Background(), &CompletionRequest{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			var capErr *CostCapError
+			if tt.wantErr && (!errors.As(err, &capErr) || capErr.RetryAfter != 24*time.Hour-10*time.Hour) {
+				t.Errorf("expected a cost cap error resetting at midnight, got %v", err)
+			}
+			if !tt.wantErr && inner.lastModel != tt.wantModel {
+				t.Errorf("expected model %q, got %q", tt.wantModel, inner.lastModel)
+			}
+		})
+	}
+}
+
+func Test_costCapFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pricing.yaml")
+	if err := os.WriteFile(path, []byte(`gpt-4o: {input_per_1k: 0.0025, output_per_1k: 0.01}`), 0o600); err != nil {
+		t.Fatalf("failed to write pricing table: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		env        map[string]string
+		wantCap    bool
+		wantAction string
+		wantWindow time.Duration
+		wantErr    bool
+	}{
+		{name: "unset"},
+		{name: "reject", env: map[string]string{"COST_CAP": "50", "COST_PRICING_FILE": path}, wantCap: true, wantAction: costCapActionReject, wantWindow: 24 * time.Hour},
+		{
+			name:    "downgrade hourly",
+			env:     map[string]string{"COST_CAP": "5", "COST_PRICING_FILE": path, "COST_CAP_WINDOW": "1h", "COST_CAP_ACTION": "downgrade", "COST_CAP_FALLBACK_MODEL": "gpt-4o-mini"},
+			wantCap: true, wantAction: costCapActionDowngrade, wantWindow: time.Hour,
+		},
+		{name: "missing pricing", env: map[string]string{"COST_CAP": "50"}, wantErr: true},
+		{name: "missing fallback", env: map[string]string{"COST_CAP": "50", "COST_PRICING_FILE": path, "COST_CAP_ACTION": "downgrade"}, wantErr: true},
+		{name: "unknown action", env: map[string]string{"COST_CAP": "50", "COST_PRICING_FILE": path, "COST_CAP_ACTION": "throttle"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"COST_CAP", "COST_PRICING_FILE", "COST_CAP_WINDOW", "COST_CAP_ACTION", "COST_CAP_FALLBACK_MODEL", "COST_CAP_WEBHOOK_URL"} {
+				t.Setenv(key, tt.env[key])
+			}
+			inner := &stubProvider{output: "4"}
+			p, err := costCapFromEnv(zap.NewNop(), inner)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			c, ok := p.(*CostCapProvider)
+			if ok != tt.wantCap {
+				t.Fatalf("expected cost cap %v, got %T", tt.wantCap, p)
+			}
+			if ok && (c.action != tt.wantAction || c.window != tt.wantWindow) {
+				t.Errorf("unexpected cost cap: action %s, window %s", c.action, c.window)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// defaultDebugHost keeps the debug endpoints, which expose the internals of
+// the process, off the network unless DEBUG_HOST says otherwise.
+const defaultDebugHost = "127.0.0.1"
+
+func init() {
+	expvar.Publish("goroutines", expvar.Func(func() any { return runtime.NumGoroutine() }))
+}
+
+// debugAddrFromEnv returns the address of the debug server from DEBUG_PORT
+// and DEBUG_HOST (127.0.0.1 by default), or "" when DEBUG_PORT is unset.
+func debugAddrFromEnv() (string, error) {
+	port, err := envInt("DEBUG_PORT")
+	if err != nil {
+		return "", err
+	}
+	if port < 0 {
+		return "", fmt.Errorf("DEBUG_PORT must not be negative")
+	}
+	if port == 0 {
+		return "", nil
+	}
+	host := getenv("DEBUG_HOST")
+	if host == "" {
+		host = defaultDebugHost
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port)), nil
+}
+
+// debugHandler serves the pprof profiles under /debug/pprof/, the expvar
+// variables, memory statistics included, under /debug/vars, and the log level
+// under /debug/loglevel, where a PUT of {"level":"debug"} changes it.
+func debugHandler(level zap.AtomicLevel) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.Handle("/debug/loglevel", level)
+	return mux
+}
+
+// startDebugServer serves the debug endpoints on addr, apart from the
+// performer and metrics ports, until ctx is cancelled.
+func startDebugServer(ctx context.Context, addr string, level zap.AtomicLevel, logger *zap.Logger) {
+	// Profiles stream for as long as requested, so writes are not timed out.
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           debugHandler(level),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		logger.Sugar().Infow("Starting debug server", zap.String("addr", addr))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Sugar().Errorw("Debug server failed", zap.Error(err))
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+}
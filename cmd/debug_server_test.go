@@ -0,0 +1,77 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func Test_debugAddrFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		port     string
+		host     string
+		wantAddr string
+		wantErr  bool
+	}{
+		{name: "unset"},
+		{name: "loopback by default", port: "6060", wantAddr: "127.0.0.1:6060"},
+		{name: "host", port: "6060", host: "0.0.0.0", wantAddr: "0.0.0.0:6060"},
+		{name: "negative", port: "-1", wantErr: true},
+		{name: "invalid", port: "pprof", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DEBUG_PORT", tt.port)
+			t.Setenv("DEBUG_HOST", tt.host)
+			addr, err := debugAddrFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if addr != tt.wantAddr {
+				t.Errorf("expected address %q, got %q", tt.wantAddr, addr)
+			}
+		})
+	}
+}
+
+func Test_debugHandler(t *testing.T) {
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{path: "/debug/pprof/", wantStatus: http.StatusOK, wantBody: "goroutine"},
+		{path: "/debug/pprof/heap?debug=1", wantStatus: http.StatusOK, wantBody: "heap profile"},
+		{path: "/debug/vars", wantStatus: http.StatusOK, wantBody: `"goroutines"`},
+		{path: "/debug/loglevel", wantStatus: http.StatusOK, wantBody: `"level":"info"`},
+		{path: "/metrics", wantStatus: http.StatusNotFound},
+	}
+
+	srv := httptest.NewServer(debugHandler(zap.NewAtomicLevel()))
+	defer srv.Close()
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := http.Get(srv.URL + tt.path)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read body: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("expected body containing %q, got %.200s", tt.wantBody, body)
+			}
+		})
+	}
+}
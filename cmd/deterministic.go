@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// deterministicSeed is the sampling seed sent in deterministic mode. It is
+// fixed rather than configurable so that every operator sends the same one.
+const deterministicSeed = 1337
+
+// modelSnapshots pins the floating model aliases of the providers to the
+// dated snapshot they currently serve. Aliases move to new snapshots without
+// notice, and operators that resolve the same alias to different snapshots
+// disagree on their results. Models not listed are sent as is.
+var modelSnapshots = map[string]string{
+	"gpt-4o":                   "gpt-4o-2024-08-06",
+	"gpt-4o-mini":              "gpt-4o-mini-2024-07-18",
+	"gpt-4-turbo":              "gpt-4-turbo-2024-04-09",
+	"openai/gpt-4o":            "openai/gpt-4o-2024-08-06",
+	"openai/gpt-4o-mini":       "openai/gpt-4o-mini-2024-07-18",
+	"claude-3-5-haiku-latest":  "claude-3-5-haiku-20241022",
+	"claude-3-5-sonnet-latest": "claude-3-5-sonnet-20241022",
+	"claude-3-7-sonnet-latest": "claude-3-7-sonnet-20250219",
+	"gemini-1.5-flash":         "gemini-1.5-flash-002",
+	"gemini-1.5-pro":           "gemini-1.5-pro-002",
+}
+
+// pinnedModel returns the snapshot model is an alias of, or model itself.
+func pinnedModel(model string) string {
+	if snapshot, ok := modelSnapshots[model]; ok {
+		return snapshot
+	}
+	return model
+}
+
+// deterministicFromEnv reports whether DETERMINISTIC, or the -deterministic
+// flag, is set.
+func deterministicFromEnv() (bool, error) {
+	v := getenv("DETERMINISTIC")
+	if v == "" {
+		return false, nil
+	}
+	deterministic, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid value for DETERMINISTIC: %w", err)
+	}
+	return deterministic, nil
+}
+
+// DeterministicProvider gives independent operators the best chance of
+// producing byte-identical results for the same task: every request is sent
+// with a temperature of 0, the fixed deterministicSeed for the providers that
+// support seeding, and the model pinned to its snapshot, see pinnedModel.
+// Temperatures requested by tasks, verifiers and SELF_CONSISTENCY_TEMPERATURES
+// are overridden.
+type DeterministicProvider struct {
+	Provider
+}
+
+func NewDeterministicProvider(p Provider) *DeterministicProvider {
+	return &DeterministicProvider{Provider: p}
+}
+
+func (p *DeterministicProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	deterministic := *req
+	deterministic.Temperature = 0
+	deterministic.Seed = deterministicSeed
+	deterministic.PinModel = true
+	return p.Provider.Complete(ctx, &deterministic)
+}
+
+// canonicalizeOutput normalizes the parts of a result that differ between
+// operators without the answer differing: line endings and trailing
+// whitespace of the output, and the latency of version 2 results.
+func canonicalizeOutput(result map[string]interface{}) {
+	if output, ok := result["llm_output"].(string); ok {
+		output = strings.ReplaceAll(output, "\r\n", "\n")
+		result["llm_output"] = strings.TrimRight(output, " \t\n")
+	}
+	if _, ok := result["latency_ms"]; ok {
+		result["latency_ms"] = int64(0)
+	}
+}
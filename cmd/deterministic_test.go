@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_deterministicFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		want    bool
+		wantErr bool
+	}{
+		{name: "unset"},
+		{name: "enabled", env: "true", want: true},
+		{name: "disabled", env: "false"},
+		{name: "invalid", env: "maybe", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DETERMINISTIC", tt.env)
+			got, err := deterministicFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_DeterministicProvider(t *testing.T) {
+	inner := &recordingProvider{name: "recording"}
+	p := NewDeterministicProvider(inner)
+
+	req := &CompletionRequest{Messages: []ChatMessage{{Role: "user", Content: "hi"}}, Temperature: 0.7, Model: "gpt-4o-mini"}
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if inner.last.Temperature != 0 || inner.last.Seed != deterministicSeed || !inner.last.PinModel {
+		t.Errorf("unexpected request: %+v", inner.last)
+	}
+	if req.Temperature != 0.7 || req.Seed != 0 || req.PinModel {
+		t.Errorf("the request of the caller was modified: %+v", req)
+	}
+	if p.Name() != "recording" {
+		t.Errorf("unexpected name %s", p.Name())
+	}
+}
+
+func Test_CompletionRequestModelOr(t *testing.T) {
+	tests := []struct {
+		name string
+		req  CompletionRequest
+		def  string
+		want string
+	}{
+		{name: "default", def: "gpt-4o-mini", want: "gpt-4o-mini"},
+		{name: "requested", req: CompletionRequest{Model: "gpt-4o"}, def: "gpt-4o-mini", want: "gpt-4o"},
+		{name: "pinned default", req: CompletionRequest{PinModel: true}, def: "gpt-4o-mini", want: "gpt-4o-mini-2024-07-18"},
+		{name: "pinned requested", req: CompletionRequest{Model: "claude-3-5-haiku-latest", PinModel: true}, def: "gpt-4o-mini", want: "claude-3-5-haiku-20241022"},
+		{name: "pinned snapshot", req: CompletionRequest{PinModel: true}, def: "gpt-4o-2024-05-13", want: "gpt-4o-2024-05-13"},
+		{name: "pinned unknown model", req: CompletionRequest{PinModel: true}, def: "llama3.2", want: "llama3.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.req.modelOr(tt.def); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_OpenAIProviderDeterministic(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body chatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if body.Model != "gpt-4o-mini-2024-07-18" || body.Seed != deterministicSeed || body.Temperature != 0 {
+			t.Errorf("unexpected request: %+v", body)
+		}
+		_, _ = w.Write([]byte(`{"model":"gpt-4o-mini-2024-07-18","choices":[{"message":{"role":"assistant","content":"hello"}}]}`))
+	}))
+	defer srv.Close()
+
+	openai, err := NewOpenAIProvider(&OpenAIConfig{APIKey: "test-key", Model: "gpt-4o-mini", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+	openai.client = srv.Client()
+
+	p := NewDeterministicProvider(openai)
+	if _, err := p.Complete(context.Background(), &CompletionRequest{
+		Messages:    []ChatMessage{{Role: "user", Content: "hi"}},
+		MaxTokens:   defaultMaxTokens,
+		Temperature: defaultTemperature,
+	}); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+}
+
+func Test_canonicalizeOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		result map[string]interface{}
+		want   map[string]interface{}
+	}{
+		{
+			name:   "line endings and trailing whitespace",
+			result: map[string]interface{}{"llm_output": "  4\r\nfour \n\t"},
+			want:   map[string]interface{}{"llm_output": "  4\nfour"},
+		},
+		{
+			name:   "latency",
+			result: map[string]interface{}{"llm_output": "4", "latency_ms": int64(812)},
+			want:   map[string]interface{}{"llm_output": "4", "latency_ms": int64(0)},
+		},
+		{
+			name:   "no output",
+			result: map[string]interface{}{"sentiment": "positive"},
+			want:   map[string]interface{}{"sentiment": "positive"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			canonicalizeOutput(tt.result)
+			got, _ := json.Marshal(tt.result)
+			want, _ := json.Marshal(tt.want)
+			if string(got) != string(want) {
+				t.Errorf("expected %s, got %s", want, got)
+			}
+		})
+	}
+}
+
+func Test_HandleTaskDeterministic(t *testing.T) {
+	task := &performerV1.TaskRequest{
+		TaskId:  []byte("test-task-id"),
+		Payload: []byte(`{"schema_version":1,"prompt":"What is 2+2?","result_version":2}`),
+	}
+
+	var results []string
+	for _, output := range []string{"4\r\n", "4  \n"} {
+		tw := NewTaskWorker(zap.NewNop(), NewDeterministicProvider(&stubProvider{output: output}), nil)
+		tw.SetDeterministic(true)
+		resp, err := tw.HandleTask(task)
+		if err != nil {
+			t.Fatalf("HandleTask failed: %v", err)
+		}
+		results = append(results, string(resp.Result))
+	}
+	if results[0] != results[1] {
+		t.Errorf("expected identical results, got\n%s\n%s", results[0], results[1])
+	}
+
+	var result struct {
+		LLMOutput string `json:"llm_output"`
+		LatencyMs int64  `json:"latency_ms"`
+		Metadata  struct {
+			Deterministic bool `json:"deterministic"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(results[0]), &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if result.LLMOutput != "4" || result.LatencyMs != 0 || !result.Metadata.Deterministic {
+		t.Errorf("unexpected result: %s", results[0])
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// dryRunFromEnv reports whether DRY_RUN, or the -dry-run flag, is set.
+func dryRunFromEnv() (bool, error) {
+	v := getenv("DRY_RUN")
+	if v == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid value for DRY_RUN: %w", err)
+	}
+	return dryRun, nil
+}
+
+// dryRunResult returns the synthetic result of a task handled in dry-run
+// mode. The task went through validation and prompt construction, but the
+// provider is not called and no verifier runs: the result reports the prompt
+// the provider would have received and the verifiers that would have checked
+// its output, and is marked dry_run.
+func (tw *TaskWorker) dryRunResult(taskType string, p *TaskPayload) map[string]interface{} {
+	req := p.CompletionRequest()
+	var messages []ChatMessage
+	if sp, ok := tw.provider.(*SystemPromptProvider); ok {
+		messages = append(messages, ChatMessage{Role: "system", Content: sp.prompt.Load().text})
+	}
+	messages = append(messages, p.Examples...)
+	req.Messages = append(messages, req.Messages...)
+
+	verifiers := []string{}
+	if h, ok := tw.tasks.handlers[taskType].(*CompletionHandler); ok {
+		verifiers = append(verifiers, h.verifier.Name())
+	}
+	pipeline := []string{}
+	if vp := tw.pipelines[taskType]; vp != nil {
+		for _, v := range vp.verifiers {
+			pipeline = append(pipeline, v.Name())
+		}
+	}
+
+	return map[string]interface{}{
+		"dry_run":  true,
+		"verified": failedVerification("dry_run"),
+		"metadata": map[string]interface{}{
+			"provider":              tw.provider.Name(),
+			"task_type":             taskType,
+			"prompt_hash":           promptHash(p),
+			"estimated_tokens_in":   estimateTokens(req),
+			"verifiers":             verifiers,
+			"verification_pipeline": pipeline,
+		},
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_dryRunFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		want    bool
+		wantErr bool
+	}{
+		{name: "unset"},
+		{name: "enabled", env: "true", want: true},
+		{name: "disabled", env: "false"},
+		{name: "invalid", env: "maybe", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DRY_RUN", tt.env)
+			got, err := dryRunFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_HandleTaskDryRun(t *testing.T) {
+	tests := []struct {
+		name         string
+		payload      string
+		wantTaskType string
+		wantErr      bool
+	}{
+		{name: "completion", payload: "What is 2+2?", wantTaskType: taskTypeCompletion},
+		{name: "classify", payload: `{"schema_version":2,"task_type":"classify","input":{"text":"great","labels":["good","bad"]}}`, wantTaskType: taskTypeClassify},
+		{name: "invalid task", payload: `{"schema_version":2,"task_type":"classify","input":{"text":"great","labels":["good"]}}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The failing provider fails any task that reaches it.
+			tw := NewTaskWorker(zap.NewNop(), NewSystemPromptProvider(&failingProvider{}, "Answer briefly."), nil)
+			tw.SetDryRun(true)
+
+			task := &performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte(tt.payload)}
+			if err := tw.ValidateTask(task); (err != nil) != tt.wantErr {
+				t.Fatalf("expected validation error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			resp, err := tw.HandleTask(task)
+			if err != nil {
+				t.Fatalf("HandleTask failed: %v", err)
+			}
+			var result struct {
+				DryRun    bool         `json:"dry_run"`
+				ErrorCode string       `json:"error_code"`
+				Verified  Verification `json:"verified"`
+				Metadata  struct {
+					Provider          string `json:"provider"`
+					TaskType          string `json:"task_type"`
+					EstimatedTokensIn int    `json:"estimated_tokens_in"`
+				} `json:"metadata"`
+			}
+			if err := json.Unmarshal(resp.Result, &result); err != nil {
+				t.Fatalf("failed to decode result: %v", err)
+			}
+			if !result.DryRun || result.ErrorCode != "" || result.Verified.Passed {
+				t.Errorf("expected an unverified dry-run result, got %s", resp.Result)
+			}
+			if result.Metadata.Provider != "failing" || result.Metadata.TaskType != tt.wantTaskType || result.Metadata.EstimatedTokensIn == 0 {
+				t.Errorf("unexpected dry-run metadata %+v", result.Metadata)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Embedder is implemented by providers that can generate text embeddings.
+type Embedder interface {
+	Name() string
+	Embed(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error)
+}
+
+// EmbeddingRequest is the provider-agnostic description of an embedding call.
+type EmbeddingRequest struct {
+	Input string
+	// Dimensions asks models that support it for a shortened vector. Zero
+	// keeps the model's native size.
+	Dimensions int
+}
+
+// EmbeddingResponse is the provider-agnostic result of an embedding call.
+type EmbeddingResponse struct {
+	Embedding []float64
+	Model     string
+}
+
+// newEmbedderFromEnv builds the provider named by EMBEDDING_PROVIDER, or
+// returns nil when embeddings are not enabled.
+func newEmbedderFromEnv(ctx context.Context) (Embedder, error) {
+	name := getenv("EMBEDDING_PROVIDER")
+	if name == "" {
+		return nil, nil
+	}
+
+	p, err := newNamedProviderFromEnv(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	embedder, ok := p.(Embedder)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support embeddings", p.Name())
+	}
+	return embedder, nil
+}
+
+// openAIEmbeddingRequest is the request body of the OpenAI embeddings API,
+// which Azure OpenAI and most self-hosted gateways also speak.
+type openAIEmbeddingRequest struct {
+	Model      string `json:"model,omitempty"`
+	Input      string `json:"input"`
+	Dimensions int    `json:"dimensions,omitempty"`
+}
+
+type openAIEmbeddingResponse struct {
+	Model string `json:"model"`
+	Data  []struct {
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+// embedOpenAI runs an embedding call against an OpenAI-compatible /embeddings
+// endpoint.
+func embedOpenAI(ctx context.Context, client *http.Client, provider, url string, headers map[string]string, model string, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	var resp openAIEmbeddingResponse
+	err := doJSONRequest(ctx, client, provider, url, headers, &openAIEmbeddingRequest{
+		Model:      model,
+		Input:      req.Input,
+		Dimensions: req.Dimensions,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("%s returned no embedding", provider)
+	}
+	return &EmbeddingResponse{
+		Embedding: resp.Data[0].Embedding,
+		Model:     resp.Model,
+	}, nil
+}
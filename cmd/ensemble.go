@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// EnsembleConfig configures cross-model consensus.
+type EnsembleConfig struct {
+	// Quorum is the number of providers whose outputs must agree for the result
+	// to be verified. Defaults to a simple majority.
+	Quorum int
+	// SimilarityThreshold selects the agreement test. Zero requires normalized
+	// outputs to match exactly; a value in (0,1] accepts outputs whose word-level
+	// Jaccard similarity is at least the threshold.
+	SimilarityThreshold float64
+}
+
+func ensembleConfigFromEnv() (*EnsembleConfig, error) {
+	quorum, err := envInt("ENSEMBLE_QUORUM")
+	if err != nil {
+		return nil, err
+	}
+	threshold, err := envFloat("ENSEMBLE_SIMILARITY_THRESHOLD")
+	if err != nil {
+		return nil, err
+	}
+	return &EnsembleConfig{
+		Quorum:              quorum,
+		SimilarityThreshold: threshold,
+	}, nil
+}
+
+// EnsembleAgreement describes how many ensemble members agreed with the selected
+// output.
+type EnsembleAgreement struct {
+	Votes     int  `json:"votes"`
+	Responses int  `json:"responses"`
+	Members   int  `json:"members"`
+	Quorum    int  `json:"quorum"`
+	Agreed    bool `json:"agreed"`
+}
+
+// EnsembleProvider sends the same request to every member concurrently and
+// returns the output that the most members agree with. The completion is only
+// considered verified when the number of agreeing members reaches the quorum.
+type EnsembleProvider struct {
+	providers []Provider
+	config    *EnsembleConfig
+	logger    *zap.Logger
+}
+
+func NewEnsembleProvider(logger *zap.Logger, cfg *EnsembleConfig, providers ...Provider) (*EnsembleProvider, error) {
+	if len(providers) < 2 {
+		return nil, fmt.Errorf("ensemble requires at least two providers")
+	}
+	if cfg.Quorum == 0 {
+		cfg.Quorum = len(providers)/2 + 1
+	}
+	if cfg.Quorum < 1 || cfg.Quorum > len(providers) {
+		return nil, fmt.Errorf("ensemble quorum %d must be between 1 and %d", cfg.Quorum, len(providers))
+	}
+	if cfg.SimilarityThreshold < 0 || cfg.SimilarityThreshold > 1 {
+		return nil, fmt.Errorf("ensemble similarity threshold must be between 0 and 1")
+	}
+	return &EnsembleProvider{
+		providers: providers,
+		config:    cfg,
+		logger:    logger,
+	}, nil
+}
+
+func (p *EnsembleProvider) Name() string {
+	names := make([]string, 0, len(p.providers))
+	for _, provider := range p.providers {
+		names = append(names, provider.Name())
+	}
+	return "ensemble(" + strings.Join(names, ",") + ")"
+}
+
+func (p *EnsembleProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	responses := make([]*CompletionResponse, len(p.providers))
+	errs := make([]error, len(p.providers))
+
+	var wg sync.WaitGroup
+	for i, provider := range p.providers {
+		wg.Add(1)
+		go func(i int, provider Provider) {
+			defer wg.Done()
+			resp, err := provider.Complete(ctx, req)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", provider.Name(), err)
+				correlatedLogger(ctx, p.logger).Sugar().Warnw("Ensemble member failed",
+					zap.String("provider", provider.Name()),
+					zap.Error(err),
+				)
+				return
+			}
+			if resp.Provider == "" {
+				resp.Provider = provider.Name()
+			}
+			responses[i] = resp
+		}(i, provider)
+	}
+	wg.Wait()
+
+	// Keep provider order so that ties are broken deterministically.
+	var candidates []*CompletionResponse
+	for _, resp := range responses {
+		if resp != nil && !resp.Refused {
+			candidates = append(candidates, resp)
+		}
+	}
+	if len(candidates) == 0 {
+		// Every member that answered refused: surface the first refusal,
+		// even when other members failed, so the task reports a refusal
+		// rather than a provider error.
+		for _, resp := range responses {
+			if resp != nil {
+				return resp, nil
+			}
+		}
+		return nil, fmt.Errorf("all ensemble members failed: %w", errors.Join(errs...))
+	}
+
+	best, bestVotes := 0, 0
+	for i := range candidates {
+		votes := 0
+		for j := range candidates {
+			if p.agree(candidates[i].Output, candidates[j].Output) {
+				votes++
+			}
+		}
+		if votes > bestVotes {
+			best, bestVotes = i, votes
+		}
+	}
+
+	selected := *candidates[best]
+	selected.Agreement = &EnsembleAgreement{
+		Votes:     bestVotes,
+		Responses: len(candidates),
+		Members:   len(p.providers),
+		Quorum:    p.config.Quorum,
+		Agreed:    bestVotes >= p.config.Quorum,
+	}
+	return &selected, nil
+}
+
+func (p *EnsembleProvider) agree(a, b string) bool {
+	return outputsAgree(a, b, p.config.SimilarityThreshold)
+}
+
+// outputsAgree reports whether two outputs agree. A threshold of zero requires
+// their normalized forms to match exactly, otherwise their Jaccard similarity
+// must reach the threshold.
+func outputsAgree(a, b string, threshold float64) bool {
+	if threshold == 0 {
+		return normalizeOutput(a) == normalizeOutput(b)
+	}
+	return jaccardSimilarity(a, b) >= threshold
+}
+
+// normalizeOutput lower-cases the output and collapses whitespace so that
+// formatting differences between models do not count as disagreement.
+func normalizeOutput(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// jaccardSimilarity returns the Jaccard index of the word sets of a and b.
+func jaccardSimilarity(a, b string) float64 {
+	setA := make(map[string]struct{})
+	for _, w := range strings.Fields(strings.ToLower(a)) {
+		setA[w] = struct{}{}
+	}
+	setB := make(map[string]struct{})
+	for _, w := range strings.Fields(strings.ToLower(b)) {
+		setB[w] = struct{}{}
+	}
+	if len(setA) == 0 && len(setB) == 0 {
+		return 1
+	}
+
+	intersection := 0
+	for w := range setA {
+		if _, ok := setB[w]; ok {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(setA)+len(setB)-intersection)
+}
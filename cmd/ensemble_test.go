@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func Test_EnsembleProvider(t *testing.T) {
+	tests := []struct {
+		name       string
+		threshold  float64
+		providers  []Provider
+		wantOutput string
+		wantVotes  int
+		wantAgreed bool
+	}{
+		{
+			name:       "exact majority",
+			providers:  []Provider{&stubProvider{output: "Paris"}, &stubProvider{output: "paris "}, &stubProvider{output: "Lyon"}},
+			wantOutput: "Paris",
+			wantVotes:  2,
+			wantAgreed: true,
+		},
+		{
+			name:       "no majority",
+			providers:  []Provider{&stubProvider{output: "a"}, &stubProvider{output: "b"}, &stubProvider{output: "c"}},
+			wantOutput: "a",
+			wantVotes:  1,
+			wantAgreed: false,
+		},
+		{
+			name:       "member failure counts against quorum",
+			providers:  []Provider{&stubProvider{output: "a"}, &failingProvider{}, &failingProvider{}},
+			wantOutput: "a",
+			wantVotes:  1,
+			wantAgreed: false,
+		},
+		{
+			name:       "similarity threshold",
+			threshold:  0.6,
+			providers:  []Provider{&stubProvider{output: "the capital is Paris"}, &stubProvider{output: "The capital is Paris."}, &stubProvider{output: "I don't know"}},
+			wantOutput: "the capital is Paris",
+			wantVotes:  2,
+			wantAgreed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewEnsembleProvider(zap.NewNop(), &EnsembleConfig{SimilarityThreshold: tt.threshold}, tt.providers...)
+			if err != nil {
+				t.Fatalf("NewEnsembleProvider failed: %v", err)
+			}
+
+			resp, err := p.Complete(context.Background(), &CompletionRequest{})
+			if err != nil {
+				t.Fatalf("Complete failed: %v", err)
+			}
+			if resp.Output != tt.wantOutput {
+				t.Errorf("expected output %q, got %q", tt.wantOutput, resp.Output)
+			}
+			if resp.Agreement.Votes != tt.wantVotes || resp.Agreement.Agreed != tt.wantAgreed {
+				t.Errorf("unexpected agreement: %+v", resp.Agreement)
+			}
+		})
+	}
+}
+
+// refusingProvider declines every request.
+type refusingProvider struct{}
+
+func (p *refusingProvider) Name() string { return "refusing" }
+
+func (p *refusingProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	return &CompletionResponse{Model: "m", Refused: true, RefusalReason: "content_filter"}, nil
+}
+
+func Test_EnsembleProviderRefusal(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []Provider
+	}{
+		{name: "every member refuses", providers: []Provider{&refusingProvider{}, &refusingProvider{}}},
+		{name: "members refuse or fail", providers: []Provider{&failingProvider{}, &refusingProvider{}, &failingProvider{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewEnsembleProvider(zap.NewNop(), &EnsembleConfig{}, tt.providers...)
+			if err != nil {
+				t.Fatalf("NewEnsembleProvider failed: %v", err)
+			}
+			resp, err := p.Complete(context.Background(), &CompletionRequest{})
+			if err != nil {
+				t.Fatalf("expected a refusal, got error %v", err)
+			}
+			if !resp.Refused || resp.RefusalReason != "content_filter" || resp.Provider != "refusing" {
+				t.Errorf("unexpected response: %+v", resp)
+			}
+		})
+	}
+}
+
+func Test_EnsembleProviderAllFail(t *testing.T) {
+	p, err := NewEnsembleProvider(zap.NewNop(), &EnsembleConfig{}, &failingProvider{}, &failingProvider{})
+	if err != nil {
+		t.Fatalf("NewEnsembleProvider failed: %v", err)
+	}
+	if _, err := p.Complete(context.Background(), &CompletionRequest{}); err == nil {
+		t.Errorf("expected error when every member fails")
+	}
+}
+
+func Test_EnsembleProviderConfig(t *testing.T) {
+	if _, err := NewEnsembleProvider(zap.NewNop(), &EnsembleConfig{}, &stubProvider{}); err == nil {
+		t.Errorf("expected error for a single-member ensemble")
+	}
+	if _, err := NewEnsembleProvider(zap.NewNop(), &EnsembleConfig{Quorum: 3}, &stubProvider{}, &stubProvider{}); err == nil {
+		t.Errorf("expected error for a quorum larger than the ensemble")
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// factCheckStubProvider answers claim extraction and support scoring with
+// fixed outputs and records the support scoring prompt.
+type factCheckStubProvider struct {
+	claims        string
+	scores        string
+	supportPrompt string
+}
+
+func (p *factCheckStubProvider) Name() string {
+	return "fact-check-stub"
+}
+
+func (p *factCheckStubProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	if req.Messages[0].Content == claimExtractionPrompt {
+		return &CompletionResponse{Output: p.claims}, nil
+	}
+	p.supportPrompt = req.Messages[1].Content
+	return &CompletionResponse{Output: p.scores}, nil
+}
+
+func Test_factCheckVerifier(t *testing.T) {
+	retriever, err := newCorpusRetriever([]byte(testFactCheckCorpus))
+	if err != nil {
+		t.Fatalf("newCorpusRetriever failed: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		claims        string
+		scores        string
+		wantReasons   []string
+		wantSupported []bool
+		wantEvidence  bool
+	}{
+		{
+			name:          "supported",
+			claims:        `{"claims": ["Paris is the capital of France."]}`,
+			scores:        `{"scores": [0.95]}`,
+			wantReasons:   []string{},
+			wantSupported: []bool{true},
+			wantEvidence:  true,
+		},
+		{
+			name:          "unsupported claim",
+			claims:        `{"claims": ["Paris is the capital of France.", "Lyon is the capital of France."]}`,
+			scores:        `{"scores": [0.95, 0.1]}`,
+			wantReasons:   []string{"unsupported_claims"},
+			wantSupported: []bool{true, false},
+			wantEvidence:  true,
+		},
+		{
+			name:          "no evidence",
+			claims:        `{"claims": ["Bananas are yellow."]}`,
+			scores:        `{"scores": [1]}`,
+			wantReasons:   []string{"unsupported_claims"},
+			wantSupported: []bool{false},
+		},
+		{
+			name:          "no claims",
+			claims:        `{"claims": []}`,
+			wantReasons:   []string{},
+			wantSupported: []bool{},
+		},
+		{name: "unparseable claims", claims: "Paris", wantReasons: []string{"claims_unparseable"}},
+		{
+			name:        "unparseable scores",
+			claims:      `{"claims": ["Paris is the capital of France."]}`,
+			scores:      `{"scores": []}`,
+			wantReasons: []string{"support_unparseable"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &factCheckStubProvider{claims: tt.claims, scores: tt.scores}
+			verifier := &factCheckVerifier{provider: provider, retriever: retriever, threshold: defaultFactCheckThreshold}
+
+			v := newVerification()
+			if err := verifier.Verify(context.Background(), &TaskPayload{Prompt: "Tell me about France."}, "Paris is the capital of France.", v); err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if !reflect.DeepEqual(v.Reasons, tt.wantReasons) {
+				t.Errorf("unexpected verification: %+v", v)
+			}
+			if tt.wantSupported == nil {
+				if v.FactCheck != nil {
+					t.Errorf("expected no fact check, got %+v", v.FactCheck)
+				}
+				return
+			}
+
+			supported := []bool{}
+			for _, c := range v.FactCheck.Claims {
+				supported = append(supported, c.Supported)
+			}
+			if !reflect.DeepEqual(supported, tt.wantSupported) || v.FactCheck.Retriever != "corpus" {
+				t.Errorf("unexpected fact check: %+v", v.FactCheck)
+			}
+			if tt.wantEvidence && !strings.Contains(provider.supportPrompt, "Paris is the capital and largest city of France.") {
+				t.Errorf("expected the evidence in the support prompt, got %q", provider.supportPrompt)
+			}
+		})
+	}
+}
+
+func Test_factCheckVerifierFromEnv(t *testing.T) {
+	corpus := filepath.Join(t.TempDir(), "corpus.json")
+	if err := os.WriteFile(corpus, []byte(testFactCheckCorpus), 0o600); err != nil {
+		t.Fatalf("failed to write corpus: %v", err)
+	}
+	t.Setenv("FACT_CHECK_SEARCH_URL", "")
+	t.Setenv("FACT_CHECK_CORPUS_FILE", corpus)
+	t.Setenv("FACT_CHECK_PROVIDER", "")
+
+	verifier, err := factCheckVerifierFromEnv(context.Background(), &stubProvider{})
+	if err != nil {
+		t.Fatalf("factCheckVerifierFromEnv failed: %v", err)
+	}
+	if verifier.provider.Name() != "stub" || verifier.threshold != defaultFactCheckThreshold {
+		t.Errorf("unexpected verifier: %+v", verifier)
+	}
+
+	t.Setenv("FACT_CHECK_THRESHOLD", "-1")
+	if _, err := factCheckVerifierFromEnv(context.Background(), &stubProvider{}); err == nil {
+		t.Errorf("expected error for a negative FACT_CHECK_THRESHOLD")
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// FailoverProvider tries an ordered list of providers and returns the first
+// successful completion. A provider that errors or exceeds its attempt timeout is
+// skipped in favour of the next one.
+type FailoverProvider struct {
+	providers      []Provider
+	attemptTimeout time.Duration
+	logger         *zap.Logger
+}
+
+func NewFailoverProvider(logger *zap.Logger, attemptTimeout time.Duration, providers ...Provider) (*FailoverProvider, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("failover chain requires at least one provider")
+	}
+	if attemptTimeout <= 0 {
+		attemptTimeout = providerTimeout
+	}
+	return &FailoverProvider{
+		providers:      providers,
+		attemptTimeout: attemptTimeout,
+		logger:         logger,
+	}, nil
+}
+
+func (p *FailoverProvider) Name() string {
+	names := make([]string, 0, len(p.providers))
+	for _, provider := range p.providers {
+		names = append(names, provider.Name())
+	}
+	return "failover(" + strings.Join(names, ",") + ")"
+}
+
+func (p *FailoverProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	var errs []error
+	for _, provider := range p.providers {
+		attemptCtx, cancel := context.WithTimeout(ctx, p.attemptTimeout)
+		resp, err := provider.Complete(attemptCtx, req)
+		cancel()
+		if err == nil {
+			if resp.Provider == "" {
+				resp.Provider = provider.Name()
+			}
+			return resp, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+		// Stop early when the task itself was cancelled rather than the attempt
+		// timing out, there is no point in trying the remaining providers.
+		if ctx.Err() != nil {
+			break
+		}
+		correlatedLogger(ctx, p.logger).Sugar().Warnw("Provider failed, falling through to next provider",
+			zap.String("provider", provider.Name()),
+			zap.Error(err),
+		)
+	}
+	return nil, fmt.Errorf("all providers failed: %w", errors.Join(errs...))
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// slowProvider blocks until its context is done.
+type slowProvider struct{}
+
+func (p *slowProvider) Name() string {
+	return "slow"
+}
+
+func (p *slowProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func Test_FailoverProvider(t *testing.T) {
+	tests := []struct {
+		name         string
+		providers    []Provider
+		wantErr      bool
+		wantProvider string
+	}{
+		{
+			name:         "primary succeeds",
+			providers:    []Provider{&stubProvider{output: "a"}, &failingProvider{}},
+			wantProvider: "stub",
+		},
+		{
+			name:         "primary errors",
+			providers:    []Provider{&failingProvider{}, &stubProvider{output: "b"}},
+			wantProvider: "stub",
+		},
+		{
+			name:         "primary times out",
+			providers:    []Provider{&slowProvider{}, &stubProvider{output: "c"}},
+			wantProvider: "stub",
+		},
+		{
+			name:      "all fail",
+			providers: []Provider{&failingProvider{}, &slowProvider{}},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewFailoverProvider(zap.NewNop(), 50*time.Millisecond, tt.providers...)
+			if err != nil {
+				t.Fatalf("NewFailoverProvider failed: %v", err)
+			}
+
+			resp, err := p.Complete(context.Background(), &CompletionRequest{})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Complete failed: %v", err)
+			}
+			if resp.Provider != tt.wantProvider {
+				t.Errorf("expected provider %s, got %s", tt.wantProvider, resp.Provider)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// FewShotExample is an input and the answer expected from the model.
+type FewShotExample struct {
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+// FewShotLibrary holds the operator's few-shot examples keyed by task type.
+// The keccak256 hash of each example set is reported in results so that task
+// creators can check that all operators used the same examples.
+type FewShotLibrary struct {
+	examples map[string][]ChatMessage
+	hashes   map[string]string
+}
+
+// fewShotLibraryFromEnv loads the examples from the JSON file named by
+// FEW_SHOT_EXAMPLES_FILE, or returns nil when none is configured. The file
+// maps task types to example lists:
+//
+//	{"classify": [{"input": "I love it", "output": "{\"label\": \"positive\", \"confidence\": 0.9}"}]}
+func fewShotLibraryFromEnv() (*FewShotLibrary, error) {
+	path := getenv("FEW_SHOT_EXAMPLES_FILE")
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read few-shot examples: %w", err)
+	}
+	return NewFewShotLibrary(data)
+}
+
+func NewFewShotLibrary(data []byte) (*FewShotLibrary, error) {
+	var sets map[string][]FewShotExample
+	if err := json.Unmarshal(data, &sets); err != nil {
+		return nil, fmt.Errorf("invalid few-shot examples: %w", err)
+	}
+
+	l := &FewShotLibrary{examples: map[string][]ChatMessage{}, hashes: map[string]string{}}
+	for taskType, examples := range sets {
+		if len(examples) == 0 {
+			continue
+		}
+		canonical, err := json.Marshal(examples)
+		if err != nil {
+			return nil, err
+		}
+		l.hashes[taskType] = crypto.Keccak256Hash(canonical).Hex()
+
+		for i, ex := range examples {
+			if ex.Input == "" || ex.Output == "" {
+				return nil, fmt.Errorf("few-shot example %d of %s needs an input and an output", i, taskType)
+			}
+			l.examples[taskType] = append(l.examples[taskType],
+				ChatMessage{Role: "user", Content: ex.Input},
+				ChatMessage{Role: "assistant", Content: ex.Output},
+			)
+		}
+	}
+	return l, nil
+}
+
+// Examples returns the example turns of a task type.
+func (l *FewShotLibrary) Examples(taskType string) []ChatMessage {
+	if l == nil {
+		return nil
+	}
+	return l.examples[taskType]
+}
+
+// Hash returns the hash of the example set of a task type, or "" when it has
+// none.
+func (l *FewShotLibrary) Hash(taskType string) string {
+	if l == nil {
+		return ""
+	}
+	return l.hashes[taskType]
+}
+
+// fewShotProvider inserts the examples of a request after its leading system
+// messages, so they apply whichever way a task handler builds its messages.
+type fewShotProvider struct {
+	Provider
+}
+
+func withFewShotExamples(p Provider) Provider {
+	return &fewShotProvider{Provider: p}
+}
+
+func (p *fewShotProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	if len(req.Examples) == 0 {
+		return p.Provider.Complete(ctx, req)
+	}
+
+	split := 0
+	for split < len(req.Messages) && req.Messages[split].Role == "system" {
+		split++
+	}
+	messages := make([]ChatMessage, 0, len(req.Messages)+len(req.Examples))
+	messages = append(messages, req.Messages[:split]...)
+	messages = append(messages, req.Examples...)
+	messages = append(messages, req.Messages[split:]...)
+
+	withExamples := *req
+	withExamples.Messages = messages
+	withExamples.Examples = nil
+	return p.Provider.Complete(ctx, &withExamples)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+const testFewShotExamples = `{
+	"completion": [{"input": "2+2", "output": "4"}],
+	"classify": [
+		{"input": "I love it", "output": "{\"label\": \"positive\", \"confidence\": 0.9}"},
+		{"input": "I hate it", "output": "{\"label\": \"negative\", \"confidence\": 0.9}"}
+	]
+}`
+
+func Test_NewFewShotLibrary(t *testing.T) {
+	l, err := NewFewShotLibrary([]byte(testFewShotExamples))
+	if err != nil {
+		t.Fatalf("NewFewShotLibrary failed: %v", err)
+	}
+
+	if got := l.Examples("classify"); len(got) != 4 || got[0].Role != "user" || got[1].Role != "assistant" {
+		t.Errorf("unexpected classify examples: %+v", got)
+	}
+	if l.Examples("summarize") != nil || l.Hash("summarize") != "" {
+		t.Errorf("expected no summarize examples")
+	}
+	if l.Hash("classify") == "" || l.Hash("classify") == l.Hash("completion") {
+		t.Errorf("unexpected hashes: %v", l.hashes)
+	}
+
+	// The hash depends on the examples, not on the file's formatting.
+	compact, err := NewFewShotLibrary([]byte(`{"completion":[{"output":"4","input":"2+2"}]}`))
+	if err != nil {
+		t.Fatalf("NewFewShotLibrary failed: %v", err)
+	}
+	if compact.Hash("completion") != l.Hash("completion") {
+		t.Errorf("expected equal hashes for equal examples")
+	}
+
+	if _, err := NewFewShotLibrary([]byte(`{"completion":[{"input":"2+2"}]}`)); err == nil {
+		t.Errorf("expected error for an example without output")
+	}
+}
+
+func Test_fewShotLibraryFromEnv(t *testing.T) {
+	l, err := fewShotLibraryFromEnv()
+	if err != nil || l != nil {
+		t.Fatalf("expected no library when unset, got %v (%v)", l, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "examples.json")
+	if err := os.WriteFile(path, []byte(testFewShotExamples), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	t.Setenv("FEW_SHOT_EXAMPLES_FILE", path)
+	if l, err = fewShotLibraryFromEnv(); err != nil || l.Hash("classify") == "" {
+		t.Errorf("expected library from file, got %v (%v)", l, err)
+	}
+}
+
+func Test_fewShotProvider(t *testing.T) {
+	inner := &recordingProvider{}
+	p := withFewShotExamples(inner)
+
+	req := &CompletionRequest{
+		Messages: []ChatMessage{{Role: "system", Content: "classify"}, {Role: "user", Content: "meh"}},
+		Examples: []ChatMessage{{Role: "user", Content: "I love it"}, {Role: "assistant", Content: "positive"}},
+	}
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	want := []ChatMessage{
+		{Role: "system", Content: "classify"},
+		{Role: "user", Content: "I love it"},
+		{Role: "assistant", Content: "positive"},
+		{Role: "user", Content: "meh"},
+	}
+	if !reflect.DeepEqual(inner.last.Messages, want) || inner.last.Examples != nil {
+		t.Errorf("unexpected request: %+v", inner.last)
+	}
+}
+
+func Test_HandleTaskUsesFewShotExamples(t *testing.T) {
+	inner := &recordingProvider{}
+	tw := NewTaskWorker(zap.NewNop(), inner, nil)
+	l, err := NewFewShotLibrary([]byte(testFewShotExamples))
+	if err != nil {
+		t.Fatalf("NewFewShotLibrary failed: %v", err)
+	}
+	tw.SetFewShotExamples(l)
+
+	resp, err := tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("3+3")})
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+
+	want := []ChatMessage{{Role: "user", Content: "2+2"}, {Role: "assistant", Content: "4"}, {Role: "user", Content: "3+3"}}
+	if !reflect.DeepEqual(inner.last.Messages, want) {
+		t.Errorf("expected %+v, got %+v", want, inner.last.Messages)
+	}
+
+	var result struct {
+		Metadata struct {
+			ExamplesHash string `json:"examples_hash"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if result.Metadata.ExamplesHash != l.Hash("completion") {
+		t.Errorf("expected examples_hash %s, got %s", l.Hash("completion"), result.Metadata.ExamplesHash)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// callerAuth restricts the performer API to the operator's own Executor, so
+// that whoever can reach the port cannot make the performer spend LLM budget.
+// Callers must send the shared token as "authorization: Bearer <token>"
+// metadata, present a client certificate naming an allowed identity, or both
+// when both are configured.
+type callerAuth struct {
+	logger *zap.Logger
+	token  string
+	// identities are the allowed common names, DNS names and URIs (e.g.
+	// SPIFFE IDs) of client certificates.
+	identities map[string]bool
+}
+
+// callerAuthFromEnv configures caller authentication from PERFORMER_AUTH_TOKEN
+// (a secret, see secretFromEnv) and PERFORMER_ALLOWED_CLIENTS, a
+// comma-separated list of client certificate identities, or returns nil when
+// neither is set. Identities can only be checked when the server requires
+// client certificates.
+func callerAuthFromEnv(ctx context.Context, logger *zap.Logger, tlsCfg *tls.Config) (*callerAuth, error) {
+	token, err := secretFromEnv(ctx, "PERFORMER_AUTH_TOKEN")
+	if err != nil {
+		return nil, err
+	}
+	identities := map[string]bool{}
+	for _, id := range strings.Split(getenv("PERFORMER_ALLOWED_CLIENTS"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			identities[id] = true
+		}
+	}
+	if token == "" && len(identities) == 0 {
+		return nil, nil
+	}
+	if len(identities) > 0 && (tlsCfg == nil || tlsCfg.ClientAuth != tls.RequireAndVerifyClientCert) {
+		return nil, fmt.Errorf("PERFORMER_ALLOWED_CLIENTS requires mutual TLS, see PERFORMER_TLS_CLIENT_CA_FILE")
+	}
+	return &callerAuth{logger: logger, token: token, identities: identities}, nil
+}
+
+// authenticate checks the caller of a request.
+func (a *callerAuth) authenticate(ctx context.Context) error {
+	var authorization string
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		authorization = values[0]
+	}
+	return a.check(authorization, clientIdentities(ctx))
+}
+
+// authenticateHTTP checks the caller of an HTTP request with the same
+// credentials as gRPC calls: the Authorization header and the verified client
+// certificate.
+func (a *callerAuth) authenticateHTTP(r *http.Request) error {
+	var ids []string
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		ids = certificateIdentities(r.TLS.VerifiedChains[0][0])
+	}
+	return a.check(r.Header.Get("Authorization"), ids)
+}
+
+// check checks the authorization value and the client certificate identities
+// presented by a caller.
+func (a *callerAuth) check(authorization string, ids []string) error {
+	if a.token != "" {
+		got, _ := strings.CutPrefix(authorization, "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(a.token)) != 1 {
+			return status.Error(codes.Unauthenticated, "missing or invalid auth token")
+		}
+	}
+	if len(a.identities) > 0 {
+		if len(ids) == 0 {
+			return status.Error(codes.Unauthenticated, "missing client certificate")
+		}
+		for _, id := range ids {
+			if a.identities[id] {
+				return nil
+			}
+		}
+		return status.Errorf(codes.PermissionDenied, "client %s is not allowed", ids[0])
+	}
+	return nil
+}
+
+// clientIdentities returns the common name, DNS names and URIs of the
+// verified client certificate of a request.
+func clientIdentities(ctx context.Context) []string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return certificateIdentities(info.State.VerifiedChains[0][0])
+}
+
+// certificateIdentities returns the common name, DNS names and URIs of cert.
+func certificateIdentities(cert *x509.Certificate) []string {
+	var ids []string
+	if cert.Subject.CommonName != "" {
+		ids = append(ids, cert.Subject.CommonName)
+	}
+	ids = append(ids, cert.DNSNames...)
+	for _, uri := range cert.URIs {
+		ids = append(ids, uri.String())
+	}
+	return ids
+}
+
+func (a *callerAuth) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if isHealthMethod(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	if err := a.authenticate(ctx); err != nil {
+		a.logger.Sugar().Warnw("Rejected unauthenticated call", zap.String("method", info.FullMethod), zap.Error(err))
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *callerAuth) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if isHealthMethod(info.FullMethod) {
+		return handler(srv, ss)
+	}
+	if err := a.authenticate(ss.Context()); err != nil {
+		a.logger.Sugar().Warnw("Rejected unauthenticated call", zap.String("method", info.FullMethod), zap.Error(err))
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// isHealthMethod reports whether method belongs to the gRPC health protocol,
+// which is served without authentication so probes need no credentials.
+func isHealthMethod(method string) bool {
+	return strings.HasPrefix(method, "/"+healthpb.Health_ServiceDesc.ServiceName+"/")
+}
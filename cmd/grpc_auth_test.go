@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func Test_callerAuthFromEnv(t *testing.T) {
+	mutual := &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert}
+
+	tests := []struct {
+		name           string
+		token          string
+		clients        string
+		tlsCfg         *tls.Config
+		wantNil        bool
+		wantIdentities int
+		wantErr        bool
+	}{
+		{name: "unset", wantNil: true},
+		{name: "token", token: "s3cret"},
+		{name: "identities", clients: "executor, spiffe://operator/executor,", tlsCfg: mutual, wantIdentities: 2},
+		{name: "identities without tls", clients: "executor", wantErr: true},
+		{name: "identities without client certificates", clients: "executor", tlsCfg: &tls.Config{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PERFORMER_AUTH_TOKEN", tt.token)
+			t.Setenv("PERFORMER_ALLOWED_CLIENTS", tt.clients)
+			auth, err := callerAuthFromEnv(context.Background(), zap.NewNop(), tt.tlsCfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (auth == nil) != tt.wantNil {
+				t.Fatalf("expected nil auth %v, got %+v", tt.wantNil, auth)
+			}
+			if auth != nil && (auth.token != tt.token || len(auth.identities) != tt.wantIdentities) {
+				t.Errorf("unexpected auth: %+v", auth)
+			}
+		})
+	}
+}
+
+func Test_callerAuthAuthenticate(t *testing.T) {
+	ca := newTestCert(t, "test-ca", nil, x509.ExtKeyUsageServerAuth)
+	executor := newTestCert(t, "executor", ca, x509.ExtKeyUsageClientAuth)
+	other := newTestCert(t, "other", ca, x509.ExtKeyUsageClientAuth)
+
+	// withCaller returns a request context carrying the token and the client
+	// certificate, when set.
+	withCaller := func(token string, cert *testCert) context.Context {
+		ctx := context.Background()
+		if token != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+token))
+		}
+		if cert != nil {
+			state := tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert.cert, ca.cert}}}
+			ctx = peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+		}
+		return ctx
+	}
+
+	tests := []struct {
+		name     string
+		auth     *callerAuth
+		ctx      context.Context
+		wantCode codes.Code
+	}{
+		{name: "valid token", auth: &callerAuth{token: "s3cret"}, ctx: withCaller("s3cret", nil), wantCode: codes.OK},
+		{name: "wrong token", auth: &callerAuth{token: "s3cret"}, ctx: withCaller("guess", nil), wantCode: codes.Unauthenticated},
+		{name: "missing token", auth: &callerAuth{token: "s3cret"}, ctx: withCaller("", nil), wantCode: codes.Unauthenticated},
+		{name: "allowed client", auth: &callerAuth{identities: map[string]bool{"executor": true}}, ctx: withCaller("", executor), wantCode: codes.OK},
+		{name: "allowed by dns name", auth: &callerAuth{identities: map[string]bool{"localhost": true}}, ctx: withCaller("", other), wantCode: codes.OK},
+		{name: "other client", auth: &callerAuth{identities: map[string]bool{"executor": true}}, ctx: withCaller("", other), wantCode: codes.PermissionDenied},
+		{name: "no client certificate", auth: &callerAuth{identities: map[string]bool{"executor": true}}, ctx: withCaller("", nil), wantCode: codes.Unauthenticated},
+		{
+			name:     "token and client",
+			auth:     &callerAuth{token: "s3cret", identities: map[string]bool{"executor": true}},
+			ctx:      withCaller("s3cret", executor),
+			wantCode: codes.OK,
+		},
+		{
+			name:     "client without token",
+			auth:     &callerAuth{token: "s3cret", identities: map[string]bool{"executor": true}},
+			ctx:      withCaller("", executor),
+			wantCode: codes.Unauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := status.Code(tt.auth.authenticate(tt.ctx)); code != tt.wantCode {
+				t.Errorf("expected %v, got %v", tt.wantCode, code)
+			}
+		})
+	}
+}
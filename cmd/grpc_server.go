@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// serverTLSConfigFromEnv configures TLS for the performer gRPC server from
+// PERFORMER_TLS_CERT_FILE and PERFORMER_TLS_KEY_FILE, or returns nil when
+// neither is set. With PERFORMER_TLS_CLIENT_CA_FILE, clients must present a
+// certificate signed by one of its CAs (mutual TLS).
+func serverTLSConfigFromEnv() (*tls.Config, error) {
+	certFile, keyFile := getenv("PERFORMER_TLS_CERT_FILE"), getenv("PERFORMER_TLS_KEY_FILE")
+	clientCAFile := getenv("PERFORMER_TLS_CLIENT_CA_FILE")
+	if certFile == "" && keyFile == "" {
+		if clientCAFile != "" {
+			return nil, fmt.Errorf("PERFORMER_TLS_CLIENT_CA_FILE requires PERFORMER_TLS_CERT_FILE and PERFORMER_TLS_KEY_FILE")
+		}
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("PERFORMER_TLS_CERT_FILE and PERFORMER_TLS_KEY_FILE must both be set")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load performer TLS certificate: %w", err)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read PERFORMER_TLS_CLIENT_CA_FILE: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("PERFORMER_TLS_CLIENT_CA_FILE contains no PEM certificates")
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// errorInfoDomain is the domain of the ErrorInfo details of failed calls.
+const errorInfoDomain = "performer.hourglass.eigenlayer"
+
+// performerService serves the performer API with a TaskWorker, as the ponos
+// performer server does, and additionally traces tasks and attaches the error
+// code and category of failed tasks to the gRPC status as ErrorInfo details.
+type performerService struct {
+	performerV1.UnimplementedPerformerServiceServer
+
+	logger *zap.Logger
+	worker *TaskWorker
+	// readiness, when set, reports the performer as not ready once shutdown
+	// starts, before the worker itself drains.
+	readiness *Readiness
+}
+
+func (s *performerService) ExecuteTask(ctx context.Context, task *performerV1.TaskRequest) (*performerV1.TaskResponse, error) {
+	ctx = withTaskCorrelationID(ctx, task)
+	logger := correlatedLogger(ctx, s.logger)
+	if err := s.worker.validateTask(ctx, task); err != nil {
+		logger.Sugar().Errorw("task is invalid", zap.String("taskId", string(task.TaskId)), zap.Error(err))
+		return nil, taskStatus(codes.Internal, "task is invalid: "+err.Error(), errorCodeInvalidTask)
+	}
+
+	res, err := s.worker.handleTask(ctx, task)
+	if err != nil {
+		logger.Sugar().Errorw("Failed to handle task", zap.String("taskId", string(task.TaskId)), zap.Error(err))
+		code, _ := classifyTaskError(err)
+		taskFailures.WithLabelValues(errorCategory(code), code).Inc()
+		if errors.Is(err, ErrShuttingDown) {
+			return nil, taskStatus(codes.Unavailable, "Failed to handle task: "+err.Error(), code)
+		}
+		return nil, taskStatus(codes.Internal, "Failed to handle task: "+err.Error(), code)
+	}
+	return &performerV1.TaskResponse{TaskId: task.TaskId, Result: res.Result}, nil
+}
+
+// taskStatus returns a gRPC status error carrying the error code of a failed
+// task and its category as ErrorInfo details.
+func taskStatus(c codes.Code, msg, code string) error {
+	st := status.New(c, msg)
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   code,
+		Domain:   errorInfoDomain,
+		Metadata: map[string]string{"category": errorCategory(code)},
+	})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// HealthCheck reports the performer ready for tasks until it starts shutting
+// down. The performer API has no not-ready status, so a draining performer
+// answers Unavailable and the aggregator sends its tasks elsewhere.
+func (s *performerService) HealthCheck(ctx context.Context, req *performerV1.HealthCheckRequest) (*performerV1.HealthCheckResponse, error) {
+	if s.worker.Draining() || (s.readiness != nil && s.readiness.Draining()) {
+		return nil, taskStatus(codes.Unavailable, ErrShuttingDown.Error(), errorCodeShuttingDown)
+	}
+	return &performerV1.HealthCheckResponse{Status: performerV1.PerformerStatus_READY_FOR_TASK}, nil
+}
+
+func (s *performerService) StartSync(ctx context.Context, req *performerV1.StartSyncRequest) (*performerV1.StartSyncResponse, error) {
+	return &performerV1.StartSyncResponse{}, nil
+}
+
+// newPerformerServer returns a gRPC server for the performer API and the
+// health protocol that serves TLS when tlsCfg is set and authenticates
+// callers when auth is set.
+func newPerformerServer(logger *zap.Logger, w *TaskWorker, readiness *Readiness, tlsCfg *tls.Config, auth *callerAuth) *grpc.Server {
+	var opts []grpc.ServerOption
+	if tlsCfg != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+	}
+	if auth != nil {
+		opts = append(opts, grpc.UnaryInterceptor(auth.unaryInterceptor), grpc.StreamInterceptor(auth.streamInterceptor))
+	}
+	srv := grpc.NewServer(opts...)
+	performerV1.RegisterPerformerServiceServer(srv, &performerService{logger: logger, worker: w, readiness: readiness})
+	readiness.Register(srv)
+	reflection.Register(srv)
+	return srv
+}
+
+// servePerformer serves the performer API on the given port until ctx is
+// cancelled.
+func servePerformer(ctx context.Context, port int, w *TaskWorker, readiness *Readiness, tlsCfg *tls.Config, auth *callerAuth, logger *zap.Logger) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	srv := newPerformerServer(logger, w, readiness, tlsCfg, auth)
+	go func() {
+		<-ctx.Done()
+		logger.Sugar().Infow("Shutting down grpc server")
+		srv.GracefulStop()
+	}()
+
+	logger.Sugar().Infow("Starting gRPC server",
+		zap.Int("port", port),
+		zap.Bool("tls", tlsCfg != nil),
+		zap.Bool("mutualTLS", tlsCfg != nil && tlsCfg.ClientAuth == tls.RequireAndVerifyClientCert),
+		zap.Bool("callerAuth", auth != nil),
+	)
+	return srv.Serve(lis)
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// testCert is a certificate and key issued by a test CA, or self-signed when
+// the CA is nil.
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCert(t *testing.T, name string, ca *testCert, usage x509.ExtKeyUsage) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	parent, signer := tmpl, key
+	if ca == nil {
+		// A CA with extended key usages would restrict what it can issue.
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	} else {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+		parent, signer = ca.cert, ca.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+// write stores the certificate and key as PEM files and returns their paths.
+func (c *testCert) write(t *testing.T, dir, name string) (string, string) {
+	t.Helper()
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0o600); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	return certFile, keyFile
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func Test_serverTLSConfigFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "test-ca", nil, x509.ExtKeyUsageServerAuth)
+	caFile, _ := ca.write(t, dir, "ca")
+	certFile, keyFile := newTestCert(t, "performer", ca, x509.ExtKeyUsageServerAuth).write(t, dir, "server")
+
+	tests := []struct {
+		name       string
+		env        map[string]string
+		wantNil    bool
+		wantMutual bool
+		wantErr    bool
+	}{
+		{name: "unset", wantNil: true},
+		{name: "tls", env: map[string]string{"PERFORMER_TLS_CERT_FILE": certFile, "PERFORMER_TLS_KEY_FILE": keyFile}},
+		{
+			name:       "mutual tls",
+			env:        map[string]string{"PERFORMER_TLS_CERT_FILE": certFile, "PERFORMER_TLS_KEY_FILE": keyFile, "PERFORMER_TLS_CLIENT_CA_FILE": caFile},
+			wantMutual: true,
+		},
+		{name: "missing key", env: map[string]string{"PERFORMER_TLS_CERT_FILE": certFile}, wantErr: true},
+		{name: "client ca without certificate", env: map[string]string{"PERFORMER_TLS_CLIENT_CA_FILE": caFile}, wantErr: true},
+		{name: "mismatched key", env: map[string]string{"PERFORMER_TLS_CERT_FILE": caFile, "PERFORMER_TLS_KEY_FILE": keyFile}, wantErr: true},
+		{
+			name:    "client ca not pem",
+			env:     map[string]string{"PERFORMER_TLS_CERT_FILE": certFile, "PERFORMER_TLS_KEY_FILE": keyFile, "PERFORMER_TLS_CLIENT_CA_FILE": keyFile},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"PERFORMER_TLS_CERT_FILE", "PERFORMER_TLS_KEY_FILE", "PERFORMER_TLS_CLIENT_CA_FILE"} {
+				t.Setenv(key, tt.env[key])
+			}
+			cfg, err := serverTLSConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (cfg == nil) != tt.wantNil {
+				t.Fatalf("expected nil config %v, got %+v", tt.wantNil, cfg)
+			}
+			if cfg != nil && (cfg.ClientAuth == tls.RequireAndVerifyClientCert) != tt.wantMutual {
+				t.Errorf("expected mutual TLS %v, got client auth %v", tt.wantMutual, cfg.ClientAuth)
+			}
+		})
+	}
+}
+
+func Test_performerServerMutualTLS(t *testing.T) {
+	ca := newTestCert(t, "test-ca", nil, x509.ExtKeyUsageServerAuth)
+	server := newTestCert(t, "performer", ca, x509.ExtKeyUsageServerAuth)
+	client := newTestCert(t, "executor", ca, x509.ExtKeyUsageClientAuth)
+	untrusted := newTestCert(t, "intruder", newTestCert(t, "other-ca", nil, x509.ExtKeyUsageServerAuth), x509.ExtKeyUsageClientAuth)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	srv := newPerformerServer(zap.NewNop(), NewTaskWorker(zap.NewNop(), &stubProvider{output: "4"}, nil), NewReadiness(), &tls.Config{
+		Certificates: []tls.Certificate{server.tlsCertificate()},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	tests := []struct {
+		name    string
+		certs   []tls.Certificate
+		wantErr bool
+	}{
+		{name: "trusted client", certs: []tls.Certificate{client.tlsCertificate()}},
+		{name: "no client certificate", wantErr: true},
+		{name: "untrusted client", certs: []tls.Certificate{untrusted.tlsCertificate()}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds := credentials.NewTLS(&tls.Config{Certificates: tt.certs, RootCAs: pool, ServerName: "localhost"})
+			conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(creds))
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			defer conn.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			resp, err := performerV1.NewPerformerServiceClient(conn).ExecuteTask(ctx, &performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && string(resp.TaskId) != "task-1" {
+				t.Errorf("unexpected response: %+v", resp)
+			}
+		})
+	}
+}
+
+func Test_performerServiceErrorDetails(t *testing.T) {
+	svc := &performerService{logger: zap.NewNop(), worker: NewTaskWorker(zap.NewNop(), &stubProvider{output: "4"}, nil)}
+
+	_, err := svc.ExecuteTask(context.Background(), &performerV1.TaskRequest{TaskId: []byte("task-1")})
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.Internal {
+		t.Fatalf("expected an internal status error, got %v", err)
+	}
+	var info *errdetails.ErrorInfo
+	for _, d := range st.Details() {
+		if i, ok := d.(*errdetails.ErrorInfo); ok {
+			info = i
+		}
+	}
+	if info == nil || info.Reason != errorCodeInvalidTask || info.Domain != errorInfoDomain || info.Metadata["category"] != errorCategoryValidation {
+		t.Errorf("unexpected error details %+v", st.Details())
+	}
+}
+
+func Test_performerServiceHealthCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		drain     func(w *TaskWorker, r *Readiness)
+		wantReady bool
+	}{
+		{name: "serving", drain: func(w *TaskWorker, r *Readiness) {}, wantReady: true},
+		{name: "shutdown started", drain: func(w *TaskWorker, r *Readiness) { r.SetDraining() }},
+		{name: "worker draining", drain: func(w *TaskWorker, r *Readiness) { _ = w.Drain(context.Background()) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewTaskWorker(zap.NewNop(), &stubProvider{output: "4"}, nil)
+			r := NewReadiness()
+			svc := &performerService{logger: zap.NewNop(), worker: w, readiness: r}
+			tt.drain(w, r)
+
+			resp, err := svc.HealthCheck(context.Background(), &performerV1.HealthCheckRequest{})
+			if tt.wantReady {
+				if err != nil || resp.Status != performerV1.PerformerStatus_READY_FOR_TASK {
+					t.Errorf("expected ready, got %v, %v", resp, err)
+				}
+				return
+			}
+			if status.Code(err) != codes.Unavailable {
+				t.Errorf("expected Unavailable, got %v, %v", resp, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// readinessInterval is how often the gRPC health status is refreshed.
+	readinessInterval = 5 * time.Second
+	// readinessTimeout bounds the readiness checks of a probe.
+	readinessTimeout = 2 * time.Second
+)
+
+// errNotConfigured fails readiness until the performer is fully configured.
+var errNotConfigured = errors.New("performer is still being configured")
+
+type readinessCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// Readiness tells Kubernetes and the Hourglass executor whether the
+// performer can take tasks: over HTTP on /healthz (liveness) and /readyz,
+// and over the standard gRPC health protocol. The performer is ready once it
+// is configured and all of its checks, such as provider reachability, pass.
+type Readiness struct {
+	configured atomic.Bool
+	draining   atomic.Bool
+	grpc       *health.Server
+
+	mu     sync.Mutex
+	checks []readinessCheck
+}
+
+func NewReadiness() *Readiness {
+	r := &Readiness{grpc: health.NewServer()}
+	r.setServing(false)
+	return r
+}
+
+// AddCheck adds a named check to readiness.
+func (r *Readiness) AddCheck(name string, check func(ctx context.Context) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, readinessCheck{name: name, check: check})
+}
+
+// SetConfigured marks the configuration of the performer as loaded and valid.
+func (r *Readiness) SetConfigured() {
+	r.configured.Store(true)
+}
+
+// SetDraining marks the performer as shutting down, so it is reported as not
+// ready from now on.
+func (r *Readiness) SetDraining() {
+	r.draining.Store(true)
+	r.setServing(false)
+}
+
+// Draining reports whether SetDraining was called.
+func (r *Readiness) Draining() bool {
+	return r.draining.Load()
+}
+
+// Check runs the readiness checks and returns the failures by check name.
+func (r *Readiness) Check(ctx context.Context) map[string]string {
+	failures := map[string]string{}
+	if !r.configured.Load() {
+		failures["config"] = errNotConfigured.Error()
+	}
+	if r.draining.Load() {
+		failures["shutdown"] = ErrShuttingDown.Error()
+	}
+	r.mu.Lock()
+	checks := append([]readinessCheck(nil), r.checks...)
+	r.mu.Unlock()
+	for _, c := range checks {
+		if err := c.check(ctx); err != nil {
+			failures[c.name] = err.Error()
+		}
+	}
+	return failures
+}
+
+// Register serves the gRPC health protocol on srv.
+func (r *Readiness) Register(srv *grpc.Server) {
+	healthpb.RegisterHealthServer(srv, r.grpc)
+}
+
+// Watch refreshes the gRPC health status until ctx is cancelled.
+func (r *Readiness) Watch(ctx context.Context, logger *zap.Logger) {
+	go func() {
+		ticker := time.NewTicker(readinessInterval)
+		defer ticker.Stop()
+		ready := false
+		for {
+			checkCtx, cancel := context.WithTimeout(ctx, readinessTimeout)
+			failures := r.Check(checkCtx)
+			cancel()
+			if ready != (len(failures) == 0) {
+				ready = !ready
+				logger.Sugar().Infow("Performer readiness changed",
+					zap.Bool("ready", ready),
+					zap.Any("failures", failures),
+				)
+				r.setServing(ready)
+			}
+			select {
+			case <-ctx.Done():
+				r.grpc.Shutdown()
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// setServing sets the status of the server and the performer service.
+func (r *Readiness) setServing(serving bool) {
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	r.grpc.SetServingStatus("", status)
+	r.grpc.SetServingStatus(performerV1.PerformerService_ServiceDesc.ServiceName, status)
+}
+
+// Handler serves /healthz, which answers while the process serves HTTP, and
+// /readyz, which fails with the failed checks until the performer is ready.
+func (r *Readiness) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), readinessTimeout)
+		defer cancel()
+		failures := r.Check(ctx)
+		if len(failures) == 0 {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("ok\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"ready": false, "failures": failures})
+	})
+	return mux
+}
+
+// startHealthServer serves the HTTP probes on the given port until ctx is
+// cancelled.
+func startHealthServer(ctx context.Context, port int, r *Readiness, logger *zap.Logger) {
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           r.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		logger.Sugar().Infow("Starting health server", zap.Int("port", port))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Sugar().Errorw("Health server failed", zap.Error(err))
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+}
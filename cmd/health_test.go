@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func Test_ReadinessHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured bool
+		draining   bool
+		checkErr   error
+		path       string
+		wantStatus int
+	}{
+		{name: "ready", configured: true, path: "/readyz", wantStatus: http.StatusOK},
+		{name: "configuring", path: "/readyz", wantStatus: http.StatusServiceUnavailable},
+		{name: "check failing", configured: true, checkErr: ErrProviderUnavailable, path: "/readyz", wantStatus: http.StatusServiceUnavailable},
+		{name: "shutting down", configured: true, draining: true, path: "/readyz", wantStatus: http.StatusServiceUnavailable},
+		{name: "alive while shutting down", configured: true, draining: true, path: "/healthz", wantStatus: http.StatusOK},
+		{name: "alive while configuring", path: "/healthz", wantStatus: http.StatusOK},
+		{name: "alive while not ready", configured: true, checkErr: ErrProviderUnavailable, path: "/healthz", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReadiness()
+			r.AddCheck("provider", func(ctx context.Context) error { return tt.checkErr })
+			if tt.configured {
+				r.SetConfigured()
+			}
+			if tt.draining {
+				r.SetDraining()
+			}
+
+			rec := httptest.NewRecorder()
+			r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func Test_ReadinessWatch(t *testing.T) {
+	var checkErr error = errors.New("unreachable")
+	r := NewReadiness()
+	r.AddCheck("provider", func(ctx context.Context) error { return checkErr })
+	r.SetConfigured()
+
+	status := func() healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := r.grpc.Check(context.Background(), &healthpb.HealthCheckRequest{Service: performerV1.PerformerService_ServiceDesc.ServiceName})
+		if err != nil {
+			t.Fatalf("health check failed: %v", err)
+		}
+		return resp.Status
+	}
+	if got := status(); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("expected NOT_SERVING before the first check, got %v", got)
+	}
+
+	checkErr = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r.Watch(ctx, zap.NewNop())
+	deadline := time.Now().Add(time.Second)
+	for status() != healthpb.HealthCheckResponse_SERVING {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the performer to become SERVING")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func Test_providersReachable(t *testing.T) {
+	providerBreakersMu.Lock()
+	saved := providerBreakers
+	providerBreakersMu.Unlock()
+	t.Cleanup(func() {
+		providerBreakersMu.Lock()
+		providerBreakers = saved
+		providerBreakersMu.Unlock()
+	})
+
+	tests := []struct {
+		name    string
+		open    []bool
+		wantErr bool
+	}{
+		{name: "no providers"},
+		{name: "closed", open: []bool{false}},
+		{name: "open", open: []bool{true}, wantErr: true},
+		{name: "failover available", open: []bool{true, false}},
+		{name: "all open", open: []bool{true, true}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providerBreakersMu.Lock()
+			providerBreakers = map[string]*CircuitBreaker{}
+			providerBreakersMu.Unlock()
+			providers := []Provider{&stubProvider{}, &gatedProvider{}}
+			for i, open := range tt.open {
+				cb := NewCircuitBreaker(zap.NewNop(), &CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Minute}, providers[i], nil)
+				if open {
+					cb.open()
+				}
+				registerProviderBreaker(cb)
+			}
+
+			err := providersReachable(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_isHealthMethod(t *testing.T) {
+	tests := []struct {
+		method string
+		want   bool
+	}{
+		{method: "/grpc.health.v1.Health/Check", want: true},
+		{method: "/grpc.health.v1.Health/Watch", want: true},
+		{method: "/eigenlayer.hourglass.v1.performer.PerformerService/ExecuteTask"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			if got := isHealthMethod(tt.method); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
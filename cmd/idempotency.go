@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+)
+
+const defaultIdempotencySize = 10000
+
+// idempotencyFromEnv keeps the responses of the tasks handled within
+// IDEMPOTENCY_WINDOW, bounded to the IDEMPOTENCY_SIZE (10000 by default) most
+// recent ones, and returns nil when the window is unset.
+func idempotencyFromEnv() (ResultCache, error) {
+	window, err := envDuration("IDEMPOTENCY_WINDOW")
+	if err != nil {
+		return nil, err
+	}
+	if window < 0 {
+		return nil, fmt.Errorf("IDEMPOTENCY_WINDOW must not be negative")
+	}
+	if window == 0 {
+		return nil, nil
+	}
+	size, err := envInt("IDEMPOTENCY_SIZE")
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("IDEMPOTENCY_SIZE must not be negative")
+	}
+	if size == 0 {
+		size = defaultIdempotencySize
+	}
+	return NewLRUResultCache(size, window), nil
+}
+
+// taskKey identifies a delivery of a task by its TaskId and payload, so a
+// different task reusing a TaskId is not answered with the response of
+// another.
+func taskKey(t *performerV1.TaskRequest) string {
+	key, _ := json.Marshal([][]byte{t.TaskId, t.Payload})
+	return crypto.Keccak256Hash(key).Hex()
+}
+
+// previousResponse returns the response already sent for t, which executors
+// redeliver after transient gRPC failures.
+func (tw *TaskWorker) previousResponse(ctx context.Context, t *performerV1.TaskRequest) (*performerV1.TaskResponse, bool) {
+	result, ok, err := tw.responses.Get(ctx, taskKey(t))
+	if err != nil {
+		correlatedLogger(ctx, tw.logger).Sugar().Warnw("Failed to read previous task responses",
+			zap.String("taskId", string(t.TaskId)),
+			zap.Error(err),
+		)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	redeliveredTasks.Inc()
+	correlatedLogger(ctx, tw.logger).Sugar().Infow("Returned previous response of redelivered task",
+		zap.String("taskId", string(t.TaskId)),
+	)
+	return &performerV1.TaskResponse{TaskId: t.TaskId, Result: result}, true
+}
+
+// rememberResponse keeps resp as the response to redeliveries of t.
+func (tw *TaskWorker) rememberResponse(ctx context.Context, t *performerV1.TaskRequest, resp *performerV1.TaskResponse) {
+	if tw.responses == nil {
+		return
+	}
+	if err := tw.responses.Set(ctx, taskKey(t), resp.Result); err != nil {
+		correlatedLogger(ctx, tw.logger).Sugar().Warnw("Failed to remember task response",
+			zap.String("taskId", string(t.TaskId)),
+			zap.Error(err),
+		)
+	}
+}
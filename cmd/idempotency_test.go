@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_idempotencyFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		wantNil  bool
+		wantSize int
+		wantErr  bool
+	}{
+		{name: "unset", wantNil: true},
+		{name: "default size", env: map[string]string{"IDEMPOTENCY_WINDOW": "1h"}, wantSize: defaultIdempotencySize},
+		{name: "size", env: map[string]string{"IDEMPOTENCY_WINDOW": "1h", "IDEMPOTENCY_SIZE": "100"}, wantSize: 100},
+		{name: "negative size", env: map[string]string{"IDEMPOTENCY_WINDOW": "1h", "IDEMPOTENCY_SIZE": "-1"}, wantErr: true},
+		{name: "invalid window", env: map[string]string{"IDEMPOTENCY_WINDOW": "an hour"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"IDEMPOTENCY_WINDOW", "IDEMPOTENCY_SIZE"} {
+				t.Setenv(key, tt.env[key])
+			}
+			store, err := idempotencyFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (store == nil) != tt.wantNil {
+				t.Fatalf("expected nil store %v, got %+v", tt.wantNil, store)
+			}
+			if lru, ok := store.(*LRUResultCache); ok && lru.size != tt.wantSize {
+				t.Errorf("expected size %d, got %d", tt.wantSize, lru.size)
+			}
+		})
+	}
+}
+
+func Test_HandleTaskRedelivery(t *testing.T) {
+	tests := []struct {
+		name string
+		// shed fails the first delivery with a retryable error.
+		shed       bool
+		redelivery *performerV1.TaskRequest
+		wantCalls  int32
+	}{
+		{name: "redelivered", redelivery: &performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")}, wantCalls: 1},
+		{name: "other task", redelivery: &performerV1.TaskRequest{TaskId: []byte("task-2"), Payload: []byte("What is 2+2?")}, wantCalls: 2},
+		{name: "reused task id", redelivery: &performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 3+3?")}, wantCalls: 2},
+		{name: "retryable failure", shed: true, redelivery: &performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")}, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := make(chan struct{})
+			close(release)
+			provider := &gatedProvider{release: release}
+			tw := NewTaskWorker(zap.NewNop(), provider, nil)
+			tw.SetIdempotency(NewLRUResultCache(10, time.Hour))
+			if tt.shed {
+				tw.SetLoadShedder(NewLoadShedder(0, 0, 1))
+			}
+
+			first, err := tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")})
+			if err != nil {
+				t.Fatalf("HandleTask failed: %v", err)
+			}
+			tw.SetLoadShedder(nil)
+			second, err := tw.HandleTask(tt.redelivery)
+			if err != nil {
+				t.Fatalf("HandleTask failed: %v", err)
+			}
+
+			if got := provider.calls.Load(); got != tt.wantCalls {
+				t.Errorf("expected %d provider calls, got %d", tt.wantCalls, got)
+			}
+			if !bytes.Equal(second.TaskId, tt.redelivery.TaskId) {
+				t.Errorf("expected task id %s, got %s", tt.redelivery.TaskId, second.TaskId)
+			}
+			if replayed := bytes.Equal(first.Result, second.Result); tt.shed && replayed {
+				t.Errorf("expected the retryable failure to be retried, got %s", second.Result)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// judgeStubProvider answers every completion with a fixed verdict and records
+// the last request.
+type judgeStubProvider struct {
+	output string
+	last   *CompletionRequest
+}
+
+func (p *judgeStubProvider) Name() string {
+	return "judge-stub"
+}
+
+func (p *judgeStubProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	p.last = req
+	return &CompletionResponse{Output: p.output, Model: "judge-model"}, nil
+}
+
+func Test_parseJudgeVerdict(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		wantVerdict string
+		wantScore   float64
+		wantOK      bool
+	}{
+		{name: "pass", output: `{"verdict":"pass","score":0.9,"reason":"correct"}`, wantVerdict: "pass", wantScore: 0.9, wantOK: true},
+		{name: "in prose", output: `Verdict: {"verdict":"FAIL","score":0.2,"reason":"wrong"}`, wantVerdict: "fail", wantScore: 0.2, wantOK: true},
+		{name: "clamped", output: `{"verdict":"pass","score":4}`, wantVerdict: "pass", wantScore: 1, wantOK: true},
+		{name: "missing score", output: `{"verdict":"pass"}`},
+		{name: "unknown verdict", output: `{"verdict":"maybe","score":0.5}`},
+		{name: "no json", output: "looks good"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, ok := parseJudgeVerdict(tt.output)
+			if ok != tt.wantOK {
+				t.Fatalf("expected ok %v, got %v", tt.wantOK, ok)
+			}
+			if ok && (verdict.Verdict != tt.wantVerdict || verdict.Score != tt.wantScore) {
+				t.Errorf("unexpected verdict: %+v", verdict)
+			}
+		})
+	}
+}
+
+func Test_judgeVerifier(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		wantPassed  bool
+		wantReasons []string
+	}{
+		{name: "pass", output: `{"verdict":"pass","score":0.9,"reason":"correct"}`, wantPassed: true, wantReasons: []string{}},
+		{name: "pass below threshold", output: `{"verdict":"pass","score":0.5,"reason":"partly correct"}`, wantReasons: []string{"judge_rejected"}},
+		{name: "fail", output: `{"verdict":"fail","score":0.9,"reason":"wrong"}`, wantReasons: []string{"judge_rejected"}},
+		{name: "unparseable", output: "I think so", wantReasons: []string{"judge_unparseable"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &judgeStubProvider{output: tt.output}
+			judge := &judgeVerifier{provider: provider, model: "judge-model", threshold: 0.7}
+			payload, err := ParseTaskPayload([]byte(`{"prompt":"What is the capital of France?"}`))
+			if err != nil {
+				t.Fatalf("ParseTaskPayload failed: %v", err)
+			}
+
+			v := newVerification()
+			if err := judge.Verify(context.Background(), payload, "Paris", v); err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if v.Passed != tt.wantPassed || strings.Join(v.Reasons, ",") != strings.Join(tt.wantReasons, ",") {
+				t.Errorf("unexpected verification: %+v", v)
+			}
+			if tt.name != "unparseable" && (v.Judge == nil || v.Judge.Provider != "judge-stub" || v.Judge.Model != "judge-model") {
+				t.Errorf("expected the judge's verdict to be recorded, got %+v", v.Judge)
+			}
+
+			user := provider.last.Messages[len(provider.last.Messages)-1].Content
+			if provider.last.Model != "judge-model" || !strings.Contains(user, "What is the capital of France?") || !strings.Contains(user, "Paris") {
+				t.Errorf("unexpected judge request: %+v", provider.last)
+			}
+		})
+	}
+}
+
+func Test_judgeVerifierFromEnv(t *testing.T) {
+	t.Setenv("LLM_PROVIDER", "ollama")
+	t.Setenv("JUDGE_PROVIDER", "ollama")
+	t.Setenv("JUDGE_MODEL", "")
+	if _, err := judgeVerifierFromEnv(context.Background()); err == nil {
+		t.Errorf("expected error for a judge using the completion provider and model")
+	}
+
+	t.Setenv("JUDGE_MODEL", "llama3.1:70b")
+	judge, err := judgeVerifierFromEnv(context.Background())
+	if err != nil {
+		t.Fatalf("judgeVerifierFromEnv failed: %v", err)
+	}
+	if judge.model != "llama3.1:70b" || judge.threshold != defaultJudgeThreshold {
+		t.Errorf("unexpected judge: %+v", judge)
+	}
+
+	t.Setenv("JUDGE_THRESHOLD", "1.5")
+	if _, err := judgeVerifierFromEnv(context.Background()); err == nil {
+		t.Errorf("expected error for JUDGE_THRESHOLD above 1")
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	defaultMaxPayloadSize             = 4 * 1024
+	defaultMaxDecompressedPayloadSize = 64 * 1024
+	defaultMaxResultSize              = 8 * 1024
+	defaultMaxPayloadTokens           = 16 * 1024
+	defaultMaxContextTokens           = 16 * 1024
+)
+
+// LimitsConfig bounds the size of task payloads and results. Byte limits
+// protect the performer and the on-chain result; token limits bound what is
+// sent to and requested from the model.
+type LimitsConfig struct {
+	// MaxPayloadSize is the largest payload accepted as received.
+	MaxPayloadSize int
+	// MaxDecompressedPayloadSize is the largest payload accepted once
+	// decompressed, so documents can be sent gzip or zstd compressed.
+	MaxDecompressedPayloadSize int
+	// MaxResultSize is the largest result returned to the executor.
+	MaxResultSize int
+	// MaxPayloadTokens is the largest estimated token count of a decoded
+	// payload.
+	MaxPayloadTokens int
+	// MaxResultTokens is the largest max_tokens a task may request. It cannot
+	// exceed maxTaskMaxTokens.
+	MaxResultTokens int
+	// MaxContextTokens is the token budget of a completion request: the
+	// estimated size of the prompt and conversation plus max_tokens.
+	MaxContextTokens int
+	// CompressResultsOver is the length above which string fields of a result
+	// are gzip compressed, so large outputs fit in MaxResultSize. Zero
+	// disables compression.
+	CompressResultsOver int
+	// TruncateResults shortens the output of results larger than
+	// MaxResultSize and flags them as truncated, instead of failing the task.
+	TruncateResults bool
+}
+
+func defaultLimitsConfig() *LimitsConfig {
+	return &LimitsConfig{
+		MaxPayloadSize:             defaultMaxPayloadSize,
+		MaxDecompressedPayloadSize: defaultMaxDecompressedPayloadSize,
+		MaxResultSize:              defaultMaxResultSize,
+		MaxPayloadTokens:           defaultMaxPayloadTokens,
+		MaxResultTokens:            maxTaskMaxTokens,
+		MaxContextTokens:           defaultMaxContextTokens,
+		TruncateResults:            true,
+	}
+}
+
+func limitsConfigFromEnv() (*LimitsConfig, error) {
+	cfg := defaultLimitsConfig()
+
+	for _, limit := range []struct {
+		key   string
+		value *int
+	}{
+		{"MAX_PAYLOAD_SIZE", &cfg.MaxPayloadSize},
+		{"MAX_DECOMPRESSED_PAYLOAD_SIZE", &cfg.MaxDecompressedPayloadSize},
+		{"MAX_RESULT_SIZE", &cfg.MaxResultSize},
+		{"MAX_PAYLOAD_TOKENS", &cfg.MaxPayloadTokens},
+		{"MAX_RESULT_TOKENS", &cfg.MaxResultTokens},
+		{"MAX_CONTEXT_TOKENS", &cfg.MaxContextTokens},
+		{"COMPRESS_RESULTS_OVER", &cfg.CompressResultsOver},
+	} {
+		v, err := envInt(limit.key)
+		if err != nil {
+			return nil, err
+		}
+		if v > 0 {
+			*limit.value = v
+		}
+	}
+
+	if v := getenv("TRUNCATE_RESULTS"); v != "" {
+		var err error
+		if cfg.TruncateResults, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid value for TRUNCATE_RESULTS: %w", err)
+		}
+	}
+
+	if cfg.MaxResultTokens > maxTaskMaxTokens {
+		return nil, fmt.Errorf("MAX_RESULT_TOKENS must not exceed %d", maxTaskMaxTokens)
+	}
+	return cfg, nil
+}
+
+// estimateTextTokens approximates the token count of a text using the common
+// heuristic of four characters per token.
+func estimateTextTokens(text string) int {
+	return (len(text) + 3) / 4
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_LimitsConfigFromEnv(t *testing.T) {
+	t.Setenv("MAX_PAYLOAD_SIZE", "16384")
+	t.Setenv("MAX_RESULT_TOKENS", "256")
+	t.Setenv("TRUNCATE_RESULTS", "false")
+
+	cfg, err := limitsConfigFromEnv()
+	if err != nil {
+		t.Fatalf("limitsConfigFromEnv failed: %v", err)
+	}
+	if cfg.MaxPayloadSize != 16384 || cfg.MaxResultTokens != 256 || cfg.MaxResultSize != defaultMaxResultSize || cfg.TruncateResults {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	t.Setenv("MAX_RESULT_TOKENS", "4096")
+	if _, err := limitsConfigFromEnv(); err == nil {
+		t.Errorf("expected error for MAX_RESULT_TOKENS above %d", maxTaskMaxTokens)
+	}
+
+	t.Setenv("MAX_RESULT_TOKENS", "")
+	t.Setenv("TRUNCATE_RESULTS", "sometimes")
+	if _, err := limitsConfigFromEnv(); err == nil {
+		t.Errorf("expected error for invalid TRUNCATE_RESULTS")
+	}
+
+	t.Setenv("TRUNCATE_RESULTS", "")
+	t.Setenv("MAX_RESULT_SIZE", "big")
+	if _, err := limitsConfigFromEnv(); err == nil {
+		t.Errorf("expected error for invalid MAX_RESULT_SIZE")
+	}
+}
+
+func Test_ValidateTaskLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  *LimitsConfig
+		payload string
+		wantErr bool
+	}{
+		{
+			name:    "large payload within raised limit",
+			limits:  &LimitsConfig{MaxPayloadSize: 16384, MaxDecompressedPayloadSize: 16384, MaxResultSize: 8192, MaxPayloadTokens: 4096, MaxResultTokens: 1024, MaxContextTokens: 16384},
+			payload: `{"prompt":"` + strings.Repeat("a", 8000) + `"}`,
+		},
+		{
+			name:    "payload over byte limit",
+			limits:  &LimitsConfig{MaxPayloadSize: 1024, MaxDecompressedPayloadSize: 16384, MaxResultSize: 8192, MaxPayloadTokens: 4096, MaxResultTokens: 1024, MaxContextTokens: 16384},
+			payload: `{"prompt":"` + strings.Repeat("a", 2000) + `"}`,
+			wantErr: true,
+		},
+		{
+			name:    "payload over token limit",
+			limits:  &LimitsConfig{MaxPayloadSize: 16384, MaxDecompressedPayloadSize: 16384, MaxResultSize: 8192, MaxPayloadTokens: 100, MaxResultTokens: 1024, MaxContextTokens: 16384},
+			payload: `{"prompt":"` + strings.Repeat("a", 2000) + `"}`,
+			wantErr: true,
+		},
+		{
+			name:    "conversation over context budget",
+			limits:  &LimitsConfig{MaxPayloadSize: 4096, MaxDecompressedPayloadSize: 16384, MaxResultSize: 8192, MaxPayloadTokens: 4096, MaxResultTokens: 1024, MaxContextTokens: 512},
+			payload: `{"messages":[{"role":"user","content":"` + strings.Repeat("a", 1200) + `"},{"role":"assistant","content":"ok"},{"role":"user","content":"and?"}],"max_tokens":256}`,
+			wantErr: true,
+		},
+		{
+			name:    "max_tokens over result token limit",
+			limits:  &LimitsConfig{MaxPayloadSize: 4096, MaxDecompressedPayloadSize: 16384, MaxResultSize: 8192, MaxPayloadTokens: 4096, MaxResultTokens: 64, MaxContextTokens: 16384},
+			payload: `{"prompt":"hi","max_tokens":128}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "valid"}, nil)
+			tw.SetLimits(tt.limits)
+
+			err := tw.ValidateTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte(tt.payload)})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_ValidateResultSizeLimit(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{}, nil)
+	tw.SetLimits(&LimitsConfig{MaxResultSize: 32})
+
+	handler, err := tw.tasks.Handler(taskTypeCompletion)
+	if err != nil {
+		t.Fatalf("Handler failed: %v", err)
+	}
+	if err := tw.ValidateResult(handler, []byte(`{"verified":true,"llm_output":"`+strings.Repeat("a", 64)+`"}`)); err == nil {
+		t.Errorf("expected error for result over the size limit")
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/metrics"
+	"sync/atomic"
+)
+
+// Guards of the load shedder, reported as the reason of shed tasks.
+const (
+	shedInflightBytes = "inflight_bytes"
+	shedGoroutines    = "goroutines"
+	shedHeap          = "heap"
+)
+
+// liveHeapMetric is the heap memory occupied by live objects as of the last
+// GC, which grows under GC pressure.
+const liveHeapMetric = "/gc/heap/live:bytes"
+
+// ErrLoadShed is returned for tasks shed because the performer is short of
+// memory or goroutines.
+var ErrLoadShed = errors.New("performer is shedding load")
+
+// LoadShedder guards the performer process: tasks are shed while the payload
+// bytes of the tasks in flight, the number of goroutines or the live heap
+// exceed their limit, so a batch of huge payloads cannot run the container
+// out of memory. A limit of 0 disables its guard.
+type LoadShedder struct {
+	maxInflightBytes int64
+	maxGoroutines    int
+	maxHeapBytes     uint64
+
+	inflight atomic.Int64
+	// liveHeap returns the live heap size; it is replaced in tests.
+	liveHeap func() uint64
+}
+
+// loadShedderFromEnv configures the guards from
+// LOAD_SHED_MAX_INFLIGHT_BYTES, LOAD_SHED_MAX_GOROUTINES and
+// LOAD_SHED_MAX_HEAP_BYTES, and returns nil when none is set.
+func loadShedderFromEnv() (*LoadShedder, error) {
+	inflight, err := envInt("LOAD_SHED_MAX_INFLIGHT_BYTES")
+	if err != nil {
+		return nil, err
+	}
+	goroutines, err := envInt("LOAD_SHED_MAX_GOROUTINES")
+	if err != nil {
+		return nil, err
+	}
+	heap, err := envInt("LOAD_SHED_MAX_HEAP_BYTES")
+	if err != nil {
+		return nil, err
+	}
+	if inflight < 0 || goroutines < 0 || heap < 0 {
+		return nil, fmt.Errorf("load shedding limits must not be negative")
+	}
+	if inflight == 0 && goroutines == 0 && heap == 0 {
+		return nil, nil
+	}
+	return NewLoadShedder(int64(inflight), goroutines, uint64(heap)), nil
+}
+
+func NewLoadShedder(maxInflightBytes int64, maxGoroutines int, maxHeapBytes uint64) *LoadShedder {
+	return &LoadShedder{
+		maxInflightBytes: maxInflightBytes,
+		maxGoroutines:    maxGoroutines,
+		maxHeapBytes:     maxHeapBytes,
+		liveHeap:         readLiveHeap,
+	}
+}
+
+// Admit accounts for a task with a payload of size bytes and returns the
+// function releasing it, or an error naming the guard that shed it.
+func (s *LoadShedder) Admit(size int) (func(), error) {
+	if s.maxGoroutines > 0 {
+		if n := runtime.NumGoroutine(); n > s.maxGoroutines {
+			return nil, s.shed(shedGoroutines, fmt.Sprintf("%d goroutines exceed the limit of %d", n, s.maxGoroutines))
+		}
+	}
+	if s.maxHeapBytes > 0 {
+		if heap := s.liveHeap(); heap > s.maxHeapBytes {
+			return nil, s.shed(shedHeap, fmt.Sprintf("live heap of %d bytes exceeds the limit of %d", heap, s.maxHeapBytes))
+		}
+	}
+
+	inflight := s.inflight.Add(int64(size))
+	// A single task is admitted when nothing else is in flight, so payloads
+	// larger than the limit are left to the payload size limits.
+	if s.maxInflightBytes > 0 && inflight > s.maxInflightBytes && inflight != int64(size) {
+		s.inflight.Add(-int64(size))
+		return nil, s.shed(shedInflightBytes, fmt.Sprintf("%d bytes of payloads in flight exceed the limit of %d", inflight, s.maxInflightBytes))
+	}
+	inflightTaskBytes.Set(float64(inflight))
+	return func() {
+		inflightTaskBytes.Set(float64(s.inflight.Add(-int64(size))))
+	}, nil
+}
+
+func (s *LoadShedder) shed(reason, detail string) error {
+	loadShed.WithLabelValues(reason).Inc()
+	return fmt.Errorf("%w: %s", ErrLoadShed, detail)
+}
+
+func readLiveHeap() uint64 {
+	sample := []metrics.Sample{{Name: liveHeapMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_LoadShedderAdmit(t *testing.T) {
+	tests := []struct {
+		name     string
+		shedder  *LoadShedder
+		inflight int64
+		size     int
+		heap     uint64
+		wantErr  bool
+	}{
+		{name: "within limits", shedder: NewLoadShedder(100, 0, 1000), inflight: 50, size: 40, heap: 500},
+		{name: "too many bytes in flight", shedder: NewLoadShedder(100, 0, 0), inflight: 50, size: 60, wantErr: true},
+		{name: "single large task", shedder: NewLoadShedder(100, 0, 0), size: 150},
+		{name: "heap over limit", shedder: NewLoadShedder(0, 0, 1000), heap: 2000, wantErr: true},
+		{name: "too many goroutines", shedder: NewLoadShedder(0, 1, 0), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.shedder.inflight.Store(tt.inflight)
+			tt.shedder.liveHeap = func() uint64 { return tt.heap }
+
+			release, err := tt.shedder.Admit(tt.size)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrLoadShed) {
+					t.Errorf("expected ErrLoadShed, got %v", err)
+				}
+				if got := tt.shedder.inflight.Load(); got != tt.inflight {
+					t.Errorf("shed task left %d bytes in flight, expected %d", got, tt.inflight)
+				}
+				return
+			}
+			release()
+			if got := tt.shedder.inflight.Load(); got != tt.inflight {
+				t.Errorf("released task left %d bytes in flight, expected %d", got, tt.inflight)
+			}
+		})
+	}
+}
+
+func Test_loadShedderFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "unset", wantNil: true},
+		{name: "inflight bytes", env: map[string]string{"LOAD_SHED_MAX_INFLIGHT_BYTES": "67108864"}},
+		{name: "all", env: map[string]string{"LOAD_SHED_MAX_INFLIGHT_BYTES": "67108864", "LOAD_SHED_MAX_GOROUTINES": "10000", "LOAD_SHED_MAX_HEAP_BYTES": "1073741824"}},
+		{name: "negative", env: map[string]string{"LOAD_SHED_MAX_GOROUTINES": "-1"}, wantErr: true},
+		{name: "invalid", env: map[string]string{"LOAD_SHED_MAX_HEAP_BYTES": "1GiB"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"LOAD_SHED_MAX_INFLIGHT_BYTES", "LOAD_SHED_MAX_GOROUTINES", "LOAD_SHED_MAX_HEAP_BYTES"} {
+				t.Setenv(key, tt.env[key])
+			}
+			s, err := loadShedderFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && (s == nil) != tt.wantNil {
+				t.Errorf("expected nil shedder %v, got %+v", tt.wantNil, s)
+			}
+		})
+	}
+}
+
+func Test_readLiveHeap(t *testing.T) {
+	if readLiveHeap() == 0 {
+		t.Errorf("expected a live heap size")
+	}
+}
+
+func Test_HandleTaskLoadShed(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "4"}, nil)
+	shedder := NewLoadShedder(0, 0, 1)
+	tw.SetLoadShedder(shedder)
+
+	resp, err := tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")})
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+	var result struct {
+		ErrorCode    string `json:"error_code"`
+		ErrorMessage string `json:"error_message"`
+		Retryable    bool   `json:"retryable"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if result.ErrorCode != errorCodeOverloaded || !result.Retryable || result.ErrorMessage == "" {
+		t.Errorf("expected a retryable overloaded result, got %s", resp.Result)
+	}
+}
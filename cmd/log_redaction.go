@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultLogPayloadSize is how much of a payload is logged under LOG_PAYLOADS.
+const defaultLogPayloadSize = 256
+
+// LogRedaction controls how tasks appear in the logs. By default a task is
+// logged by its ID, payload size and payload hash, so that prompts and the
+// personal data they carry stay out of the logs. Logged payloads still have
+// their secrets masked.
+type LogRedaction struct {
+	// LogPayloads logs payloads and metadata instead of their hashes. It is
+	// meant for debugging.
+	LogPayloads bool
+	// MaxPayloadSize is the number of payload bytes logged under
+	// LogPayloads; longer payloads are truncated.
+	MaxPayloadSize int
+}
+
+// logRedactionFromEnv reads LOG_PAYLOADS, which logs task payloads for
+// debugging, and LOG_PAYLOAD_MAX_SIZE, the bytes of a payload logged (256 by
+// default). It returns nil, which logs hashes only, when LOG_PAYLOADS is unset.
+func logRedactionFromEnv() (*LogRedaction, error) {
+	v := getenv("LOG_PAYLOADS")
+	if v == "" {
+		return nil, nil
+	}
+	logPayloads, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for LOG_PAYLOADS: %w", err)
+	}
+	if !logPayloads {
+		return nil, nil
+	}
+	size, err := envInt("LOG_PAYLOAD_MAX_SIZE")
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("LOG_PAYLOAD_MAX_SIZE must not be negative")
+	}
+	if size == 0 {
+		size = defaultLogPayloadSize
+	}
+	return &LogRedaction{LogPayloads: true, MaxPayloadSize: size}, nil
+}
+
+// taskField returns t as a log field.
+func (r *LogRedaction) taskField(t *performerV1.TaskRequest) zap.Field {
+	return zap.Object("task", loggedTask{task: t, redaction: r})
+}
+
+// loggedTask marshals a task for the logs, hashing its payload unless
+// payloads are logged.
+type loggedTask struct {
+	task      *performerV1.TaskRequest
+	redaction *LogRedaction
+}
+
+func (l loggedTask) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("taskId", string(l.task.TaskId))
+	enc.AddInt("payloadSize", len(l.task.Payload))
+	enc.AddString("payloadHash", crypto.Keccak256Hash(l.task.Payload).Hex())
+	if l.redaction == nil || !l.redaction.LogPayloads {
+		return nil
+	}
+	enc.AddString("payload", truncateLogValue(redactSecrets(string(l.task.Payload)), l.redaction.MaxPayloadSize))
+	if len(l.task.Metadata) > 0 {
+		enc.AddString("metadata", truncateLogValue(redactSecrets(string(l.task.Metadata)), l.redaction.MaxPayloadSize))
+	}
+	return nil
+}
+
+// truncateLogValue cuts s to size bytes, noting how much was cut.
+func truncateLogValue(s string, size int) string {
+	if len(s) <= size {
+		return s
+	}
+	return fmt.Sprintf("%s...(%d more bytes)", s[:size], len(s)-size)
+}
+
+// redactSecrets masks the private keys and API keys found in s.
+func redactSecrets(s string) string {
+	return secretKeyPattern.ReplaceAllString(s, redactedSecret)
+}
+
+// redactingCore masks secrets in the messages and string and error fields of
+// log entries, such as API keys echoed back in provider errors.
+type redactingCore struct {
+	zapcore.Core
+}
+
+// newRedactingCore wraps core; it is installed with zap.WrapCore.
+func newRedactingCore(core zapcore.Core) zapcore.Core {
+	return &redactingCore{Core: core}
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(redactFields(fields))}
+}
+
+func (c *redactingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactingCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = redactSecrets(ent.Message)
+	return c.Core.Write(ent, redactFields(fields))
+}
+
+// redactFields returns fields with the secrets in their strings and errors
+// masked.
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		switch f.Type {
+		case zapcore.StringType:
+			f.String = redactSecrets(f.String)
+		case zapcore.ErrorType:
+			if err, ok := f.Interface.(error); ok && err != nil {
+				f = zap.String(f.Key, redactSecrets(err.Error()))
+			}
+		}
+		redacted[i] = f
+	}
+	return redacted
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_logRedactionFromEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		logPayloads string
+		maxSize     string
+		want        *LogRedaction
+		wantErr     bool
+	}{
+		{name: "unset"},
+		{name: "disabled", logPayloads: "false"},
+		{name: "enabled", logPayloads: "true", want: &LogRedaction{LogPayloads: true, MaxPayloadSize: defaultLogPayloadSize}},
+		{name: "sized", logPayloads: "true", maxSize: "16", want: &LogRedaction{LogPayloads: true, MaxPayloadSize: 16}},
+		{name: "invalid", logPayloads: "yes please", wantErr: true},
+		{name: "negative size", logPayloads: "true", maxSize: "-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LOG_PAYLOADS", tt.logPayloads)
+			t.Setenv("LOG_PAYLOAD_MAX_SIZE", tt.maxSize)
+			got, err := logRedactionFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_LogRedactionTaskField(t *testing.T) {
+	task := &performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("Email jane@example.com my key sk-abcdefghijklmnopqrstuvwxyz please")}
+
+	tests := []struct {
+		name        string
+		redaction   *LogRedaction
+		wantPayload string
+	}{
+		{name: "hashed"},
+		{name: "debug", redaction: &LogRedaction{LogPayloads: true, MaxPayloadSize: 1024}, wantPayload: "Email jane@example.com my key [REDACTED] please"},
+		{name: "truncated", redaction: &LogRedaction{LogPayloads: true, MaxPayloadSize: 5}, wantPayload: "Email...(42 more bytes)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc := zapcore.NewMapObjectEncoder()
+			tt.redaction.taskField(task).AddTo(enc)
+			logged := enc.Fields["task"].(map[string]interface{})
+			if logged["taskId"] != "task-1" || logged["payloadHash"] == "" {
+				t.Errorf("expected the task ID and payload hash, got %v", logged)
+			}
+			payload, _ := logged["payload"].(string)
+			if payload != tt.wantPayload {
+				t.Errorf("expected payload %q, got %q", tt.wantPayload, payload)
+			}
+		})
+	}
+}
+
+func Test_redactingCore(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	l := zap.New(core).WithOptions(zap.WrapCore(newRedactingCore))
+	key := "sk-abcdefghijklmnopqrstuvwxyz"
+
+	l.With(zap.String("apiKey", key)).Error("provider rejected "+key,
+		zap.Error(errors.New("openai returned status 401: Incorrect API key provided: "+key)),
+		zap.Int("status", 401),
+	)
+	l.Debug("dropped " + key)
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	if strings.Contains(entries[0].Message, key) {
+		t.Errorf("expected the message to be redacted, got %q", entries[0].Message)
+	}
+	for k, v := range entries[0].ContextMap() {
+		if s, ok := v.(string); ok && strings.Contains(s, key) {
+			t.Errorf("expected field %s to be redacted, got %q", k, s)
+		}
+	}
+	if entries[0].ContextMap()["status"] != int64(401) {
+		t.Errorf("expected other fields to be kept, got %v", entries[0].ContextMap())
+	}
+}
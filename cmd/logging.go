@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Log formats.
+const (
+	logFormatJSON    = "json"
+	logFormatConsole = "console"
+)
+
+// loggerConfigFromEnv returns the zap configuration of the performer, the
+// production configuration unless overridden by:
+//
+//   - LOG_LEVEL: debug, info (the default), warn or error.
+//   - LOG_FORMAT: json (the default) or console.
+//   - LOG_SAMPLING: false logs every entry instead of sampling repeated ones.
+//   - LOG_OUTPUT: comma-separated paths the logs are written to, stderr by
+//     default; "stdout" and "stderr" name the standard streams.
+func loggerConfigFromEnv() (zap.Config, error) {
+	cfg := zap.NewProductionConfig()
+	if v := getenv("LOG_LEVEL"); v != "" {
+		level, err := zap.ParseAtomicLevel(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid value for LOG_LEVEL: %w", err)
+		}
+		cfg.Level = level
+	}
+	switch format := getenv("LOG_FORMAT"); format {
+	case "", logFormatJSON:
+	case logFormatConsole:
+		cfg.Encoding = logFormatConsole
+		cfg.EncoderConfig = zap.NewDevelopmentEncoderConfig()
+	default:
+		return cfg, fmt.Errorf("unsupported LOG_FORMAT %q, expected %s or %s", format, logFormatJSON, logFormatConsole)
+	}
+	if v := getenv("LOG_SAMPLING"); v != "" {
+		sampling, err := strconv.ParseBool(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid value for LOG_SAMPLING: %w", err)
+		}
+		if !sampling {
+			cfg.Sampling = nil
+		}
+	}
+	if v := strings.TrimSpace(getenv("LOG_OUTPUT")); v != "" {
+		cfg.OutputPaths = nil
+		for _, path := range strings.Split(v, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				cfg.OutputPaths = append(cfg.OutputPaths, path)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// loggerFromEnv builds the performer logger, which masks secrets in its
+// entries, and returns the level it logs at, which can be changed at runtime.
+func loggerFromEnv() (*zap.Logger, zap.AtomicLevel, error) {
+	cfg, err := loggerConfigFromEnv()
+	if err != nil {
+		return nil, cfg.Level, err
+	}
+	l, err := cfg.Build(zap.WrapCore(newRedactingCore))
+	if err != nil {
+		return nil, cfg.Level, fmt.Errorf("failed to build logger: %w", err)
+	}
+	return l, cfg.Level, nil
+}
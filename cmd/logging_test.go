@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func Test_loggerConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantLevel    zapcore.Level
+		wantEncoding string
+		wantSampling bool
+		wantOutput   []string
+		wantErr      bool
+	}{
+		{name: "production defaults", wantLevel: zap.InfoLevel, wantEncoding: logFormatJSON, wantSampling: true, wantOutput: []string{"stderr"}},
+		{name: "debug console", env: map[string]string{"LOG_LEVEL": "debug", "LOG_FORMAT": "console"}, wantLevel: zap.DebugLevel, wantEncoding: logFormatConsole, wantSampling: true, wantOutput: []string{"stderr"}},
+		{name: "unsampled", env: map[string]string{"LOG_SAMPLING": "false"}, wantLevel: zap.InfoLevel, wantEncoding: logFormatJSON, wantOutput: []string{"stderr"}},
+		{name: "outputs", env: map[string]string{"LOG_OUTPUT": "stdout, /var/log/performer.log"}, wantLevel: zap.InfoLevel, wantEncoding: logFormatJSON, wantSampling: true, wantOutput: []string{"stdout", "/var/log/performer.log"}},
+		{name: "invalid level", env: map[string]string{"LOG_LEVEL": "loud"}, wantErr: true},
+		{name: "invalid format", env: map[string]string{"LOG_FORMAT": "xml"}, wantErr: true},
+		{name: "invalid sampling", env: map[string]string{"LOG_SAMPLING": "sometimes"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"LOG_LEVEL", "LOG_FORMAT", "LOG_SAMPLING", "LOG_OUTPUT"} {
+				t.Setenv(key, tt.env[key])
+			}
+			cfg, err := loggerConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if cfg.Level.Level() != tt.wantLevel || cfg.Encoding != tt.wantEncoding || (cfg.Sampling != nil) != tt.wantSampling || !reflect.DeepEqual(cfg.OutputPaths, tt.wantOutput) {
+				t.Errorf("unexpected config: level %v, encoding %s, sampling %v, outputs %v", cfg.Level, cfg.Encoding, cfg.Sampling != nil, cfg.OutputPaths)
+			}
+		})
+	}
+}
+
+func Test_loggerFromEnvLevelChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "performer.log")
+	t.Setenv("LOG_LEVEL", "info")
+	t.Setenv("LOG_FORMAT", "")
+	t.Setenv("LOG_SAMPLING", "")
+	t.Setenv("LOG_OUTPUT", path)
+
+	l, level, err := loggerFromEnv()
+	if err != nil {
+		t.Fatalf("loggerFromEnv failed: %v", err)
+	}
+	if l.Core().Enabled(zap.DebugLevel) {
+		t.Fatalf("expected debug entries to be dropped at info level")
+	}
+
+	rec := httptest.NewRecorder()
+	level.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/debug/loglevel", strings.NewReader(`{"level":"debug"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the level to be changed, got %d: %s", rec.Code, rec.Body)
+	}
+	if !l.Core().Enabled(zap.DebugLevel) {
+		t.Errorf("expected debug entries to be logged after the level change")
+	}
+}
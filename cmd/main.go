@@ -2,19 +2,25 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"strings"
+	"math"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
 	"time"
 	"unicode/utf8"
 
 	"bytes"
 	"encoding/json"
-	"io/ioutil"
-	"net/http"
-	"os"
 
-	"github.com/Layr-Labs/hourglass-monorepo/ponos/pkg/performer/server"
+	"github.com/Layr-Labs/hourglass-monorepo/ponos/pkg/rpcServer"
 	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
@@ -25,19 +31,313 @@ import (
 // return the result to the Executor where the result is signed and return to the
 // Aggregator to place in the outbox once the signing threshold is met.
 
+// defaultTaskTimeout is how long a task may be handled for by default. It
+// leaves room for a provider request of defaultProviderTimeout and its
+// verification. Provider calls are cancelled once it elapses instead of
+// generating output nobody reads.
+const defaultTaskTimeout = 30 * time.Second
+
+// defaultPerformerPort is the port the Executor sends tasks to by default.
+const defaultPerformerPort = 8080
+
 type TaskWorker struct {
-	logger *zap.Logger
+	logger   *zap.Logger
+	provider Provider
+	// decoder converts on-chain encoded payloads; nil passes payloads through.
+	decoder PayloadDecoder
+	tasks   *TaskRegistry
+	// limits, templates and examples can be replaced while tasks are
+	// handled, see ConfigReloader.
+	limits atomic.Pointer[LimitsConfig]
+	// templates renders payloads that name a prompt template.
+	templates atomic.Pointer[PromptTemplates]
+	// examples are the few-shot examples per task type; nil disables them.
+	examples atomic.Pointer[FewShotLibrary]
+	// encoder converts results for on-chain use; nil returns JSON results.
+	encoder ResultEncoder
+	// pipelines are the verification pipelines per task type.
+	pipelines map[string]*VerificationPipeline
+	// signers are the allowed payload signers per task type; nil accepts
+	// unsigned tasks.
+	signers *TaskSigners
+	// injection scores payloads for prompt injection; nil disables it.
+	injection *InjectionDetector
+	// patterns is the operator's denylist and allowlist; nil disables it.
+	patterns *PatternFilter
+	// decryptor decrypts encrypted payloads; nil rejects them.
+	decryptor *PayloadDecryptor
+	// rateLimiter limits the tasks of each sender; nil disables it.
+	rateLimiter *SenderRateLimiter
+	// pool bounds the tasks handled at once; nil leaves them unbounded.
+	pool *WorkerPool
+	// budget caps the tokens spent per window; nil disables it.
+	budget *TokenBudget
+	// shedder sheds tasks when memory or goroutines run short; nil disables
+	// it.
+	shedder *LoadShedder
+	// cache answers repeated tasks with their earlier result; nil disables
+	// it.
+	cache ResultCache
+	// modelSettings is the hash of the model settings the cache keys are
+	// scoped to.
+	modelSettings string
+	// responses keeps the responses sent for recent tasks to answer their
+	// redeliveries; nil disables it.
+	responses ResultCache
+	// audit keeps the hash-chained audit log of tasks; nil disables it.
+	audit *AuditLog
+	// redaction controls how tasks are logged; nil logs payload hashes only.
+	redaction *LogRedaction
+	// timeout bounds the handling of a task.
+	timeout time.Duration
+	// verifyTimeout bounds the verification pipeline of a result; 0 leaves
+	// it bounded by timeout only.
+	verifyTimeout time.Duration
+	// dryRun skips the provider call and verification of tasks, see
+	// dryRunResult.
+	dryRun bool
+	// deterministic normalizes results so that operators agree on them, see
+	// canonicalizeOutput.
+	deterministic bool
+	// drain tracks the tasks in flight for shutdown.
+	drain taskDrain
+}
+
+func NewTaskWorker(logger *zap.Logger, provider Provider, decoder PayloadDecoder) *TaskWorker {
+	tw := &TaskWorker{
+		logger:    logger,
+		provider:  provider,
+		decoder:   decoder,
+		tasks:     newDefaultTaskRegistry(withUsageTracking(withFewShotExamples(provider))),
+		injection: defaultInjectionDetector(),
+		timeout:   defaultTaskTimeout,
+	}
+	tw.limits.Store(defaultLimitsConfig())
+	tw.templates.Store(mustBuiltinPromptTemplates())
+	return tw
+}
+
+// SetPromptTemplates replaces the built-in prompt templates.
+func (tw *TaskWorker) SetPromptTemplates(templates *PromptTemplates) {
+	tw.templates.Store(templates)
+}
+
+// SetFewShotExamples sets the few-shot examples added to the tasks' prompts.
+func (tw *TaskWorker) SetFewShotExamples(examples *FewShotLibrary) {
+	tw.examples.Store(examples)
+}
+
+// SetResultEncoder sets the encoding of the results returned to the executor.
+func (tw *TaskWorker) SetResultEncoder(encoder ResultEncoder) {
+	tw.encoder = encoder
+}
+
+// SetVerifier replaces the verifier of completion outputs.
+func (tw *TaskWorker) SetVerifier(verifier Verifier) {
+	if h, ok := tw.tasks.handlers[taskTypeCompletion].(*CompletionHandler); ok {
+		h.SetVerifier(verifier)
+	}
+}
+
+// SetSafetyCheck sets the safety check of completion outputs; nil disables it.
+func (tw *TaskWorker) SetSafetyCheck(safety *SafetyCheck) {
+	if h, ok := tw.tasks.handlers[taskTypeCompletion].(*CompletionHandler); ok {
+		h.SetSafetyCheck(safety)
+	}
+}
+
+// SetOutputSanitizer sets the sanitizer of completion outputs; nil disables
+// it.
+func (tw *TaskWorker) SetOutputSanitizer(sanitizer *OutputSanitizer) {
+	if h, ok := tw.tasks.handlers[taskTypeCompletion].(*CompletionHandler); ok {
+		h.SetOutputSanitizer(sanitizer)
+	}
+}
+
+// SetVerificationPipelines sets the verification pipelines run on the results
+// of each task type, in addition to the task type's own checks.
+func (tw *TaskWorker) SetVerificationPipelines(pipelines map[string]*VerificationPipeline) {
+	tw.pipelines = pipelines
+}
+
+// SetTaskSigners sets the signers whose payload signatures tasks must carry;
+// nil accepts unsigned tasks.
+func (tw *TaskWorker) SetTaskSigners(signers *TaskSigners) {
+	tw.signers = signers
+}
+
+// SetInjectionDetector replaces the prompt injection detector; nil disables
+// it.
+func (tw *TaskWorker) SetInjectionDetector(injection *InjectionDetector) {
+	tw.injection = injection
+}
+
+// SetPatternFilter sets the operator pattern list payloads are checked
+// against; nil disables it.
+func (tw *TaskWorker) SetPatternFilter(patterns *PatternFilter) {
+	tw.patterns = patterns
+}
+
+// SetPayloadDecryptor sets the key encrypted payloads are decrypted with; nil
+// rejects encrypted payloads.
+func (tw *TaskWorker) SetPayloadDecryptor(decryptor *PayloadDecryptor) {
+	tw.decryptor = decryptor
 }
 
-func NewTaskWorker(logger *zap.Logger) *TaskWorker {
-	return &TaskWorker{
-		logger: logger,
+// SetSenderRateLimiter sets the per-sender rate limits; nil disables them.
+func (tw *TaskWorker) SetSenderRateLimiter(limiter *SenderRateLimiter) {
+	tw.rateLimiter = limiter
+}
+
+// SetWorkerPool sets the pool bounding the tasks handled at once; nil leaves
+// them unbounded.
+func (tw *TaskWorker) SetWorkerPool(pool *WorkerPool) {
+	tw.pool = pool
+	// A task takes a single worker, so the chunks of a summarized document
+	// are not called for more concurrently than the pool runs tasks.
+	if h, ok := tw.tasks.handlers[taskTypeSummarize].(*SummarizeHandler); ok {
+		concurrency := defaultSummaryChunkConcurrency
+		if pool != nil {
+			concurrency = pool.Size()
+		}
+		h.SetChunkConcurrency(concurrency)
 	}
 }
 
+// SetTokenBudget sets the token budget tasks are refused beyond; nil disables
+// it.
+func (tw *TaskWorker) SetTokenBudget(budget *TokenBudget) {
+	tw.budget = budget
+}
+
+// SetLoadShedder sets the memory and goroutine guards; nil disables them.
+func (tw *TaskWorker) SetLoadShedder(shedder *LoadShedder) {
+	tw.shedder = shedder
+}
+
+// SetResultCache sets the cache of task results; nil disables it. The model
+// settings are captured for the cache keys, see resultCacheScope.
+func (tw *TaskWorker) SetResultCache(cache ResultCache) {
+	tw.cache = cache
+	tw.modelSettings = modelSettingsHash()
+}
+
+// SetIdempotency sets the store of the responses sent for recent tasks; nil
+// disables it.
+func (tw *TaskWorker) SetIdempotency(responses ResultCache) {
+	tw.responses = responses
+}
+
+// SetAuditLog sets the audit log of tasks and results; nil disables it.
+func (tw *TaskWorker) SetAuditLog(audit *AuditLog) {
+	tw.audit = audit
+}
+
+// SetTaskTimeout sets how long a task may be handled for.
+func (tw *TaskWorker) SetTaskTimeout(timeout time.Duration) {
+	tw.timeout = timeout
+}
+
+// SetVerificationTimeout bounds the verification of results; 0 leaves it
+// bounded by the task timeout only.
+func (tw *TaskWorker) SetVerificationTimeout(timeout time.Duration) {
+	tw.verifyTimeout = timeout
+	if h, ok := tw.tasks.handlers[taskTypeCompletion].(*CompletionHandler); ok {
+		h.SetVerificationTimeout(timeout)
+	}
+}
+
+// SetDryRun sets whether tasks are handled in dry-run mode, answered with a
+// synthetic result instead of calling the provider.
+func (tw *TaskWorker) SetDryRun(dryRun bool) {
+	tw.dryRun = dryRun
+}
+
+// SetDeterministic sets whether results are normalized for agreement between
+// operators. The provider is made deterministic separately, see
+// DeterministicProvider.
+func (tw *TaskWorker) SetDeterministic(deterministic bool) {
+	tw.deterministic = deterministic
+}
+
+// SetLogRedaction sets how tasks are logged; nil logs payload hashes only.
+func (tw *TaskWorker) SetLogRedaction(redaction *LogRedaction) {
+	tw.redaction = redaction
+}
+
+// SetLimits replaces the default payload and result size limits.
+func (tw *TaskWorker) SetLimits(limits *LimitsConfig) {
+	tw.limits.Store(limits)
+}
+
+// RegisterTaskHandler adds or replaces the handler of a task type.
+func (tw *TaskWorker) RegisterTaskHandler(taskType string, h TaskHandler) {
+	tw.tasks.Register(taskType, h)
+}
+
+// decodePayload returns the task payload in the form ParseTaskPayload expects:
+// hex and base64 text wrapping a structured payload is decoded first,
+// encrypted payloads are decrypted, then the configured decoder is applied,
+// gzip and zstd payloads are decompressed and CBOR payloads are converted to
+// JSON. It also returns the payload encoding when it is not apparent from the
+// result, i.e. for CBOR.
+func (tw *TaskWorker) decodePayload(ctx context.Context, raw []byte) ([]byte, string, error) {
+	data := decodeTextPayload(raw)
+	if tw.decryptor != nil {
+		var (
+			encrypted bool
+			err       error
+		)
+		if data, encrypted, err = tw.decryptor.Decrypt(data); err != nil {
+			return nil, "", err
+		}
+		// Only the hash of a decrypted payload may be logged.
+		if encrypted {
+			correlatedLogger(ctx, tw.logger).Sugar().Infow("Decrypted task payload", zap.String("plaintextHash", crypto.Keccak256Hash(data).Hex()))
+		}
+	} else if isEncryptedPayload(data) {
+		return nil, "", ErrEncryptedPayload
+	}
+	if tw.decoder != nil {
+		var err error
+		if data, err = tw.decoder.Decode(data); err != nil {
+			return nil, "", err
+		}
+	}
+	data, err := decompressPayload(data, tw.limits.Load().MaxDecompressedPayloadSize)
+	if err != nil {
+		return nil, "", err
+	}
+	if isCBORPayload(data) {
+		data, err = cborToJSON(data)
+		return data, payloadEncodingCBOR, err
+	}
+	return data, "", nil
+}
+
+// ValidateTask implements the ponos worker interface, which carries no
+// context. The performer service validates tasks with the context of the
+// gRPC call instead.
 func (tw *TaskWorker) ValidateTask(t *performerV1.TaskRequest) error {
-	tw.logger.Sugar().Infow("Validating task",
-		zap.Any("task", t),
+	return tw.validateTask(context.Background(), t)
+}
+
+func (tw *TaskWorker) validateTask(ctx context.Context, t *performerV1.TaskRequest) (err error) {
+	ctx = withTaskCorrelationID(ctx, t)
+	logger := correlatedLogger(ctx, tw.logger)
+	_, span := startTaskSpan(ctx, "ValidateTask", t)
+	defer func() {
+		endSpan(span, err)
+		if err != nil {
+			taskFailures.WithLabelValues(errorCategoryValidation, errorCodeInvalidTask).Inc()
+		}
+		if err != nil && tw.audit != nil {
+			tw.audit.RecordRejection(t, err)
+		}
+	}()
+
+	logger.Sugar().Infow("Validating task",
+		tw.redaction.taskField(t),
 	)
 
 	// Validate task ID is not empty
@@ -50,48 +350,77 @@ func (tw *TaskWorker) ValidateTask(t *performerV1.TaskRequest) error {
 		return fmt.Errorf("task payload cannot be empty")
 	}
 
-	// Validate payload size (prevent extremely large prompts)
-	maxPayloadSize := 4096 // 4KB limit
-	if len(t.Payload) > maxPayloadSize {
-		return fmt.Errorf("task payload size %d exceeds maximum allowed size %d", len(t.Payload), maxPayloadSize)
+	// Validate payload size (prevent extremely large prompts). The limit of
+	// the task type is checked once the payload is decoded.
+	if size := tw.maxPayloadSize(); len(t.Payload) > size {
+		return fmt.Errorf("task payload size %d exceeds maximum allowed size %d", len(t.Payload), size)
 	}
 
-	if !bytes.Contains(t.Payload, []byte{0}) {
+	data, _, err := tw.decodePayload(ctx, t.Payload)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Contains(data, []byte{0}) {
 
-		if !utf8.Valid(t.Payload) {
+		if !utf8.Valid(data) {
 			return fmt.Errorf("task payload contains invalid UTF-8 characters")
 		}
 	}
 
-	prompt := string(t.Payload)
-	if len(strings.TrimSpace(prompt)) == 0 {
-		return fmt.Errorf("task prompt cannot be empty or whitespace only")
+	if tw.patterns != nil {
+		if err := tw.patterns.Check(string(data)); err != nil {
+			return err
+		}
 	}
 
-	maliciousPatterns := []string{
-		"<script>", "</script>", "javascript:", "data:text/html",
-		"eval(", "exec(", "system(", "rm -rf", "DROP TABLE",
+	if tokens := estimateTextTokens(string(data)); tokens > tw.limits.Load().MaxPayloadTokens {
+		return fmt.Errorf("task payload of about %d tokens exceeds maximum allowed %d tokens", tokens, tw.limits.Load().MaxPayloadTokens)
 	}
 
-	for _, pattern := range maliciousPatterns {
-		if strings.Contains(strings.ToLower(prompt), strings.ToLower(pattern)) {
-			return fmt.Errorf("task payload contains potentially malicious content: %s", pattern)
+	payload, err := ParseTaskPayload(data)
+	if err != nil {
+		return err
+	}
+	if tw.signers != nil {
+		signer, err := tw.signers.Verify(payload.TaskType, t.Payload, t.Metadata)
+		if err != nil {
+			return err
+		}
+		if signer != (common.Address{}) {
+			logger.Sugar().Infow("Verified task signature", zap.String("taskId", string(t.TaskId)), zap.String("signer", signer.Hex()))
 		}
 	}
-
-	// Validate Azure OpenAI environment variables are set
-	apiKey := os.Getenv("AZURE_OPENAI_KEY")
-	endpoint := os.Getenv("AZURE_OPENAI_ENDPOINT")
-	if apiKey == "" || endpoint == "" {
-		return fmt.Errorf("Azure OpenAI configuration not properly set")
+	if err := tw.templates.Load().Render(payload); err != nil {
+		return fmt.Errorf("invalid task payload: %w", err)
+	}
+	if err := payload.Validate(); err != nil {
+		return fmt.Errorf("invalid task payload: %w", err)
+	}
+	if payload.MaxTokens > tw.limits.Load().MaxResultTokens {
+		return fmt.Errorf("invalid task payload: max_tokens must be between 1 and %d", tw.limits.Load().MaxResultTokens)
+	}
+	req := payload.CompletionRequest()
+	if tokens := estimateTokens(req) + req.MaxTokens; tokens > tw.limits.Load().MaxContextTokens {
+		return fmt.Errorf("task of about %d tokens including max_tokens exceeds the budget of %d tokens", tokens, tw.limits.Load().MaxContextTokens)
+	}
+	handler, err := tw.tasks.Handler(payload.TaskType)
+	if err != nil {
+		return err
+	}
+	if size := tw.limitsFor(payload.TaskType).MaxPayloadSize; len(t.Payload) > size {
+		return fmt.Errorf("task payload size %d exceeds maximum allowed size %d", len(t.Payload), size)
+	}
+	if err := handler.Validate(payload); err != nil {
+		return err
 	}
 
-	// Validate endpoint format
-	if !strings.HasPrefix(endpoint, "https://") {
-		return fmt.Errorf("Azure OpenAI endpoint must use HTTPS")
+	// Validate an LLM provider is configured
+	if tw.provider == nil {
+		return fmt.Errorf("LLM provider not configured")
 	}
 
-	tw.logger.Sugar().Infow("Task validation passed",
+	logger.Sugar().Infow("Task validation passed",
 		zap.String("taskId", string(t.TaskId)),
 		zap.Int("payloadSize", len(t.Payload)),
 	)
@@ -99,16 +428,22 @@ func (tw *TaskWorker) ValidateTask(t *performerV1.TaskRequest) error {
 	return nil
 }
 
-func (tw *TaskWorker) ValidateResult(resultBytes []byte) error {
+// ValidateResult checks the fields shared by all results and, unless the task
+// failed with an error code, the result schema of the task type's handler.
+// The result size is checked against the global limit.
+func (tw *TaskWorker) ValidateResult(handler TaskHandler, resultBytes []byte) error {
+	return tw.validateResult(context.Background(), handler, resultBytes, tw.limits.Load())
+}
+
+func (tw *TaskWorker) validateResult(ctx context.Context, handler TaskHandler, resultBytes []byte, limits *LimitsConfig) error {
 	// Validate result is not empty
 	if len(resultBytes) == 0 {
 		return fmt.Errorf("result cannot be empty")
 	}
 
 	// Validate result size (prevent extremely large results)
-	maxResultSize := 8192 // 8KB limit
-	if len(resultBytes) > maxResultSize {
-		return fmt.Errorf("result size %d exceeds maximum allowed size %d", len(resultBytes), maxResultSize)
+	if len(resultBytes) > limits.MaxResultSize {
+		return fmt.Errorf("result size %d exceeds maximum allowed size %d", len(resultBytes), limits.MaxResultSize)
 	}
 
 	// Validate result is valid JSON
@@ -117,134 +452,800 @@ func (tw *TaskWorker) ValidateResult(resultBytes []byte) error {
 		return fmt.Errorf("result is not valid JSON: %w", err)
 	}
 
-	// Validate required fields exist
-	requiredFields := []string{"llm_output", "verified"}
-	for _, field := range requiredFields {
-		if _, exists := result[field]; !exists {
-			return fmt.Errorf("result missing required field: %s", field)
+	// Validate verified exists and is a verification
+	if _, exists := result["verified"]; !exists {
+		return fmt.Errorf("result missing required field: verified")
+	}
+	if err := validateVerification(result["verified"]); err != nil {
+		return err
+	}
+
+	// Validate refused is a boolean when present
+	if v, exists := result["refused"]; exists {
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("refused field must be a boolean")
 		}
 	}
 
-	// Validate llm_output is a string
-	if llmOutput, ok := result["llm_output"].(string); !ok {
-		return fmt.Errorf("llm_output field must be a string")
-	} else {
-		// Validate llm_output is not empty
-		if len(strings.TrimSpace(llmOutput)) == 0 {
-			return fmt.Errorf("llm_output cannot be empty or whitespace only")
+	// Validate truncated is a boolean when present
+	if v, exists := result[resultTruncatedField]; exists {
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("truncated field must be a boolean")
+		}
+	}
+
+	// Validate the compressed fields when present
+	if _, exists := result[resultCompressedField]; exists {
+		if err := validateCompressedFields(result); err != nil {
+			return err
 		}
 	}
 
-	// Validate verified is a boolean
-	if _, ok := result["verified"].(bool); !ok {
-		return fmt.Errorf("verified field must be a boolean")
+	// Validate error_code, error_message and retryable when present. Failed
+	// tasks carry no task-specific output.
+	if v, exists := result["error_code"]; exists {
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("error_code field must be a string")
+		}
+		if _, ok := result["error_message"].(string); !ok {
+			return fmt.Errorf("error_message field must be a string")
+		}
+		if _, ok := result["retryable"].(bool); !ok {
+			return fmt.Errorf("retryable field must be a boolean")
+		}
+	} else if dryRun, exists := result["dry_run"]; exists {
+		// Dry-run results carry no task-specific output.
+		if dryRun != true {
+			return fmt.Errorf("dry_run field must be true when present")
+		}
+	} else if err := handler.ValidateResult(result); err != nil {
+		return err
 	}
 
-	tw.logger.Sugar().Infow("Result validation passed",
+	// Validate the attestation when present
+	if v, exists := result[attestationField]; exists {
+		if err := validateAttestation(v); err != nil {
+			return err
+		}
+	}
+
+	// Validate the execution metadata of version 2 results
+	if v, exists := result["result_version"]; exists {
+		switch v {
+		case float64(resultVersion1):
+		case float64(resultVersion2):
+			if err := validateExecutionMetadata(result); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported result_version %v", v)
+		}
+	}
+
+	if err := verifyResultDigest(result); err != nil {
+		return err
+	}
+
+	correlatedLogger(ctx, tw.logger).Sugar().Infow("Result validation passed",
 		zap.Int("resultSize", len(resultBytes)),
 	)
 
 	return nil
 }
 
+// Drain stops taking new tasks, which fail with ErrShuttingDown, and waits
+// for the tasks in flight to finish until ctx is done.
+func (tw *TaskWorker) Drain(ctx context.Context) error {
+	return tw.drain.drain(ctx)
+}
+
+// Draining reports whether Drain was called.
+func (tw *TaskWorker) Draining() bool {
+	return tw.drain.isDraining()
+}
+
+// HandleTask implements the ponos worker interface, which carries no context.
+// The performer service handles tasks with the context of the gRPC call
+// instead, see handleTask.
 func (tw *TaskWorker) HandleTask(t *performerV1.TaskRequest) (*performerV1.TaskResponse, error) {
-	tw.logger.Sugar().Infow("Handling task",
-		zap.Any("task", t),
-	)
+	return tw.handleTask(context.Background(), t)
+}
 
-	// Call Azure OpenAI LLM
-	apiKey := os.Getenv("AZURE_OPENAI_KEY")
-	endpoint := os.Getenv("AZURE_OPENAI_ENDPOINT")
-	if apiKey == "" || endpoint == "" {
-		return nil, fmt.Errorf("Azure OpenAI API key or endpoint not set")
+// handleTask handles t within the deadline of ctx, which carries the
+// executor's deadline when called by the performer service, bounded by the
+// task timeout. Once ctx is cancelled or its deadline passes, the provider
+// request and verification of the task are abandoned so that they stop
+// spending provider budget.
+func (tw *TaskWorker) handleTask(ctx context.Context, t *performerV1.TaskRequest) (_ *performerV1.TaskResponse, err error) {
+	ctx = withTaskCorrelationID(ctx, t)
+	logger := correlatedLogger(ctx, tw.logger)
+	ctx, span := startTaskSpan(ctx, "HandleTask", t)
+	defer func() { endSpan(span, err) }()
+
+	if err := tw.drain.begin(); err != nil {
+		return nil, err
 	}
+	defer tw.drain.end()
 
-	prompt := string(t.Payload)
-	requestBody, err := json.Marshal(map[string]interface{}{
-		"messages":    []map[string]string{{"role": "user", "content": prompt}},
-		"max_tokens":  64,
-		"temperature": 0.2,
-	})
-	if err != nil {
+	logger.Sugar().Infow("Handling task",
+		tw.redaction.taskField(t),
+	)
+
+	ctx, cancel := context.WithTimeout(ctx, tw.timeout)
+	defer cancel()
+	// Tasks whose caller gave up, or whose deadline passed while they
+	// waited to be served, are not started.
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(requestBody))
+	if tw.responses != nil && !tw.dryRun {
+		if resp, ok := tw.previousResponse(ctx, t); ok {
+			return resp, nil
+		}
+	}
+
+	data, encoding, err := tw.decodePayload(ctx, t.Payload)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("api-key", apiKey)
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	payload, err := ParseTaskPayload(data)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
+	if err := tw.templates.Load().Render(payload); err != nil {
+		return nil, err
+	}
+	taskType := payload.TaskType
+	if taskType == "" {
+		taskType = taskTypeCompletion
+	}
+	payload.Examples = tw.examples.Load().Examples(taskType)
+	span.SetAttributes(attribute.String("task.type", taskType))
+	if encoding == "" {
+		encoding = payloadEncodingText
+		if payload.SchemaVersion > 0 {
+			encoding = payloadEncodingJSON
+		}
+	}
+	handler, err := tw.tasks.Handler(payload.TaskType)
 	if err != nil {
 		return nil, err
 	}
 
-	var llmResp struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
+	// Repeated tasks are answered from the cache without spending any of the
+	// performer's capacity or LLM budget.
+	var cacheKey string
+	if tw.cache != nil && !tw.dryRun {
+		cacheKey = resultCacheKey(payload, encoding, tw.resultCacheScope(taskType, payload))
+		if resp, ok := tw.cachedResponse(ctx, t, cacheKey); ok {
+			tw.rememberResponse(ctx, t, resp)
+			return resp, nil
+		}
 	}
-	if err := json.Unmarshal(body, &llmResp); err != nil {
-		return nil, err
+
+	if tw.shedder != nil {
+		release, err := tw.shedder.Admit(len(t.Payload) + len(data))
+		if err != nil {
+			return tw.errorResponse(ctx, t, handler, tw.limitsFor(payload.TaskType), err)
+		}
+		defer release()
+	}
+	// Rate limited tasks are rejected before any LLM budget is spent.
+	if tw.rateLimiter != nil {
+		if err := tw.rateLimiter.Allow(taskSender(t.Metadata)); err != nil {
+			return tw.errorResponse(ctx, t, handler, tw.limitsFor(payload.TaskType), err)
+		}
+	}
+	if tw.budget != nil {
+		if err := tw.budget.Check(); err != nil {
+			return tw.errorResponse(ctx, t, handler, tw.limitsFor(payload.TaskType), err)
+		}
+	}
+	if tw.pool != nil {
+		release, err := tw.pool.Acquire(ctx)
+		if err != nil {
+			return tw.errorResponse(ctx, t, handler, tw.limitsFor(payload.TaskType), err)
+		}
+		defer release()
 	}
 
-	llmOutput := ""
-	if len(llmResp.Choices) > 0 {
-		llmOutput = llmResp.Choices[0].Message.Content
+	// Every provider call of the task is charged to the budget once it
+	// finishes, the checks of the payload and the output included, whether or
+	// not it succeeds.
+	ctx, usage := withTaskUsage(ctx)
+	if tw.budget != nil {
+		defer func() { tw.budget.Record(usage.spent()) }()
 	}
 
-	// Simple AI-based verification: check if output contains 'valid'
-	verified := false
-	if llmOutput != "" && bytes.Contains([]byte(llmOutput), []byte("valid")) {
-		verified = true
+	var injection *InjectionResult
+	if tw.injection != nil {
+		if injection, err = tw.injection.Detect(ctx, string(data)); err != nil {
+			return tw.errorResponse(ctx, t, handler, tw.limitsFor(payload.TaskType), err)
+		}
+		logger.Sugar().Infow("Scored prompt injection",
+			zap.String("taskId", string(t.TaskId)),
+			zap.Float64("score", injection.Score),
+			zap.Strings("rules", injection.Rules),
+			zap.Bool("flagged", injection.Flagged),
+		)
 	}
 
-	result := map[string]interface{}{
-		"llm_output": llmOutput,
-		"verified":   verified,
+	if tw.dryRun {
+		return tw.taskResponse(ctx, t, handler, tw.limitsFor(payload.TaskType), tw.dryRunResult(taskType, payload))
+	}
+	// Waiting for a worker or scoring the payload may have used up the
+	// deadline; the provider is not called for a task nobody waits for.
+	if err := ctx.Err(); err != nil {
+		return tw.errorResponse(ctx, t, handler, tw.limitsFor(payload.TaskType), err)
 	}
-	resultBytes, err := json.Marshal(result)
+
+	start := time.Now()
+	result, err := handler.Handle(ctx, payload)
+	latency := time.Since(start)
+	if err != nil {
+		// Return a well-formed result so the aggregator can tell a provider
+		// outage apart from an operator fault or a wrong answer.
+		return tw.errorResponse(ctx, t, handler, tw.limitsFor(payload.TaskType), err)
+	}
+	if err := tw.verifyPipeline(ctx, taskType, payload, result); err != nil {
+		return tw.errorResponse(ctx, t, handler, tw.limitsFor(payload.TaskType), err)
+	}
+	if verification, ok := result["verified"].(*Verification); ok && !verification.Passed {
+		taskFailures.WithLabelValues(errorCategoryVerification, "unverified").Inc()
+	}
+	// Echo the payload encoding and schema version so task creators can tell
+	// which payload format the performer understood, and the hashes of the
+	// operator's system prompt and few-shot examples.
+	if metadata, ok := result["metadata"].(map[string]interface{}); ok {
+		metadata["payload_encoding"] = encoding
+		if payload.SchemaVersion > 0 {
+			metadata["schema_version"] = payload.SchemaVersion
+		}
+		if payload.Template != "" {
+			metadata["template"] = templateID(payload.Template, payload.TemplateVersion)
+		}
+		if hash := tw.examples.Load().Hash(taskType); hash != "" {
+			metadata["examples_hash"] = hash
+		}
+		if sp, ok := tw.provider.(*SystemPromptProvider); ok {
+			metadata["system_prompt_hash"] = sp.Hash()
+		}
+		if injection != nil {
+			metadata["injection"] = injection
+		}
+		// Cached results keep the correlation ID and performer version of
+		// the task that computed them.
+		metadata["correlation_id"] = correlationID(ctx)
+		metadata["performer_version"] = performerVersion
+		if tw.deterministic {
+			metadata["deterministic"] = true
+		}
+	}
+	if payload.ResultVersion == resultVersion2 {
+		addExecutionMetadata(result, payload, usage, latency)
+	}
+	if attestation := usage.lastAttestation(); attestation != nil {
+		attestation.PromptTemplateHash = tw.templates.Load().Hash(payload.Template, payload.TemplateVersion)
+		attestation.ExamplesHash = tw.examples.Load().Hash(taskType)
+		if sp, ok := tw.provider.(*SystemPromptProvider); ok {
+			attestation.SystemPromptHash = sp.Hash()
+		}
+		result[attestationField] = attestation
+	}
+	if tw.deterministic {
+		canonicalizeOutput(result)
+	}
+	resultBytes, err := tw.canonicalResult(ctx, t, handler, tw.limitsFor(payload.TaskType), result)
+	if err != nil {
+		return nil, err
+	}
+	if tw.cache != nil {
+		if err := tw.cache.Set(ctx, cacheKey, resultBytes); err != nil {
+			logger.Sugar().Warnw("Failed to cache task result",
+				zap.String("taskId", string(t.TaskId)),
+				zap.Error(err),
+			)
+		}
+	}
+	resp, err := tw.encodeResponse(t, resultBytes)
+	if err != nil {
+		return nil, err
+	}
+	tw.rememberResponse(ctx, t, resp)
+	return resp, nil
+}
+
+// taskResponse serializes and validates result within the limits of its task
+// type and converts it to the configured result encoding.
+func (tw *TaskWorker) taskResponse(ctx context.Context, t *performerV1.TaskRequest, handler TaskHandler, limits *LimitsConfig, result map[string]interface{}) (*performerV1.TaskResponse, error) {
+	resultBytes, err := tw.canonicalResult(ctx, t, handler, limits, result)
+	if err != nil {
+		return nil, err
+	}
+	return tw.encodeResponse(t, resultBytes)
+}
+
+// canonicalResult serializes result as canonical JSON and validates it within
+// the limits of its task type.
+func (tw *TaskWorker) canonicalResult(ctx context.Context, t *performerV1.TaskRequest, handler TaskHandler, limits *LimitsConfig, result map[string]interface{}) ([]byte, error) {
+	resultBytes, digest, err := encodeResult(result, limits)
 	if err != nil {
 		return nil, err
 	}
 
 	// Validate the result before returning
-	if err := tw.ValidateResult(resultBytes); err != nil {
+	if err := tw.validateResult(ctx, handler, resultBytes, limits); err != nil {
 		return nil, fmt.Errorf("result validation failed: %w", err)
 	}
 
+	correlatedLogger(ctx, tw.logger).Sugar().Infow("Task completed",
+		zap.String("taskId", string(t.TaskId)),
+		zap.String("resultDigest", digest),
+	)
+	if tw.audit != nil {
+		tw.audit.RecordResult(t, auditSourceHandled, resultBytes)
+	}
+	return resultBytes, nil
+}
+
+// encodeResponse converts the canonical result of t to the configured result
+// encoding.
+func (tw *TaskWorker) encodeResponse(t *performerV1.TaskRequest, resultBytes []byte) (*performerV1.TaskResponse, error) {
+	if tw.encoder != nil {
+		var err error
+		if resultBytes, err = tw.encoder.Encode(resultBytes); err != nil {
+			return nil, err
+		}
+	}
 	return &performerV1.TaskResponse{
 		TaskId: t.TaskId,
 		Result: resultBytes,
 	}, nil
 }
 
+// encodeResult adds the result digest and serializes result as canonical
+// JSON. Large text fields are compressed when compression is enabled. When
+// truncation is enabled, results still larger than MaxResultSize have their
+// longest text field shortened to fit and are flagged as truncated.
+func encodeResult(result map[string]interface{}, limits *LimitsConfig) ([]byte, string, error) {
+	if limits.CompressResultsOver > 0 {
+		if err := compressResultFields(result, limits.CompressResultsOver); err != nil {
+			return nil, "", err
+		}
+	}
+	for {
+		delete(result, resultDigestField)
+		digest, err := addResultDigest(result)
+		if err != nil {
+			return nil, "", err
+		}
+		resultBytes, err := canonicalJSON(result)
+		if err != nil {
+			return nil, "", err
+		}
+
+		excess := len(resultBytes) - limits.MaxResultSize
+		if excess <= 0 || !limits.TruncateResults {
+			return resultBytes, digest, nil
+		}
+		// Flag the result first so the flag itself is accounted for.
+		if result[resultTruncatedField] != true {
+			result[resultTruncatedField] = true
+			continue
+		}
+		if !truncateLongestString(result, excess) {
+			return resultBytes, digest, nil
+		}
+	}
+}
+
+// errorResponse builds the result returned when a task handler fails.
+func (tw *TaskWorker) errorResponse(ctx context.Context, t *performerV1.TaskRequest, handler TaskHandler, limits *LimitsConfig, cause error) (*performerV1.TaskResponse, error) {
+	code, retryable := classifyTaskError(cause)
+	category := errorCategory(code)
+	taskFailures.WithLabelValues(category, code).Inc()
+	correlatedLogger(ctx, tw.logger).Sugar().Warnw("Task failed",
+		zap.String("taskId", string(t.TaskId)),
+		zap.String("errorCode", code),
+		zap.String("errorCategory", category),
+		zap.Bool("retryable", retryable),
+		zap.Error(cause),
+	)
+
+	result := map[string]interface{}{
+		"llm_output":     "",
+		"verified":       failedVerification(code),
+		"error_code":     code,
+		"error_category": category,
+		"error_message":  cause.Error(),
+		"retryable":      retryable,
+		"metadata": map[string]interface{}{
+			"provider":          tw.provider.Name(),
+			"correlation_id":    correlationID(ctx),
+			"performer_version": performerVersion,
+		},
+	}
+	var delayed retryAfterError
+	if errors.As(cause, &delayed) {
+		result["retry_after_seconds"] = int(math.Ceil(delayed.retryAfter().Seconds()))
+	}
+	resp, err := tw.taskResponse(ctx, t, handler, limits, result)
+	if err != nil {
+		return nil, err
+	}
+	// Retryable failures are not remembered so that redeliveries retry them.
+	if !retryable {
+		tw.rememberResponse(context.Background(), t, resp)
+	}
+	return resp, nil
+}
+
 func main() {
 	ctx := context.Background()
-	l, _ := zap.NewProduction()
+	if err := loadConfig(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) || errors.Is(err, errVersionRequested) {
+			return
+		}
+		panic(fmt.Errorf("failed to load configuration: %w", err))
+	}
+	// Report every configuration problem before starting anything, rather
+	// than the first one, or one found on the first task.
+	if err := validateConfig(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	port, timeout, err := performerSettingsFromEnv()
+	if err != nil {
+		panic(err)
+	}
+	gracePeriod, err := shutdownGracePeriodFromEnv()
+	if err != nil {
+		panic(err)
+	}
+	l, level, err := loggerFromEnv()
+	if err != nil {
+		panic(fmt.Errorf("failed to configure logging: %w", err))
+	}
+	defer func() { _ = l.Sync() }()
+
+	build := currentBuildInfo()
+	buildInfo.WithLabelValues(build.Version, build.Commit, build.GoVersion).Set(1)
+	l.Sugar().Infow("Starting performer",
+		zap.String("version", build.Version),
+		zap.String("commit", build.Commit),
+		zap.String("buildTime", build.BuildTime),
+		zap.String("profile", getenv("PERFORMER_PROFILE")),
+	)
+
+	// The probes are served while the performer is configured, which can
+	// take a while when providers are slow to answer their readiness probes.
+	readiness := NewReadiness()
+	healthPort, err := envInt("HEALTH_PORT")
+	if err != nil {
+		panic(err)
+	}
+	if healthPort > 0 {
+		startHealthServer(ctx, healthPort, readiness, l)
+	}
+	readiness.AddCheck("provider", providersReachable)
+
+	provider, err := NewProviderFromEnv(ctx, l)
+	if err != nil {
+		panic(fmt.Errorf("failed to configure LLM provider: %w", err))
+	}
+	l.Sugar().Infow("Using LLM provider", zap.String("provider", provider.Name()))
+	if dev, _ := devModeFromEnv(); dev {
+		l.Sugar().Warnw("Running in dev mode, tasks are answered by the mock provider")
+	}
+	deterministic, err := deterministicFromEnv()
+	if err != nil {
+		panic(err)
+	}
+	if deterministic {
+		l.Sugar().Infow("Running in deterministic mode", zap.Int64("seed", deterministicSeed))
+		provider = NewDeterministicProvider(provider)
+	}
+
+	if provider, err = costCapFromEnv(l, provider); err != nil {
+		panic(fmt.Errorf("failed to configure cost cap: %w", err))
+	}
+	if provider, err = coalescingFromEnv(l, provider); err != nil {
+		panic(fmt.Errorf("failed to configure request coalescing: %w", err))
+	}
+
+	redactor, err := piiRedactorFromEnv()
+	if err != nil {
+		panic(fmt.Errorf("failed to configure PII redaction: %w", err))
+	}
+	if redactor != nil {
+		provider = NewRedactingProvider(provider, redactor)
+	}
+
+	systemPrompt, err := systemPromptFromEnv()
+	if err != nil {
+		panic(err)
+	}
+	var sp *SystemPromptProvider
+	if systemPrompt != "" {
+		sp = NewSystemPromptProvider(provider, systemPrompt)
+		l.Sugar().Infow("Using operator system prompt", zap.String("hash", sp.Hash()))
+		provider = sp
+	}
+
+	metricsPort := defaultMetricsPort
+	if getenv("METRICS_PORT") != "" {
+		if metricsPort, err = envInt("METRICS_PORT"); err != nil {
+			panic(err)
+		}
+	}
+	if metricsPort > 0 {
+		startMetricsServer(ctx, metricsPort, l)
+	}
+
+	debugAddr, err := debugAddrFromEnv()
+	if err != nil {
+		panic(err)
+	}
+	if debugAddr != "" {
+		startDebugServer(ctx, debugAddr, level, l)
+	}
+
+	decoder, err := payloadDecoderFromEnv()
+	if err != nil {
+		panic(fmt.Errorf("failed to configure payload decoder: %w", err))
+	}
+
+	limits, err := limitsConfigFromEnv()
+	if err != nil {
+		panic(fmt.Errorf("failed to configure limits: %w", err))
+	}
+
+	templates, err := NewPromptTemplates(getenv("PROMPT_TEMPLATE_DIR"))
+	if err != nil {
+		panic(err)
+	}
+
+	w := NewTaskWorker(l, provider, decoder)
+	w.SetTaskTimeout(timeout)
+	verificationTimeout, err := verificationTimeoutFromEnv()
+	if err != nil {
+		panic(err)
+	}
+	w.SetVerificationTimeout(verificationTimeout)
+	dryRun, err := dryRunFromEnv()
+	if err != nil {
+		panic(err)
+	}
+	if dryRun {
+		l.Sugar().Warnw("Running in dry-run mode, tasks are answered with synthetic results without calling the provider")
+	}
+	w.SetDryRun(dryRun)
+	w.SetDeterministic(deterministic)
+	w.SetLimits(limits)
+	w.SetPromptTemplates(templates)
 
-	w := NewTaskWorker(l)
+	examples, err := fewShotLibraryFromEnv()
+	if err != nil {
+		panic(err)
+	}
+	w.SetFewShotExamples(examples)
+
+	verifier, err := verifierFromEnv(ctx, provider)
+	if err != nil {
+		panic(fmt.Errorf("failed to configure verifier: %w", err))
+	}
+	w.SetVerifier(verifier)
+
+	pipelines, err := verificationPipelinesFromEnv(ctx, provider)
+	if err != nil {
+		panic(fmt.Errorf("failed to configure verification pipelines: %w", err))
+	}
+	w.SetVerificationPipelines(pipelines)
+
+	safety, err := safetyCheckFromEnv(ctx, provider)
+	if err != nil {
+		panic(fmt.Errorf("failed to configure safety check: %w", err))
+	}
+	w.SetSafetyCheck(safety)
+
+	sanitizer, err := outputSanitizerFromEnv()
+	if err != nil {
+		panic(fmt.Errorf("failed to configure output sanitizer: %w", err))
+	}
+	w.SetOutputSanitizer(sanitizer)
+
+	encoder, err := resultEncoderFromEnv()
+	if err != nil {
+		panic(fmt.Errorf("failed to configure result encoder: %w", err))
+	}
+	w.SetResultEncoder(encoder)
+
+	embedder, err := newEmbedderFromEnv(ctx)
+	if err != nil {
+		panic(fmt.Errorf("failed to configure embedding provider: %w", err))
+	}
+	if embedder != nil {
+		w.RegisterTaskHandler(taskTypeEmbed, NewEmbedHandler(embedder))
+	}
+
+	moderator, err := newModeratorFromEnv(ctx)
+	if err != nil {
+		panic(fmt.Errorf("failed to configure moderation provider: %w", err))
+	}
+	if moderator != nil {
+		w.RegisterTaskHandler(taskTypeModerate, NewModerateHandler(moderator))
+	}
+
+	if err := w.tasks.LimitsFromEnv(); err != nil {
+		panic(fmt.Errorf("failed to configure task limits: %w", err))
+	}
+
+	injection, err := injectionDetectorFromEnv(ctx, provider)
+	if err != nil {
+		panic(fmt.Errorf("failed to configure injection detection: %w", err))
+	}
+	w.SetInjectionDetector(injection)
+
+	patterns, err := patternFilterFromEnv(ctx, l)
+	if err != nil {
+		panic(fmt.Errorf("failed to configure pattern list: %w", err))
+	}
+	w.SetPatternFilter(patterns)
+
+	if configFilePath() != "" {
+		go NewConfigReloader(l, w, sp, patterns).Watch(ctx, configPollInterval)
+	}
+
+	decryptor, err := payloadDecryptorFromEnv(ctx)
+	if err != nil {
+		panic(fmt.Errorf("failed to configure payload decryption: %w", err))
+	}
+	if decryptor != nil {
+		l.Sugar().Infow("Accepting encrypted payloads", zap.String("publicKey", decryptor.PublicKey()))
+	}
+	w.SetPayloadDecryptor(decryptor)
+
+	rateLimiter, err := senderRateLimiterFromEnv()
+	if err != nil {
+		panic(fmt.Errorf("failed to configure sender rate limits: %w", err))
+	}
+	w.SetSenderRateLimiter(rateLimiter)
+
+	pool, err := workerPoolFromEnv()
+	if err != nil {
+		panic(fmt.Errorf("failed to configure worker pool: %w", err))
+	}
+	w.SetWorkerPool(pool)
+
+	budget, err := tokenBudgetFromEnv()
+	if err != nil {
+		panic(fmt.Errorf("failed to configure token budget: %w", err))
+	}
+	w.SetTokenBudget(budget)
+
+	shedder, err := loadShedderFromEnv()
+	if err != nil {
+		panic(fmt.Errorf("failed to configure load shedding: %w", err))
+	}
+	w.SetLoadShedder(shedder)
+
+	cache, err := resultCacheFromEnv(ctx)
+	if err != nil {
+		panic(fmt.Errorf("failed to configure result cache: %w", err))
+	}
+	w.SetResultCache(cache)
 
-	pp, err := server.NewPonosPerformerWithRpcServer(&server.PonosPerformerConfig{
-		Port:    8080,
-		Timeout: 5 * time.Second,
-	}, w, l)
+	responses, err := idempotencyFromEnv()
 	if err != nil {
-		panic(fmt.Errorf("failed to create performer: %w", err))
+		panic(fmt.Errorf("failed to configure idempotency: %w", err))
 	}
+	w.SetIdempotency(responses)
 
-	if err := pp.Start(ctx); err != nil {
+	audit, err := auditLogFromEnv(l)
+	if err != nil {
+		panic(fmt.Errorf("failed to configure audit log: %w", err))
+	}
+	w.SetAuditLog(audit)
+
+	redaction, err := logRedactionFromEnv()
+	if err != nil {
+		panic(fmt.Errorf("failed to configure log redaction: %w", err))
+	}
+	if redaction != nil {
+		l.Sugar().Warnw("Logging task payloads", zap.Int("maxPayloadSize", redaction.MaxPayloadSize))
+	}
+	w.SetLogRedaction(redaction)
+
+	shutdownTracing, err := tracingFromEnv(ctx, l)
+	if err != nil {
+		panic(fmt.Errorf("failed to configure tracing: %w", err))
+	}
+	if shutdownTracing != nil {
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+			defer cancel()
+			_ = shutdownTracing(ctx)
+		}()
+	}
+
+	signers, err := taskSignersFromEnv(w.tasks.TaskTypes())
+	if err != nil {
+		panic(fmt.Errorf("failed to configure task signers: %w", err))
+	}
+	w.SetTaskSigners(signers)
+
+	tlsCfg, err := serverTLSConfigFromEnv()
+	if err != nil {
+		panic(fmt.Errorf("failed to configure TLS: %w", err))
+	}
+	auth, err := callerAuthFromEnv(ctx, l, tlsCfg)
+	if err != nil {
+		panic(fmt.Errorf("failed to configure caller authentication: %w", err))
+	}
+	if auth != nil && tlsCfg == nil {
+		l.Sugar().Warnw("PERFORMER_AUTH_TOKEN is sent in plaintext without TLS")
+	}
+
+	// The re-verification endpoint is opt-in, see TaskWorker.Reverify.
+	reverifyAddr, err := reverifyAddrFromEnv()
+	if err != nil {
 		panic(err)
 	}
+	if reverifyAddr != "" {
+		startReverifyServer(ctx, reverifyAddr, w, tlsCfg, auth, l)
+	}
+	readiness.SetConfigured()
+	readiness.Watch(ctx, l)
+
+	// The task server is stopped on its own context so that the probes,
+	// metrics and providers keep working while in-flight tasks are drained.
+	serveCtx, stopServing := context.WithCancel(ctx)
+	defer stopServing()
+	served := make(chan error, 1)
+	if tlsCfg != nil || auth != nil {
+		// The ponos RPC server takes neither TLS credentials nor interceptors.
+		go func() { served <- servePerformer(serveCtx, port, w, readiness, tlsCfg, auth, l) }()
+	} else {
+		rpc, err := rpcServer.NewRpcServer(&rpcServer.RpcServerConfig{GrpcPort: port}, l)
+		if err != nil {
+			panic(fmt.Errorf("failed to create RPC server: %w", err))
+		}
+		// The performer service replaces the ponos one on the ponos RPC server so
+		// failed calls carry their error details.
+		performerV1.RegisterPerformerServiceServer(rpc.GetGrpcServer(), &performerService{logger: l, worker: w, readiness: readiness})
+		readiness.Register(rpc.GetGrpcServer())
+		if err := rpc.Start(serveCtx); err != nil {
+			panic(err)
+		}
+	}
+
+	signals, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	select {
+	case err := <-served:
+		if err != nil {
+			panic(err)
+		}
+		return
+	case <-signals.Done():
+	}
+
+	// Stop taking tasks, then give the ones in flight the grace period to
+	// finish before the deferred flushes of spans and logs run.
+	l.Sugar().Infow("Shutting down, draining in-flight tasks", zap.Duration("gracePeriod", gracePeriod))
+	readiness.SetDraining()
+	drainCtx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+	stopServing()
+	if err := w.Drain(drainCtx); err != nil {
+		l.Sugar().Warnw("Shutting down with tasks in flight", zap.Error(err))
+	} else {
+		l.Sugar().Infow("Drained in-flight tasks")
+	}
+	if audit != nil {
+		if err := audit.Flush(drainCtx); err != nil {
+			l.Sugar().Warnw("Failed to flush audit log", zap.Error(err))
+		}
+	}
 }
@@ -1,12 +1,28 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"testing"
 
 	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
 	"go.uber.org/zap"
 )
 
+// stubProvider is a Provider that returns a fixed output without any network calls.
+type stubProvider struct {
+	output string
+}
+
+func (p *stubProvider) Name() string {
+	return "stub"
+}
+
+func (p *stubProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	return &CompletionResponse{Output: p.output, Model: "stub-model"}, nil
+}
+
 func Test_TaskRequestPayload(t *testing.T) {
 	// ------------------------------------------------------------------------
 	// Write your test cases here
@@ -17,7 +33,7 @@ func Test_TaskRequestPayload(t *testing.T) {
 		t.Errorf("Failed to create logger: %v", err)
 	}
 
-	taskWorker := NewTaskWorker(logger)
+	taskWorker := NewTaskWorker(logger, &stubProvider{output: "the prompt is valid"}, nil)
 
 	taskRequest := &performerV1.TaskRequest{
 		TaskId:   []byte("test-task-id"),
@@ -37,3 +53,82 @@ func Test_TaskRequestPayload(t *testing.T) {
 
 	t.Logf("Response: %v", resp)
 }
+
+func Test_HandleTaskResultMetadata(t *testing.T) {
+	taskWorker := NewTaskWorker(zap.NewNop(), &stubProvider{output: "the prompt is valid"}, nil)
+
+	resp, err := taskWorker.HandleTask(&performerV1.TaskRequest{
+		TaskId:  []byte("test-task-id"),
+		Payload: []byte("test-data"),
+	})
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+
+	var result struct {
+		LLMOutput string       `json:"llm_output"`
+		Verified  Verification `json:"verified"`
+		Metadata  struct {
+			Provider string `json:"provider"`
+			Model    string `json:"model"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if result.Metadata.Provider != "stub" || result.Metadata.Model != "stub-model" {
+		t.Errorf("unexpected metadata: %+v", result.Metadata)
+	}
+	if !result.Verified.Passed || result.Verified.Confidence != 1 || len(result.Verified.Checks) == 0 {
+		t.Errorf("expected result to be verified, got %+v", result.Verified)
+	}
+}
+
+func Test_handleTaskCancelled(t *testing.T) {
+	task := &performerV1.TaskRequest{TaskId: []byte("test-task-id"), Payload: []byte("What is 2+2?")}
+
+	t.Run("before the task starts", func(t *testing.T) {
+		inner := &gatedProvider{release: make(chan struct{})}
+		close(inner.release)
+		tw := NewTaskWorker(zap.NewNop(), inner, nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := tw.validateTask(ctx, task); err != nil {
+			t.Fatalf("validateTask failed: %v", err)
+		}
+		if _, err := tw.handleTask(ctx, task); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if got := inner.calls.Load(); got != 0 {
+			t.Errorf("expected no provider calls, got %d", got)
+		}
+	})
+
+	t.Run("during the provider call", func(t *testing.T) {
+		inner := &blockingProvider{started: make(chan struct{}, 1), release: make(chan struct{})}
+		defer close(inner.release)
+		tw := NewTaskWorker(zap.NewNop(), &cancellableProvider{inner}, nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-inner.started
+			cancel()
+		}()
+		resp, err := tw.handleTask(ctx, task)
+		if err != nil {
+			t.Fatalf("handleTask failed: %v", err)
+		}
+
+		var result struct {
+			ErrorCode string `json:"error_code"`
+			Retryable bool   `json:"retryable"`
+		}
+		if err := json.Unmarshal(resp.Result, &result); err != nil {
+			t.Fatalf("failed to decode result: %v", err)
+		}
+		if result.ErrorCode != errorCodeTaskCancelled || !result.Retryable {
+			t.Errorf("unexpected result: %s", resp.Result)
+		}
+	})
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+const defaultMetricsPort = 9091
+
+var (
+	providerRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "performer",
+		Name:      "provider_request_duration_seconds",
+		Help:      "Latency of LLM provider completion calls.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 4, 8, 16},
+	}, []string{"provider", "model", "outcome"})
+
+	// providerLatencyQuantiles exposes the p50, p95 and p99 latency of each
+	// provider and model over the last 10 minutes, for operators without a
+	// Prometheus server to compute quantiles from the histogram.
+	providerLatencyQuantiles = promauto.NewSummaryVec(prometheus.SummaryOpts{
+		Namespace:  "performer",
+		Name:       "provider_request_latency_seconds",
+		Help:       "Latency quantiles of successful LLM provider completion calls.",
+		Objectives: map[float64]float64{0.5: 0.05, 0.95: 0.01, 0.99: 0.001},
+		MaxAge:     10 * time.Minute,
+	}, []string{"provider", "model"})
+
+	providerRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "performer",
+		Name:      "provider_requests_total",
+		Help:      "LLM provider completion calls by outcome, for error rates.",
+	}, []string{"provider", "model", "outcome"})
+
+	providerTimeToFirstToken = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "performer",
+		Name:      "provider_time_to_first_token_seconds",
+		Help:      "Time until the first output token of a streamed LLM completion.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 4, 8},
+	}, []string{"provider"})
+
+	providerRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "performer",
+		Name:      "provider_retries_total",
+		Help:      "Number of retried LLM provider completion calls.",
+	}, []string{"provider"})
+
+	providerTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "performer",
+		Name:      "provider_tokens_total",
+		Help:      "Prompt and completion tokens reported by LLM providers.",
+	}, []string{"provider", "type"})
+
+	providerCircuitState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "performer",
+		Name:      "provider_circuit_state",
+		Help:      "Circuit breaker state per provider (0 closed, 1 open, 2 half-open).",
+	}, []string{"provider"})
+
+	piiRedactions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "performer",
+		Name:      "pii_redactions_total",
+		Help:      "Values masked in prompts before they were sent to a provider.",
+	}, []string{"kind"})
+
+	patternListReloads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "performer",
+		Name:      "pattern_list_reloads_total",
+		Help:      "Loads of the operator pattern list by outcome.",
+	}, []string{"outcome"})
+
+	buildInfo = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "performer",
+		Name:      "build_info",
+		Help:      "Build of the running performer, always 1.",
+	}, []string{"version", "commit", "go_version"})
+
+	configReloads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "performer",
+		Name:      "config_reloads_total",
+		Help:      "Reloads of the configuration file by outcome.",
+	}, []string{"outcome"})
+
+	senderRateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "performer",
+		Name:      "sender_rate_limited_total",
+		Help:      "Tasks rejected because their sender exceeded a rate limit.",
+	}, []string{"reason"})
+
+	workerPoolActive = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "performer",
+		Name:      "worker_pool_active",
+		Help:      "Tasks being handled by the worker pool.",
+	})
+
+	workerPoolQueued = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "performer",
+		Name:      "worker_pool_queued",
+		Help:      "Tasks waiting for a worker.",
+	})
+
+	workerPoolWait = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "performer",
+		Name:      "worker_pool_wait_seconds",
+		Help:      "Time tasks waited in the queue for a worker.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2, 4, 8, 16},
+	})
+
+	workerPoolRejected = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "performer",
+		Name:      "worker_pool_rejected_total",
+		Help:      "Tasks rejected because the worker pool was full.",
+	})
+
+	inflightTaskBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "performer",
+		Name:      "inflight_task_bytes",
+		Help:      "Payload bytes of the tasks being handled.",
+	})
+
+	loadShed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "performer",
+		Name:      "load_shed_total",
+		Help:      "Tasks shed by the memory and goroutine guards.",
+	}, []string{"reason"})
+
+	resultCacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "performer",
+		Name:      "result_cache_requests_total",
+		Help:      "Result cache lookups by outcome, hit or miss.",
+	}, []string{"outcome"})
+
+	redeliveredTasks = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "performer",
+		Name:      "redelivered_tasks_total",
+		Help:      "Redelivered tasks answered with their previous response.",
+	})
+
+	auditLogErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "performer",
+		Name:      "audit_log_errors_total",
+		Help:      "Audit records that could not be written, by sink.",
+	}, []string{"sink"})
+
+	taskFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "performer",
+		Name:      "task_failures_total",
+		Help:      "Failed tasks by error category and code; unverified results count as verification_failed.",
+	}, []string{"category", "code"})
+
+	coalescedRequests = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "performer",
+		Name:      "coalesced_requests_total",
+		Help:      "Completion requests served by the provider call of an identical request in flight.",
+	})
+
+	tokenBudgetRemaining = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "performer",
+		Name:      "token_budget_remaining",
+		Help:      "Tokens left in the current budget window.",
+	}, []string{"window"})
+
+	providerCost = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "performer",
+		Name:      "provider_cost_usd_total",
+		Help:      "Spend on LLM completions in USD, priced with the pricing table.",
+	}, []string{"model"})
+
+	costCapSpend = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "performer",
+		Name:      "cost_cap_spend_usd",
+		Help:      "Spend in USD of the current cost cap window.",
+	})
+
+	costCapBreaches = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "performer",
+		Name:      "cost_cap_breaches_total",
+		Help:      "Cost cap windows whose spend reached the cap.",
+	})
+)
+
+// meteredProvider wraps a Provider and records the latency and token usage of
+// every completion so operators can compare providers against each other and
+// reconcile costs.
+type meteredProvider struct {
+	Provider
+}
+
+func withMetrics(p Provider) Provider {
+	return &meteredProvider{Provider: p}
+}
+
+func (p *meteredProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	start := time.Now()
+	resp, err := p.Provider.Complete(ctx, req)
+
+	latency := time.Since(start).Seconds()
+	model := metricModel(req, resp)
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	} else {
+		providerLatencyQuantiles.WithLabelValues(p.Name(), model).Observe(latency)
+	}
+	providerRequestDuration.WithLabelValues(p.Name(), model, outcome).Observe(latency)
+	providerRequests.WithLabelValues(p.Name(), model, outcome).Inc()
+	if resp != nil {
+		providerTokens.WithLabelValues(p.Name(), "prompt").Add(float64(resp.TokensIn))
+		providerTokens.WithLabelValues(p.Name(), "completion").Add(float64(resp.TokensOut))
+	}
+
+	return resp, err
+}
+
+// metricModel is the model label of a completion: the model that answered,
+// or the one requested when the call failed, or "default" when the provider
+// used its configured model without reporting it.
+func metricModel(req *CompletionRequest, resp *CompletionResponse) string {
+	if resp != nil && resp.Model != "" {
+		return resp.Model
+	}
+	if req.Model != "" {
+		return req.Model
+	}
+	return "default"
+}
+
+// startMetricsServer serves the Prometheus /metrics endpoint on the given port
+// until ctx is cancelled.
+func startMetricsServer(ctx context.Context, port int, logger *zap.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		logger.Sugar().Infow("Starting metrics server", zap.Int("port", port))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Sugar().Errorw("Metrics server failed", zap.Error(err))
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type failingProvider struct{}
+
+func (p *failingProvider) Name() string {
+	return "failing"
+}
+
+func (p *failingProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	return nil, errors.New("upstream unavailable")
+}
+
+func Test_MeteredProvider(t *testing.T) {
+	ok := withMetrics(&stubProvider{output: "hello"})
+	if _, err := ok.Complete(context.Background(), &CompletionRequest{}); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if got := testutil.CollectAndCount(providerRequestDuration, "performer_provider_request_duration_seconds"); got == 0 {
+		t.Errorf("expected latency to be recorded")
+	}
+
+	metered := withMetrics(&usageStubProvider{output: "hello", tokensIn: 7, tokensOut: 3})
+	if _, err := metered.Complete(context.Background(), &CompletionRequest{}); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if got := testutil.ToFloat64(providerTokens.WithLabelValues("usage-stub", "prompt")); got != 7 {
+		t.Errorf("expected 7 prompt tokens, got %v", got)
+	}
+	if got := testutil.ToFloat64(providerTokens.WithLabelValues("usage-stub", "completion")); got != 3 {
+		t.Errorf("expected 3 completion tokens, got %v", got)
+	}
+
+	failing := withMetrics(&failingProvider{})
+	if _, err := failing.Complete(context.Background(), &CompletionRequest{}); err == nil {
+		t.Fatalf("expected error to be propagated")
+	}
+	if failing.Name() != "failing" {
+		t.Errorf("expected wrapped provider name, got %s", failing.Name())
+	}
+	if _, err := failing.Complete(context.Background(), &CompletionRequest{Model: "gpt-4o"}); err == nil {
+		t.Fatalf("expected error to be propagated")
+	}
+	if got := testutil.ToFloat64(providerRequests.WithLabelValues("failing", "gpt-4o", "error")); got != 1 {
+		t.Errorf("expected 1 error of the requested model, got %v", got)
+	}
+	if got := testutil.ToFloat64(providerRequests.WithLabelValues("stub", "stub-model", "success")); got == 0 {
+		t.Errorf("expected successes to be counted by the model that answered")
+	}
+	if got := testutil.CollectAndCount(providerLatencyQuantiles, "performer_provider_request_latency_seconds"); got == 0 {
+		t.Errorf("expected latency quantiles to be recorded")
+	}
+}
+
+func Test_metricModel(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *CompletionRequest
+		resp *CompletionResponse
+		want string
+	}{
+		{name: "answered", req: &CompletionRequest{Model: "gpt-4o"}, resp: &CompletionResponse{Model: "gpt-4o-2024-08-06"}, want: "gpt-4o-2024-08-06"},
+		{name: "failed", req: &CompletionRequest{Model: "gpt-4o"}, want: "gpt-4o"},
+		{name: "unreported", req: &CompletionRequest{}, resp: &CompletionResponse{}, want: "default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := metricModel(tt.req, tt.resp); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
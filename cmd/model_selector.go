@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// ModelAllowlist holds the provider:model pairs task creators may request. An
+// entry with model "*" allows every model of that provider.
+type ModelAllowlist map[string]map[string]bool
+
+// parseModelAllowlist parses a comma-separated list of provider:model entries,
+// e.g. "openai:gpt-4o,openai:gpt-4o-mini,anthropic:*".
+func parseModelAllowlist(s string) (ModelAllowlist, error) {
+	allowlist := ModelAllowlist{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		provider, model, ok := strings.Cut(entry, ":")
+		if !ok || provider == "" || model == "" {
+			return nil, fmt.Errorf("invalid model allowlist entry %q, expected provider:model", entry)
+		}
+		if allowlist[provider] == nil {
+			allowlist[provider] = map[string]bool{}
+		}
+		allowlist[provider][model] = true
+	}
+	return allowlist, nil
+}
+
+// Allows reports whether the provider may serve the model. An empty model
+// selects the provider's configured default and is allowed when the provider
+// has any entry.
+func (a ModelAllowlist) Allows(provider, model string) bool {
+	models, ok := a[provider]
+	if !ok {
+		return false
+	}
+	return model == "" || models["*"] || models[model]
+}
+
+// Providers returns the names of all providers referenced by the allowlist.
+func (a ModelAllowlist) Providers() []string {
+	names := make([]string, 0, len(a))
+	for name := range a {
+		names = append(names, name)
+	}
+	return names
+}
+
+// ModelSelector lets tasks pick a provider and model from the operator's
+// allowlist. Requests for anything outside the allowlist, and requests without
+// a selection, are served by the default provider with its configured model.
+type ModelSelector struct {
+	defaultProvider Provider
+	providers       map[string]Provider
+	allowlist       ModelAllowlist
+	logger          *zap.Logger
+}
+
+func NewModelSelector(logger *zap.Logger, defaultProvider Provider, providers map[string]Provider, allowlist ModelAllowlist) *ModelSelector {
+	return &ModelSelector{
+		defaultProvider: defaultProvider,
+		providers:       providers,
+		allowlist:       allowlist,
+		logger:          logger,
+	}
+}
+
+func (s *ModelSelector) Name() string {
+	return s.defaultProvider.Name()
+}
+
+func (s *ModelSelector) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	if req.RequestedModel == "" && req.RequestedProvider == "" {
+		return s.defaultProvider.Complete(ctx, req)
+	}
+
+	providerName := req.RequestedProvider
+	if providerName == "" {
+		providerName = s.defaultProvider.Name()
+	}
+
+	target, ok := s.providers[providerName]
+	if !ok && providerName == s.defaultProvider.Name() {
+		target, ok = s.defaultProvider, true
+	}
+	if !ok || !s.allowlist.Allows(providerName, req.RequestedModel) {
+		correlatedLogger(ctx, s.logger).Sugar().Warnw("Requested model not allowed, falling back to default",
+			zap.String("provider", req.RequestedProvider),
+			zap.String("model", req.RequestedModel),
+		)
+		fallback := *req
+		fallback.RequestedModel, fallback.RequestedProvider = "", ""
+		return s.defaultProvider.Complete(ctx, &fallback)
+	}
+
+	selected := *req
+	selected.Model = req.RequestedModel
+	selected.RequestedModel, selected.RequestedProvider = "", ""
+	resp, err := target.Complete(ctx, &selected)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Provider == "" {
+		resp.Provider = target.Name()
+	}
+	return resp, nil
+}
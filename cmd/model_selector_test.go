@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+// recordingProvider records the last request it received.
+type recordingProvider struct {
+	name string
+	last *CompletionRequest
+}
+
+func (p *recordingProvider) Name() string {
+	return p.name
+}
+
+func (p *recordingProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	p.last = req
+	return &CompletionResponse{Output: "ok", Model: req.Model}, nil
+}
+
+func Test_ModelSelector(t *testing.T) {
+	allowlist, err := parseModelAllowlist("azure:*,openai:gpt-4o,anthropic:*")
+	if err != nil {
+		t.Fatalf("parseModelAllowlist failed: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		provider     string
+		model        string
+		wantProvider string
+		wantModel    string
+	}{
+		{name: "no selection", wantProvider: "azure"},
+		{name: "allowed model", provider: "openai", model: "gpt-4o", wantProvider: "openai", wantModel: "gpt-4o"},
+		{name: "disallowed model", provider: "openai", model: "o1", wantProvider: "azure"},
+		{name: "wildcard", provider: "anthropic", model: "claude-3-opus-latest", wantProvider: "anthropic", wantModel: "claude-3-opus-latest"},
+		{name: "provider default model", provider: "anthropic", wantProvider: "anthropic"},
+		{name: "model on default provider", model: "gpt-4o-2024-08-06", wantProvider: "azure", wantModel: "gpt-4o-2024-08-06"},
+		{name: "unknown provider", provider: "groq", model: "llama", wantProvider: "azure"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := &recordingProvider{name: "azure"}
+			providers := map[string]Provider{
+				"openai":    &recordingProvider{name: "openai"},
+				"anthropic": &recordingProvider{name: "anthropic"},
+			}
+			s := NewModelSelector(zap.NewNop(), def, providers, allowlist)
+
+			resp, err := s.Complete(context.Background(), &CompletionRequest{RequestedProvider: tt.provider, RequestedModel: tt.model})
+			if err != nil {
+				t.Fatalf("Complete failed: %v", err)
+			}
+
+			served := resp.Provider
+			if served == "" {
+				served = s.Name()
+			}
+			if served != tt.wantProvider || resp.Model != tt.wantModel {
+				t.Errorf("expected %s/%s, got %s/%s", tt.wantProvider, tt.wantModel, served, resp.Model)
+			}
+		})
+	}
+}
+
+func Test_ParseModelAllowlist(t *testing.T) {
+	if _, err := parseModelAllowlist("openai"); err == nil {
+		t.Errorf("expected error for entry without a model")
+	}
+}
+
+func Test_HandleTaskModelOverrideWithoutAllowlist(t *testing.T) {
+	inner := &recordingProvider{name: "openai"}
+	tw := NewTaskWorker(zap.NewNop(), inner, nil)
+	req := &performerV1.TaskRequest{
+		TaskId:  []byte("task-1"),
+		Payload: []byte(`{"schema_version":1,"prompt":"hi","model":"o1","provider":"openai"}`),
+	}
+	if _, err := tw.HandleTask(req); err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+	if inner.last.Model != "" {
+		t.Errorf("expected the configured model, got %q", inner.last.Model)
+	}
+}
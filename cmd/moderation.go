@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// moderationFlagThreshold is the category score above which content is
+// flagged by moderators that only return scores.
+const moderationFlagThreshold = 0.5
+
+// moderationCategories are the categories scored by the completion-based
+// moderator. Dedicated moderation endpoints may return finer-grained ones.
+var moderationCategories = []string{"harassment", "hate", "illicit", "self-harm", "sexual", "violence"}
+
+// Moderator is implemented by providers that can score content against
+// moderation categories.
+type Moderator interface {
+	Name() string
+	Moderate(ctx context.Context, req *ModerationRequest) (*ModerationResponse, error)
+}
+
+// ModerationRequest is the provider-agnostic description of a moderation call.
+type ModerationRequest struct {
+	Input string
+}
+
+// ModerationResponse is the provider-agnostic result of a moderation call.
+type ModerationResponse struct {
+	Flagged bool
+	// Scores maps each category to a score between 0 and 1.
+	Scores map[string]float64
+	Model  string
+}
+
+// newModeratorFromEnv builds the provider named by MODERATION_PROVIDER, or
+// returns nil when moderate tasks should be served by the completion model.
+func newModeratorFromEnv(ctx context.Context) (Moderator, error) {
+	name := getenv("MODERATION_PROVIDER")
+	if name == "" {
+		return nil, nil
+	}
+
+	p, err := newNamedProviderFromEnv(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	moderator, ok := p.(Moderator)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support moderation", p.Name())
+	}
+	return moderator, nil
+}
+
+// completionModerator moderates content by prompting a completion model for
+// category scores, for deployments without a dedicated moderation endpoint.
+type completionModerator struct {
+	provider Provider
+}
+
+func (m *completionModerator) Name() string {
+	return m.provider.Name()
+}
+
+func (m *completionModerator) Moderate(ctx context.Context, req *ModerationRequest) (*ModerationResponse, error) {
+	example := make([]string, len(moderationCategories))
+	for i, category := range moderationCategories {
+		example[i] = fmt.Sprintf("%q: <score>", category)
+	}
+
+	completion, err := m.provider.Complete(ctx, &CompletionRequest{
+		Messages: []ChatMessage{
+			{
+				Role: "system",
+				Content: "You are a content moderator. Rate how strongly the user's text contains each category, " +
+					"from 0 (absent) to 1 (certainly present). Reply with JSON only, in the form {" + strings.Join(example, ", ") + "}.",
+			},
+			{Role: "user", Content: req.Input},
+		},
+		MaxTokens:   128,
+		Temperature: 0,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	scores, err := parseModerationScores(completion.Output)
+	if err != nil {
+		return nil, fmt.Errorf("%s returned invalid moderation scores: %w", m.Name(), err)
+	}
+	resp := &ModerationResponse{Scores: scores, Model: completion.Model}
+	for _, score := range scores {
+		if score >= moderationFlagThreshold {
+			resp.Flagged = true
+		}
+	}
+	return resp, nil
+}
+
+// parseModerationScores extracts the JSON object of category scores from the
+// model output. Every category must be present; scores are clamped to [0, 1].
+func parseModerationScores(output string) (map[string]float64, error) {
+	start, end := strings.Index(output, "{"), strings.LastIndex(output, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON object in output")
+	}
+	var raw map[string]float64
+	if err := json.Unmarshal([]byte(output[start:end+1]), &raw); err != nil {
+		return nil, err
+	}
+
+	scores := make(map[string]float64, len(moderationCategories))
+	for _, category := range moderationCategories {
+		score, ok := raw[category]
+		if !ok {
+			return nil, fmt.Errorf("missing category %q", category)
+		}
+		scores[category] = clampScore(score)
+	}
+	return scores, nil
+}
+
+func clampScore(score float64) float64 {
+	if score < 0 {
+		return 0
+	}
+	if score > 1 {
+		return 1
+	}
+	return score
+}
+
+// openAIModerationRequest is the request body of the OpenAI moderations API.
+type openAIModerationRequest struct {
+	Model string `json:"model,omitempty"`
+	Input string `json:"input"`
+}
+
+type openAIModerationResponse struct {
+	Model   string `json:"model"`
+	Results []struct {
+		Flagged        bool               `json:"flagged"`
+		CategoryScores map[string]float64 `json:"category_scores"`
+	} `json:"results"`
+}
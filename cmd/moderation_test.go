@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_parseModerationScores(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    map[string]float64
+		wantErr bool
+	}{
+		{
+			name:   "json",
+			output: `{"harassment": 0, "hate": 0.1, "illicit": 0, "self-harm": 0, "sexual": 0, "violence": 0.9}`,
+			want:   map[string]float64{"harassment": 0, "hate": 0.1, "illicit": 0, "self-harm": 0, "sexual": 0, "violence": 0.9},
+		},
+		{
+			name:   "surrounding text and out of range",
+			output: "Scores: {\"harassment\": -1, \"hate\": 0, \"illicit\": 0, \"self-harm\": 0, \"sexual\": 0, \"violence\": 3, \"spam\": 1}",
+			want:   map[string]float64{"harassment": 0, "hate": 0, "illicit": 0, "self-harm": 0, "sexual": 0, "violence": 1},
+		},
+		{name: "missing category", output: `{"hate": 0.1}`, wantErr: true},
+		{name: "not json", output: "looks fine to me", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseModerationScores(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseModerationScores() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for category, score := range tt.want {
+				if got[category] != score {
+					t.Errorf("score of %s = %v, want %v", category, got[category], score)
+				}
+			}
+			if !tt.wantErr && len(got) != len(tt.want) {
+				t.Errorf("unexpected categories: %v", got)
+			}
+		})
+	}
+}
+
+func Test_completionModerator(t *testing.T) {
+	m := &completionModerator{provider: &stubProvider{
+		output: `{"harassment": 0.7, "hate": 0, "illicit": 0, "self-harm": 0, "sexual": 0, "violence": 0.2}`,
+	}}
+
+	resp, err := m.Moderate(context.Background(), &ModerationRequest{Input: "you are awful"})
+	if err != nil {
+		t.Fatalf("Moderate failed: %v", err)
+	}
+	if !resp.Flagged || resp.Scores["harassment"] != 0.7 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
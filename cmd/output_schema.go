@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+const (
+	// maxSchemaRetries is how many times a completion is re-prompted after its
+	// output violates the payload's output schema.
+	maxSchemaRetries = 2
+
+	// outputSchemaURL is the location the payload's schema is compiled under.
+	outputSchemaURL = "urn:performer:output_schema"
+)
+
+// compileOutputSchema compiles the JSON Schema of a payload's output_schema
+// field. Loading remote $refs is disabled so a task cannot make operators
+// fetch arbitrary URLs.
+func compileOutputSchema(schema json.RawMessage) (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("output_schema must be a JSON Schema: %w", err)
+	}
+
+	c := jsonschema.NewCompiler()
+	c.UseLoader(jsonschema.SchemeURLLoader{})
+	if err := c.AddResource(outputSchemaURL, doc); err != nil {
+		return nil, fmt.Errorf("invalid output_schema: %w", err)
+	}
+	sch, err := c.Compile(outputSchemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output_schema: %w", err)
+	}
+	return sch, nil
+}
+
+// validateOutput checks that a completion output is JSON satisfying the
+// schema. Outputs wrapped in a Markdown code fence are accepted.
+func validateOutput(sch *jsonschema.Schema, output string) error {
+	inst, err := jsonschema.UnmarshalJSON(strings.NewReader(extractCode(output)))
+	if err != nil {
+		return fmt.Errorf("output is not valid JSON: %w", err)
+	}
+	return sch.Validate(inst)
+}
+
+// outputSchemaInstruction is the system prompt asking the model to answer in
+// the shape of the schema.
+func outputSchemaInstruction(schema json.RawMessage) string {
+	return "Reply with JSON only, without explanations, that validates against this JSON Schema:\n" + string(schema)
+}
+
+// schemaRetryPrompt asks the model to correct an output that violated the
+// schema.
+func schemaRetryPrompt(err error) string {
+	return fmt.Sprintf("Your reply does not validate against the JSON Schema: %v\nReply again with corrected JSON only.", err)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// outputsProvider answers completions with the queued outputs in order,
+// repeating the last one, and records the requests it received.
+type outputsProvider struct {
+	outputs  []string
+	requests []*CompletionRequest
+}
+
+func (p *outputsProvider) Name() string {
+	return "outputs"
+}
+
+func (p *outputsProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	p.requests = append(p.requests, &CompletionRequest{Messages: append([]ChatMessage(nil), req.Messages...)})
+	output := p.outputs[len(p.outputs)-1]
+	if len(p.requests) <= len(p.outputs) {
+		output = p.outputs[len(p.requests)-1]
+	}
+	return &CompletionResponse{Output: output, Model: "outputs-model"}, nil
+}
+
+const testOutputSchema = `{
+	"type": "object",
+	"properties": {"answer": {"type": "integer"}},
+	"required": ["answer"]
+}`
+
+func Test_compileOutputSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr bool
+	}{
+		{name: "valid", schema: testOutputSchema},
+		{name: "not json", schema: `{"type":`, wantErr: true},
+		{name: "invalid keyword value", schema: `{"type": "integer", "minimum": "zero"}`, wantErr: true},
+		{name: "remote ref", schema: `{"$ref": "https://example.com/schema.json"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := compileOutputSchema(json.RawMessage(tt.schema)); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_validateOutput(t *testing.T) {
+	sch, err := compileOutputSchema(json.RawMessage(testOutputSchema))
+	if err != nil {
+		t.Fatalf("compileOutputSchema failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		output  string
+		wantErr bool
+	}{
+		{name: "valid", output: `{"answer": 4}`},
+		{name: "fenced", output: "```json\n{\"answer\": 4}\n```"},
+		{name: "wrong type", output: `{"answer": "four"}`, wantErr: true},
+		{name: "missing property", output: `{}`, wantErr: true},
+		{name: "not json", output: "four", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateOutput(sch, tt.output); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_CompletionHandlerOutputSchema(t *testing.T) {
+	tests := []struct {
+		name         string
+		outputs      []string
+		wantPassed   bool
+		wantAttempts int
+	}{
+		{name: "valid first time", outputs: []string{`{"answer": 4}`}, wantPassed: true, wantAttempts: 1},
+		{name: "valid after retry", outputs: []string{"four", `{"answer": 4}`}, wantPassed: true, wantAttempts: 2},
+		{name: "never valid", outputs: []string{`{"answer": "four"}`}, wantAttempts: 1 + maxSchemaRetries},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &outputsProvider{outputs: tt.outputs}
+			payload, err := ParseTaskPayload([]byte(`{"prompt":"What is 2+2?","output_schema":` + testOutputSchema + `}`))
+			if err != nil {
+				t.Fatalf("ParseTaskPayload failed: %v", err)
+			}
+			if err := payload.Validate(); err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+
+			result, err := NewCompletionHandler(provider).Handle(context.Background(), payload)
+			if err != nil {
+				t.Fatalf("Handle failed: %v", err)
+			}
+
+			verification := result["verified"].(*Verification)
+			if verification.Passed != tt.wantPassed || !reflect.DeepEqual(verification.Checks, []string{"output_schema", "non_empty"}) {
+				t.Errorf("unexpected verification: %+v", verification)
+			}
+			if got := result["metadata"].(map[string]interface{})["schema_attempts"]; got != tt.wantAttempts || len(provider.requests) != tt.wantAttempts {
+				t.Errorf("expected %d attempts, got %v after %d requests", tt.wantAttempts, got, len(provider.requests))
+			}
+
+			first := provider.requests[0].Messages
+			if first[0].Role != "system" || !strings.Contains(first[0].Content, `"required": ["answer"]`) {
+				t.Errorf("expected the schema in the system prompt, got %+v", first[0])
+			}
+			if tt.wantAttempts > 1 {
+				retry := provider.requests[1].Messages
+				if len(retry) != len(first)+2 || retry[len(retry)-2].Role != "assistant" || retry[len(retry)-2].Content != tt.outputs[0] {
+					t.Errorf("expected the retry to carry the rejected output, got %+v", retry)
+				}
+			}
+		})
+	}
+}
+
+func Test_PayloadValidateOutputSchema(t *testing.T) {
+	payload, err := ParseTaskPayload([]byte(`{"prompt":"What is 2+2?","output_schema":{"type":"nonsense"}}`))
+	if err != nil {
+		t.Fatalf("ParseTaskPayload failed: %v", err)
+	}
+	if err := payload.Validate(); err == nil {
+		t.Errorf("expected error for an invalid output_schema")
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrBlockedPattern is returned for task payloads matching a blocked pattern.
+var ErrBlockedPattern = errors.New("task payload matches a blocked pattern")
+
+// PatternList is one version of the operator's denylist and allowlist:
+//
+//	{"blocked": ["(?i)wire the funds", ...], "allowed": ["(?i)drop table of contents", ...]}
+//
+// Patterns are Go regular expressions. Allowed patterns are exceptions to the
+// blocked ones: a blocked match is ignored when an allowed pattern matches
+// the text it matched.
+type PatternList struct {
+	blocked []*regexp.Regexp
+	allowed []*regexp.Regexp
+}
+
+// ParsePatternList parses a pattern list document.
+func ParsePatternList(data []byte) (*PatternList, error) {
+	var doc struct {
+		Blocked []string `json:"blocked"`
+		Allowed []string `json:"allowed"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid pattern list: %w", err)
+	}
+	l := &PatternList{}
+	var err error
+	if l.blocked, err = compilePatterns("blocked", doc.Blocked); err != nil {
+		return nil, err
+	}
+	if l.allowed, err = compilePatterns("allowed", doc.Allowed); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func compilePatterns(kind string, patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for i, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern %d: %w", kind, i, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// Check returns ErrBlockedPattern when text matches a blocked pattern that no
+// allowed pattern excepts.
+func (l *PatternList) Check(text string) error {
+	for _, re := range l.blocked {
+		for _, match := range re.FindAllString(text, -1) {
+			if !l.isAllowed(match) {
+				return fmt.Errorf("%w: %s", ErrBlockedPattern, re)
+			}
+		}
+	}
+	return nil
+}
+
+func (l *PatternList) isAllowed(match string) bool {
+	for _, re := range l.allowed {
+		if re.MatchString(match) {
+			return true
+		}
+	}
+	return false
+}
+
+// PatternFilter checks task payloads against a pattern list loaded from a
+// file or URL. The list is loaded again at an interval and on SIGHUP, so
+// operators can respond to new attack patterns without a redeploy. A reload
+// that fails keeps the current list.
+type PatternFilter struct {
+	logger *zap.Logger
+	source string
+	client *http.Client
+
+	current atomic.Pointer[PatternList]
+}
+
+// patternFilterFromEnv loads the pattern list from PATTERN_LIST_SOURCE, a file
+// path or an http(s) URL, and returns nil when it is unset. The list is
+// reloaded every PATTERN_LIST_RELOAD_INTERVAL, when set, and on SIGHUP.
+func patternFilterFromEnv(ctx context.Context, logger *zap.Logger) (*PatternFilter, error) {
+	source := getenv("PATTERN_LIST_SOURCE")
+	if source == "" {
+		return nil, nil
+	}
+	interval, err := envDuration("PATTERN_LIST_RELOAD_INTERVAL")
+	if err != nil {
+		return nil, err
+	}
+	f, err := NewPatternFilter(ctx, logger, source)
+	if err != nil {
+		return nil, err
+	}
+	go f.watch(ctx, interval)
+	return f, nil
+}
+
+// NewPatternFilter loads the pattern list from source.
+func NewPatternFilter(ctx context.Context, logger *zap.Logger, source string) (*PatternFilter, error) {
+	f := &PatternFilter{logger: logger, source: source, client: &http.Client{Timeout: 10 * time.Second}}
+	if err := f.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Check checks text against the current pattern list.
+func (f *PatternFilter) Check(text string) error {
+	return f.current.Load().Check(text)
+}
+
+// Reload loads the pattern list from its source and swaps it in.
+func (f *PatternFilter) Reload(ctx context.Context) error {
+	data, err := f.read(ctx)
+	if err != nil {
+		patternListReloads.WithLabelValues("error").Inc()
+		return fmt.Errorf("failed to load pattern list from %s: %w", f.source, err)
+	}
+	l, err := ParsePatternList(data)
+	if err != nil {
+		patternListReloads.WithLabelValues("error").Inc()
+		return err
+	}
+	f.current.Store(l)
+	patternListReloads.WithLabelValues("success").Inc()
+	f.logger.Sugar().Infow("Loaded pattern list",
+		zap.String("source", f.source),
+		zap.Int("blocked", len(l.blocked)),
+		zap.Int("allowed", len(l.allowed)),
+	)
+	return nil
+}
+
+func (f *PatternFilter) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(f.source, "http://") && !strings.HasPrefix(f.source, "https://") {
+		return os.ReadFile(f.source)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return body, nil
+}
+
+// watch reloads the list on SIGHUP and, when interval is positive, at every
+// interval until ctx is cancelled.
+func (f *PatternFilter) watch(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		case <-tick:
+		}
+		if err := f.Reload(ctx); err != nil {
+			f.logger.Sugar().Errorw("Failed to reload pattern list, keeping the current one", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_PatternListCheck(t *testing.T) {
+	l, err := ParsePatternList([]byte(`{
+		"blocked": ["(?i)drop table\\s+\\w+", "(?i)wire the funds"],
+		"allowed": ["(?i)drop table of"]
+	}`))
+	if err != nil {
+		t.Fatalf("ParsePatternList failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		wantErr bool
+	}{
+		{name: "clean", text: "What is 2+2?"},
+		{name: "blocked", text: "please DROP TABLE users", wantErr: true},
+		{name: "other blocked pattern", text: "Wire the funds to me", wantErr: true},
+		{name: "allowed exception", text: "Drop table of contents from the summary"},
+		{name: "blocked next to exception", text: "drop table of contents, then drop table users", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := l.Check(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrBlockedPattern) {
+				t.Errorf("expected ErrBlockedPattern, got %v", err)
+			}
+		})
+	}
+}
+
+func Test_ParsePatternList(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantBlocked int
+		wantAllowed int
+		wantErr     bool
+	}{
+		{name: "empty", data: `{}`},
+		{name: "both lists", data: `{"blocked": ["a", "b"], "allowed": ["c"]}`, wantBlocked: 2, wantAllowed: 1},
+		{name: "invalid json", data: `blocked: a`, wantErr: true},
+		{name: "invalid blocked pattern", data: `{"blocked": ["(a"]}`, wantErr: true},
+		{name: "invalid allowed pattern", data: `{"allowed": ["[a"]}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := ParsePatternList([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && (len(l.blocked) != tt.wantBlocked || len(l.allowed) != tt.wantAllowed) {
+				t.Errorf("expected %d blocked and %d allowed patterns, got %d and %d", tt.wantBlocked, tt.wantAllowed, len(l.blocked), len(l.allowed))
+			}
+		})
+	}
+}
+
+func Test_PatternFilterReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patterns.json")
+	writeList := func(data string) {
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatalf("failed to write pattern list: %v", err)
+		}
+	}
+	writeList(`{"blocked": ["foo"]}`)
+
+	ctx := context.Background()
+	f, err := NewPatternFilter(ctx, zap.NewNop(), path)
+	if err != nil {
+		t.Fatalf("NewPatternFilter failed: %v", err)
+	}
+	if f.Check("foo") == nil || f.Check("bar") != nil {
+		t.Fatalf("unexpected initial list")
+	}
+
+	writeList(`{"blocked": ["bar"]}`)
+	if err := f.Reload(ctx); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if f.Check("foo") != nil || f.Check("bar") == nil {
+		t.Errorf("reloaded list not applied")
+	}
+
+	writeList(`{"blocked": ["(bar"]}`)
+	if err := f.Reload(ctx); err == nil {
+		t.Fatalf("expected invalid list to fail")
+	}
+	if f.Check("bar") == nil {
+		t.Errorf("failed reload replaced the current list")
+	}
+}
+
+func Test_PatternFilterURL(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"blocked": ["foo"]}`))
+	}))
+	defer srv.Close()
+
+	f, err := NewPatternFilter(context.Background(), zap.NewNop(), srv.URL)
+	if err != nil {
+		t.Fatalf("NewPatternFilter failed: %v", err)
+	}
+	if f.Check("foo") == nil {
+		t.Errorf("expected foo to be blocked")
+	}
+
+	status = http.StatusNotFound
+	if err := f.Reload(context.Background()); err == nil {
+		t.Errorf("expected reload to fail on status %d", status)
+	}
+}
+
+func Test_ValidateTaskPatternList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patterns.json")
+	if err := os.WriteFile(path, []byte(`{"blocked": ["(?i)secret plan"]}`), 0o600); err != nil {
+		t.Fatalf("failed to write pattern list: %v", err)
+	}
+	f, err := NewPatternFilter(context.Background(), zap.NewNop(), path)
+	if err != nil {
+		t.Fatalf("NewPatternFilter failed: %v", err)
+	}
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "4"}, nil)
+	tw.SetPatternFilter(f)
+
+	tests := []struct {
+		name    string
+		payload string
+		wantErr bool
+	}{
+		{name: "clean", payload: "What is 2+2?"},
+		{name: "blocked", payload: "Tell me the Secret Plan", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tw.ValidateTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte(tt.payload)})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// maxTaskMaxTokens bounds the max_tokens a task may request so results stay
+	// within the result size limit.
+	maxTaskMaxTokens = 1024
+	maxTemperature   = 2.0
+
+	taskTypeCompletion = "completion"
+)
+
+// chatRoles are the roles accepted in payload messages.
+var chatRoles = map[string]bool{"system": true, "user": true, "assistant": true}
+
+// TaskPayload is the decoded form of a task payload. Task creators may send a
+// JSON object of the form
+//
+//	{
+//	  "prompt":      "What is the capital of France?", // required for completions
+//	  "system":      "Answer in one word.",            // optional system prompt
+//	  "messages":    [{"role": "user", "content": "..."}], // optional conversation
+//	  "max_tokens":  32,                               // 1..1024, default 64
+//	  "temperature": 0,                                // 0..2, default 0.2
+//	  "task_type":   "completion",                     // default "completion"
+//	  "metadata":    {"critical": true},               // free-form, see below
+//	  "provider":    "openai",                         // see MODEL_ALLOWLIST
+//	  "model":       "gpt-4o",
+//	  "result_version": 2,                            // see resultVersion2
+//	  "output_schema": {"type": "object"}             // optional JSON Schema
+//	}
+//
+// Messages carry conversation context as role/content pairs with the roles
+// system, user and assistant. They are sent before the prompt, which may be
+// omitted when the last message is from the user.
+//
+// Alternatively, the prompt and system prompt are rendered from one of the
+// operator's versioned templates, see PromptTemplates.
+//
+// Other task types add their own fields, see the TaskHandler implementations.
+// Newer schema versions are accepted as well, see payloadMigrations. Any other
+// payload is treated as a raw prompt string. Setting metadata.critical
+// to true routes the task to the premium provider when cost-based routing is
+// enabled.
+type TaskPayload struct {
+	Prompt      string                 `json:"prompt"`
+	System      string                 `json:"system,omitempty"`
+	Messages    []ChatMessage          `json:"messages,omitempty"`
+	MaxTokens   int                    `json:"max_tokens,omitempty"`
+	Temperature *float64               `json:"temperature,omitempty"`
+	TaskType    string                 `json:"task_type,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+
+	// Template names a versioned prompt template rendered with Variables in
+	// place of Prompt and System.
+	Template        string                 `json:"template,omitempty"`
+	TemplateVersion int                    `json:"template_version,omitempty"`
+	Variables       map[string]interface{} `json:"variables,omitempty"`
+
+	// Model and Provider request a specific model, honoured only when the
+	// operator configured MODEL_ALLOWLIST and it permits the model. They are
+	// ignored otherwise.
+	Model    string `json:"model,omitempty"`
+	Provider string `json:"provider,omitempty"`
+
+	// ResultVersion selects the result schema, see resultVersion2. Zero means
+	// version 1.
+	ResultVersion int `json:"result_version,omitempty"`
+
+	// OutputSchema is a JSON Schema the completion output must satisfy, see
+	// CompletionHandler.
+	OutputSchema json.RawMessage `json:"output_schema,omitempty"`
+
+	// Examples are the operator's few-shot examples for the task type, set by
+	// the TaskWorker.
+	Examples []ChatMessage `json:"-"`
+
+	// SchemaVersion is the schema version the payload was written in. It is
+	// zero for raw-string payloads.
+	SchemaVersion int `json:"-"`
+
+	// raw holds the structured payload so task handlers can decode their own
+	// fields. It is empty for raw-string payloads.
+	raw []byte
+}
+
+// ParseTaskPayload decodes a task payload. Payloads that are not a JSON object
+// with a prompt, messages, template or task_type field fall back to the legacy raw-string format.
+// A JSON object whose fields have the wrong type or whose schema version is
+// not supported is rejected rather than being sent to the model verbatim.
+func ParseTaskPayload(raw []byte) (*TaskPayload, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) > 0 && trimmed[0] == '{' && json.Valid(trimmed) {
+		migrated, version, err := migratePayload(trimmed)
+		if err != nil {
+			return nil, err
+		}
+
+		var p TaskPayload
+		err = json.Unmarshal(migrated, &p)
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("invalid task payload field %s: expected %s", typeErr.Field, typeErr.Type)
+		}
+		if err == nil && (p.Prompt != "" || p.TaskType != "" || len(p.Messages) > 0 || p.Template != "") {
+			p.SchemaVersion = version
+			p.raw = migrated
+			return &p, nil
+		}
+	}
+	return &TaskPayload{Prompt: string(raw)}, nil
+}
+
+// Validate checks the optional settings of a structured payload.
+func (p *TaskPayload) Validate() error {
+	if p.MaxTokens < 0 || p.MaxTokens > maxTaskMaxTokens {
+		return fmt.Errorf("max_tokens must be between 1 and %d", maxTaskMaxTokens)
+	}
+	if p.Temperature != nil && (*p.Temperature < 0 || *p.Temperature > maxTemperature) {
+		return fmt.Errorf("temperature must be between 0 and %g", maxTemperature)
+	}
+	if p.ResultVersion < 0 || p.ResultVersion > maxResultVersion {
+		return fmt.Errorf("result_version must be between 1 and %d", maxResultVersion)
+	}
+	for i, m := range p.Messages {
+		if !chatRoles[m.Role] {
+			return fmt.Errorf("messages[%d]: role must be one of system, user or assistant", i)
+		}
+		if strings.TrimSpace(m.Content) == "" {
+			return fmt.Errorf("messages[%d]: content cannot be empty or whitespace only", i)
+		}
+	}
+	if len(p.Messages) > 0 && p.Prompt == "" && p.Messages[len(p.Messages)-1].Role != "user" {
+		return fmt.Errorf("the last message must be from the user when prompt is omitted")
+	}
+	if len(p.OutputSchema) > 0 {
+		if _, err := compileOutputSchema(p.OutputSchema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasPrompt reports whether the payload carries a prompt or a conversation.
+func (p *TaskPayload) HasPrompt() bool {
+	return strings.TrimSpace(p.Prompt) != "" || len(p.Messages) > 0
+}
+
+// Decode decodes the task type specific fields of a structured payload into v.
+func (p *TaskPayload) Decode(v interface{}) error {
+	if len(p.raw) == 0 {
+		return fmt.Errorf("task type %q requires a JSON payload", p.TaskType)
+	}
+	if err := json.Unmarshal(p.raw, v); err != nil {
+		return fmt.Errorf("invalid %s task payload: %w", p.TaskType, err)
+	}
+	return nil
+}
+
+// Critical reports whether the task creator flagged the task as critical.
+func (p *TaskPayload) Critical() bool {
+	critical, _ := p.Metadata["critical"].(bool)
+	return critical
+}
+
+// CompletionRequest builds the provider request for the payload, applying the
+// performer's defaults for unset settings.
+func (p *TaskPayload) CompletionRequest() *CompletionRequest {
+	var messages []ChatMessage
+	if p.System != "" {
+		messages = append(messages, ChatMessage{Role: "system", Content: p.System})
+	}
+	messages = append(messages, p.Messages...)
+	if p.Prompt != "" || len(p.Messages) == 0 {
+		messages = append(messages, ChatMessage{Role: "user", Content: p.Prompt})
+	}
+
+	req := &CompletionRequest{
+		Messages:    messages,
+		MaxTokens:   defaultMaxTokens,
+		Temperature: defaultTemperature,
+		Critical:    p.Critical(),
+		Examples:    p.Examples,
+
+		RequestedModel:    p.Model,
+		RequestedProvider: p.Provider,
+	}
+	if p.MaxTokens > 0 {
+		req.MaxTokens = p.MaxTokens
+	}
+	if p.Temperature != nil {
+		req.Temperature = *p.Temperature
+	}
+	return req
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// PayloadDecoder converts the payload bytes received from the TaskMailbox into
+// the JSON or raw-string form understood by ParseTaskPayload.
+type PayloadDecoder interface {
+	Decode(raw []byte) ([]byte, error)
+}
+
+// payloadDecoderFromEnv returns the decoder selected by PAYLOAD_ENCODING, or nil
+// when payloads are passed through unchanged.
+func payloadDecoderFromEnv() (PayloadDecoder, error) {
+	switch encoding := getenv("PAYLOAD_ENCODING"); encoding {
+	case "", "raw":
+		return nil, nil
+	case "abi":
+		return NewABIPayloadDecoder(getenv("PAYLOAD_ABI_TYPES"))
+	case "protobuf":
+		return &ProtobufPayloadDecoder{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported payload encoding %q", encoding)
+	}
+}
+
+// ABIPayloadDecoder decodes ABI-encoded payloads created on-chain. The layout
+// of the task definition is described by a type list in Solidity syntax:
+//
+//	string                                 abi.encode(prompt)
+//	bytes                                  abi.encode(bytes(payloadJSON))
+//	string prompt,string system,uint256 max_tokens
+//	                                       abi.encode(prompt, system, maxTokens)
+//	(string prompt,string model)           abi.encode(TaskStruct(...))
+//
+// A single unnamed string or bytes value is used as the payload itself. Named
+// values become the fields of a structured JSON payload.
+type ABIPayloadDecoder struct {
+	args abi.Arguments
+	// tuple is set when the payload is a single ABI-encoded struct.
+	tuple bool
+}
+
+func NewABIPayloadDecoder(spec string) (*ABIPayloadDecoder, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("ABI payload types not set")
+	}
+
+	if strings.HasPrefix(spec, "(") && strings.HasSuffix(spec, ")") {
+		fields, err := parseABIFields(spec[1 : len(spec)-1])
+		if err != nil {
+			return nil, err
+		}
+		components := make([]abi.ArgumentMarshaling, len(fields))
+		for i, f := range fields {
+			if f.Name == "" {
+				return nil, fmt.Errorf("ABI tuple field %d must be named", i)
+			}
+			components[i] = abi.ArgumentMarshaling{Name: f.Name, Type: f.Type.String()}
+		}
+		tupleType, err := abi.NewType("tuple", "", components)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ABI tuple %q: %w", spec, err)
+		}
+		return &ABIPayloadDecoder{args: abi.Arguments{{Type: tupleType}}, tuple: true}, nil
+	}
+
+	args, err := parseABIFields(spec)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 1 {
+		for i, arg := range args {
+			if arg.Name == "" {
+				return nil, fmt.Errorf("ABI payload value %d must be named", i)
+			}
+		}
+	}
+	return &ABIPayloadDecoder{args: args}, nil
+}
+
+// parseABIFields parses a comma-separated list of "type [name]" entries.
+func parseABIFields(spec string) (abi.Arguments, error) {
+	var args abi.Arguments
+	for _, entry := range strings.Split(spec, ",") {
+		parts := strings.Fields(entry)
+		if len(parts) == 0 || len(parts) > 2 {
+			return nil, fmt.Errorf("invalid ABI payload entry %q, expected \"type [name]\"", strings.TrimSpace(entry))
+		}
+		typ, err := abi.NewType(parts[0], "", nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ABI type %q: %w", parts[0], err)
+		}
+		arg := abi.Argument{Type: typ}
+		if len(parts) == 2 {
+			arg.Name = parts[1]
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+func (d *ABIPayloadDecoder) Decode(raw []byte) ([]byte, error) {
+	values, err := d.args.Unpack(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ABI-decode task payload: %w", err)
+	}
+
+	if d.tuple {
+		fields := map[string]interface{}{}
+		v := reflect.ValueOf(values[0])
+		for i, name := range d.args[0].Type.TupleRawNames {
+			fields[name] = abiJSONValue(v.Field(i).Interface())
+		}
+		return json.Marshal(fields)
+	}
+
+	if len(d.args) == 1 && d.args[0].Name == "" {
+		switch v := values[0].(type) {
+		case string:
+			return []byte(v), nil
+		case []byte:
+			return v, nil
+		}
+		return nil, fmt.Errorf("unnamed ABI payload must be string or bytes, got %s", d.args[0].Type)
+	}
+
+	fields := map[string]interface{}{}
+	for i, arg := range d.args {
+		fields[arg.Name] = abiJSONValue(values[i])
+	}
+	return json.Marshal(fields)
+}
+
+// abiJSONValue converts a decoded ABI value into its JSON payload form.
+func abiJSONValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *big.Int:
+		if v.IsInt64() {
+			return v.Int64()
+		}
+		return v.String()
+	case []byte:
+		return string(v)
+	case common.Address:
+		return v.Hex()
+	default:
+		return v
+	}
+}
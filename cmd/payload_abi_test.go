@@ -0,0 +1,91 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func abiEncode(t *testing.T, spec string, values ...interface{}) []byte {
+	t.Helper()
+	d, err := NewABIPayloadDecoder(spec)
+	if err != nil {
+		t.Fatalf("NewABIPayloadDecoder failed: %v", err)
+	}
+	data, err := d.args.Pack(values...)
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	return data
+}
+
+func Test_ABIPayloadDecoder(t *testing.T) {
+	type task struct {
+		Prompt string
+		Model  string
+	}
+
+	tests := []struct {
+		name   string
+		spec   string
+		values []interface{}
+		want   string
+	}{
+		{
+			name:   "string",
+			spec:   "string",
+			values: []interface{}{"What is the capital of France?"},
+			want:   "What is the capital of France?",
+		},
+		{
+			name:   "bytes",
+			spec:   "bytes",
+			values: []interface{}{[]byte(`{"prompt":"hi"}`)},
+			want:   `{"prompt":"hi"}`,
+		},
+		{
+			name:   "named values",
+			spec:   "string prompt, string system, uint256 max_tokens",
+			values: []interface{}{"hi", "be brief", big.NewInt(32)},
+			want:   `{"max_tokens":32,"prompt":"hi","system":"be brief"}`,
+		},
+		{
+			name:   "tuple",
+			spec:   "(string prompt,string model)",
+			values: []interface{}{task{Prompt: "hi", Model: "gpt-4o"}},
+			want:   `{"model":"gpt-4o","prompt":"hi"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewABIPayloadDecoder(tt.spec)
+			if err != nil {
+				t.Fatalf("NewABIPayloadDecoder failed: %v", err)
+			}
+
+			got, err := d.Decode(abiEncode(t, tt.spec, tt.values...))
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_ABIPayloadDecoderErrors(t *testing.T) {
+	for _, spec := range []string{"", "strng", "string, string", "(string)"} {
+		if _, err := NewABIPayloadDecoder(spec); err == nil {
+			t.Errorf("expected error for spec %q", spec)
+		}
+	}
+
+	d, err := NewABIPayloadDecoder("string")
+	if err != nil {
+		t.Fatalf("NewABIPayloadDecoder failed: %v", err)
+	}
+	if _, err := d.Decode([]byte("not abi")); err == nil {
+		t.Errorf("expected error for malformed payload")
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Payload encodings reported in result metadata.
+const (
+	payloadEncodingText = "text"
+	payloadEncodingJSON = "json"
+	payloadEncodingCBOR = "cbor"
+)
+
+// cborSelfDescribeTag is the optional tag 55799 that marks data as CBOR.
+var cborSelfDescribeTag = []byte{0xd9, 0xd9, 0xf7}
+
+var cborDecMode = func() cbor.DecMode {
+	mode, err := cbor.DecOptions{
+		DefaultMapType: reflect.TypeOf(map[string]interface{}{}),
+	}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}()
+
+// isCBORPayload reports whether data is a CBOR map, the CBOR form of a
+// structured payload. A map header is a UTF-8 continuation byte, so it cannot
+// start a text or JSON payload.
+func isCBORPayload(data []byte) bool {
+	data = bytes.TrimPrefix(data, cborSelfDescribeTag)
+	return len(data) > 0 && data[0]>>5 == 5 // major type 5: map
+}
+
+// cborToJSON converts a CBOR payload to the equivalent JSON object so it can
+// be parsed like any other structured payload. Byte strings become base64
+// strings, as encoding/json does for []byte.
+func cborToJSON(data []byte) ([]byte, error) {
+	var v map[string]interface{}
+	if err := cborDecMode.Unmarshal(bytes.TrimPrefix(data, cborSelfDescribeTag), &v); err != nil {
+		return nil, fmt.Errorf("invalid CBOR payload: %w", err)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CBOR payload: %w", err)
+	}
+	return out, nil
+}
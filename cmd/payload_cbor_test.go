@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"github.com/fxamacker/cbor/v2"
+	"go.uber.org/zap"
+)
+
+func cborBytes(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	data, err := cbor.Marshal(v)
+	if err != nil {
+		t.Fatalf("cbor.Marshal failed: %v", err)
+	}
+	return data
+}
+
+func Test_cborToJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "structured payload",
+			payload: cborBytes(t, map[string]interface{}{"prompt": "hi", "max_tokens": 32, "metadata": map[string]interface{}{"critical": true}}),
+			want:    `{"max_tokens":32,"metadata":{"critical":true},"prompt":"hi"}`,
+		},
+		{
+			name:    "self-described",
+			payload: append([]byte{0xd9, 0xd9, 0xf7}, cborBytes(t, map[string]interface{}{"prompt": "hi"})...),
+			want:    `{"prompt":"hi"}`,
+		},
+		{
+			name:    "non-string keys",
+			payload: cborBytes(t, map[int]string{1: "hi"}),
+			wantErr: true,
+		},
+		{
+			name:    "trailing data",
+			payload: append(cborBytes(t, map[string]interface{}{"prompt": "hi"}), 0x00),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !isCBORPayload(tt.payload) {
+				t.Fatalf("payload not detected as CBOR")
+			}
+			got, err := cborToJSON(tt.payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_isCBORPayloadText(t *testing.T) {
+	for _, payload := range []string{"", "What is 2+2?", `{"prompt":"hi"}`, "¿Qué hora es?"} {
+		if isCBORPayload([]byte(payload)) {
+			t.Errorf("%q detected as CBOR", payload)
+		}
+	}
+}
+
+func Test_HandleTaskReportsPayloadEncoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		want    string
+	}{
+		{name: "text", payload: []byte("What is 2+2?"), want: payloadEncodingText},
+		{name: "json", payload: []byte(`{"prompt":"What is 2+2?"}`), want: payloadEncodingJSON},
+		{name: "cbor", payload: cborBytes(t, map[string]interface{}{"prompt": "What is 2+2?"}), want: payloadEncodingCBOR},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "valid"}, nil)
+			req := &performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: tt.payload}
+			if err := tw.ValidateTask(req); err != nil {
+				t.Fatalf("ValidateTask failed: %v", err)
+			}
+			resp, err := tw.HandleTask(req)
+			if err != nil {
+				t.Fatalf("HandleTask failed: %v", err)
+			}
+
+			var result struct {
+				Metadata struct {
+					PayloadEncoding string `json:"payload_encoding"`
+				} `json:"metadata"`
+			}
+			if err := json.Unmarshal(resp.Result, &result); err != nil {
+				t.Fatalf("failed to decode result: %v", err)
+			}
+			if result.Metadata.PayloadEncoding != tt.want {
+				t.Errorf("expected payload_encoding %q, got %q", tt.want, result.Metadata.PayloadEncoding)
+			}
+		})
+	}
+}
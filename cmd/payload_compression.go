@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Magic numbers of the supported compression formats.
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// decompressPayload inflates payloads that start with a gzip or zstd magic
+// number and returns other payloads unchanged. It fails when the inflated
+// payload would exceed limit bytes.
+func decompressPayload(data []byte, limit int) ([]byte, error) {
+	var (
+		r      io.Reader
+		format string
+	)
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip payload: %w", err)
+		}
+		defer zr.Close()
+		r, format = zr, "gzip"
+	case bytes.HasPrefix(data, zstdMagic):
+		zr, err := zstd.NewReader(bytes.NewReader(data), zstd.WithDecoderMaxMemory(uint64(limit)+1))
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd payload: %w", err)
+		}
+		defer zr.Close()
+		r, format = zr, "zstd"
+	default:
+		return data, nil
+	}
+
+	// Read one byte past the limit to detect oversized payloads without
+	// inflating them completely.
+	out, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s payload: %w", format, err)
+	}
+	if len(out) > limit {
+		return nil, fmt.Errorf("decompressed task payload exceeds maximum allowed size %d", limit)
+	}
+	return out, nil
+}
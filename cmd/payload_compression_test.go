@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"github.com/klauspost/compress/zstd"
+	"go.uber.org/zap"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("gzip write failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func zstdBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	zw, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("zstd writer failed: %v", err)
+	}
+	defer zw.Close()
+	return zw.EncodeAll(data, nil)
+}
+
+func Test_decompressPayload(t *testing.T) {
+	prompt := []byte(`{"prompt":"What is the capital of France?"}`)
+	large := []byte(strings.Repeat("a", 1024))
+
+	tests := []struct {
+		name    string
+		payload []byte
+		limit   int
+		want    []byte
+		wantErr bool
+	}{
+		{name: "uncompressed", payload: prompt, limit: 1024, want: prompt},
+		{name: "gzip", payload: gzipBytes(t, prompt), limit: 1024, want: prompt},
+		{name: "zstd", payload: zstdBytes(t, prompt), limit: 1024, want: prompt},
+		{name: "gzip over limit", payload: gzipBytes(t, large), limit: 512, wantErr: true},
+		{name: "zstd over limit", payload: zstdBytes(t, large), limit: 512, wantErr: true},
+		{name: "corrupt gzip", payload: []byte{0x1f, 0x8b, 0x00}, limit: 1024, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decompressPayload(tt.payload, tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && !bytes.Equal(got, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_ValidateTaskCompressedPayload(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "valid"}, nil)
+
+	// A document larger than the wire limit fits once compressed.
+	document := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 200)
+	payload := gzipBytes(t, []byte(`{"task_type":"summarize","document":"`+document+`"}`))
+	if len(payload) > 4096 {
+		t.Fatalf("compressed payload unexpectedly large: %d bytes", len(payload))
+	}
+
+	if err := tw.ValidateTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: payload}); err != nil {
+		t.Errorf("ValidateTask failed: %v", err)
+	}
+}
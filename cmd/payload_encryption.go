@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/ecies"
+)
+
+// eciesOverhead is the size an ECIES ciphertext adds to its plaintext on
+// secp256k1: the uncompressed ephemeral public key, the AES IV and the
+// HMAC-SHA256 tag.
+const eciesOverhead = 65 + 16 + 32
+
+// ErrEncryptedPayload is returned for encrypted payloads when the performer
+// has no decryption key.
+var ErrEncryptedPayload = errors.New("task payload is encrypted but no PAYLOAD_DECRYPTION_KEY is configured")
+
+// isEncryptedPayload reports whether data looks like an ECIES ciphertext,
+// which starts with the uncompressed ephemeral public key.
+func isEncryptedPayload(data []byte) bool {
+	return len(data) > eciesOverhead && data[0] == 0x04
+}
+
+// PayloadDecryptor decrypts payloads encrypted to the operator's secp256k1
+// public key with ECIES (AES-128-CTR and HMAC-SHA256, as implemented by
+// go-ethereum), so prompts are not readable by everyone observing the task
+// mailbox. Payloads are encrypted whole, before any other encoding such as
+// compression, and may be sent as raw bytes, hex or base64.
+type PayloadDecryptor struct {
+	key *ecies.PrivateKey
+	// required rejects payloads that are not encrypted.
+	required bool
+}
+
+// payloadDecryptorFromEnv loads the hex-encoded secp256k1 private key from
+// PAYLOAD_DECRYPTION_KEY, or any secret reference it names, and returns nil
+// when it is unset. PAYLOAD_ENCRYPTION_REQUIRED=true rejects plaintext
+// payloads.
+func payloadDecryptorFromEnv(ctx context.Context) (*PayloadDecryptor, error) {
+	hexKey, err := secretFromEnv(ctx, "PAYLOAD_DECRYPTION_KEY")
+	if err != nil {
+		return nil, err
+	}
+	if hexKey == "" {
+		return nil, nil
+	}
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PAYLOAD_DECRYPTION_KEY: %w", err)
+	}
+	d := NewPayloadDecryptor(key)
+	if v := getenv("PAYLOAD_ENCRYPTION_REQUIRED"); v != "" {
+		if d.required, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid value for PAYLOAD_ENCRYPTION_REQUIRED: %w", err)
+		}
+	}
+	return d, nil
+}
+
+func NewPayloadDecryptor(key *ecdsa.PrivateKey) *PayloadDecryptor {
+	return &PayloadDecryptor{key: ecies.ImportECDSA(key)}
+}
+
+// PublicKey returns the hex-encoded uncompressed public key task creators
+// encrypt payloads to.
+func (d *PayloadDecryptor) PublicKey() string {
+	return hexutil.Encode(crypto.FromECDSAPub(d.key.PublicKey.ExportECDSA()))
+}
+
+// Decrypt returns the plaintext of an encrypted payload and whether it was
+// encrypted. Other payloads are returned unchanged unless encryption is
+// required.
+func (d *PayloadDecryptor) Decrypt(data []byte) ([]byte, bool, error) {
+	if !isEncryptedPayload(data) {
+		if d.required {
+			return nil, false, fmt.Errorf("task payload must be encrypted")
+		}
+		return data, false, nil
+	}
+	plaintext, err := d.key.Decrypt(data, nil, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to decrypt task payload: %w", err)
+	}
+	return plaintext, true, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/ecies"
+	"go.uber.org/zap"
+)
+
+// encryptTestPayload encrypts payload to the public key of hexKey.
+func encryptTestPayload(t *testing.T, payload []byte, hexKey string) []byte {
+	t.Helper()
+	key, err := crypto.HexToECDSA(hexKey)
+	if err != nil {
+		t.Fatalf("invalid key: %v", err)
+	}
+	ct, err := ecies.Encrypt(rand.Reader, ecies.ImportECDSAPublic(&key.PublicKey), payload, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+	return ct
+}
+
+func testDecryptor(t *testing.T, required bool) *PayloadDecryptor {
+	t.Helper()
+	key, err := crypto.HexToECDSA(testSignerKey)
+	if err != nil {
+		t.Fatalf("invalid key: %v", err)
+	}
+	d := NewPayloadDecryptor(key)
+	d.required = required
+	return d
+}
+
+func Test_PayloadDecryptorDecrypt(t *testing.T) {
+	payload := []byte(`{"prompt": "What is my account balance?"}`)
+
+	tests := []struct {
+		name          string
+		required      bool
+		data          []byte
+		want          []byte
+		wantEncrypted bool
+		wantErr       bool
+	}{
+		{name: "encrypted", data: encryptTestPayload(t, payload, testSignerKey), want: payload, wantEncrypted: true},
+		{name: "plaintext", data: payload, want: payload},
+		{name: "plaintext when required", required: true, data: payload, wantErr: true},
+		{name: "other key", data: encryptTestPayload(t, payload, testOtherKey), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, encrypted, err := testDecryptor(t, tt.required).Decrypt(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if string(got) != string(tt.want) || encrypted != tt.wantEncrypted {
+				t.Errorf("expected %q (encrypted %v), got %q (encrypted %v)", tt.want, tt.wantEncrypted, got, encrypted)
+			}
+		})
+	}
+}
+
+func Test_payloadDecryptorFromEnv(t *testing.T) {
+	tests := []struct {
+		name         string
+		key          string
+		required     string
+		wantRequired bool
+		wantNil      bool
+		wantErr      bool
+	}{
+		{name: "unset", wantNil: true},
+		{name: "key", key: testSignerKey},
+		{name: "prefixed key", key: "0x" + testSignerKey, required: "true", wantRequired: true},
+		{name: "invalid key", key: "0x1234", wantErr: true},
+		{name: "invalid required", key: testSignerKey, required: "always", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PAYLOAD_DECRYPTION_KEY", tt.key)
+			t.Setenv("PAYLOAD_ENCRYPTION_REQUIRED", tt.required)
+			d, err := payloadDecryptorFromEnv(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (d == nil) != tt.wantNil {
+				t.Fatalf("expected nil decryptor %v, got %+v", tt.wantNil, d)
+			}
+			if d != nil && d.required != tt.wantRequired {
+				t.Errorf("expected required %v, got %v", tt.wantRequired, d.required)
+			}
+		})
+	}
+}
+
+func Test_PayloadDecryptorPublicKey(t *testing.T) {
+	d := testDecryptor(t, false)
+	pub, err := crypto.UnmarshalPubkey(hexutil.MustDecode(d.PublicKey()))
+	if err != nil {
+		t.Fatalf("invalid public key: %v", err)
+	}
+	if got, want := crypto.PubkeyToAddress(*pub), testKeyAddress(t, testSignerKey); got != want {
+		t.Errorf("expected the key of %s, got %s", want.Hex(), got.Hex())
+	}
+}
+
+func Test_HandleTaskEncryptedPayload(t *testing.T) {
+	ct := encryptTestPayload(t, []byte("What is 2+2?"), testSignerKey)
+
+	tests := []struct {
+		name      string
+		decryptor *PayloadDecryptor
+		payload   []byte
+		wantErr   error
+	}{
+		{name: "raw", decryptor: testDecryptor(t, false), payload: ct},
+		{name: "hex", decryptor: testDecryptor(t, false), payload: []byte(hexutil.Encode(ct))},
+		{name: "base64", decryptor: testDecryptor(t, false), payload: []byte(base64.StdEncoding.EncodeToString(ct))},
+		{name: "no key", payload: ct, wantErr: ErrEncryptedPayload},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "4"}, nil)
+			tw.SetPayloadDecryptor(tt.decryptor)
+			task := &performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: tt.payload}
+
+			if err := tw.ValidateTask(task); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			resp, err := tw.HandleTask(task)
+			if err != nil {
+				t.Fatalf("HandleTask failed: %v", err)
+			}
+			var result struct {
+				LLMOutput string `json:"llm_output"`
+			}
+			if err := json.Unmarshal(resp.Result, &result); err != nil || result.LLMOutput != "4" {
+				t.Errorf("unexpected result %s", resp.Result)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	taskV1 "github.com/Layr-Labs/hourglass-avs-template/gen/protos/avs/v1/task"
+)
+
+// ProtobufPayloadDecoder decodes payloads serialized as the avs.v1.task
+// TaskPayload message defined in protos/avs/v1/task/task.proto, so task
+// creators can build payloads from generated, strongly-typed code.
+type ProtobufPayloadDecoder struct{}
+
+// Decode converts the message to the equivalent JSON payload. Task-specific
+// fields are merged into the top level; the typed fields take precedence.
+func (d *ProtobufPayloadDecoder) Decode(raw []byte) ([]byte, error) {
+	var msg taskV1.TaskPayload
+	if err := proto.Unmarshal(raw, &msg); err != nil {
+		return nil, fmt.Errorf("invalid protobuf payload: %w", err)
+	}
+
+	fields := map[string]interface{}{}
+	for key, value := range msg.GetFields().AsMap() {
+		fields[key] = value
+	}
+	for key, value := range map[string]string{
+		"prompt":    msg.GetPrompt(),
+		"system":    msg.GetSystem(),
+		"task_type": msg.GetTaskType(),
+		"provider":  msg.GetProvider(),
+		"model":     msg.GetModel(),
+	} {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+	if params := msg.GetParams(); params != nil {
+		if params.MaxTokens > 0 {
+			fields["max_tokens"] = params.MaxTokens
+		}
+		if params.Temperature != nil {
+			fields["temperature"] = params.GetTemperature()
+		}
+	}
+	if msg.Metadata != nil {
+		fields["metadata"] = msg.GetMetadata().AsMap()
+	}
+	return json.Marshal(fields)
+}
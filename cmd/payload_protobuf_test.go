@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	taskV1 "github.com/Layr-Labs/hourglass-avs-template/gen/protos/avs/v1/task"
+)
+
+func Test_ProtobufPayloadDecoder(t *testing.T) {
+	zero := 0.0
+	fields, err := structpb.NewStruct(map[string]interface{}{"document": "A long text.", "max_words": 50, "prompt": "ignored"})
+	if err != nil {
+		t.Fatalf("NewStruct failed: %v", err)
+	}
+	metadata, err := structpb.NewStruct(map[string]interface{}{"critical": true})
+	if err != nil {
+		t.Fatalf("NewStruct failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		msg  *taskV1.TaskPayload
+		want string
+	}{
+		{
+			name: "prompt",
+			msg:  &taskV1.TaskPayload{Prompt: "hi"},
+			want: `{"prompt":"hi"}`,
+		},
+		{
+			name: "all fields",
+			msg: &taskV1.TaskPayload{
+				Prompt:   "hi",
+				System:   "be brief",
+				TaskType: "completion",
+				Params:   &taskV1.GenerationParams{MaxTokens: 32, Temperature: &zero},
+				Provider: "openai",
+				Model:    "gpt-4o",
+				Metadata: metadata,
+			},
+			want: `{"max_tokens":32,"metadata":{"critical":true},"model":"gpt-4o","prompt":"hi","provider":"openai","system":"be brief","task_type":"completion","temperature":0}`,
+		},
+		{
+			name: "task fields",
+			msg:  &taskV1.TaskPayload{TaskType: "summarize", Prompt: "summarize this", Fields: fields},
+			want: `{"document":"A long text.","max_words":50,"prompt":"summarize this","task_type":"summarize"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := proto.Marshal(tt.msg)
+			if err != nil {
+				t.Fatalf("proto.Marshal failed: %v", err)
+			}
+			got, err := (&ProtobufPayloadDecoder{}).Decode(raw)
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+
+			payload, err := ParseTaskPayload(got)
+			if err != nil {
+				t.Fatalf("ParseTaskPayload failed: %v", err)
+			}
+			if payload.Prompt != tt.msg.Prompt || payload.TaskType != tt.msg.TaskType {
+				t.Errorf("unexpected payload: %+v", payload)
+			}
+		})
+	}
+}
+
+func Test_ProtobufPayloadDecoderInvalid(t *testing.T) {
+	if _, err := (&ProtobufPayloadDecoder{}).Decode([]byte{0xff, 0xff, 0xff}); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Payload schema versions. Version 1 is the flat layout documented on
+// TaskPayload and is assumed when schema_version is absent. Version 2 groups
+// the fields by purpose:
+//
+//	{
+//	  "schema_version": 2,
+//	  "task_type":      "classify",
+//	  "input":          {"text": "...", "labels": ["a", "b"]}, // prompt, system and task fields
+//	  "options":        {"max_tokens": 32, "temperature": 0},
+//	  "model":          {"provider": "openai", "name": "gpt-4o"},
+//	  "metadata":       {"critical": true}
+//	}
+//
+// Every version is migrated to the version 1 layout before it is decoded, so
+// task handlers only ever see one format.
+const (
+	schemaVersion1 = 1
+	schemaVersion2 = 2
+)
+
+// payloadMigrations convert a payload object of the keyed schema version to
+// the version 1 layout. Supporting a new version means adding its migration.
+var payloadMigrations = map[int]func(fields map[string]json.RawMessage) (map[string]json.RawMessage, error){
+	schemaVersion1: func(fields map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+		return fields, nil
+	},
+	schemaVersion2: migratePayloadV2,
+}
+
+// supportedSchemaVersions returns the payload schema versions this performer
+// accepts, in ascending order.
+func supportedSchemaVersions() []int {
+	versions := make([]int, 0, len(payloadMigrations))
+	for v := range payloadMigrations {
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// migratePayload rewrites a JSON payload object to the version 1 layout and
+// returns the schema version it was written in. Objects without
+// schema_version are returned unchanged.
+func migratePayload(raw []byte) ([]byte, int, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, 0, err
+	}
+	versionField, ok := fields["schema_version"]
+	if !ok {
+		return raw, schemaVersion1, nil
+	}
+
+	var version int
+	if err := json.Unmarshal(versionField, &version); err != nil {
+		return nil, 0, fmt.Errorf("invalid task payload field schema_version: expected int")
+	}
+	migrate, ok := payloadMigrations[version]
+	if !ok {
+		supported := make([]string, 0, len(payloadMigrations))
+		for _, v := range supportedSchemaVersions() {
+			supported = append(supported, fmt.Sprint(v))
+		}
+		return nil, 0, fmt.Errorf("unsupported payload schema_version %d, expected one of: %s", version, strings.Join(supported, ", "))
+	}
+
+	delete(fields, "schema_version")
+	migrated, err := migrate(fields)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid schema_version %d payload: %w", version, err)
+	}
+	out, err := json.Marshal(migrated)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, version, nil
+}
+
+// migratePayloadV2 flattens the input, options and model groups of a version
+// 2 payload. Fields outside the groups are kept as they are.
+func migratePayloadV2(fields map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	out := map[string]json.RawMessage{}
+	for key, value := range fields {
+		switch key {
+		case "input", "options", "model":
+		default:
+			out[key] = value
+		}
+	}
+
+	for _, group := range []string{"input", "options"} {
+		value, ok := fields[group]
+		if !ok {
+			continue
+		}
+		var members map[string]json.RawMessage
+		if err := json.Unmarshal(value, &members); err != nil {
+			return nil, fmt.Errorf("%s must be an object", group)
+		}
+		for key, member := range members {
+			if _, dup := out[key]; dup {
+				return nil, fmt.Errorf("field %s is set more than once", key)
+			}
+			out[key] = member
+		}
+	}
+
+	if value, ok := fields["model"]; ok {
+		var model struct {
+			Provider json.RawMessage `json:"provider"`
+			Name     json.RawMessage `json:"name"`
+		}
+		if err := json.Unmarshal(value, &model); err != nil {
+			return nil, fmt.Errorf("model must be an object")
+		}
+		if model.Provider != nil {
+			out["provider"] = model.Provider
+		}
+		if model.Name != nil {
+			out["model"] = model.Name
+		}
+	}
+	return out, nil
+}
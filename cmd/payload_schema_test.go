@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_migratePayload(t *testing.T) {
+	tests := []struct {
+		name        string
+		payload     string
+		want        string
+		wantVersion int
+		wantErr     bool
+	}{
+		{
+			name:        "unversioned",
+			payload:     `{"prompt":"hi"}`,
+			want:        `{"prompt":"hi"}`,
+			wantVersion: 1,
+		},
+		{
+			name:        "version 2 task fields",
+			payload:     `{"schema_version":2,"task_type":"classify","input":{"text":"great","labels":["good","bad"]}}`,
+			want:        `{"labels":["good","bad"],"task_type":"classify","text":"great"}`,
+			wantVersion: 2,
+		},
+		{
+			name:    "version 2 duplicate field",
+			payload: `{"schema_version":2,"prompt":"hi","input":{"prompt":"hello"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "version 2 group is not an object",
+			payload: `{"schema_version":2,"input":"hi"}`,
+			wantErr: true,
+		},
+		{
+			name:    "version is not a number",
+			payload: `{"schema_version":"2","prompt":"hi"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, version, err := migratePayload([]byte(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if string(got) != tt.want || version != tt.wantVersion {
+				t.Errorf("expected %s (version %d), got %s (version %d)", tt.want, tt.wantVersion, got, version)
+			}
+		})
+	}
+}
+
+func Test_HandleTaskReportsSchemaVersion(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "valid"}, nil)
+
+	resp, err := tw.HandleTask(&performerV1.TaskRequest{
+		TaskId:  []byte("task-1"),
+		Payload: []byte(`{"schema_version":2,"input":{"prompt":"hi"},"options":{"max_tokens":16}}`),
+	})
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+
+	var result struct {
+		Metadata struct {
+			SchemaVersion int `json:"schema_version"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if result.Metadata.SchemaVersion != 2 {
+		t.Errorf("expected schema_version 2, got %d", result.Metadata.SchemaVersion)
+	}
+}
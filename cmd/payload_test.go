@@ -0,0 +1,204 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ParseTaskPayload(t *testing.T) {
+	zero := 0.0
+
+	tests := []struct {
+		name    string
+		payload string
+		want    *TaskPayload
+		wantErr bool
+	}{
+		{
+			name:    "raw prompt",
+			payload: "What is the capital of France?",
+			want:    &TaskPayload{Prompt: "What is the capital of France?"},
+		},
+		{
+			name:    "structured prompt",
+			payload: `{"prompt":"hi","provider":"openai","model":"gpt-4o"}`,
+			want:    &TaskPayload{Prompt: "hi", Provider: "openai", Model: "gpt-4o", SchemaVersion: 1},
+		},
+		{
+			name:    "full schema",
+			payload: `{"prompt":"hi","system":"be brief","max_tokens":32,"temperature":0,"task_type":"completion","metadata":{"critical":true}}`,
+			want: &TaskPayload{
+				Prompt:      "hi",
+				System:      "be brief",
+				MaxTokens:   32,
+				Temperature: &zero,
+				TaskType:    "completion",
+				Metadata:    map[string]interface{}{"critical": true},
+
+				SchemaVersion: 1,
+			},
+		},
+		{
+			name:    "task type without prompt",
+			payload: `{"task_type":"summarize","document":"..."}`,
+			want:    &TaskPayload{TaskType: "summarize", SchemaVersion: 1},
+		},
+		{
+			name:    "messages without prompt",
+			payload: `{"messages":[{"role":"user","content":"hi"}]}`,
+			want:    &TaskPayload{Messages: []ChatMessage{{Role: "user", Content: "hi"}}, SchemaVersion: 1},
+		},
+		{
+			name:    "json without prompt is a raw prompt",
+			payload: `{"question":"hi"}`,
+			want:    &TaskPayload{Prompt: `{"question":"hi"}`},
+		},
+		{
+			name:    "malformed json is a raw prompt",
+			payload: `{"prompt":`,
+			want:    &TaskPayload{Prompt: `{"prompt":`},
+		},
+		{
+			name:    "explicit schema version 1",
+			payload: `{"schema_version":1,"prompt":"hi"}`,
+			want:    &TaskPayload{Prompt: "hi", SchemaVersion: 1},
+		},
+		{
+			name:    "schema version 2",
+			payload: `{"schema_version":2,"input":{"prompt":"hi","system":"be brief"},"options":{"max_tokens":32,"temperature":0},"model":{"provider":"openai","name":"gpt-4o"},"metadata":{"critical":true}}`,
+			want: &TaskPayload{
+				Prompt:      "hi",
+				System:      "be brief",
+				MaxTokens:   32,
+				Temperature: &zero,
+				Metadata:    map[string]interface{}{"critical": true},
+				Provider:    "openai",
+				Model:       "gpt-4o",
+
+				SchemaVersion: 2,
+			},
+		},
+		{
+			name:    "unsupported schema version",
+			payload: `{"schema_version":99,"prompt":"hi"}`,
+			wantErr: true,
+		},
+		{
+			name:    "mistyped field",
+			payload: `{"prompt":"hi","max_tokens":"many"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTaskPayload([]byte(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			got.raw = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_TaskPayloadValidate(t *testing.T) {
+	hot := 2.5
+
+	tests := []struct {
+		name    string
+		payload TaskPayload
+		wantErr bool
+	}{
+		{name: "defaults", payload: TaskPayload{Prompt: "hi"}},
+		{name: "max tokens too large", payload: TaskPayload{Prompt: "hi", MaxTokens: maxTaskMaxTokens + 1}, wantErr: true},
+		{name: "temperature out of range", payload: TaskPayload{Prompt: "hi", Temperature: &hot}, wantErr: true},
+		{
+			name:    "conversation",
+			payload: TaskPayload{Messages: []ChatMessage{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "hello"}, {Role: "user", Content: "how are you?"}}},
+		},
+		{
+			name:    "conversation followed by prompt",
+			payload: TaskPayload{Prompt: "and now?", Messages: []ChatMessage{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "hello"}}},
+		},
+		{
+			name:    "unknown role",
+			payload: TaskPayload{Messages: []ChatMessage{{Role: "tool", Content: "hi"}}},
+			wantErr: true,
+		},
+		{
+			name:    "empty message",
+			payload: TaskPayload{Messages: []ChatMessage{{Role: "user", Content: " "}}},
+			wantErr: true,
+		},
+		{
+			name:    "conversation ending with assistant",
+			payload: TaskPayload{Messages: []ChatMessage{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "hello"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.payload.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_TaskPayloadCompletionRequest(t *testing.T) {
+	p := &TaskPayload{
+		Prompt:    "hi",
+		System:    "be brief",
+		MaxTokens: 16,
+		Metadata:  map[string]interface{}{"critical": true},
+	}
+
+	req := p.CompletionRequest()
+	wantMessages := []ChatMessage{{Role: "system", Content: "be brief"}, {Role: "user", Content: "hi"}}
+	if !reflect.DeepEqual(req.Messages, wantMessages) {
+		t.Errorf("unexpected messages: %+v", req.Messages)
+	}
+	if req.MaxTokens != 16 || req.Temperature != defaultTemperature || !req.Critical {
+		t.Errorf("unexpected request: %+v", req)
+	}
+}
+
+func Test_TaskPayloadCompletionRequestMessages(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload *TaskPayload
+		want    []ChatMessage
+	}{
+		{
+			name: "conversation",
+			payload: &TaskPayload{
+				System:   "be brief",
+				Messages: []ChatMessage{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "hello"}, {Role: "user", Content: "bye"}},
+			},
+			want: []ChatMessage{{Role: "system", Content: "be brief"}, {Role: "user", Content: "hi"}, {Role: "assistant", Content: "hello"}, {Role: "user", Content: "bye"}},
+		},
+		{
+			name: "conversation followed by prompt",
+			payload: &TaskPayload{
+				Prompt:   "bye",
+				Messages: []ChatMessage{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "hello"}},
+			},
+			want: []ChatMessage{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "hello"}, {Role: "user", Content: "bye"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.payload.CompletionRequest().Messages; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
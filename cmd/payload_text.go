@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// minBase64PayloadSize keeps short words, which are often valid base64, from
+// being mistaken for encoded payloads.
+const minBase64PayloadSize = 8
+
+// decodeTextPayload unwraps payloads that arrive as text-encoded bytes, as is
+// common for tasks created on-chain: "0x"-prefixed hex and standard or URL-safe
+// base64. Other payloads are returned unchanged.
+//
+// Hex and base64 are ambiguous with plain text, so a payload is only decoded
+// when it decodes to a payload the performer parses, see isStructuredPayload,
+// and base64 only when it has no whitespace and is padded to a multiple of
+// four characters. Prompts that merely look encoded, such as "QuickFox" or
+// "0xdeadbeef", are sent as written.
+func decodeTextPayload(data []byte) []byte {
+	trimmed := bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(trimmed, []byte("0x")) || bytes.HasPrefix(trimmed, []byte("0X")) {
+		decoded := make([]byte, hex.DecodedLen(len(trimmed)-2))
+		if _, err := hex.Decode(decoded, trimmed[2:]); err == nil && isStructuredPayload(decoded) {
+			return decoded
+		}
+		return data
+	}
+
+	if len(trimmed) < minBase64PayloadSize || len(trimmed)%4 != 0 {
+		return data
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding} {
+		decoded := make([]byte, enc.DecodedLen(len(trimmed)))
+		n, err := enc.Decode(decoded, trimmed)
+		if err != nil {
+			continue
+		}
+		decoded = decoded[:n]
+		if isStructuredPayload(decoded) {
+			return decoded
+		}
+	}
+	return data
+}
+
+// isStructuredPayload reports whether decoded text-encoded bytes are a
+// payload the performer parses rather than a prompt: a JSON object, a CBOR
+// map, or a compressed or encrypted payload.
+func isStructuredPayload(data []byte) bool {
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("{")) {
+		return json.Valid(trimmed)
+	}
+	if isCBORPayload(data) {
+		_, err := cborToJSON(data)
+		return err == nil
+	}
+	return bytes.HasPrefix(data, gzipMagic) || bytes.HasPrefix(data, zstdMagic) || isEncryptedPayload(data)
+}
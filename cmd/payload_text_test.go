@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_decodeTextPayload(t *testing.T) {
+	prompt := `{"prompt":"What is the capital of France?"}`
+	cborPrompt := string(cborBytes(t, map[string]interface{}{"prompt": "hi"}))
+
+	tests := []struct {
+		name    string
+		payload []byte
+		want    string
+	}{
+		{name: "plain json", payload: []byte(prompt), want: prompt},
+		{name: "plain prompt", payload: []byte("What is 2+2?"), want: "What is 2+2?"},
+		{name: "hex", payload: []byte("0x" + hex.EncodeToString([]byte(prompt))), want: prompt},
+		{name: "uppercase hex prefix", payload: []byte("0X" + hex.EncodeToString([]byte(prompt))), want: prompt},
+		{name: "invalid hex is a prompt", payload: []byte("0x is a prefix"), want: "0x is a prefix"},
+		{name: "base64", payload: []byte(base64.StdEncoding.EncodeToString([]byte(prompt))), want: prompt},
+		{name: "url-safe base64", payload: []byte(base64.URLEncoding.EncodeToString([]byte(`{"prompt":"<<???>>"}`))), want: `{"prompt":"<<???>>"}`},
+		{name: "short word", payload: []byte("test"), want: "test"},
+		{name: "base64 alphabet decoding to binary", payload: []byte("ABCDEFGH"), want: "ABCDEFGH"},
+		{name: "word that is valid base64", payload: []byte("QuickFox"), want: "QuickFox"},
+		{name: "base64 of plain text", payload: []byte(base64.StdEncoding.EncodeToString([]byte("What is 2+2?"))), want: base64.StdEncoding.EncodeToString([]byte("What is 2+2?"))},
+		{name: "hex cbor", payload: []byte("0x" + hex.EncodeToString([]byte(cborPrompt))), want: cborPrompt},
+		{name: "hex word", payload: []byte("0xdeadbeef"), want: "0xdeadbeef"},
+		{name: "hex of plain text", payload: []byte("0x" + hex.EncodeToString([]byte("What is 2+2?"))), want: "0x" + hex.EncodeToString([]byte("What is 2+2?"))},
+		{name: "hex of invalid json", payload: []byte("0x" + hex.EncodeToString([]byte("{not json"))), want: "0x" + hex.EncodeToString([]byte("{not json"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(decodeTextPayload(tt.payload)); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_ValidateTaskHexPayload(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "valid"}, nil)
+
+	// Hex-encoded JSON used to be sent to the model as a raw prompt.
+	payload := []byte("0x" + hex.EncodeToString([]byte(`{"prompt":"Qu'est-ce que c'est ?"}`)))
+	if err := tw.ValidateTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: payload}); err != nil {
+		t.Errorf("ValidateTask failed: %v", err)
+	}
+
+	gzipped := []byte(base64.StdEncoding.EncodeToString(gzipBytes(t, []byte(`{"prompt":"hi"}`))))
+	if err := tw.ValidateTask(&performerV1.TaskRequest{TaskId: []byte("task-2"), Payload: gzipped}); err != nil {
+		t.Errorf("ValidateTask failed for base64 gzip payload: %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kinds of personal data the redactor detects.
+const (
+	piiKey     = "key"
+	piiEmail   = "email"
+	piiAddress = "address"
+	piiPhone   = "phone"
+)
+
+// secretKeyPattern matches private keys and API keys: PEM private key blocks,
+// AWS access key IDs, OpenAI-style, GitHub and Slack tokens, and 32-byte hex
+// strings such as Ethereum private keys.
+var secretKeyPattern = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----` +
+	`|\bAKIA[0-9A-Z]{16}\b|\bsk-[A-Za-z0-9_-]{20,}|\bgh[pousr]_[A-Za-z0-9]{36,}\b|\bxox[abprs]-[A-Za-z0-9-]{10,}|\b0x[0-9a-fA-F]{64}\b`)
+
+// piiPatterns are applied in order, so that e.g. the digits of a key are not
+// taken for a phone number.
+var piiPatterns = []struct {
+	kind string
+	re   *regexp.Regexp
+}{
+	{piiKey, secretKeyPattern},
+	{piiEmail, regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	{piiAddress, regexp.MustCompile(`\b\d{1,5}\s+(?:[A-Z][a-z]+\s+){1,4}(?:Street|St|Avenue|Ave|Road|Rd|Boulevard|Blvd|Lane|Ln|Drive|Dr|Court|Ct|Way|Place|Pl)\b\.?`)},
+	{piiPhone, regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{2,4}\)[ .-]?|\b\d{2,4}[ .-])\d{3,4}[ .-]?\d{3,4}\b`)},
+}
+
+// PIIRedactor masks personal data and credentials in prompts before they
+// leave the performer for a third-party provider.
+type PIIRedactor struct {
+	kinds map[string]bool
+	// restore puts the masked values back into the output.
+	restore bool
+}
+
+// piiRedactorFromEnv configures redaction from PII_REDACTION, a
+// comma-separated list of the kinds to mask (email, phone, address, key) or
+// "all", and returns nil when it is unset. PII_RESTORE=false leaves the
+// placeholders in the output instead of the original values.
+func piiRedactorFromEnv() (*PIIRedactor, error) {
+	v := strings.TrimSpace(getenv("PII_REDACTION"))
+	if v == "" {
+		return nil, nil
+	}
+	r := &PIIRedactor{kinds: map[string]bool{}, restore: true}
+	for _, kind := range strings.Split(v, ",") {
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		if kind == "all" {
+			for _, p := range piiPatterns {
+				r.kinds[p.kind] = true
+			}
+			continue
+		}
+		if !isPIIKind(kind) {
+			return nil, fmt.Errorf("unsupported PII kind %q, expected one of: all, %s, %s, %s, %s", kind, piiEmail, piiPhone, piiAddress, piiKey)
+		}
+		r.kinds[kind] = true
+	}
+	if s := getenv("PII_RESTORE"); s != "" {
+		var err error
+		if r.restore, err = strconv.ParseBool(s); err != nil {
+			return nil, fmt.Errorf("invalid value for PII_RESTORE: %w", err)
+		}
+	}
+	return r, nil
+}
+
+func isPIIKind(kind string) bool {
+	for _, p := range piiPatterns {
+		if p.kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// redactions maps the placeholders of one request to the values they mask.
+// It never leaves the performer.
+type redactions struct {
+	placeholders map[string]string
+	values       map[string]string
+	counts       map[string]int
+}
+
+func newRedactions() *redactions {
+	return &redactions{placeholders: map[string]string{}, values: map[string]string{}, counts: map[string]int{}}
+}
+
+// Redact replaces the personal data in text with placeholders such as
+// [EMAIL_1]. The same value gets the same placeholder across the request.
+func (r *PIIRedactor) Redact(text string, m *redactions) string {
+	for _, p := range piiPatterns {
+		if !r.kinds[p.kind] {
+			continue
+		}
+		text = p.re.ReplaceAllStringFunc(text, func(value string) string {
+			if ph, ok := m.placeholders[value]; ok {
+				return ph
+			}
+			m.counts[p.kind]++
+			ph := fmt.Sprintf("[%s_%d]", strings.ToUpper(p.kind), m.counts[p.kind])
+			m.placeholders[value] = ph
+			m.values[ph] = value
+			return ph
+		})
+	}
+	return text
+}
+
+// Restore puts the masked values back in place of their placeholders.
+func (m *redactions) Restore(text string) string {
+	if len(m.values) == 0 {
+		return text
+	}
+	pairs := make([]string, 0, 2*len(m.values))
+	for ph, value := range m.values {
+		pairs = append(pairs, ph, value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// RedactingProvider masks personal data in the messages of every request and
+// restores it in the output, so the provider never sees it.
+type RedactingProvider struct {
+	Provider
+	redactor *PIIRedactor
+}
+
+func NewRedactingProvider(p Provider, redactor *PIIRedactor) *RedactingProvider {
+	return &RedactingProvider{Provider: p, redactor: redactor}
+}
+
+func (p *RedactingProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	m := newRedactions()
+	redacted := *req
+	redacted.Messages = make([]ChatMessage, len(req.Messages))
+	for i, msg := range req.Messages {
+		redacted.Messages[i] = ChatMessage{Role: msg.Role, Content: p.redactor.Redact(msg.Content, m)}
+	}
+	for kind, n := range m.counts {
+		piiRedactions.WithLabelValues(kind).Add(float64(n))
+	}
+
+	resp, err := p.Provider.Complete(ctx, &redacted)
+	if err != nil || !p.redactor.restore {
+		return resp, err
+	}
+	restored := *resp
+	restored.Output = m.Restore(resp.Output)
+	return &restored, nil
+}
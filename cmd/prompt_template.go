@@ -0,0 +1,132 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// builtinTemplates are the prompt templates shipped with the performer.
+//
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// templateFileName matches template files named <name>.v<version>.tmpl.
+var templateFileName = regexp.MustCompile(`^([a-z0-9_-]+)\.v([0-9]+)\.tmpl$`)
+
+// PromptTemplates renders versioned text/template prompts, so the prompt logic
+// of a task is controlled by the AVS and task payloads only supply variables:
+//
+//	{"template": "qa", "template_version": 1, "variables": {"context": "...", "question": "..."}}
+//
+// A template renders the user prompt and may define a "system" template for
+// the system prompt.
+type PromptTemplates struct {
+	templates map[string]*template.Template
+	// hashes are the keccak256 hashes of the template sources.
+	hashes map[string]string
+}
+
+// NewPromptTemplates loads the built-in templates and, when dir is set, the
+// templates in dir, which take precedence.
+func NewPromptTemplates(dir string) (*PromptTemplates, error) {
+	pt := &PromptTemplates{templates: map[string]*template.Template{}, hashes: map[string]string{}}
+	if err := pt.load(builtinTemplates, "templates"); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := pt.load(os.DirFS(dir), "."); err != nil {
+			return nil, err
+		}
+	}
+	return pt, nil
+}
+
+// mustBuiltinPromptTemplates returns the built-in templates, which are checked
+// by the tests and cannot fail to load.
+func mustBuiltinPromptTemplates() *PromptTemplates {
+	pt, err := NewPromptTemplates("")
+	if err != nil {
+		panic(err)
+	}
+	return pt
+}
+
+func (pt *PromptTemplates) load(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("failed to read prompt templates: %w", err)
+	}
+	for _, entry := range entries {
+		match := templateFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read prompt template %s: %w", entry.Name(), err)
+		}
+		version, _ := strconv.Atoi(match[2])
+		id := templateID(match[1], version)
+		tmpl, err := template.New(id).Option("missingkey=error").Parse(string(data))
+		if err != nil {
+			return fmt.Errorf("invalid prompt template %s: %w", entry.Name(), err)
+		}
+		pt.templates[id] = tmpl
+		pt.hashes[id] = crypto.Keccak256Hash(data).Hex()
+	}
+	return nil
+}
+
+// Hash returns the keccak256 hash of the source of a template, or "" when
+// there is no such template.
+func (pt *PromptTemplates) Hash(name string, version int) string {
+	return pt.hashes[templateID(name, version)]
+}
+
+func templateID(name string, version int) string {
+	return fmt.Sprintf("%s@%d", name, version)
+}
+
+// Render sets the prompt and system prompt of a payload that names a template.
+// Payloads without a template are left unchanged.
+func (pt *PromptTemplates) Render(p *TaskPayload) error {
+	if p.Template == "" {
+		return nil
+	}
+	if p.Prompt != "" || p.System != "" {
+		return fmt.Errorf("prompt and system cannot be combined with a template")
+	}
+	if p.TemplateVersion < 1 {
+		return fmt.Errorf("template_version is required with template %q", p.Template)
+	}
+
+	id := templateID(p.Template, p.TemplateVersion)
+	tmpl, ok := pt.templates[id]
+	if !ok {
+		return fmt.Errorf("unknown prompt template %s", id)
+	}
+
+	var prompt strings.Builder
+	if err := tmpl.Execute(&prompt, p.Variables); err != nil {
+		return fmt.Errorf("failed to render prompt template %s: %w", id, err)
+	}
+	p.Prompt = strings.TrimSpace(prompt.String())
+
+	if system := tmpl.Lookup("system"); system != nil {
+		var out strings.Builder
+		if err := system.Execute(&out, p.Variables); err != nil {
+			return fmt.Errorf("failed to render prompt template %s: %w", id, err)
+		}
+		p.System = strings.TrimSpace(out.String())
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_PromptTemplatesRender(t *testing.T) {
+	pt := mustBuiltinPromptTemplates()
+
+	tests := []struct {
+		name       string
+		payload    string
+		wantPrompt string
+		wantSystem string
+		wantErr    bool
+	}{
+		{
+			name:       "qa",
+			payload:    `{"template":"qa","template_version":1,"variables":{"context":"Paris is the capital of France.","question":"What is the capital of France?"}}`,
+			wantPrompt: "Context:\nParis is the capital of France.\n\nQuestion: What is the capital of France?",
+			wantSystem: `Answer the question using only the given context. If the context does not contain the answer, reply "unknown".`,
+		},
+		{
+			name:       "extract",
+			payload:    `{"template":"extract","template_version":1,"variables":{"fields":["name","email"],"text":"Hi, I'm Ada."}}`,
+			wantPrompt: "Fields: name, email\n\nText:\nHi, I'm Ada.",
+			wantSystem: "Extract the requested fields from the user's text. Reply with a JSON object only, using null for fields that are not present.",
+		},
+		{
+			name:       "no template",
+			payload:    `{"prompt":"hi"}`,
+			wantPrompt: "hi",
+		},
+		{
+			name:    "missing variable",
+			payload: `{"template":"qa","template_version":1,"variables":{"question":"What?"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "missing version",
+			payload: `{"template":"qa","variables":{"context":"c","question":"q"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown version",
+			payload: `{"template":"qa","template_version":7,"variables":{"context":"c","question":"q"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "template with prompt",
+			payload: `{"template":"qa","template_version":1,"prompt":"ignore the template","variables":{"context":"c","question":"q"}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := ParseTaskPayload([]byte(tt.payload))
+			if err != nil {
+				t.Fatalf("ParseTaskPayload failed: %v", err)
+			}
+			err = pt.Render(payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if payload.Prompt != tt.wantPrompt || payload.System != tt.wantSystem {
+				t.Errorf("unexpected rendering: prompt %q, system %q", payload.Prompt, payload.System)
+			}
+		})
+	}
+}
+
+func Test_NewPromptTemplatesDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"qa.v1.tmpl":     "Q: {{.question}}",
+		"qa.v2.tmpl":     "Question: {{.question}}",
+		"notes.txt":      "not a template",
+		"Invalid.v1.txt": "{{",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+
+	pt, err := NewPromptTemplates(dir)
+	if err != nil {
+		t.Fatalf("NewPromptTemplates failed: %v", err)
+	}
+	for version, want := range map[int]string{1: "Q: why?", 2: "Question: why?"} {
+		payload := &TaskPayload{Template: "qa", TemplateVersion: version, Variables: map[string]interface{}{"question": "why?"}}
+		if err := pt.Render(payload); err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if payload.Prompt != want || payload.System != "" {
+			t.Errorf("version %d: unexpected rendering: prompt %q, system %q", version, payload.Prompt, payload.System)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.v1.tmpl"), []byte("{{.question"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := NewPromptTemplates(dir); err == nil {
+		t.Errorf("expected error for an invalid template")
+	}
+
+	if pt.Hash("qa", 1) == "" || pt.Hash("qa", 1) == pt.Hash("qa", 2) {
+		t.Errorf("expected distinct hashes, got %q and %q", pt.Hash("qa", 1), pt.Hash("qa", 2))
+	}
+	if hash := pt.Hash("qa", 3); hash != "" {
+		t.Errorf("expected no hash for an unknown template, got %q", hash)
+	}
+}
+
+func Test_HandleTaskReportsTemplate(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "valid"}, nil)
+	req := &performerV1.TaskRequest{
+		TaskId:  []byte("task-1"),
+		Payload: []byte(`{"template":"qa","template_version":1,"variables":{"context":"Paris is the capital of France.","question":"What is the capital of France?"}}`),
+	}
+	if err := tw.ValidateTask(req); err != nil {
+		t.Fatalf("ValidateTask failed: %v", err)
+	}
+	resp, err := tw.HandleTask(req)
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+
+	var result struct {
+		Metadata struct {
+			Template string `json:"template"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if result.Metadata.Template != "qa@1" {
+		t.Errorf("expected template qa@1, got %q", result.Metadata.Template)
+	}
+}
@@ -0,0 +1,661 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultMaxTokens       = 64
+	defaultTemperature     = 0.2
+	defaultProviderTimeout = 10 * time.Second
+)
+
+// Provider is an LLM backend that the TaskWorker sends prompts to. Each supported
+// vendor (Azure OpenAI, OpenAI, ...) has its own implementation that translates the
+// request into the vendor's wire format and maps the response back.
+type Provider interface {
+	// Name returns the short identifier of the provider, e.g. "azure" or "openai".
+	Name() string
+
+	// Complete runs a chat completion and returns the generated output.
+	Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error)
+}
+
+// ReadinessProber is implemented by providers that can check, before any task is
+// served, that their backend is reachable and able to serve the configured model.
+type ReadinessProber interface {
+	Probe(ctx context.Context) error
+}
+
+// ChatMessage is a single role/content pair of a chat conversation.
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// CompletionRequest is the provider-agnostic description of a chat completion.
+type CompletionRequest struct {
+	Messages    []ChatMessage
+	MaxTokens   int
+	Temperature float64
+
+	// Model overrides the provider's configured model. It is set by the
+	// operator's own wrappers, such as ModelSelector or a cost cap downgrade;
+	// an empty Model means the configured model is used.
+	Model string
+
+	// RequestedModel and RequestedProvider carry the model and provider
+	// requested by the task. Only ModelSelector honours them, within the
+	// operator's allowlist; every other provider ignores them.
+	RequestedModel    string
+	RequestedProvider string
+
+	// Critical marks requests that should be served by the premium route when
+	// cost-based routing is enabled.
+	Critical bool
+
+	// Stream asks providers that support it to stream the output, so that
+	// cancelling ctx stops a long generation mid-way. Providers without
+	// streaming support ignore it.
+	Stream bool
+
+	// Examples are few-shot example turns inserted between the system prompt
+	// and the conversation, see withFewShotExamples.
+	Examples []ChatMessage
+
+	// Seed asks providers that support it to sample reproducibly. Zero
+	// leaves sampling unseeded.
+	Seed int64
+	// PinModel sends model aliases as the snapshot they are pinned to, see
+	// pinnedModel.
+	PinModel bool
+}
+
+// modelOr returns Model, or def when it is empty, pinned to its snapshot when PinModel is set.
+func (r *CompletionRequest) modelOr(def string) string {
+	model := def
+	if r.Model != "" {
+		model = r.Model
+	}
+	if r.PinModel {
+		return pinnedModel(model)
+	}
+	return model
+}
+
+// CompletionParameters are the model and generation parameters a provider
+// was sent, after every wrapper adjusted the request. They are attested in
+// the results of tasks, see Attestation.
+type CompletionParameters struct {
+	// Model is the model as requested from the provider, which may differ
+	// from the model the provider reports having served.
+	Model       string  `json:"model,omitempty"`
+	MaxTokens   int     `json:"max_tokens"`
+	Temperature float64 `json:"temperature"`
+	Seed        int64   `json:"seed,omitempty"`
+	// TopP, TopK and RepetitionPenalty are set by the providers configured
+	// with them.
+	TopP              float64 `json:"top_p,omitempty"`
+	TopK              int     `json:"top_k,omitempty"`
+	RepetitionPenalty float64 `json:"repetition_penalty,omitempty"`
+}
+
+// parameters returns the parameters of r as sent to a provider for model.
+func (r *CompletionRequest) parameters(model string) *CompletionParameters {
+	return &CompletionParameters{
+		Model:       model,
+		MaxTokens:   r.MaxTokens,
+		Temperature: r.Temperature,
+		Seed:        r.Seed,
+	}
+}
+
+// CompletionResponse is the provider-agnostic result of a chat completion.
+type CompletionResponse struct {
+	Output string
+	Model  string
+	// Provider names the provider that served the request when it differs from
+	// the one the TaskWorker called, e.g. behind a failover chain.
+	Provider string
+
+	// Refused is set when the provider declined to answer, e.g. because of a
+	// safety filter. RefusalReason carries the provider's reason code.
+	Refused       bool
+	RefusalReason string
+
+	// Agreement is set by the ensemble provider and records whether enough
+	// members produced the same output.
+	Agreement *EnsembleAgreement
+
+	// TokensIn and TokensOut are the prompt and output token counts reported
+	// by the provider, or zero when it does not report usage.
+	TokensIn  int
+	TokensOut int
+	// Coalesced is set on the copies of a response handed to the requests
+	// that waited for an identical one, see CoalescingProvider. Their usage
+	// was spent by that request and is not charged again.
+	Coalesced bool
+
+	// APIVersion is the version of the provider API that served the request,
+	// for the providers with a versioned API.
+	APIVersion string
+	// Parameters are the parameters the provider was sent, or nil for
+	// responses not produced by a provider.
+	Parameters *CompletionParameters
+}
+
+// NewProviderFromEnv builds the provider selected by the environment:
+//   - LLM_ENSEMBLE takes a comma-separated list of providers that are queried
+//     concurrently and must reach ENSEMBLE_QUORUM agreement;
+//   - LLM_PROVIDERS takes a comma-separated, ordered list of providers that are
+//     chained for failover;
+//   - LLM_ROUTES takes a cost table of provider:cost_per_1k:max_prompt_tokens
+//     entries used to pick a provider per task;
+//   - otherwise the single provider named by LLM_PROVIDER is used.
+//
+// Azure OpenAI is the default when none is set to stay compatible with existing
+// deployments. In dev mode, DEV_MODE or -dev, the mock provider serves every
+// task instead, see MockProvider.
+//
+// When MODEL_ALLOWLIST is set, tasks may additionally request any provider:model
+// pair it lists.
+//
+// When SECRETS_REFRESH_INTERVAL is set, the secrets the providers were built
+// with are checked at that interval and the providers are rebuilt when one of
+// them is rotated, see RotatingProvider.
+func NewProviderFromEnv(ctx context.Context, logger *zap.Logger) (Provider, error) {
+	transportCfg, err := transportConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	providerTransport = &correlationTransport{base: &tracingTransport{base: newHTTPTransport(transportCfg)}}
+	if providerTimeout, err = providerTimeoutFromEnv(); err != nil {
+		return nil, err
+	}
+
+	interval, err := envDuration("SECRETS_REFRESH_INTERVAL")
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		return buildProviderFromEnv(ctx, logger)
+	}
+	store, err := defaultSecretStore()
+	if err != nil {
+		return nil, err
+	}
+	return NewRotatingProvider(ctx, logger, store, interval, func(ctx context.Context) (Provider, error) {
+		return buildProviderFromEnv(ctx, logger)
+	})
+}
+
+// buildProviderFromEnv builds the provider stack described by
+// NewProviderFromEnv.
+func buildProviderFromEnv(ctx context.Context, logger *zap.Logger) (Provider, error) {
+	p, err := newBaseProviderFromEnv(ctx, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	allowlistEnv := strings.TrimSpace(getenv("MODEL_ALLOWLIST"))
+	if allowlistEnv == "" {
+		return p, nil
+	}
+	allowlist, err := parseModelAllowlist(allowlistEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	providers := map[string]Provider{}
+	for _, name := range allowlist.Providers() {
+		if name == p.Name() {
+			continue
+		}
+		selectable, err := newProviderFromEnv(ctx, logger, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure allowlisted provider %s: %w", name, err)
+		}
+		providers[name] = selectable
+	}
+	return NewModelSelector(logger, p, providers, allowlist), nil
+}
+
+func newBaseProviderFromEnv(ctx context.Context, logger *zap.Logger) (Provider, error) {
+	dev, err := devModeFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if dev {
+		return newNamedProviderFromEnv(ctx, "mock")
+	}
+	ensemble := strings.TrimSpace(getenv("LLM_ENSEMBLE"))
+	chain := strings.TrimSpace(getenv("LLM_PROVIDERS"))
+	routes := strings.TrimSpace(getenv("LLM_ROUTES"))
+
+	modes := 0
+	for _, v := range []string{ensemble, chain, routes} {
+		if v != "" {
+			modes++
+		}
+	}
+
+	switch {
+	case modes > 1:
+		return nil, fmt.Errorf("only one of LLM_ENSEMBLE, LLM_PROVIDERS and LLM_ROUTES can be set")
+	case routes != "":
+		specs, err := parseRouteSpecs(routes)
+		if err != nil {
+			return nil, err
+		}
+		var resolved []Route
+		for _, spec := range specs {
+			p, err := newProviderFromEnv(ctx, logger, spec.Provider)
+			if err != nil {
+				return nil, fmt.Errorf("failed to configure provider %s: %w", spec.Provider, err)
+			}
+			resolved = append(resolved, Route{
+				Provider:        p,
+				CostPer1KTokens: spec.CostPer1KTokens,
+				MaxPromptTokens: spec.MaxPromptTokens,
+			})
+		}
+		router, err := NewRouterProvider(logger, resolved...)
+		if err != nil {
+			return nil, err
+		}
+		currentRouter.Store(router)
+		return router, nil
+	case ensemble != "":
+		providers, err := newProvidersFromEnv(ctx, logger, ensemble)
+		if err != nil {
+			return nil, err
+		}
+		cfg, err := ensembleConfigFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return NewEnsembleProvider(logger, cfg, providers...)
+	case chain != "":
+		providers, err := newProvidersFromEnv(ctx, logger, chain)
+		if err != nil {
+			return nil, err
+		}
+		if len(providers) == 1 {
+			return providers[0], nil
+		}
+		return NewFailoverProvider(logger, providerTimeout, providers...)
+	default:
+		return newProviderFromEnv(ctx, logger, getenv("LLM_PROVIDER"))
+	}
+}
+
+// newProvidersFromEnv builds every provider of a comma-separated list.
+func newProvidersFromEnv(ctx context.Context, logger *zap.Logger, names string) ([]Provider, error) {
+	var providers []Provider
+	for _, name := range strings.Split(names, ",") {
+		p, err := newProviderFromEnv(ctx, logger, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure provider %s: %w", strings.TrimSpace(name), err)
+		}
+		providers = append(providers, p)
+	}
+	return providers, nil
+}
+
+// newProviderFromEnv builds a single named provider, checks its readiness when
+// the provider supports probing and wraps it with request batching (when
+// enabled and supported), latency metrics, retries and a circuit breaker whose
+// health checks run until ctx is cancelled.
+func newProviderFromEnv(ctx context.Context, logger *zap.Logger, name string) (Provider, error) {
+	p, err := newNamedProviderFromEnv(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	prober, _ := p.(ReadinessProber)
+	if prober != nil {
+		probeCtx, cancel := context.WithTimeout(ctx, providerTimeout)
+		err := prober.Probe(probeCtx)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("%s is not ready: %w", p.Name(), err)
+		}
+	}
+
+	retryCfg, err := retryConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	breakerCfg, err := circuitBreakerConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	batchCfg, err := batchConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if batcher, ok := p.(BatchCompleter); ok && batchCfg.Window > 0 {
+		p = NewBatchingProvider(logger, batchCfg, p, batcher)
+	}
+
+	retrying := NewRetryProvider(logger, retryCfg, withMetrics(p))
+	breaker := NewCircuitBreaker(logger, breakerCfg, retrying, prober)
+	breaker.Start(ctx)
+	registerProviderBreaker(breaker)
+	return breaker, nil
+}
+
+func newNamedProviderFromEnv(ctx context.Context, name string) (Provider, error) {
+	switch name := strings.ToLower(strings.TrimSpace(name)); name {
+	case "", "azure":
+		cfg, err := azureConfigFromEnv(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return NewAzureProvider(cfg)
+	case "openai":
+		cfg, err := openAIConfigFromEnv(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return NewOpenAIProvider(cfg)
+	case "anthropic":
+		cfg, err := anthropicConfigFromEnv(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return NewAnthropicProvider(cfg)
+	case "bedrock":
+		cfg, err := bedrockConfigFromEnv(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return NewBedrockProvider(ctx, cfg)
+	case "vertex":
+		return NewVertexProvider(ctx, vertexConfigFromEnv())
+	case "ollama":
+		return NewOllamaProvider(ollamaConfigFromEnv())
+	case "openai-compatible":
+		cfg, err := openAICompatibleConfigFromEnv(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return NewOpenAICompatibleProvider(cfg)
+	case "mock":
+		cfg, err := mockConfigFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return NewMockProvider(cfg), nil
+	case "tgi":
+		cfg, err := tgiConfigFromEnv(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return NewTGIProvider(cfg)
+	case "groq":
+		cfg, err := groqConfigFromEnv(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return NewGroqProvider(cfg)
+	case "openrouter":
+		cfg, err := openRouterConfigFromEnv(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return NewOpenRouterProvider(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported LLM provider: %s", name)
+	}
+}
+
+// envFloat parses an optional floating point environment variable, returning 0
+// when it is unset.
+func envFloat(key string) (float64, error) {
+	v := getenv(key)
+	if v == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	return f, nil
+}
+
+// envInt parses an optional integer environment variable, returning 0 when it
+// is unset.
+func envInt(key string) (int, error) {
+	v := getenv(key)
+	if v == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	return i, nil
+}
+
+// envDuration parses an optional duration environment variable such as "30s",
+// returning 0 when it is unset.
+func envDuration(key string) (time.Duration, error) {
+	v := getenv(key)
+	if v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	return d, nil
+}
+
+// ProviderError is returned when a provider answers with a non-2xx status code.
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+	// RetryAfter is the delay requested by the provider's Retry-After header,
+	// or zero when none was sent.
+	RetryAfter time.Duration
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s returned status %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// doJSONRequest POSTs body as JSON to url and decodes the JSON response into out.
+func doJSONRequest(ctx context.Context, client *http.Client, provider, url string, headers map[string]string, body, out interface{}) error {
+	resp, err := postJSON(ctx, client, provider, url, headers, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", provider, err)
+	}
+	return nil
+}
+
+// postJSON POSTs body as JSON to url. Non-2xx responses are returned as a
+// ProviderError; otherwise the caller must close the response body.
+func postJSON(ctx context.Context, client *http.Client, provider, url string, headers map[string]string, body interface{}) (*http.Response, error) {
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, &ProviderError{
+			Provider:   provider,
+			StatusCode: resp.StatusCode,
+			Body:       strings.TrimSpace(string(respBody)),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+	return resp, nil
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an
+// HTTP date. It returns zero for a missing or malformed header.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// probeHTTP sends a GET request to url and reports whether the server answered
+// with a 2xx status. It is used by providers whose API exposes a cheap,
+// authenticated listing endpoint such as /models.
+func probeHTTP(ctx context.Context, client *http.Client, url string, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+// chatCompletionRequest is the request body of the OpenAI chat completions API,
+// which Azure OpenAI and most self-hosted gateways also speak.
+type chatCompletionRequest struct {
+	Model       string        `json:"model,omitempty"`
+	Messages    []ChatMessage `json:"messages"`
+	MaxTokens   int           `json:"max_tokens"`
+	Temperature float64       `json:"temperature"`
+	Seed        int64         `json:"seed,omitempty"`
+	Stream      bool          `json:"stream,omitempty"`
+	// StreamOptions asks streaming servers to report token usage in a final
+	// event.
+	StreamOptions *chatStreamOptions `json:"stream_options,omitempty"`
+}
+
+type chatStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// chatCompletionUsage is the token usage block of OpenAI chat completion
+// responses and of the final event of a stream.
+type chatCompletionUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// chatCompletionResponse is the subset of the OpenAI chat completions response
+// that the performer consumes.
+type chatCompletionResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage chatCompletionUsage `json:"usage"`
+}
+
+func newChatCompletionRequest(model string, req *CompletionRequest) *chatCompletionRequest {
+	r := &chatCompletionRequest{
+		Model:       model,
+		Messages:    req.Messages,
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+		Seed:        req.Seed,
+		Stream:      req.Stream,
+	}
+	if req.Stream {
+		r.StreamOptions = &chatStreamOptions{IncludeUsage: true}
+	}
+	return r
+}
+
+// completeChat runs a chat completion against an OpenAI-compatible
+// /chat/completions endpoint.
+func completeChat(ctx context.Context, client *http.Client, provider, url string, headers map[string]string, model string, req *CompletionRequest) (*CompletionResponse, error) {
+	var completion *CompletionResponse
+	if req.Stream {
+		var err error
+		if completion, err = streamChat(ctx, client, provider, url, headers, newChatCompletionRequest(model, req)); err != nil {
+			return nil, err
+		}
+	} else {
+		var resp chatCompletionResponse
+		if err := doJSONRequest(ctx, client, provider, url, headers, newChatCompletionRequest(model, req), &resp); err != nil {
+			return nil, err
+		}
+		completion = resp.toCompletionResponse()
+	}
+	completion.Parameters = req.parameters(model)
+	return completion, nil
+}
+
+func (r *chatCompletionResponse) toCompletionResponse() *CompletionResponse {
+	output := ""
+	if len(r.Choices) > 0 {
+		output = r.Choices[0].Message.Content
+	}
+	return &CompletionResponse{
+		Output:    output,
+		Model:     r.Model,
+		TokensIn:  r.Usage.PromptTokens,
+		TokensOut: r.Usage.CompletionTokens,
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultAnthropicBaseURL = "https://api.anthropic.com"
+	defaultAnthropicModel   = "claude-3-5-haiku-latest"
+	defaultAnthropicVersion = "2023-06-01"
+)
+
+// AnthropicConfig configures the Anthropic Claude provider.
+type AnthropicConfig struct {
+	// APIKey is sent in the x-api-key header.
+	APIKey string
+	// Model is the Claude model name, e.g. "claude-3-5-haiku-latest".
+	Model string
+	// Version is sent in the anthropic-version header.
+	Version string
+	// BaseURL overrides the default https://api.anthropic.com.
+	BaseURL string
+}
+
+func anthropicConfigFromEnv(ctx context.Context) (*AnthropicConfig, error) {
+	apiKey, err := secretFromEnv(ctx, "ANTHROPIC_API_KEY")
+	if err != nil {
+		return nil, err
+	}
+	return &AnthropicConfig{
+		APIKey:  apiKey,
+		Model:   getenv("ANTHROPIC_MODEL"),
+		Version: getenv("ANTHROPIC_VERSION"),
+		BaseURL: getenv("ANTHROPIC_BASE_URL"),
+	}, nil
+}
+
+// AnthropicProvider sends chat completions to the Anthropic Messages API.
+type AnthropicProvider struct {
+	config *AnthropicConfig
+	client *http.Client
+}
+
+func NewAnthropicProvider(cfg *AnthropicConfig) (*AnthropicProvider, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("Anthropic API key not set")
+	}
+	if cfg.Model == "" {
+		cfg.Model = defaultAnthropicModel
+	}
+	if cfg.Version == "" {
+		cfg.Version = defaultAnthropicVersion
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultAnthropicBaseURL
+	}
+	if !strings.HasPrefix(cfg.BaseURL, "https://") {
+		return nil, fmt.Errorf("Anthropic base URL must use HTTPS")
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	return &AnthropicProvider{
+		config: cfg,
+		client: newHTTPClient(),
+	}, nil
+}
+
+func (p *AnthropicProvider) Name() string {
+	return "anthropic"
+}
+
+// anthropicMessagesRequest is the request body of the Messages API. System
+// instructions are a top-level field rather than a message role.
+type anthropicMessagesRequest struct {
+	Model       string        `json:"model"`
+	System      string        `json:"system,omitempty"`
+	Messages    []ChatMessage `json:"messages"`
+	MaxTokens   int           `json:"max_tokens"`
+	Temperature float64       `json:"temperature"`
+}
+
+// anthropicMessagesResponse is the subset of the Messages API response that the
+// performer consumes. Output is returned as a list of typed content blocks.
+type anthropicMessagesResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+func (p *AnthropicProvider) headers() map[string]string {
+	return map[string]string{
+		"x-api-key":         p.config.APIKey,
+		"anthropic-version": p.config.Version,
+	}
+}
+
+func (p *AnthropicProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	body := &anthropicMessagesRequest{
+		Model:       req.modelOr(p.config.Model),
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+	}
+	var system []string
+	for _, m := range req.Messages {
+		if m.Role == "system" {
+			system = append(system, m.Content)
+			continue
+		}
+		body.Messages = append(body.Messages, m)
+	}
+	body.System = strings.Join(system, "\n\n")
+
+	var resp anthropicMessagesResponse
+	err := doJSONRequest(ctx, p.client, p.Name(), p.config.BaseURL+"/v1/messages", p.headers(), body, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Concatenate the text blocks; other block types (e.g. tool_use) are not
+	// requested by the performer and are ignored.
+	var output strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			output.WriteString(block.Text)
+		}
+	}
+
+	// The Messages API takes no seed.
+	params := req.parameters(body.Model)
+	params.Seed = 0
+	return &CompletionResponse{
+		Output:     output.String(),
+		Model:      resp.Model,
+		TokensIn:   resp.Usage.InputTokens,
+		TokensOut:  resp.Usage.OutputTokens,
+		APIVersion: p.config.Version,
+		Parameters: params,
+	}, nil
+}
+
+// Probe checks that the API is reachable and the key is accepted by listing models.
+func (p *AnthropicProvider) Probe(ctx context.Context) error {
+	return probeHTTP(ctx, p.client, p.config.BaseURL+"/v1/models", p.headers())
+}
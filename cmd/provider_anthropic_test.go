@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_AnthropicProvider(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.Header.Get("x-api-key"); got != "test-key" {
+			t.Errorf("unexpected x-api-key header: %s", got)
+		}
+		if got := r.Header.Get("anthropic-version"); got != defaultAnthropicVersion {
+			t.Errorf("unexpected anthropic-version header: %s", got)
+		}
+
+		var body anthropicMessagesRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if body.System != "be brief" {
+			t.Errorf("expected system prompt to be lifted, got %q", body.System)
+		}
+		if len(body.Messages) != 1 || body.Messages[0].Role != "user" {
+			t.Errorf("unexpected messages: %+v", body.Messages)
+		}
+
+		_, _ = w.Write([]byte(`{"model":"claude-test","content":[{"type":"text","text":"hello "},{"type":"text","text":"world"}],"stop_reason":"end_turn","usage":{"input_tokens":12,"output_tokens":2}}`))
+	}))
+	defer srv.Close()
+
+	p, err := NewAnthropicProvider(&AnthropicConfig{APIKey: "test-key", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("NewAnthropicProvider failed: %v", err)
+	}
+	p.client = srv.Client()
+
+	resp, err := p.Complete(context.Background(), &CompletionRequest{
+		Messages: []ChatMessage{
+			{Role: "system", Content: "be brief"},
+			{Role: "user", Content: "hi"},
+		},
+		MaxTokens: defaultMaxTokens,
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if resp.Output != "hello world" || resp.Model != "claude-test" || resp.TokensIn != 12 || resp.TokensOut != 2 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const defaultAzureAPIVersion = "2024-06-01"
+
+// ErrModelOverrideUnsupported is returned by providers that cannot serve
+// another model than the one they are configured with.
+var ErrModelOverrideUnsupported = errors.New("model override not supported")
+
+// AzureConfig configures the Azure OpenAI provider.
+type AzureConfig struct {
+	// APIKey is sent in the api-key header.
+	APIKey string
+	// Endpoint is the resource endpoint, e.g. https://my-resource.openai.azure.com.
+	// A full chat completions URL is still accepted when Deployment is empty.
+	Endpoint string
+	// Deployment is the name of the model deployment to send prompts to.
+	Deployment string
+	// APIVersion is sent as the api-version query parameter.
+	APIVersion string
+	// EmbeddingDeployment is the deployment used for embed tasks.
+	EmbeddingDeployment string
+}
+
+func azureConfigFromEnv(ctx context.Context) (*AzureConfig, error) {
+	apiKey, err := secretFromEnv(ctx, "AZURE_OPENAI_KEY")
+	if err != nil {
+		return nil, err
+	}
+	cfg := &AzureConfig{
+		APIKey:     apiKey,
+		Endpoint:   getenv("AZURE_OPENAI_ENDPOINT"),
+		Deployment: getenv("AZURE_OPENAI_DEPLOYMENT"),
+		APIVersion: getenv("AZURE_OPENAI_API_VERSION"),
+
+		EmbeddingDeployment: getenv("AZURE_OPENAI_EMBEDDING_DEPLOYMENT"),
+	}
+	if cfg.APIVersion == "" {
+		cfg.APIVersion = defaultAzureAPIVersion
+	}
+	return cfg, nil
+}
+
+// AzureProvider sends chat completions to an Azure OpenAI deployment.
+type AzureProvider struct {
+	config *AzureConfig
+	client *http.Client
+}
+
+func NewAzureProvider(cfg *AzureConfig) (*AzureProvider, error) {
+	if cfg.APIKey == "" || cfg.Endpoint == "" {
+		return nil, fmt.Errorf("Azure OpenAI API key or endpoint not set")
+	}
+	if !strings.HasPrefix(cfg.Endpoint, "https://") {
+		return nil, fmt.Errorf("Azure OpenAI endpoint must use HTTPS")
+	}
+	if cfg.Deployment == "" && !strings.Contains(cfg.Endpoint, "/openai/deployments/") {
+		return nil, fmt.Errorf("Azure OpenAI deployment not set")
+	}
+	return &AzureProvider{
+		config: cfg,
+		client: newHTTPClient(),
+	}, nil
+}
+
+func (p *AzureProvider) Name() string {
+	return "azure"
+}
+
+// Complete sends the request to the configured deployment, or to the deployment
+// named by the request's model when one is set. A legacy endpoint, a full chat
+// completions URL, names its deployment itself and takes no model.
+func (p *AzureProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	if p.config.Deployment == "" && req.Model != "" {
+		return nil, fmt.Errorf("%w: model %q cannot be selected when AZURE_OPENAI_ENDPOINT is a full deployment URL, set AZURE_OPENAI_DEPLOYMENT instead", ErrModelOverrideUnsupported, req.Model)
+	}
+	deployment := req.modelOr(p.config.Deployment)
+	resp, err := completeChat(ctx, p.client, p.Name(), p.chatURL(deployment), map[string]string{
+		"api-key": p.config.APIKey,
+	}, "", req)
+	if err != nil {
+		return nil, err
+	}
+	// The deployment is sent in the URL rather than as the model.
+	resp.APIVersion = p.config.APIVersion
+	resp.Parameters.Model = deployment
+	return resp, nil
+}
+
+func (p *AzureProvider) Embed(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	if p.config.EmbeddingDeployment == "" {
+		return nil, fmt.Errorf("Azure OpenAI embedding deployment not set")
+	}
+	return embedOpenAI(ctx, p.client, p.Name(), p.deploymentURL(p.config.EmbeddingDeployment, "embeddings"), map[string]string{
+		"api-key": p.config.APIKey,
+	}, "", req)
+}
+
+// chatURL builds the chat completions URL of a deployment. Without a deployment
+// the endpoint is assumed to already be a full chat completions URL.
+func (p *AzureProvider) chatURL(deployment string) string {
+	if deployment == "" {
+		return p.config.Endpoint
+	}
+
+	return p.deploymentURL(deployment, "chat/completions")
+}
+
+// deploymentURL builds the URL of an operation of a deployment.
+func (p *AzureProvider) deploymentURL(deployment, operation string) string {
+	u := strings.TrimSuffix(p.config.Endpoint, "/") + "/openai/deployments/" + url.PathEscape(deployment) + "/" + operation
+	return u + "?" + url.Values{"api-version": {p.config.APIVersion}}.Encode()
+}
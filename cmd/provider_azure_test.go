@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_AzureProvider(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/gpt-4o-prod/chat/completions" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("api-version"); got != "2024-10-21" {
+			t.Errorf("unexpected api-version: %s", got)
+		}
+		if got := r.Header.Get("api-key"); got != "azure-key" {
+			t.Errorf("unexpected api-key header: %s", got)
+		}
+
+		_, _ = w.Write([]byte(`{"model":"gpt-4o","choices":[{"message":{"content":"hello"}}]}`))
+	}))
+	defer srv.Close()
+
+	p, err := NewAzureProvider(&AzureConfig{
+		APIKey:     "azure-key",
+		Endpoint:   srv.URL + "/",
+		Deployment: "gpt-4o-prod",
+		APIVersion: "2024-10-21",
+	})
+	if err != nil {
+		t.Fatalf("NewAzureProvider failed: %v", err)
+	}
+	p.client = srv.Client()
+
+	resp, err := p.Complete(context.Background(), &CompletionRequest{
+		Messages: []ChatMessage{{Role: "user", Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if resp.Output != "hello" {
+		t.Errorf("unexpected output: %s", resp.Output)
+	}
+	if resp.APIVersion != "2024-10-21" || resp.Parameters == nil || resp.Parameters.Model != "gpt-4o-prod" {
+		t.Errorf("unexpected attested API version %q and parameters %+v", resp.APIVersion, resp.Parameters)
+	}
+}
+
+func Test_AzureProviderLegacyEndpointModelOverride(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/gpt-4o/chat/completions" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"model":"gpt-4o","choices":[{"message":{"content":"hello"}}]}`))
+	}))
+	defer srv.Close()
+
+	p, err := NewAzureProvider(&AzureConfig{
+		APIKey:   "azure-key",
+		Endpoint: srv.URL + "/openai/deployments/gpt-4o/chat/completions?api-version=2024-02-01",
+	})
+	if err != nil {
+		t.Fatalf("NewAzureProvider failed: %v", err)
+	}
+	p.client = srv.Client()
+
+	req := &CompletionRequest{Messages: []ChatMessage{{Role: "user", Content: "hi"}}}
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	req.Model = "gpt-4o-mini"
+	if _, err := p.Complete(context.Background(), req); !errors.Is(err, ErrModelOverrideUnsupported) {
+		t.Errorf("expected ErrModelOverrideUnsupported, got %v", err)
+	}
+}
+
+func Test_AzureChatURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     AzureConfig
+		deployment string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "resource endpoint",
+			config:     AzureConfig{Endpoint: "https://res.openai.azure.com", Deployment: "gpt-4o", APIVersion: "2024-06-01"},
+			deployment: "gpt-4o",
+			want:       "https://res.openai.azure.com/openai/deployments/gpt-4o/chat/completions?api-version=2024-06-01",
+		},
+		{
+			name:       "deployment selected by request",
+			config:     AzureConfig{Endpoint: "https://res.openai.azure.com/", Deployment: "gpt-4o", APIVersion: "2024-06-01"},
+			deployment: "gpt-4o mini",
+			want:       "https://res.openai.azure.com/openai/deployments/gpt-4o%20mini/chat/completions?api-version=2024-06-01",
+		},
+		{
+			name:   "legacy full URL",
+			config: AzureConfig{Endpoint: "https://res.openai.azure.com/openai/deployments/gpt-4o/chat/completions?api-version=2024-02-01"},
+			want:   "https://res.openai.azure.com/openai/deployments/gpt-4o/chat/completions?api-version=2024-02-01",
+		},
+		{
+			name:    "missing deployment",
+			config:  AzureConfig{Endpoint: "https://res.openai.azure.com"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.config
+			cfg.APIKey = "azure-key"
+			p, err := NewAzureProvider(&cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if got := p.chatURL(tt.deployment); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+const defaultBedrockModelID = "anthropic.claude-3-haiku-20240307-v1:0"
+
+// BedrockConfig configures the AWS Bedrock provider. When no static credentials
+// are set the default AWS credential chain is used (environment, shared config,
+// ECS task role, EC2 instance profile, web identity), so operators running in AWS
+// do not need to manage long-lived keys.
+type BedrockConfig struct {
+	// Region is the AWS region hosting the model, e.g. "us-east-1".
+	Region string
+	// ModelID is the Bedrock model or inference profile ID.
+	ModelID string
+	// AccessKeyID, SecretAccessKey and SessionToken are optional static credentials.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// RoleARN is an optional IAM role assumed on top of the base credentials.
+	RoleARN string
+}
+
+func bedrockConfigFromEnv(ctx context.Context) (*BedrockConfig, error) {
+	cfg := &BedrockConfig{
+		Region:      getenv("BEDROCK_REGION"),
+		ModelID:     getenv("BEDROCK_MODEL_ID"),
+		AccessKeyID: getenv("BEDROCK_ACCESS_KEY_ID"),
+		RoleARN:     getenv("BEDROCK_ROLE_ARN"),
+	}
+	var err error
+	if cfg.SecretAccessKey, err = secretFromEnv(ctx, "BEDROCK_SECRET_ACCESS_KEY"); err != nil {
+		return nil, err
+	}
+	if cfg.SessionToken, err = secretFromEnv(ctx, "BEDROCK_SESSION_TOKEN"); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// bedrockConverseAPI is the subset of the Bedrock runtime client used by the
+// provider, extracted so tests can substitute a fake.
+type bedrockConverseAPI interface {
+	Converse(ctx context.Context, params *bedrockruntime.ConverseInput, optFns ...func(*bedrockruntime.Options)) (*bedrockruntime.ConverseOutput, error)
+}
+
+// BedrockProvider sends chat completions to AWS Bedrock using the model-agnostic
+// Converse API, which covers the Titan, Claude, Llama and Mistral model families.
+type BedrockProvider struct {
+	config *BedrockConfig
+	client bedrockConverseAPI
+}
+
+func NewBedrockProvider(ctx context.Context, cfg *BedrockConfig) (*BedrockProvider, error) {
+	if cfg.ModelID == "" {
+		cfg.ModelID = defaultBedrockModelID
+	}
+	if (cfg.AccessKeyID == "") != (cfg.SecretAccessKey == "") {
+		return nil, fmt.Errorf("Bedrock access key ID and secret access key must be set together")
+	}
+
+	opts := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithHTTPClient(newHTTPClient()),
+	}
+	if cfg.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.Region))
+	}
+	if cfg.AccessKeyID != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken),
+		))
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	if awsCfg.Region == "" {
+		return nil, fmt.Errorf("Bedrock region not set")
+	}
+	if cfg.RoleARN != "" {
+		awsCfg.Credentials = aws.NewCredentialsCache(
+			stscreds.NewAssumeRoleProvider(sts.NewFromConfig(awsCfg), cfg.RoleARN),
+		)
+	}
+
+	return &BedrockProvider{
+		config: cfg,
+		client: bedrockruntime.NewFromConfig(awsCfg),
+	}, nil
+}
+
+func (p *BedrockProvider) Name() string {
+	return "bedrock"
+}
+
+func (p *BedrockProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	modelID := req.modelOr(p.config.ModelID)
+	input := &bedrockruntime.ConverseInput{
+		ModelId: aws.String(modelID),
+		InferenceConfig: &types.InferenceConfiguration{
+			MaxTokens:   aws.Int32(int32(req.MaxTokens)),
+			Temperature: aws.Float32(float32(req.Temperature)),
+		},
+	}
+	for _, m := range req.Messages {
+		if m.Role == "system" {
+			input.System = append(input.System, &types.SystemContentBlockMemberText{Value: m.Content})
+			continue
+		}
+		input.Messages = append(input.Messages, types.Message{
+			Role:    types.ConversationRole(m.Role),
+			Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: m.Content}},
+		})
+	}
+
+	out, err := p.client.Converse(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("bedrock converse failed: %w", err)
+	}
+
+	msg, ok := out.Output.(*types.ConverseOutputMemberMessage)
+	if !ok {
+		return nil, fmt.Errorf("bedrock returned unexpected output type %T", out.Output)
+	}
+
+	var output strings.Builder
+	for _, block := range msg.Value.Content {
+		if text, ok := block.(*types.ContentBlockMemberText); ok {
+			output.WriteString(text.Value)
+		}
+	}
+
+	// The Converse API takes no seed.
+	params := req.parameters(modelID)
+	params.Seed = 0
+	resp := &CompletionResponse{
+		Output:     output.String(),
+		Model:      modelID,
+		Parameters: params,
+	}
+	if out.Usage != nil {
+		resp.TokensIn = int(aws.ToInt32(out.Usage.InputTokens))
+		resp.TokensOut = int(aws.ToInt32(out.Usage.OutputTokens))
+	}
+	return resp, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+)
+
+type fakeBedrockClient struct {
+	input *bedrockruntime.ConverseInput
+}
+
+func (c *fakeBedrockClient) Converse(ctx context.Context, params *bedrockruntime.ConverseInput, optFns ...func(*bedrockruntime.Options)) (*bedrockruntime.ConverseOutput, error) {
+	c.input = params
+	return &bedrockruntime.ConverseOutput{
+		Output: &types.ConverseOutputMemberMessage{
+			Value: types.Message{
+				Role:    types.ConversationRoleAssistant,
+				Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: "hello"}},
+			},
+		},
+	}, nil
+}
+
+func Test_BedrockProvider(t *testing.T) {
+	fake := &fakeBedrockClient{}
+	p := &BedrockProvider{
+		config: &BedrockConfig{ModelID: "meta.llama3-8b-instruct-v1:0"},
+		client: fake,
+	}
+
+	resp, err := p.Complete(context.Background(), &CompletionRequest{
+		Messages: []ChatMessage{
+			{Role: "system", Content: "be brief"},
+			{Role: "user", Content: "hi"},
+		},
+		MaxTokens:   defaultMaxTokens,
+		Temperature: defaultTemperature,
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if resp.Output != "hello" || resp.Model != "meta.llama3-8b-instruct-v1:0" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if len(fake.input.System) != 1 || len(fake.input.Messages) != 1 {
+		t.Errorf("expected system prompt to be split from messages: %+v", fake.input)
+	}
+	if *fake.input.InferenceConfig.MaxTokens != defaultMaxTokens {
+		t.Errorf("unexpected max tokens: %d", *fake.input.InferenceConfig.MaxTokens)
+	}
+}
+
+func Test_BedrockProviderPartialCredentials(t *testing.T) {
+	_, err := NewBedrockProvider(context.Background(), &BedrockConfig{Region: "us-east-1", AccessKeyID: "AKIA"})
+	if err == nil {
+		t.Errorf("expected error when only the access key ID is set")
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultGroqBaseURL = "https://api.groq.com/openai/v1"
+	defaultGroqModel   = "llama-3.1-8b-instant"
+)
+
+// GroqConfig configures the Groq provider.
+type GroqConfig struct {
+	// APIKey is sent as a bearer token.
+	APIKey string
+	// Model is the Groq-hosted model name, e.g. "llama-3.1-8b-instant".
+	Model string
+	// BaseURL overrides the default https://api.groq.com/openai/v1.
+	BaseURL string
+}
+
+func groqConfigFromEnv(ctx context.Context) (*GroqConfig, error) {
+	apiKey, err := secretFromEnv(ctx, "GROQ_API_KEY")
+	if err != nil {
+		return nil, err
+	}
+	return &GroqConfig{
+		APIKey:  apiKey,
+		Model:   getenv("GROQ_MODEL"),
+		BaseURL: getenv("GROQ_BASE_URL"),
+	}, nil
+}
+
+// GroqProvider sends chat completions to Groq's OpenAI-compatible API for
+// low-latency inference.
+type GroqProvider struct {
+	config *GroqConfig
+	client *http.Client
+}
+
+func NewGroqProvider(cfg *GroqConfig) (*GroqProvider, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("Groq API key not set")
+	}
+	if cfg.Model == "" {
+		cfg.Model = defaultGroqModel
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultGroqBaseURL
+	}
+	if !strings.HasPrefix(cfg.BaseURL, "https://") {
+		return nil, fmt.Errorf("Groq base URL must use HTTPS")
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	return &GroqProvider{
+		config: cfg,
+		client: newHTTPClient(),
+	}, nil
+}
+
+func (p *GroqProvider) Name() string {
+	return "groq"
+}
+
+func (p *GroqProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	return completeChat(ctx, p.client, p.Name(), p.config.BaseURL+"/chat/completions", map[string]string{
+		"Authorization": "Bearer " + p.config.APIKey,
+	}, req.modelOr(p.config.Model), req)
+}
+
+// Probe checks that the API is reachable and the key is accepted by listing models.
+func (p *GroqProvider) Probe(ctx context.Context) error {
+	return probeHTTP(ctx, p.client, p.config.BaseURL+"/models", map[string]string{"Authorization": "Bearer " + p.config.APIKey})
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_GroqProvider(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/v1/chat/completions" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer gsk-test" {
+			t.Errorf("unexpected Authorization header: %s", got)
+		}
+
+		var body chatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if body.Model != defaultGroqModel {
+			t.Errorf("unexpected model: %s", body.Model)
+		}
+
+		_, _ = w.Write([]byte(`{"model":"llama-3.1-8b-instant","choices":[{"message":{"content":"hello"}}]}`))
+	}))
+	defer srv.Close()
+
+	p, err := NewGroqProvider(&GroqConfig{APIKey: "gsk-test", BaseURL: srv.URL + "/openai/v1"})
+	if err != nil {
+		t.Fatalf("NewGroqProvider failed: %v", err)
+	}
+	p.client = srv.Client()
+
+	resp, err := p.Complete(context.Background(), &CompletionRequest{
+		Messages: []ChatMessage{{Role: "user", Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if resp.Output != "hello" {
+		t.Errorf("unexpected output: %s", resp.Output)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultMockModel = "mock-1"
+
+// defaultMockResponses are the canned responses served when
+// MOCK_RESPONSES_FILE is unset.
+//
+//go:embed mock_responses.json
+var defaultMockResponses []byte
+
+// MockResponse is a canned response, served for prompts whose last user
+// message contains Contains, compared case-insensitively.
+type MockResponse struct {
+	Contains string `json:"contains"`
+	Output   string `json:"output"`
+}
+
+// MockConfig configures the mock provider.
+type MockConfig struct {
+	// Responses are matched in order against the prompt.
+	Responses []MockResponse
+	// Latency delays every response, to exercise timeouts locally.
+	Latency time.Duration
+}
+
+// devModeFromEnv reports whether DEV_MODE, or the -dev flag, is set.
+func devModeFromEnv() (bool, error) {
+	v := getenv("DEV_MODE")
+	if v == "" {
+		return false, nil
+	}
+	dev, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid value for DEV_MODE: %w", err)
+	}
+	return dev, nil
+}
+
+func mockConfigFromEnv() (*MockConfig, error) {
+	data := defaultMockResponses
+	if path := getenv("MOCK_RESPONSES_FILE"); path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("failed to read MOCK_RESPONSES_FILE: %w", err)
+		}
+	}
+	cfg := &MockConfig{}
+	if err := json.Unmarshal(data, &cfg.Responses); err != nil {
+		return nil, fmt.Errorf("invalid mock responses: %w", err)
+	}
+	for i, r := range cfg.Responses {
+		if r.Contains == "" {
+			return nil, fmt.Errorf("mock response %d must set contains", i+1)
+		}
+	}
+	latency, err := envDuration("MOCK_LATENCY")
+	if err != nil {
+		return nil, err
+	}
+	if latency < 0 {
+		return nil, fmt.Errorf("MOCK_LATENCY must not be negative")
+	}
+	cfg.Latency = latency
+	return cfg, nil
+}
+
+// MockProvider serves deterministic canned responses without calling any
+// backend, so that AVS developers can run the performer, executor and
+// aggregator locally without API keys or spend. Prompts matching none of its
+// responses are echoed back. It is selected with LLM_PROVIDER=mock or in dev
+// mode.
+type MockProvider struct {
+	config *MockConfig
+}
+
+func NewMockProvider(cfg *MockConfig) *MockProvider {
+	return &MockProvider{config: cfg}
+}
+
+func (p *MockProvider) Name() string {
+	return "mock"
+}
+
+func (p *MockProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	if p.config.Latency > 0 {
+		timer := time.NewTimer(p.config.Latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	prompt := ""
+	for _, m := range req.Messages {
+		if m.Role == "user" {
+			prompt = m.Content
+		}
+	}
+	output := fmt.Sprintf("Mock response to: %s", prompt)
+	for _, r := range p.config.Responses {
+		if strings.Contains(strings.ToLower(prompt), strings.ToLower(r.Contains)) {
+			output = r.Output
+			break
+		}
+	}
+	model := req.modelOr(defaultMockModel)
+	return &CompletionResponse{
+		Output:     output,
+		Model:      model,
+		TokensIn:   estimateTokens(req),
+		TokensOut:  (len(output) + 3) / 4,
+		Parameters: req.parameters(model),
+	}, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func Test_MockProvider(t *testing.T) {
+	cfg, err := mockConfigFromEnv()
+	if err != nil {
+		t.Fatalf("mockConfigFromEnv failed: %v", err)
+	}
+	p := NewMockProvider(cfg)
+
+	tests := []struct {
+		name   string
+		prompt string
+		want   string
+	}{
+		{name: "canned", prompt: "What is the capital of France?", want: "Paris"},
+		{name: "case-insensitive", prompt: "HELLO there", want: "Hello from the mock provider!"},
+		{name: "echo", prompt: "Tell me a story", want: "Mock response to: Tell me a story"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := p.Complete(context.Background(), &CompletionRequest{Messages: []ChatMessage{{Role: "user", Content: tt.prompt}}})
+			if err != nil {
+				t.Fatalf("Complete failed: %v", err)
+			}
+			if resp.Output != tt.want || resp.Model != defaultMockModel {
+				t.Errorf("expected %q from %s, got %q from %s", tt.want, defaultMockModel, resp.Output, resp.Model)
+			}
+			if resp.TokensIn == 0 || resp.TokensOut == 0 {
+				t.Errorf("expected token usage, got %d and %d", resp.TokensIn, resp.TokensOut)
+			}
+		})
+	}
+}
+
+func Test_MockProviderLatency(t *testing.T) {
+	p := NewMockProvider(&MockConfig{Latency: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Complete(ctx, &CompletionRequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline to cut the latency short, got %v", err)
+	}
+}
+
+func Test_mockConfigFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name      string
+		file      string
+		latency   string
+		wantCount int
+		wantErr   bool
+	}{
+		{name: "embedded", wantCount: 4},
+		{name: "file", file: write("ok.json", `[{"contains":"ping","output":"pong"}]`), wantCount: 1},
+		{name: "missing file", file: filepath.Join(dir, "missing.json"), wantErr: true},
+		{name: "invalid file", file: write("bad.json", `{`), wantErr: true},
+		{name: "empty match", file: write("empty.json", `[{"output":"pong"}]`), wantErr: true},
+		{name: "negative latency", latency: "-1s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MOCK_RESPONSES_FILE", tt.file)
+			t.Setenv("MOCK_LATENCY", tt.latency)
+			cfg, err := mockConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && len(cfg.Responses) != tt.wantCount {
+				t.Errorf("expected %d responses, got %d", tt.wantCount, len(cfg.Responses))
+			}
+		})
+	}
+}
+
+func Test_DevMode(t *testing.T) {
+	withConfigSettings(t)
+	t.Setenv("LLM_PROVIDER", "openai")
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("DEV_MODE", "")
+	if err := loadConfig([]string{"-dev"}); err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+
+	p, err := newBaseProviderFromEnv(context.Background(), zap.NewNop())
+	if err != nil {
+		t.Fatalf("newBaseProviderFromEnv failed: %v", err)
+	}
+	if p.Name() != "mock" {
+		t.Errorf("expected the mock provider in dev mode, got %s", p.Name())
+	}
+	names, err := providerNamesFromEnv()
+	if err != nil || len(names) != 1 || names[0] != "mock" {
+		t.Errorf("expected only the mock provider to be validated, got %v, %v", names, err)
+	}
+}
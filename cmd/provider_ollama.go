@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultOllamaHost  = "http://localhost:11434"
+	defaultOllamaModel = "llama3.2"
+
+	defaultOllamaEmbeddingModel = "nomic-embed-text"
+)
+
+// OllamaConfig configures the local Ollama provider.
+type OllamaConfig struct {
+	// Host is the base URL of the Ollama server.
+	Host string
+	// Model is the name of a model that has been pulled into Ollama.
+	Model string
+	// EmbeddingModel is the model used for embed tasks.
+	EmbeddingModel string
+}
+
+func ollamaConfigFromEnv() *OllamaConfig {
+	return &OllamaConfig{
+		Host:  getenv("OLLAMA_HOST"),
+		Model: getenv("OLLAMA_MODEL"),
+
+		EmbeddingModel: getenv("OLLAMA_EMBEDDING_MODEL"),
+	}
+}
+
+// OllamaProvider sends chat completions to a local Ollama server, letting the
+// performer run fully offline. Plain HTTP is allowed since the server is expected
+// to run on the same host or private network.
+type OllamaProvider struct {
+	config *OllamaConfig
+	client *http.Client
+}
+
+func NewOllamaProvider(cfg *OllamaConfig) (*OllamaProvider, error) {
+	if cfg.Host == "" {
+		cfg.Host = defaultOllamaHost
+	}
+	if cfg.Model == "" {
+		cfg.Model = defaultOllamaModel
+	}
+	if cfg.EmbeddingModel == "" {
+		cfg.EmbeddingModel = defaultOllamaEmbeddingModel
+	}
+	if !strings.HasPrefix(cfg.Host, "http://") && !strings.HasPrefix(cfg.Host, "https://") {
+		return nil, fmt.Errorf("Ollama host must be an http:// or https:// URL")
+	}
+	cfg.Host = strings.TrimSuffix(cfg.Host, "/")
+
+	return &OllamaProvider{
+		config: cfg,
+		client: newHTTPClient(),
+	}, nil
+}
+
+func (p *OllamaProvider) Name() string {
+	return "ollama"
+}
+
+type ollamaChatRequest struct {
+	Model    string        `json:"model"`
+	Messages []ChatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+	Options  struct {
+		NumPredict  int     `json:"num_predict"`
+		Temperature float64 `json:"temperature"`
+		Seed        int64   `json:"seed,omitempty"`
+	} `json:"options"`
+}
+
+type ollamaChatResponse struct {
+	Model   string      `json:"model"`
+	Message ChatMessage `json:"message"`
+	Done    bool        `json:"done"`
+	// PromptEvalCount and EvalCount are the prompt and output token counts.
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
+}
+
+func (p *OllamaProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	body := &ollamaChatRequest{
+		Model:    req.modelOr(p.config.Model),
+		Messages: req.Messages,
+	}
+	body.Options.NumPredict = req.MaxTokens
+	body.Options.Temperature = req.Temperature
+	body.Options.Seed = req.Seed
+
+	var resp ollamaChatResponse
+	if err := doJSONRequest(ctx, p.client, p.Name(), p.config.Host+"/api/chat", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &CompletionResponse{
+		Output:     resp.Message.Content,
+		Model:      resp.Model,
+		TokensIn:   resp.PromptEvalCount,
+		TokensOut:  resp.EvalCount,
+		Parameters: req.parameters(body.Model),
+	}, nil
+}
+
+// Probe checks that the Ollama server is reachable and that the configured model
+// has been pulled, so a misconfigured host fails at startup instead of on the
+// first task.
+func (p *OllamaProvider) Probe(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.config.Host+"/api/tags", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Ollama server at %s is not reachable: %w", p.config.Host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Ollama server at %s returned status %d", p.config.Host, resp.StatusCode)
+	}
+
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return fmt.Errorf("failed to decode Ollama model list: %w", err)
+	}
+	for _, m := range tags.Models {
+		// Ollama reports "llama3.2:latest" for a model pulled as "llama3.2".
+		if m.Name == p.config.Model || m.Name == p.config.Model+":latest" {
+			return nil
+		}
+	}
+	return fmt.Errorf("Ollama model %s has not been pulled on %s", p.config.Model, p.config.Host)
+}
+
+type ollamaEmbedRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+}
+
+type ollamaEmbedResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float64 `json:"embeddings"`
+}
+
+func (p *OllamaProvider) Embed(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	var resp ollamaEmbedResponse
+	body := &ollamaEmbedRequest{Model: p.config.EmbeddingModel, Input: req.Input}
+	if err := doJSONRequest(ctx, p.client, p.Name(), p.config.Host+"/api/embed", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Embeddings) == 0 {
+		return nil, fmt.Errorf("%s returned no embedding", p.Name())
+	}
+
+	embedding := resp.Embeddings[0]
+	// Ollama has no native dimension reduction; truncate instead, which is
+	// what Matryoshka-trained models such as nomic-embed-text expect.
+	if req.Dimensions > 0 && req.Dimensions < len(embedding) {
+		embedding = embedding[:req.Dimensions]
+	}
+	return &EmbeddingResponse{Embedding: embedding, Model: resp.Model}, nil
+}
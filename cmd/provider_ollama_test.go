@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newOllamaTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			_, _ = w.Write([]byte(`{"models":[{"name":"llama3.2:latest"}]}`))
+		case "/api/chat":
+			var body ollamaChatRequest
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("failed to decode request: %v", err)
+			}
+			if body.Stream {
+				t.Errorf("expected non-streaming request")
+			}
+			if body.Options.NumPredict != defaultMaxTokens {
+				t.Errorf("unexpected num_predict: %d", body.Options.NumPredict)
+			}
+			_, _ = w.Write([]byte(`{"model":"llama3.2","message":{"role":"assistant","content":"hello"},"done":true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func Test_OllamaProvider(t *testing.T) {
+	srv := newOllamaTestServer(t)
+	defer srv.Close()
+
+	p, err := NewOllamaProvider(&OllamaConfig{Host: srv.URL})
+	if err != nil {
+		t.Fatalf("NewOllamaProvider failed: %v", err)
+	}
+
+	if err := p.Probe(context.Background()); err != nil {
+		t.Errorf("Probe failed: %v", err)
+	}
+
+	resp, err := p.Complete(context.Background(), &CompletionRequest{
+		Messages:  []ChatMessage{{Role: "user", Content: "hi"}},
+		MaxTokens: defaultMaxTokens,
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if resp.Output != "hello" {
+		t.Errorf("unexpected output: %s", resp.Output)
+	}
+}
+
+func Test_OllamaProviderProbeMissingModel(t *testing.T) {
+	srv := newOllamaTestServer(t)
+	defer srv.Close()
+
+	p, err := NewOllamaProvider(&OllamaConfig{Host: srv.URL, Model: "mistral"})
+	if err != nil {
+		t.Fatalf("NewOllamaProvider failed: %v", err)
+	}
+	if err := p.Probe(context.Background()); err == nil {
+		t.Errorf("expected Probe to fail for a model that has not been pulled")
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultOpenAIBaseURL = "https://api.openai.com/v1"
+	defaultOpenAIModel   = "gpt-4o-mini"
+
+	defaultOpenAIEmbeddingModel  = "text-embedding-3-small"
+	defaultOpenAIModerationModel = "omni-moderation-latest"
+)
+
+// OpenAIConfig configures the native OpenAI (api.openai.com) provider.
+type OpenAIConfig struct {
+	// APIKey is sent as a bearer token.
+	APIKey string
+	// Model is the model name, e.g. "gpt-4o-mini".
+	Model string
+	// Organization is sent in the OpenAI-Organization header when set.
+	Organization string
+	// BaseURL overrides the default https://api.openai.com/v1.
+	BaseURL string
+	// EmbeddingModel is the model used for embed tasks.
+	EmbeddingModel string
+	// ModerationModel is the model used for moderate tasks.
+	ModerationModel string
+}
+
+func openAIConfigFromEnv(ctx context.Context) (*OpenAIConfig, error) {
+	apiKey, err := secretFromEnv(ctx, "OPENAI_API_KEY")
+	if err != nil {
+		return nil, err
+	}
+	return &OpenAIConfig{
+		APIKey:       apiKey,
+		Model:        getenv("OPENAI_MODEL"),
+		Organization: getenv("OPENAI_ORGANIZATION"),
+		BaseURL:      getenv("OPENAI_BASE_URL"),
+
+		EmbeddingModel:  getenv("OPENAI_EMBEDDING_MODEL"),
+		ModerationModel: getenv("OPENAI_MODERATION_MODEL"),
+	}, nil
+}
+
+// OpenAIProvider sends chat completions to the OpenAI API.
+type OpenAIProvider struct {
+	config *OpenAIConfig
+	client *http.Client
+}
+
+func NewOpenAIProvider(cfg *OpenAIConfig) (*OpenAIProvider, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("OpenAI API key not set")
+	}
+	if cfg.Model == "" {
+		cfg.Model = defaultOpenAIModel
+	}
+	if cfg.EmbeddingModel == "" {
+		cfg.EmbeddingModel = defaultOpenAIEmbeddingModel
+	}
+	if cfg.ModerationModel == "" {
+		cfg.ModerationModel = defaultOpenAIModerationModel
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultOpenAIBaseURL
+	}
+	if !strings.HasPrefix(cfg.BaseURL, "https://") {
+		return nil, fmt.Errorf("OpenAI base URL must use HTTPS")
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	return &OpenAIProvider{
+		config: cfg,
+		client: newHTTPClient(),
+	}, nil
+}
+
+func (p *OpenAIProvider) Name() string {
+	return "openai"
+}
+
+func (p *OpenAIProvider) headers() map[string]string {
+	headers := map[string]string{
+		"Authorization": "Bearer " + p.config.APIKey,
+	}
+	if p.config.Organization != "" {
+		headers["OpenAI-Organization"] = p.config.Organization
+	}
+	return headers
+}
+
+func (p *OpenAIProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	return completeChat(ctx, p.client, p.Name(), p.config.BaseURL+"/chat/completions", p.headers(), req.modelOr(p.config.Model), req)
+}
+
+// Probe checks that the API is reachable and the key is accepted by listing models.
+func (p *OpenAIProvider) Probe(ctx context.Context) error {
+	return probeHTTP(ctx, p.client, p.config.BaseURL+"/models", p.headers())
+}
+
+func (p *OpenAIProvider) Embed(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	return embedOpenAI(ctx, p.client, p.Name(), p.config.BaseURL+"/embeddings", p.headers(), p.config.EmbeddingModel, req)
+}
+
+func (p *OpenAIProvider) Moderate(ctx context.Context, req *ModerationRequest) (*ModerationResponse, error) {
+	var resp openAIModerationResponse
+	err := doJSONRequest(ctx, p.client, p.Name(), p.config.BaseURL+"/moderations", p.headers(), &openAIModerationRequest{
+		Model: p.config.ModerationModel,
+		Input: req.Input,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Results) == 0 {
+		return nil, fmt.Errorf("%s returned no moderation result", p.Name())
+	}
+	return &ModerationResponse{
+		Flagged: resp.Results[0].Flagged,
+		Scores:  resp.Results[0].CategoryScores,
+		Model:   resp.Model,
+	}, nil
+}
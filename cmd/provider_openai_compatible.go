@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// OpenAICompatibleConfig configures a provider for any server that implements the
+// OpenAI /v1/chat/completions API, such as vLLM, LM Studio or a LiteLLM proxy.
+type OpenAICompatibleConfig struct {
+	// BaseURL is the API root including the version prefix, e.g. "http://localhost:8000/v1".
+	BaseURL string
+	// APIKey is an optional bearer token.
+	APIKey string
+	// Model is the model name as exposed by the server.
+	Model string
+	// EmbeddingModel is the model used for embed tasks. Embeddings are
+	// unavailable when it is empty.
+	EmbeddingModel string
+}
+
+func openAICompatibleConfigFromEnv(ctx context.Context) (*OpenAICompatibleConfig, error) {
+	apiKey, err := secretFromEnv(ctx, "OPENAI_COMPATIBLE_API_KEY")
+	if err != nil {
+		return nil, err
+	}
+	return &OpenAICompatibleConfig{
+		BaseURL: getenv("OPENAI_COMPATIBLE_BASE_URL"),
+		APIKey:  apiKey,
+		Model:   getenv("OPENAI_COMPATIBLE_MODEL"),
+
+		EmbeddingModel: getenv("OPENAI_COMPATIBLE_EMBEDDING_MODEL"),
+	}, nil
+}
+
+// OpenAICompatibleProvider sends chat completions to a self-hosted or proxied
+// OpenAI-compatible endpoint. Unlike the native OpenAI provider, plain HTTP and
+// unauthenticated servers are allowed since these typically run on a private network.
+type OpenAICompatibleProvider struct {
+	config *OpenAICompatibleConfig
+	client *http.Client
+}
+
+func NewOpenAICompatibleProvider(cfg *OpenAICompatibleConfig) (*OpenAICompatibleProvider, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("OpenAI-compatible base URL not set")
+	}
+	if !strings.HasPrefix(cfg.BaseURL, "http://") && !strings.HasPrefix(cfg.BaseURL, "https://") {
+		return nil, fmt.Errorf("OpenAI-compatible base URL must be an http:// or https:// URL")
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("OpenAI-compatible model not set")
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	return &OpenAICompatibleProvider{
+		config: cfg,
+		client: newHTTPClient(),
+	}, nil
+}
+
+func (p *OpenAICompatibleProvider) Name() string {
+	return "openai-compatible"
+}
+
+func (p *OpenAICompatibleProvider) headers() map[string]string {
+	if p.config.APIKey == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + p.config.APIKey}
+}
+
+func (p *OpenAICompatibleProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	return completeChat(ctx, p.client, p.Name(), p.config.BaseURL+"/chat/completions", p.headers(), req.modelOr(p.config.Model), req)
+}
+
+func (p *OpenAICompatibleProvider) Embed(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	if p.config.EmbeddingModel == "" {
+		return nil, fmt.Errorf("OpenAI-compatible embedding model not set")
+	}
+	return embedOpenAI(ctx, p.client, p.Name(), p.config.BaseURL+"/embeddings", p.headers(), p.config.EmbeddingModel, req)
+}
+
+// Probe checks that the server is reachable by listing the models it serves.
+func (p *OpenAICompatibleProvider) Probe(ctx context.Context) error {
+	return probeHTTP(ctx, p.client, p.config.BaseURL+"/models", p.headers())
+}
+
+// completionsRequest is the request body of the legacy /completions API, which
+// accepts a list of prompts and answers all of them in one response.
+type completionsRequest struct {
+	Model       string   `json:"model"`
+	Prompt      []string `json:"prompt"`
+	MaxTokens   int      `json:"max_tokens"`
+	Temperature float64  `json:"temperature"`
+	Seed        int64    `json:"seed,omitempty"`
+}
+
+type completionsResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Index int    `json:"index"`
+		Text  string `json:"text"`
+	} `json:"choices"`
+	Usage chatCompletionUsage `json:"usage"`
+}
+
+// CompleteBatch serves requests sharing the same model and sampling parameters
+// with a single /completions call. The endpoint takes plain prompts, so only
+// requests that are a single user message can be batched, see isPlainPrompt.
+// The usage the endpoint reports for the batch is apportioned to the requests.
+func (p *OpenAICompatibleProvider) CompleteBatch(ctx context.Context, reqs []*CompletionRequest) ([]*CompletionResponse, error) {
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+	for i, req := range reqs {
+		if !isPlainPrompt(req) {
+			return nil, fmt.Errorf("%s cannot batch request %d, a conversation of %d messages", p.Name(), i, len(req.Messages))
+		}
+	}
+
+	body := &completionsRequest{
+		Model:       reqs[0].modelOr(p.config.Model),
+		MaxTokens:   reqs[0].MaxTokens,
+		Temperature: reqs[0].Temperature,
+		Seed:        reqs[0].Seed,
+	}
+	for _, req := range reqs {
+		body.Prompt = append(body.Prompt, req.Messages[0].Content)
+	}
+
+	var resp completionsResponse
+	if err := doJSONRequest(ctx, p.client, p.Name(), p.config.BaseURL+"/completions", p.headers(), body, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]*CompletionResponse, len(reqs))
+	for _, choice := range resp.Choices {
+		if choice.Index < 0 || choice.Index >= len(results) {
+			return nil, fmt.Errorf("%s returned unexpected choice index %d", p.Name(), choice.Index)
+		}
+		results[choice.Index] = &CompletionResponse{Output: choice.Text, Model: resp.Model, Parameters: reqs[choice.Index].parameters(body.Model)}
+	}
+	for i, r := range results {
+		if r == nil {
+			return nil, fmt.Errorf("%s returned no result for prompt %d", p.Name(), i)
+		}
+	}
+	splitBatchUsage(resp.Usage, reqs, results)
+	return results, nil
+}
+
+// splitBatchUsage apportions the usage of a batch to its results in
+// proportion to the estimated tokens of each prompt and output. Usage not
+// reported by the provider is left for usageProvider to estimate.
+func splitBatchUsage(usage chatCompletionUsage, reqs []*CompletionRequest, results []*CompletionResponse) {
+	promptWeights := make([]int, len(reqs))
+	outputWeights := make([]int, len(reqs))
+	for i := range reqs {
+		promptWeights[i] = estimateTokens(reqs[i])
+		outputWeights[i] = estimateTextTokens(results[i].Output)
+	}
+	tokensIn := apportion(usage.PromptTokens, promptWeights)
+	tokensOut := apportion(usage.CompletionTokens, outputWeights)
+	for i, r := range results {
+		r.TokensIn, r.TokensOut = tokensIn[i], tokensOut[i]
+	}
+}
+
+// apportion splits total in proportion to weights, evenly when they are all
+// zero. The shares add up to total.
+func apportion(total int, weights []int) []int {
+	shares := make([]int, len(weights))
+	sum := 0
+	for _, w := range weights {
+		sum += w
+	}
+	assigned := 0
+	for i, w := range weights {
+		if sum > 0 {
+			shares[i] = total * w / sum
+		} else {
+			shares[i] = total / len(weights)
+		}
+		assigned += shares[i]
+	}
+	shares[len(shares)-1] += total - assigned
+	return shares
+}
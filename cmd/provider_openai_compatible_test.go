@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_OpenAICompatibleProvider(t *testing.T) {
+	tests := []struct {
+		name       string
+		apiKey     string
+		wantHeader string
+	}{
+		{name: "unauthenticated", apiKey: "", wantHeader: ""},
+		{name: "bearer token", apiKey: "sk-local", wantHeader: "Bearer sk-local"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/chat/completions" {
+					t.Errorf("unexpected path: %s", r.URL.Path)
+				}
+				if got := r.Header.Get("Authorization"); got != tt.wantHeader {
+					t.Errorf("unexpected Authorization header: %q", got)
+				}
+				_, _ = w.Write([]byte(`{"model":"Qwen/Qwen2.5-7B-Instruct","choices":[{"message":{"content":"hello"}}]}`))
+			}))
+			defer srv.Close()
+
+			p, err := NewOpenAICompatibleProvider(&OpenAICompatibleConfig{
+				BaseURL: srv.URL + "/v1",
+				APIKey:  tt.apiKey,
+				Model:   "Qwen/Qwen2.5-7B-Instruct",
+			})
+			if err != nil {
+				t.Fatalf("NewOpenAICompatibleProvider failed: %v", err)
+			}
+
+			resp, err := p.Complete(context.Background(), &CompletionRequest{
+				Messages: []ChatMessage{{Role: "user", Content: "hi"}},
+			})
+			if err != nil {
+				t.Fatalf("Complete failed: %v", err)
+			}
+			if resp.Output != "hello" {
+				t.Errorf("unexpected output: %s", resp.Output)
+			}
+		})
+	}
+}
+
+func Test_OpenAICompatibleProviderConfig(t *testing.T) {
+	if _, err := NewOpenAICompatibleProvider(&OpenAICompatibleConfig{Model: "m"}); err == nil {
+		t.Errorf("expected error for missing base URL")
+	}
+	if _, err := NewOpenAICompatibleProvider(&OpenAICompatibleConfig{BaseURL: "localhost:8000", Model: "m"}); err == nil {
+		t.Errorf("expected error for base URL without scheme")
+	}
+	if _, err := NewOpenAICompatibleProvider(&OpenAICompatibleConfig{BaseURL: "http://localhost:8000/v1"}); err == nil {
+		t.Errorf("expected error for missing model")
+	}
+}
+
+func Test_OpenAICompatibleCompleteBatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/completions" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+
+		var body completionsRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if len(body.Prompt) != 2 || body.Prompt[0] != "first" || body.Prompt[1] != "second" {
+			t.Errorf("unexpected prompts: %q", body.Prompt)
+		}
+
+		// Choices may come back out of order.
+		_, _ = w.Write([]byte(`{"model":"qwen","choices":[{"index":1,"text":"two"},{"index":0,"text":"one"}],"usage":{"prompt_tokens":11,"completion_tokens":5}}`))
+	}))
+	defer srv.Close()
+
+	p, err := NewOpenAICompatibleProvider(&OpenAICompatibleConfig{BaseURL: srv.URL + "/v1", Model: "qwen"})
+	if err != nil {
+		t.Fatalf("NewOpenAICompatibleProvider failed: %v", err)
+	}
+
+	resps, err := p.CompleteBatch(context.Background(), []*CompletionRequest{
+		{Messages: []ChatMessage{{Role: "user", Content: "first"}}},
+		{Messages: []ChatMessage{{Role: "user", Content: "second"}}},
+	})
+	if err != nil {
+		t.Fatalf("CompleteBatch failed: %v", err)
+	}
+	if resps[0].Output != "one" || resps[1].Output != "two" {
+		t.Errorf("unexpected outputs: %q, %q", resps[0].Output, resps[1].Output)
+	}
+	if in, out := resps[0].TokensIn+resps[1].TokensIn, resps[0].TokensOut+resps[1].TokensOut; in != 11 || out != 5 || resps[0].TokensIn == 0 || resps[1].TokensIn == 0 {
+		t.Errorf("expected the batch usage to be split between the requests, got %+v and %+v", resps[0], resps[1])
+	}
+
+	if _, err := p.CompleteBatch(context.Background(), []*CompletionRequest{
+		{Messages: []ChatMessage{{Role: "system", Content: "be brief"}, {Role: "user", Content: "first"}}},
+	}); err == nil {
+		t.Errorf("expected error for a conversation")
+	}
+}
+
+func Test_apportion(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int
+		weights []int
+		want    []int
+	}{
+		{name: "proportional", total: 10, weights: []int{1, 4}, want: []int{2, 8}},
+		{name: "remainder to the last", total: 10, weights: []int{1, 1, 1}, want: []int{3, 3, 4}},
+		{name: "no weights", total: 5, weights: []int{0, 0}, want: []int{2, 3}},
+		{name: "nothing to split", weights: []int{3, 1}, want: []int{0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := apportion(tt.total, tt.weights); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_OpenAIProvider(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("unexpected Authorization header: %s", got)
+		}
+		if got := r.Header.Get("OpenAI-Organization"); got != "org-test" {
+			t.Errorf("unexpected OpenAI-Organization header: %s", got)
+		}
+
+		var body chatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if body.Model != "gpt-test" {
+			t.Errorf("unexpected model: %s", body.Model)
+		}
+
+		_, _ = w.Write([]byte(`{"model":"gpt-test-0001","choices":[{"message":{"role":"assistant","content":"hello"}}],"usage":{"prompt_tokens":9,"completion_tokens":1}}`))
+	}))
+	defer srv.Close()
+
+	p, err := NewOpenAIProvider(&OpenAIConfig{
+		APIKey:       "test-key",
+		Model:        "gpt-test",
+		Organization: "org-test",
+		BaseURL:      srv.URL + "/v1/",
+	})
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+	p.client = srv.Client()
+
+	resp, err := p.Complete(context.Background(), &CompletionRequest{
+		Messages:  []ChatMessage{{Role: "user", Content: "hi"}},
+		MaxTokens: defaultMaxTokens,
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if resp.Output != "hello" || resp.Model != "gpt-test-0001" || resp.TokensIn != 9 || resp.TokensOut != 1 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func Test_OpenAIProviderErrors(t *testing.T) {
+	if _, err := NewOpenAIProvider(&OpenAIConfig{}); err == nil {
+		t.Errorf("expected error for missing API key")
+	}
+	if _, err := NewOpenAIProvider(&OpenAIConfig{APIKey: "k", BaseURL: "http://example.com"}); err == nil {
+		t.Errorf("expected error for non-HTTPS base URL")
+	}
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":{"message":"bad key"}}`))
+	}))
+	defer srv.Close()
+
+	p, err := NewOpenAIProvider(&OpenAIConfig{APIKey: "k", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+	p.client = srv.Client()
+
+	_, err = p.Complete(context.Background(), &CompletionRequest{})
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) || providerErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected ProviderError with status 401, got %v", err)
+	}
+}
+
+func Test_OpenAIProviderEmbed(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+
+		var body openAIEmbeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if body.Model != defaultOpenAIEmbeddingModel || body.Input != "hello" || body.Dimensions != 3 {
+			t.Errorf("unexpected request: %+v", body)
+		}
+
+		_, _ = w.Write([]byte(`{"model":"text-embedding-3-small","data":[{"embedding":[0.1,0.2,0.3]}]}`))
+	}))
+	defer srv.Close()
+
+	p, err := NewOpenAIProvider(&OpenAIConfig{APIKey: "sk-test", BaseURL: srv.URL + "/v1"})
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+	p.client = srv.Client()
+
+	resp, err := p.Embed(context.Background(), &EmbeddingRequest{Input: "hello", Dimensions: 3})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if len(resp.Embedding) != 3 || resp.Model != "text-embedding-3-small" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func Test_OpenAIProviderModerate(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/moderations" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+
+		var body openAIModerationRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if body.Model != defaultOpenAIModerationModel || body.Input != "hello" {
+			t.Errorf("unexpected request: %+v", body)
+		}
+
+		_, _ = w.Write([]byte(`{"model":"omni-moderation-2024-09-26","results":[{"flagged":false,"category_scores":{"hate":0.01,"violence":0.02}}]}`))
+	}))
+	defer srv.Close()
+
+	p, err := NewOpenAIProvider(&OpenAIConfig{APIKey: "sk-test", BaseURL: srv.URL + "/v1"})
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+	p.client = srv.Client()
+
+	resp, err := p.Moderate(context.Background(), &ModerationRequest{Input: "hello"})
+	if err != nil {
+		t.Fatalf("Moderate failed: %v", err)
+	}
+	if resp.Flagged || len(resp.Scores) != 2 || resp.Scores["violence"] != 0.02 || resp.Model != "omni-moderation-2024-09-26" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
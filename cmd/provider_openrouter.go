@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultOpenRouterBaseURL = "https://openrouter.ai/api/v1"
+	defaultOpenRouterModel   = "openai/gpt-4o-mini"
+)
+
+// OpenRouterConfig configures the OpenRouter gateway provider.
+type OpenRouterConfig struct {
+	// APIKey is sent as a bearer token.
+	APIKey string
+	// Model is the OpenRouter model slug, e.g. "anthropic/claude-3.5-haiku" or
+	// "openrouter/auto" to let OpenRouter pick.
+	Model string
+	// BaseURL overrides the default https://openrouter.ai/api/v1.
+	BaseURL string
+	// SiteURL and AppName are optional attribution headers shown on openrouter.ai.
+	SiteURL string
+	AppName string
+}
+
+func openRouterConfigFromEnv(ctx context.Context) (*OpenRouterConfig, error) {
+	apiKey, err := secretFromEnv(ctx, "OPENROUTER_API_KEY")
+	if err != nil {
+		return nil, err
+	}
+	return &OpenRouterConfig{
+		APIKey:  apiKey,
+		Model:   getenv("OPENROUTER_MODEL"),
+		BaseURL: getenv("OPENROUTER_BASE_URL"),
+		SiteURL: getenv("OPENROUTER_SITE_URL"),
+		AppName: getenv("OPENROUTER_APP_NAME"),
+	}, nil
+}
+
+// OpenRouterProvider sends chat completions through the OpenRouter gateway, which
+// gives access to many upstream models with a single API key. The model that
+// actually served the request is taken from the response, since OpenRouter may
+// route "auto" or fallback requests to a different model than the one requested.
+type OpenRouterProvider struct {
+	config *OpenRouterConfig
+	client *http.Client
+}
+
+func NewOpenRouterProvider(cfg *OpenRouterConfig) (*OpenRouterProvider, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("OpenRouter API key not set")
+	}
+	if cfg.Model == "" {
+		cfg.Model = defaultOpenRouterModel
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultOpenRouterBaseURL
+	}
+	if !strings.HasPrefix(cfg.BaseURL, "https://") {
+		return nil, fmt.Errorf("OpenRouter base URL must use HTTPS")
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	return &OpenRouterProvider{
+		config: cfg,
+		client: newHTTPClient(),
+	}, nil
+}
+
+func (p *OpenRouterProvider) Name() string {
+	return "openrouter"
+}
+
+func (p *OpenRouterProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	headers := map[string]string{
+		"Authorization": "Bearer " + p.config.APIKey,
+	}
+	if p.config.SiteURL != "" {
+		headers["HTTP-Referer"] = p.config.SiteURL
+	}
+	if p.config.AppName != "" {
+		headers["X-Title"] = p.config.AppName
+	}
+
+	resp, err := completeChat(ctx, p.client, p.Name(), p.config.BaseURL+"/chat/completions", headers, req.modelOr(p.config.Model), req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Model == "" {
+		resp.Model = req.modelOr(p.config.Model)
+	}
+	return resp, nil
+}
+
+// Probe checks that the gateway is reachable by listing models.
+func (p *OpenRouterProvider) Probe(ctx context.Context) error {
+	return probeHTTP(ctx, p.client, p.config.BaseURL+"/models", nil)
+}
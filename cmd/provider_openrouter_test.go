@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_OpenRouterProvider(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer or-key" {
+			t.Errorf("unexpected Authorization header: %s", got)
+		}
+		if got := r.Header.Get("X-Title"); got != "my-avs" {
+			t.Errorf("unexpected X-Title header: %s", got)
+		}
+		_, _ = w.Write([]byte(`{"model":"anthropic/claude-3.5-haiku","choices":[{"message":{"content":"hello"}}]}`))
+	}))
+	defer srv.Close()
+
+	p, err := NewOpenRouterProvider(&OpenRouterConfig{
+		APIKey:  "or-key",
+		Model:   "openrouter/auto",
+		BaseURL: srv.URL,
+		AppName: "my-avs",
+	})
+	if err != nil {
+		t.Fatalf("NewOpenRouterProvider failed: %v", err)
+	}
+	p.client = srv.Client()
+
+	resp, err := p.Complete(context.Background(), &CompletionRequest{
+		Messages: []ChatMessage{{Role: "user", Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if resp.Model != "anthropic/claude-3.5-haiku" {
+		t.Errorf("expected routed model to be reported, got %s", resp.Model)
+	}
+}
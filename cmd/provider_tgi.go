@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	tgiModeChat     = "chat"
+	tgiModeGenerate = "generate"
+)
+
+// TGIConfig configures the Hugging Face text-generation-inference provider.
+type TGIConfig struct {
+	// URL is the base URL of the TGI server or Inference Endpoint.
+	URL string
+	// Mode selects the API: "chat" uses /v1/chat/completions (Messages API),
+	// "generate" uses the native /generate route with a flattened prompt.
+	Mode string
+	// APIKey is an optional bearer token, required by HF Inference Endpoints.
+	APIKey string
+
+	// Optional sampling parameters; zero values leave the server defaults in place.
+	TopP              float64
+	TopK              int
+	RepetitionPenalty float64
+	Stop              []string
+}
+
+func tgiConfigFromEnv(ctx context.Context) (*TGIConfig, error) {
+	apiKey, err := secretFromEnv(ctx, "TGI_API_KEY")
+	if err != nil {
+		return nil, err
+	}
+	cfg := &TGIConfig{
+		URL:    getenv("TGI_URL"),
+		Mode:   getenv("TGI_MODE"),
+		APIKey: apiKey,
+	}
+	if stop := getenv("TGI_STOP"); stop != "" {
+		cfg.Stop = strings.Split(stop, ",")
+	}
+
+	if cfg.TopP, err = envFloat("TGI_TOP_P"); err != nil {
+		return nil, err
+	}
+	if cfg.TopK, err = envInt("TGI_TOP_K"); err != nil {
+		return nil, err
+	}
+	if cfg.RepetitionPenalty, err = envFloat("TGI_REPETITION_PENALTY"); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// TGIProvider sends completions to a text-generation-inference server.
+type TGIProvider struct {
+	config *TGIConfig
+	client *http.Client
+}
+
+func NewTGIProvider(cfg *TGIConfig) (*TGIProvider, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("TGI URL not set")
+	}
+	if !strings.HasPrefix(cfg.URL, "http://") && !strings.HasPrefix(cfg.URL, "https://") {
+		return nil, fmt.Errorf("TGI URL must be an http:// or https:// URL")
+	}
+	cfg.URL = strings.TrimSuffix(cfg.URL, "/")
+
+	switch cfg.Mode {
+	case "":
+		cfg.Mode = tgiModeChat
+	case tgiModeChat, tgiModeGenerate:
+	default:
+		return nil, fmt.Errorf("unsupported TGI mode: %s", cfg.Mode)
+	}
+
+	return &TGIProvider{
+		config: cfg,
+		client: newHTTPClient(),
+	}, nil
+}
+
+func (p *TGIProvider) Name() string {
+	return "tgi"
+}
+
+// tgiParameters are the generation parameters shared by both TGI routes. Pointer
+// fields are omitted when unset so the server applies its own defaults.
+type tgiParameters struct {
+	MaxNewTokens      int      `json:"max_new_tokens,omitempty"`
+	Temperature       *float64 `json:"temperature,omitempty"`
+	TopP              *float64 `json:"top_p,omitempty"`
+	TopK              *int     `json:"top_k,omitempty"`
+	RepetitionPenalty *float64 `json:"repetition_penalty,omitempty"`
+	Stop              []string `json:"stop,omitempty"`
+	DoSample          bool     `json:"do_sample"`
+}
+
+type tgiGenerateRequest struct {
+	Inputs     string        `json:"inputs"`
+	Parameters tgiParameters `json:"parameters"`
+}
+
+type tgiGenerateResponse struct {
+	GeneratedText string `json:"generated_text"`
+}
+
+// tgiChatRequest extends the OpenAI chat request with TGI's extra sampling knobs.
+type tgiChatRequest struct {
+	chatCompletionRequest
+	TopP              *float64 `json:"top_p,omitempty"`
+	TopK              *int     `json:"top_k,omitempty"`
+	RepetitionPenalty *float64 `json:"repetition_penalty,omitempty"`
+	Stop              []string `json:"stop,omitempty"`
+}
+
+func (p *TGIProvider) headers() map[string]string {
+	if p.config.APIKey == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + p.config.APIKey}
+}
+
+func (p *TGIProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	if p.config.Mode == tgiModeGenerate {
+		return p.generate(ctx, req)
+	}
+
+	// TGI serves a single model and ignores the model field, "tgi" is the
+	// conventional placeholder.
+	body := &tgiChatRequest{
+		chatCompletionRequest: *newChatCompletionRequest("tgi", req),
+		TopP:                  optionalFloat(p.config.TopP),
+		TopK:                  optionalInt(p.config.TopK),
+		RepetitionPenalty:     optionalFloat(p.config.RepetitionPenalty),
+		Stop:                  p.config.Stop,
+	}
+
+	url := p.config.URL + "/v1/chat/completions"
+	var completion *CompletionResponse
+	if req.Stream {
+		var err error
+		if completion, err = streamChat(ctx, p.client, p.Name(), url, p.headers(), body); err != nil {
+			return nil, err
+		}
+	} else {
+		var resp chatCompletionResponse
+		if err := doJSONRequest(ctx, p.client, p.Name(), url, p.headers(), body, &resp); err != nil {
+			return nil, err
+		}
+		completion = resp.toCompletionResponse()
+	}
+	completion.Parameters = p.parameters(req, "tgi")
+	return completion, nil
+}
+
+// parameters returns the parameters of req as sent to TGI, including the
+// sampling knobs of the configuration.
+func (p *TGIProvider) parameters(req *CompletionRequest, model string) *CompletionParameters {
+	params := req.parameters(model)
+	params.TopP, params.TopK, params.RepetitionPenalty = p.config.TopP, p.config.TopK, p.config.RepetitionPenalty
+	return params
+}
+
+func (p *TGIProvider) generate(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	// The /generate route takes a single prompt, so the conversation is
+	// flattened in order.
+	var prompt []string
+	for _, m := range req.Messages {
+		prompt = append(prompt, m.Content)
+	}
+
+	body := &tgiGenerateRequest{
+		Inputs: strings.Join(prompt, "\n\n"),
+		Parameters: tgiParameters{
+			MaxNewTokens:      req.MaxTokens,
+			TopP:              optionalFloat(p.config.TopP),
+			TopK:              optionalInt(p.config.TopK),
+			RepetitionPenalty: optionalFloat(p.config.RepetitionPenalty),
+			Stop:              p.config.Stop,
+		},
+	}
+	// TGI rejects a temperature of 0; greedy decoding is expressed by
+	// disabling sampling instead.
+	if req.Temperature > 0 {
+		body.Parameters.Temperature = optionalFloat(req.Temperature)
+		body.Parameters.DoSample = true
+	}
+
+	var resp tgiGenerateResponse
+	if err := doJSONRequest(ctx, p.client, p.Name(), p.config.URL+"/generate", p.headers(), body, &resp); err != nil {
+		return nil, err
+	}
+	// The /generate route is not sent the seed.
+	params := p.parameters(req, "tgi")
+	params.Seed = 0
+	return &CompletionResponse{
+		Output:     resp.GeneratedText,
+		Model:      "tgi",
+		Parameters: params,
+	}, nil
+}
+
+func optionalFloat(v float64) *float64 {
+	if v == 0 {
+		return nil
+	}
+	return &v
+}
+
+func optionalInt(v int) *int {
+	if v == 0 {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_TGIProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/generate":
+			var body tgiGenerateRequest
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("failed to decode request: %v", err)
+			}
+			if body.Inputs != "be brief\n\nhi" {
+				t.Errorf("unexpected inputs: %q", body.Inputs)
+			}
+			if body.Parameters.TopK == nil || *body.Parameters.TopK != 40 {
+				t.Errorf("expected top_k to be forwarded: %+v", body.Parameters)
+			}
+			if !body.Parameters.DoSample {
+				t.Errorf("expected sampling to be enabled for a non-zero temperature")
+			}
+			_, _ = w.Write([]byte(`{"generated_text":"from generate"}`))
+		case "/v1/chat/completions":
+			var body tgiChatRequest
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("failed to decode request: %v", err)
+			}
+			if body.TopK == nil || *body.TopK != 40 || len(body.Messages) != 2 {
+				t.Errorf("unexpected chat request: %+v", body)
+			}
+			_, _ = w.Write([]byte(`{"model":"tgi","choices":[{"message":{"content":"from chat"}}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	for mode, want := range map[string]string{tgiModeChat: "from chat", tgiModeGenerate: "from generate"} {
+		t.Run(mode, func(t *testing.T) {
+			p, err := NewTGIProvider(&TGIConfig{URL: srv.URL, Mode: mode, TopK: 40})
+			if err != nil {
+				t.Fatalf("NewTGIProvider failed: %v", err)
+			}
+
+			resp, err := p.Complete(context.Background(), &CompletionRequest{
+				Messages: []ChatMessage{
+					{Role: "system", Content: "be brief"},
+					{Role: "user", Content: "hi"},
+				},
+				MaxTokens:   defaultMaxTokens,
+				Temperature: defaultTemperature,
+			})
+			if err != nil {
+				t.Fatalf("Complete failed: %v", err)
+			}
+			if resp.Output != want {
+				t.Errorf("unexpected output: %s", resp.Output)
+			}
+		})
+	}
+}
+
+func Test_TGIProviderInvalidMode(t *testing.T) {
+	if _, err := NewTGIProvider(&TGIConfig{URL: "http://localhost:8080", Mode: "stream"}); err == nil {
+		t.Errorf("expected error for unsupported mode")
+	}
+}
+
+func Test_TGIProviderStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body tgiChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if !body.Stream || body.TopK == nil || *body.TopK != 40 {
+			t.Errorf("unexpected chat request: %+v", body)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"model\":\"tgi\",\"choices\":[{\"delta\":{\"content\":\"from \"}}]}\n\n" +
+			"data: {\"choices\":[{\"delta\":{\"content\":\"stream\"}}],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":2}}\n\n" +
+			"data: [DONE]\n\n"))
+	}))
+	defer srv.Close()
+
+	p, err := NewTGIProvider(&TGIConfig{URL: srv.URL, Mode: tgiModeChat, TopK: 40})
+	if err != nil {
+		t.Fatalf("NewTGIProvider failed: %v", err)
+	}
+	resp, err := p.Complete(context.Background(), &CompletionRequest{
+		Messages:    []ChatMessage{{Role: "user", Content: "hi"}},
+		MaxTokens:   defaultMaxTokens,
+		Temperature: defaultTemperature,
+		Stream:      true,
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if resp.Output != "from stream" || resp.TokensOut != 2 || resp.Parameters == nil {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
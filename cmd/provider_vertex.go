@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	defaultVertexLocation = "us-central1"
+	defaultVertexModel    = "gemini-1.5-flash-002"
+	vertexScope           = "https://www.googleapis.com/auth/cloud-platform"
+	// vertexAPIVersion is the version of the Vertex AI API called.
+	vertexAPIVersion = "v1"
+)
+
+// VertexConfig configures the Google Vertex AI (Gemini) provider. When
+// CredentialsFile is empty, Application Default Credentials are used.
+type VertexConfig struct {
+	// Project is the GCP project ID.
+	Project string
+	// Location is the Vertex AI region, e.g. "us-central1".
+	Location string
+	// Model is the Gemini model name, e.g. "gemini-1.5-flash-002".
+	Model string
+	// CredentialsFile is an optional path to a service-account JSON key.
+	CredentialsFile string
+	// BaseURL overrides the regional https://{location}-aiplatform.googleapis.com endpoint.
+	BaseURL string
+}
+
+func vertexConfigFromEnv() *VertexConfig {
+	return &VertexConfig{
+		Project:         getenv("VERTEX_PROJECT"),
+		Location:        getenv("VERTEX_LOCATION"),
+		Model:           getenv("VERTEX_MODEL"),
+		CredentialsFile: getenv("VERTEX_CREDENTIALS_FILE"),
+		BaseURL:         getenv("VERTEX_BASE_URL"),
+	}
+}
+
+// VertexProvider sends chat completions to Gemini models on Vertex AI.
+type VertexProvider struct {
+	config *VertexConfig
+	client *http.Client
+}
+
+func NewVertexProvider(ctx context.Context, cfg *VertexConfig) (*VertexProvider, error) {
+	if cfg.Project == "" {
+		return nil, fmt.Errorf("Vertex AI project not set")
+	}
+	if cfg.Location == "" {
+		cfg.Location = defaultVertexLocation
+	}
+	if cfg.Model == "" {
+		cfg.Model = defaultVertexModel
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = fmt.Sprintf("https://%s-aiplatform.googleapis.com", cfg.Location)
+	}
+	if !strings.HasPrefix(cfg.BaseURL, "https://") {
+		return nil, fmt.Errorf("Vertex AI base URL must use HTTPS")
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	var creds *google.Credentials
+	var err error
+	if cfg.CredentialsFile != "" {
+		data, readErr := os.ReadFile(cfg.CredentialsFile)
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read Vertex AI credentials file: %w", readErr)
+		}
+		creds, err = google.CredentialsFromJSON(ctx, data, vertexScope)
+	} else {
+		creds, err = google.FindDefaultCredentials(ctx, vertexScope)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Google credentials: %w", err)
+	}
+
+	// Both the token refreshes and the API calls go through the shared transport.
+	client := oauth2.NewClient(context.WithValue(ctx, oauth2.HTTPClient, newHTTPClient()), creds.TokenSource)
+	client.Timeout = providerTimeout
+
+	return &VertexProvider{
+		config: cfg,
+		client: client,
+	}, nil
+}
+
+func (p *VertexProvider) Name() string {
+	return "vertex"
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+// geminiGenerateRequest is the request body of the generateContent method.
+type geminiGenerateRequest struct {
+	Contents          []geminiContent `json:"contents"`
+	SystemInstruction *geminiContent  `json:"systemInstruction,omitempty"`
+	GenerationConfig  struct {
+		MaxOutputTokens int     `json:"maxOutputTokens"`
+		Temperature     float64 `json:"temperature"`
+		Seed            int64   `json:"seed,omitempty"`
+	} `json:"generationConfig"`
+}
+
+// geminiGenerateResponse is the subset of the generateContent response that the
+// performer consumes, including the fields that signal a safety block.
+type geminiGenerateResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	ModelVersion  string `json:"modelVersion"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+// geminiBlockedFinishReasons are the candidate finish reasons that mean Gemini
+// refused to produce (or withheld) the output.
+var geminiBlockedFinishReasons = map[string]bool{
+	"SAFETY":             true,
+	"RECITATION":         true,
+	"BLOCKLIST":          true,
+	"PROHIBITED_CONTENT": true,
+	"SPII":               true,
+}
+
+func (p *VertexProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	body := &geminiGenerateRequest{}
+	body.GenerationConfig.MaxOutputTokens = req.MaxTokens
+	body.GenerationConfig.Temperature = req.Temperature
+	body.GenerationConfig.Seed = req.Seed
+	for _, m := range req.Messages {
+		switch m.Role {
+		case "system":
+			if body.SystemInstruction == nil {
+				body.SystemInstruction = &geminiContent{}
+			}
+			body.SystemInstruction.Parts = append(body.SystemInstruction.Parts, geminiPart{Text: m.Content})
+		case "assistant":
+			body.Contents = append(body.Contents, geminiContent{Role: "model", Parts: []geminiPart{{Text: m.Content}}})
+		default:
+			body.Contents = append(body.Contents, geminiContent{Role: "user", Parts: []geminiPart{{Text: m.Content}}})
+		}
+	}
+
+	modelName := req.modelOr(p.config.Model)
+	url := fmt.Sprintf("%s/%s/projects/%s/locations/%s/publishers/google/models/%s:generateContent",
+		p.config.BaseURL, vertexAPIVersion, p.config.Project, p.config.Location, modelName)
+
+	var resp geminiGenerateResponse
+	if err := doJSONRequest(ctx, p.client, p.Name(), url, nil, body, &resp); err != nil {
+		return nil, err
+	}
+
+	model := resp.ModelVersion
+	if model == "" {
+		model = modelName
+	}
+	params := req.parameters(modelName)
+
+	if resp.PromptFeedback.BlockReason != "" {
+		return &CompletionResponse{Model: model, Refused: true, RefusalReason: resp.PromptFeedback.BlockReason, APIVersion: vertexAPIVersion, Parameters: params}, nil
+	}
+	if len(resp.Candidates) == 0 {
+		return &CompletionResponse{Model: model, APIVersion: vertexAPIVersion, Parameters: params}, nil
+	}
+
+	candidate := resp.Candidates[0]
+	if geminiBlockedFinishReasons[candidate.FinishReason] {
+		return &CompletionResponse{Model: model, Refused: true, RefusalReason: candidate.FinishReason, APIVersion: vertexAPIVersion, Parameters: params}, nil
+	}
+
+	var output strings.Builder
+	for _, part := range candidate.Content.Parts {
+		output.WriteString(part.Text)
+	}
+	return &CompletionResponse{
+		Output:     output.String(),
+		Model:      model,
+		TokensIn:   resp.UsageMetadata.PromptTokenCount,
+		TokensOut:  resp.UsageMetadata.CandidatesTokenCount,
+		APIVersion: vertexAPIVersion,
+		Parameters: params,
+	}, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_VertexProvider(t *testing.T) {
+	tests := []struct {
+		name          string
+		response      string
+		wantOutput    string
+		wantRefused   bool
+		wantRefReason string
+	}{
+		{
+			name:       "text output",
+			response:   `{"candidates":[{"content":{"role":"model","parts":[{"text":"hello"}]},"finishReason":"STOP"}],"modelVersion":"gemini-test-002"}`,
+			wantOutput: "hello",
+		},
+		{
+			name:          "blocked prompt",
+			response:      `{"promptFeedback":{"blockReason":"SAFETY"}}`,
+			wantRefused:   true,
+			wantRefReason: "SAFETY",
+		},
+		{
+			name:          "blocked candidate",
+			response:      `{"candidates":[{"content":{"parts":[]},"finishReason":"PROHIBITED_CONTENT"}]}`,
+			wantRefused:   true,
+			wantRefReason: "PROHIBITED_CONTENT",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				want := "/v1/projects/p1/locations/europe-west4/publishers/google/models/gemini-test:generateContent"
+				if r.URL.Path != want {
+					t.Errorf("unexpected path: %s", r.URL.Path)
+				}
+
+				var body geminiGenerateRequest
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+				}
+				if body.SystemInstruction == nil || len(body.Contents) != 1 {
+					t.Errorf("unexpected request: %+v", body)
+				}
+
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer srv.Close()
+
+			p := &VertexProvider{
+				config: &VertexConfig{Project: "p1", Location: "europe-west4", Model: "gemini-test", BaseURL: srv.URL},
+				client: srv.Client(),
+			}
+
+			resp, err := p.Complete(context.Background(), &CompletionRequest{
+				Messages: []ChatMessage{
+					{Role: "system", Content: "be brief"},
+					{Role: "user", Content: "hi"},
+				},
+				MaxTokens: defaultMaxTokens,
+			})
+			if err != nil {
+				t.Fatalf("Complete failed: %v", err)
+			}
+			if resp.Output != tt.wantOutput || resp.Refused != tt.wantRefused || resp.RefusalReason != tt.wantRefReason {
+				t.Errorf("unexpected response: %+v", resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reasons a sender is rate limited.
+const (
+	rateLimitQPS   = "qps"
+	rateLimitDaily = "daily_quota"
+)
+
+// anonymousSender is the sender of tasks whose metadata names none. They
+// share one limit.
+const anonymousSender = "anonymous"
+
+// maxTrackedSenders bounds the senders the limiter keeps state for; idle
+// senders are dropped beyond it.
+const maxTrackedSenders = 10000
+
+// ErrSenderRateLimited is matched by the RateLimitError of a rejected task.
+var ErrSenderRateLimited = errors.New("sender rate limited")
+
+// RateLimitError reports which limit a sender exceeded and when it may send
+// again.
+type RateLimitError struct {
+	Sender     string
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("sender %s exceeded its %s limit, retry after %s", e.Sender, e.Reason, e.RetryAfter.Round(time.Second))
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrSenderRateLimited
+}
+
+func (e *RateLimitError) retryAfter() time.Duration {
+	return e.RetryAfter
+}
+
+// taskSender returns the sender of a task from its metadata, which names it
+// as "sender" or, for applications, "app":
+//
+//	{"sender": "0x...", "app": "my-dapp"}
+func taskSender(metadata []byte) string {
+	var md struct {
+		Sender string `json:"sender"`
+		App    string `json:"app"`
+	}
+	if len(metadata) > 0 && json.Unmarshal(metadata, &md) == nil {
+		if s := strings.TrimSpace(md.Sender); s != "" {
+			return strings.ToLower(s)
+		}
+		if s := strings.TrimSpace(md.App); s != "" {
+			return strings.ToLower(s)
+		}
+	}
+	return anonymousSender
+}
+
+// senderState is the token bucket and daily usage of one sender.
+type senderState struct {
+	tokens float64
+	last   time.Time
+	day    string
+	used   int
+}
+
+// SenderRateLimiter limits the tasks of each sender with a token bucket
+// refilled at qps up to burst tasks, and a quota of tasks per UTC day, so a
+// single sender cannot spend the operator's LLM budget.
+type SenderRateLimiter struct {
+	qps        float64
+	burst      int
+	dailyQuota int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	senders map[string]*senderState
+}
+
+// senderRateLimiterFromEnv configures per-sender limits from
+// SENDER_RATE_LIMIT_QPS, SENDER_RATE_LIMIT_BURST (by default the QPS rounded
+// up) and SENDER_DAILY_QUOTA, and returns nil when no limit is set.
+func senderRateLimiterFromEnv() (*SenderRateLimiter, error) {
+	qps, err := envFloat("SENDER_RATE_LIMIT_QPS")
+	if err != nil {
+		return nil, err
+	}
+	burst, err := envInt("SENDER_RATE_LIMIT_BURST")
+	if err != nil {
+		return nil, err
+	}
+	quota, err := envInt("SENDER_DAILY_QUOTA")
+	if err != nil {
+		return nil, err
+	}
+	if qps < 0 || burst < 0 || quota < 0 {
+		return nil, fmt.Errorf("sender rate limits must not be negative")
+	}
+	if qps == 0 && quota == 0 {
+		return nil, nil
+	}
+	return NewSenderRateLimiter(qps, burst, quota), nil
+}
+
+// NewSenderRateLimiter returns a limiter; a qps or dailyQuota of 0 disables
+// that limit.
+func NewSenderRateLimiter(qps float64, burst, dailyQuota int) *SenderRateLimiter {
+	if burst <= 0 {
+		burst = int(math.Ceil(qps))
+	}
+	return &SenderRateLimiter{qps: qps, burst: burst, dailyQuota: dailyQuota, now: time.Now, senders: map[string]*senderState{}}
+}
+
+// Allow takes one task from the sender's limits, or returns a RateLimitError
+// when either is exhausted.
+func (l *SenderRateLimiter) Allow(sender string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	day := now.UTC().Format(time.DateOnly)
+	s, ok := l.senders[sender]
+	if !ok {
+		if len(l.senders) >= maxTrackedSenders {
+			l.prune(now, day)
+		}
+		s = &senderState{tokens: float64(l.burst), last: now, day: day}
+		l.senders[sender] = s
+	}
+	if s.day != day {
+		s.day, s.used = day, 0
+	}
+
+	if l.dailyQuota > 0 && s.used >= l.dailyQuota {
+		midnight := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+		senderRateLimited.WithLabelValues(rateLimitDaily).Inc()
+		return &RateLimitError{Sender: sender, Reason: rateLimitDaily, RetryAfter: midnight.Sub(now)}
+	}
+	if l.qps > 0 {
+		s.tokens = math.Min(float64(l.burst), s.tokens+now.Sub(s.last).Seconds()*l.qps)
+		s.last = now
+		if s.tokens < 1 {
+			senderRateLimited.WithLabelValues(rateLimitQPS).Inc()
+			return &RateLimitError{Sender: sender, Reason: rateLimitQPS, RetryAfter: time.Duration((1 - s.tokens) / l.qps * float64(time.Second))}
+		}
+		s.tokens--
+	}
+	s.used++
+	return nil
+}
+
+// prune drops the senders whose bucket has refilled and who sent no task
+// today, whose state is the same as a new sender's.
+func (l *SenderRateLimiter) prune(now time.Time, day string) {
+	for sender, s := range l.senders {
+		refilled := l.qps == 0 || s.tokens+now.Sub(s.last).Seconds()*l.qps >= float64(l.burst)
+		if refilled && (s.day != day || s.used == 0) {
+			delete(l.senders, sender)
+		}
+	}
+}
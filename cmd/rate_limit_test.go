@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_taskSender(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata string
+		want     string
+	}{
+		{name: "no metadata", want: anonymousSender},
+		{name: "sender", metadata: `{"sender": "0xAbC", "app": "dapp"}`, want: "0xabc"},
+		{name: "app", metadata: `{"app": "My-Dapp"}`, want: "my-dapp"},
+		{name: "neither", metadata: `{"signature": "0x12"}`, want: anonymousSender},
+		{name: "not json", metadata: `sender`, want: anonymousSender},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := taskSender([]byte(tt.metadata)); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_SenderRateLimiterAllow(t *testing.T) {
+	start := time.Date(2024, 10, 15, 23, 59, 0, 0, time.UTC)
+
+	type call struct {
+		sender     string
+		at         time.Duration
+		wantReason string
+	}
+	tests := []struct {
+		name   string
+		qps    float64
+		burst  int
+		quota  int
+		calls  []call
+		wantIn time.Duration
+	}{
+		{
+			name: "burst then refill",
+			qps:  1, burst: 2,
+			calls: []call{
+				{sender: "a"},
+				{sender: "a"},
+				{sender: "a", wantReason: rateLimitQPS},
+				{sender: "b"},
+				{sender: "a", at: time.Second},
+			},
+		},
+		{
+			name:  "daily quota resets at midnight",
+			quota: 2,
+			calls: []call{
+				{sender: "a"},
+				{sender: "a", at: 10 * time.Second},
+				{sender: "a", at: 20 * time.Second, wantReason: rateLimitDaily},
+				{sender: "a", at: time.Minute},
+			},
+		},
+		{
+			name: "rejected tasks do not use the quota",
+			qps:  1, burst: 1, quota: 2,
+			calls: []call{
+				{sender: "a"},
+				{sender: "a", wantReason: rateLimitQPS},
+				{sender: "a", at: time.Second},
+				{sender: "a", at: 2 * time.Second, wantReason: rateLimitDaily},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewSenderRateLimiter(tt.qps, tt.burst, tt.quota)
+			for i, c := range tt.calls {
+				l.now = func() time.Time { return start.Add(c.at) }
+				err := l.Allow(c.sender)
+				var rateErr *RateLimitError
+				switch {
+				case c.wantReason == "" && err != nil:
+					t.Fatalf("call %d: unexpected error %v", i, err)
+				case c.wantReason != "" && !errors.As(err, &rateErr):
+					t.Fatalf("call %d: expected a rate limit error, got %v", i, err)
+				case c.wantReason != "" && (rateErr.Reason != c.wantReason || rateErr.RetryAfter <= 0):
+					t.Fatalf("call %d: unexpected rate limit error %+v", i, rateErr)
+				}
+			}
+		})
+	}
+}
+
+func Test_senderRateLimiterFromEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		wantBurst int
+		wantNil   bool
+		wantErr   bool
+	}{
+		{name: "unset", wantNil: true},
+		{name: "qps", env: map[string]string{"SENDER_RATE_LIMIT_QPS": "0.5"}, wantBurst: 1},
+		{name: "qps and burst", env: map[string]string{"SENDER_RATE_LIMIT_QPS": "2", "SENDER_RATE_LIMIT_BURST": "10"}, wantBurst: 10},
+		{name: "quota only", env: map[string]string{"SENDER_DAILY_QUOTA": "100"}},
+		{name: "negative", env: map[string]string{"SENDER_DAILY_QUOTA": "-1"}, wantErr: true},
+		{name: "invalid", env: map[string]string{"SENDER_RATE_LIMIT_QPS": "fast"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"SENDER_RATE_LIMIT_QPS", "SENDER_RATE_LIMIT_BURST", "SENDER_DAILY_QUOTA"} {
+				t.Setenv(key, tt.env[key])
+			}
+			l, err := senderRateLimiterFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (l == nil) != tt.wantNil {
+				t.Fatalf("expected nil limiter %v, got %+v", tt.wantNil, l)
+			}
+			if l != nil && l.burst != tt.wantBurst {
+				t.Errorf("expected burst %d, got %d", tt.wantBurst, l.burst)
+			}
+		})
+	}
+}
+
+func Test_HandleTaskRateLimited(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "4"}, nil)
+	tw.SetSenderRateLimiter(NewSenderRateLimiter(0, 0, 1))
+	task := &performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?"), Metadata: []byte(`{"app": "dapp"}`)}
+
+	if _, err := tw.HandleTask(task); err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+	resp, err := tw.HandleTask(task)
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+	var result struct {
+		ErrorCode  string `json:"error_code"`
+		Retryable  bool   `json:"retryable"`
+		RetryAfter int    `json:"retry_after_seconds"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if result.ErrorCode != errorCodeRateLimited || !result.Retryable || result.RetryAfter <= 0 {
+		t.Errorf("expected a retryable rate limited result, got %s", resp.Result)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis serves GET, SET, AUTH and SELECT from memory and records the
+// commands it received.
+type fakeRedis struct {
+	password string
+
+	mu       sync.Mutex
+	values   map[string]string
+	commands []string
+}
+
+func startFakeRedis(t *testing.T, password string) (*fakeRedis, string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s := &fakeRedis{password: password, values: map[string]string{}}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s, ln.Addr().String()
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authed := s.password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, strings.Join(args, " "))
+		var reply string
+		switch {
+		case args[0] == "AUTH":
+			if authed = args[len(args)-1] == s.password; authed {
+				reply = "+OK\r\n"
+			} else {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case !authed:
+			reply = "-NOAUTH Authentication required.\r\n"
+		case args[0] == "SELECT":
+			reply = "+OK\r\n"
+		case args[0] == "SET":
+			s.values[args[1]] = args[2]
+			reply = "+OK\r\n"
+		case args[0] == "GET":
+			if v, ok := s.values[args[1]]; ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+			} else {
+				reply = "$-1\r\n"
+			}
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		s.mu.Unlock()
+		io.WriteString(conn, reply)
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		arg := make([]byte, size+2)
+		if _, err := io.ReadFull(r, arg); err != nil {
+			return nil, err
+		}
+		args[i] = string(arg[:size])
+	}
+	return args, nil
+}
+
+func Test_RedisResultCache(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		userinfo string
+		db       string
+		wantErr  bool
+	}{
+		{name: "no auth"},
+		{name: "password", password: "secret", userinfo: ":secret@"},
+		{name: "user and password", password: "secret", userinfo: "performer:secret@"},
+		{name: "database", db: "/2"},
+		{name: "wrong password", password: "secret", userinfo: ":wrong@", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, addr := startFakeRedis(t, tt.password)
+			c, err := NewRedisResultCache("redis://"+tt.userinfo+addr+tt.db, time.Minute)
+			if err != nil {
+				t.Fatalf("NewRedisResultCache failed: %v", err)
+			}
+			ctx := context.Background()
+
+			err = c.Set(ctx, "key", []byte(`{"llm_output":"4"}`))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			got, hit, err := c.Get(ctx, "key")
+			if err != nil || !hit || string(got) != `{"llm_output":"4"}` {
+				t.Errorf("expected a hit, got %q %v %v", got, hit, err)
+			}
+			if _, hit, err := c.Get(ctx, "other"); err != nil || hit {
+				t.Errorf("expected a miss, got %v %v", hit, err)
+			}
+
+			server.mu.Lock()
+			defer server.mu.Unlock()
+			if _, ok := server.values[redisKeyPrefix+"key"]; !ok {
+				t.Errorf("expected the result under the prefixed key, got %v", server.values)
+			}
+			for _, cmd := range server.commands {
+				if strings.HasPrefix(cmd, "SET ") && !strings.HasSuffix(cmd, " PX 60000") {
+					t.Errorf("expected the result to expire with the TTL, got %q", cmd)
+				}
+			}
+			if tt.db != "" && !slices.Contains(server.commands, "SELECT 2") {
+				t.Errorf("expected database 2 to be selected, got %v", server.commands)
+			}
+		})
+	}
+}
+
+func Test_NewRedisResultCache(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		wantAddr string
+		wantTLS  bool
+		wantErr  bool
+	}{
+		{name: "default port", url: "redis://redis", wantAddr: "redis:6379"},
+		{name: "tls", url: "rediss://redis:6380", wantAddr: "redis:6380", wantTLS: true},
+		{name: "unsupported scheme", url: "http://redis:6379", wantErr: true},
+		{name: "invalid database", url: "redis://redis:6379/zero", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewRedisResultCache(tt.url, time.Minute)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && (c.addr != tt.wantAddr || c.tls != tt.wantTLS) {
+				t.Errorf("expected %s tls %v, got %s tls %v", tt.wantAddr, tt.wantTLS, c.addr, c.tls)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ResultEncoder converts the canonical JSON result into the form returned to
+// the executor.
+type ResultEncoder interface {
+	Encode(result []byte) ([]byte, error)
+}
+
+// resultEncoderFromEnv returns the encoder selected by RESULT_ENCODING, or nil
+// when results are returned as JSON.
+func resultEncoderFromEnv() (ResultEncoder, error) {
+	switch encoding := getenv("RESULT_ENCODING"); encoding {
+	case "", "json":
+		return nil, nil
+	case "abi":
+		return &ABIResultEncoder{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported result encoding %q", encoding)
+	}
+}
+
+// abiResultArguments is the layout of ABI-encoded results:
+//
+//	abi.decode(result, (bytes32 outputHash, bool verified, string modelId))
+var abiResultArguments = func() abi.Arguments {
+	bytes32, _ := abi.NewType("bytes32", "", nil)
+	boolean, _ := abi.NewType("bool", "", nil)
+	str, _ := abi.NewType("string", "", nil)
+	return abi.Arguments{
+		{Name: "outputHash", Type: bytes32},
+		{Name: "verified", Type: boolean},
+		{Name: "modelId", Type: str},
+	}
+}()
+
+// executionResultFields are the result fields that describe how an operator
+// produced a result rather than its output: they differ between operators
+// that agree on the output and are left out of the ABI output hash.
+var executionResultFields = map[string]bool{
+	"metadata":          true,
+	"verified":          true,
+	attestationField:    true,
+	resultDigestField:   true,
+	"result_version":    true,
+	"provider":          true,
+	"model":             true,
+	"prompt_hash":       true,
+	"tokens_in":         true,
+	"tokens_out":        true,
+	"latency_ms":        true,
+	"performer_version": true,
+}
+
+// ABIResultEncoder ABI-encodes results so they can be consumed directly by
+// on-chain verification contracts. The output hash is the keccak256 of the
+// canonical JSON of the task output: the result without its
+// executionResultFields, text output canonicalized as in deterministic mode.
+// Operators that produce the same output thus encode the same hash, whatever
+// their metadata.
+type ABIResultEncoder struct{}
+
+func (e *ABIResultEncoder) Encode(result []byte) ([]byte, error) {
+	var fields struct {
+		Verified struct {
+			Passed bool `json:"passed"`
+		} `json:"verified"`
+		Metadata struct {
+			Model string `json:"model"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(result, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode result: %w", err)
+	}
+	outputHash, err := resultOutputHash(result)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := abiResultArguments.Pack(outputHash, fields.Verified.Passed, fields.Metadata.Model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ABI-encode result: %w", err)
+	}
+	return out, nil
+}
+
+// resultOutputHash returns the keccak256 of the canonical JSON of the task
+// output of a JSON result, see ABIResultEncoder.
+func resultOutputHash(result []byte) ([32]byte, error) {
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(result, &decoded); err != nil {
+		return [32]byte{}, fmt.Errorf("failed to decode result: %w", err)
+	}
+	output := make(map[string]interface{}, len(decoded))
+	for key, value := range decoded {
+		if !executionResultFields[key] {
+			output[key] = value
+		}
+	}
+	canonicalizeOutput(output)
+	canonical, err := canonicalJSON(output)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("failed to encode result output: %w", err)
+	}
+	return crypto.Keccak256Hash(canonical), nil
+}
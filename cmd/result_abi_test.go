@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_resultEncoderFromEnv(t *testing.T) {
+	tests := []struct {
+		encoding string
+		wantNil  bool
+		wantErr  bool
+	}{
+		{encoding: "", wantNil: true},
+		{encoding: "json", wantNil: true},
+		{encoding: "abi"},
+		{encoding: "xml", wantNil: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			t.Setenv("RESULT_ENCODING", tt.encoding)
+			encoder, err := resultEncoderFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if (encoder == nil) != tt.wantNil {
+				t.Errorf("expected nil encoder %v, got %v", tt.wantNil, encoder)
+			}
+		})
+	}
+}
+
+func Test_HandleTaskABIResult(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "valid"}, nil)
+	resp, err := tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")})
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+	wantHash, err := resultOutputHash(resp.Result)
+	if err != nil {
+		t.Fatalf("resultOutputHash failed: %v", err)
+	}
+
+	tw.SetResultEncoder(&ABIResultEncoder{})
+	resp, err = tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")})
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+
+	values, err := abiResultArguments.Unpack(resp.Result)
+	if err != nil {
+		t.Fatalf("failed to ABI-decode result: %v", err)
+	}
+	outputHash, verified, modelID := values[0].([32]byte), values[1].(bool), values[2].(string)
+	if outputHash != wantHash || !verified || modelID != "stub-model" {
+		t.Errorf("unexpected result (%x, %v, %q), expected output hash %x", outputHash, verified, modelID, wantHash)
+	}
+}
+
+func Test_ABIResultEncoderOutputHash(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      string
+		wantEqual bool
+	}{
+		{
+			name:      "metadata differs",
+			a:         `{"llm_output":"4","verified":{"passed":true},"metadata":{"model":"m","correlation_id":"a","performer_version":"v1"},"result_digest":"0x01","latency_ms":120,"tokens_in":5}`,
+			b:         `{"llm_output":"4","verified":{"passed":true},"metadata":{"model":"m","correlation_id":"b","performer_version":"v2"},"result_digest":"0x02","latency_ms":930,"tokens_in":7}`,
+			wantEqual: true,
+		},
+		{
+			name:      "trailing whitespace differs",
+			a:         `{"llm_output":"4\r\n","verified":{"passed":true},"metadata":{"model":"m"}}`,
+			b:         `{"llm_output":"4","verified":{"passed":true},"metadata":{"model":"m"}}`,
+			wantEqual: true,
+		},
+		{
+			name: "output differs",
+			a:    `{"llm_output":"4","verified":{"passed":true},"metadata":{"model":"m"}}`,
+			b:    `{"llm_output":"5","verified":{"passed":true},"metadata":{"model":"m"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := (&ABIResultEncoder{}).Encode([]byte(tt.a))
+			if err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+			b, err := (&ABIResultEncoder{}).Encode([]byte(tt.b))
+			if err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+			if bytes.Equal(a, b) != tt.wantEqual {
+				t.Errorf("expected equal encodings %v, got %x and %x", tt.wantEqual, a, b)
+			}
+		})
+	}
+}
+
+func Test_ABIResultEncoderInvalidResult(t *testing.T) {
+	if _, err := (&ABIResultEncoder{}).Encode([]byte(`{"verified":`)); err == nil {
+		t.Errorf("expected error for invalid result")
+	}
+}
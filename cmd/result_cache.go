@@ -0,0 +1,231 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+)
+
+const defaultResultCacheTTL = 10 * time.Minute
+
+// ResultCache stores the canonical JSON results of tasks by the hash of
+// their prompt and parameters, see resultCacheKey.
+type ResultCache interface {
+	// Get returns the result cached under key, if any.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set caches result under key.
+	Set(ctx context.Context, key string, result []byte) error
+}
+
+// resultCacheFromEnv configures the result cache from RESULT_CACHE_SIZE, the
+// number of results kept in memory, and RESULT_CACHE_TTL, how long they are
+// served (10m by default). When RESULT_CACHE_REDIS_URL (a secret, see
+// secretFromEnv) is set, results are cached in Redis instead so the replicas
+// of an operator share them. It returns nil when neither the size nor Redis
+// is set.
+func resultCacheFromEnv(ctx context.Context) (ResultCache, error) {
+	size, err := envInt("RESULT_CACHE_SIZE")
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("RESULT_CACHE_SIZE must not be negative")
+	}
+	redisURL, err := secretFromEnv(ctx, "RESULT_CACHE_REDIS_URL")
+	if err != nil {
+		return nil, err
+	}
+	if size == 0 && redisURL == "" {
+		return nil, nil
+	}
+	ttl, err := envDuration("RESULT_CACHE_TTL")
+	if err != nil {
+		return nil, err
+	}
+	if ttl == 0 {
+		ttl = defaultResultCacheTTL
+	}
+	if redisURL != "" {
+		c, err := NewRedisResultCache(redisURL, ttl)
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	return NewLRUResultCache(size, ttl), nil
+}
+
+// resultCacheScope is the configuration of the operator that determines the
+// result of a task along with the task itself. Results cached under another
+// scope, before a configuration reload or by a replica configured otherwise,
+// are not served.
+type resultCacheScope struct {
+	ExamplesHash     string
+	SystemPromptHash string
+	// Template and TemplateHash identify the resolved prompt template and
+	// its content.
+	Template     string
+	TemplateHash string
+	// ModelSettings is the hash of the model settings, see
+	// modelSettingsHash.
+	ModelSettings string
+	Deterministic bool
+}
+
+// resultCacheScope returns the scope of the results of payload.
+func (tw *TaskWorker) resultCacheScope(taskType string, p *TaskPayload) *resultCacheScope {
+	scope := &resultCacheScope{
+		ExamplesHash:  tw.examples.Load().Hash(taskType),
+		ModelSettings: tw.modelSettings,
+		Deterministic: tw.deterministic,
+	}
+	if sp, ok := tw.provider.(*SystemPromptProvider); ok {
+		scope.SystemPromptHash = sp.Hash()
+	}
+	if p.Template != "" {
+		scope.Template = templateID(p.Template, p.TemplateVersion)
+		scope.TemplateHash = tw.templates.Load().Hash(p.Template, p.TemplateVersion)
+	}
+	return scope
+}
+
+// resultCacheKey hashes everything that determines the result of a task: the
+// structured payload, or the prompt of a raw-string payload, its encoding, the
+// generation parameters it resolves to and the scope of the operator's
+// configuration.
+func resultCacheKey(p *TaskPayload, encoding string, scope *resultCacheScope) string {
+	task := p.raw
+	if task == nil {
+		task = []byte(p.Prompt)
+	}
+	req := p.CompletionRequest()
+	key, _ := json.Marshal(struct {
+		Task          []byte
+		SchemaVersion int
+		Encoding      string
+		MaxTokens     int
+		Temperature   float64
+		Scope         *resultCacheScope
+	}{task, p.SchemaVersion, encoding, req.MaxTokens, req.Temperature, scope})
+	return crypto.Keccak256Hash(key).Hex()
+}
+
+// modelSettingsHash returns the hash of the settings that select the
+// providers and models: LLM_PROVIDER and the other LLM_ settings,
+// MODEL_ALLOWLIST and every model and deployment setting. They are only read
+// at startup, but results cached in Redis outlive a restart.
+func modelSettingsHash() string {
+	var settings []string
+	for _, f := range configFields(reflect.TypeOf(Config{})) {
+		if strings.HasPrefix(f.env, "LLM_") || f.env == "MODEL_ALLOWLIST" ||
+			strings.HasSuffix(f.env, "_MODEL") || strings.HasSuffix(f.env, "_MODEL_ID") || strings.HasSuffix(f.env, "_DEPLOYMENT") {
+			settings = append(settings, f.env+"="+getenv(f.env))
+		}
+	}
+	sort.Strings(settings)
+	return crypto.Keccak256Hash([]byte(strings.Join(settings, "\n"))).Hex()
+}
+
+// cachedResponse answers t with the result cached under key. Cache errors are
+// logged and treated as misses so an unavailable cache never fails tasks.
+func (tw *TaskWorker) cachedResponse(ctx context.Context, t *performerV1.TaskRequest, key string) (*performerV1.TaskResponse, bool) {
+	resultBytes, ok, err := tw.cache.Get(ctx, key)
+	if err != nil {
+		correlatedLogger(ctx, tw.logger).Sugar().Warnw("Failed to read result cache",
+			zap.String("taskId", string(t.TaskId)),
+			zap.Error(err),
+		)
+	}
+	if !ok || err != nil {
+		resultCacheRequests.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	resp, err := tw.encodeResponse(t, resultBytes)
+	if err != nil {
+		correlatedLogger(ctx, tw.logger).Sugar().Warnw("Failed to encode cached result",
+			zap.String("taskId", string(t.TaskId)),
+			zap.Error(err),
+		)
+		resultCacheRequests.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	resultCacheRequests.WithLabelValues("hit").Inc()
+	if tw.audit != nil {
+		tw.audit.RecordResult(t, auditSourceCache, resultBytes)
+	}
+	correlatedLogger(ctx, tw.logger).Sugar().Infow("Served task from cache",
+		zap.String("taskId", string(t.TaskId)),
+		zap.String("cacheKey", key),
+	)
+	return resp, true
+}
+
+type lruEntry struct {
+	key     string
+	result  []byte
+	expires time.Time
+}
+
+// LRUResultCache keeps the most recently used results in memory, each for a
+// fixed TTL.
+type LRUResultCache struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func NewLRUResultCache(size int, ttl time.Duration) *LRUResultCache {
+	return &LRUResultCache{size: size, ttl: ttl, now: time.Now, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (c *LRUResultCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := el.Value.(*lruEntry)
+	if !c.now().Before(entry.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(el)
+	return entry.result, true, nil
+}
+
+func (c *LRUResultCache) Set(ctx context.Context, key string, result []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(c.ttl)
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*lruEntry)
+		entry.result, entry.expires = result, expires
+		c.order.MoveToFront(el)
+		return nil
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, result: result, expires: expires})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_LRUResultCache(t *testing.T) {
+	tests := []struct {
+		name string
+		// ops sets ("+key") or reads ("key") keys in order.
+		ops     []string
+		elapsed time.Duration
+		get     string
+		wantHit bool
+	}{
+		{name: "hit", ops: []string{"+a"}, get: "a", wantHit: true},
+		{name: "miss", ops: []string{"+a"}, get: "b"},
+		{name: "least recently used evicted", ops: []string{"+a", "+b", "+c"}, get: "a"},
+		{name: "recently read kept", ops: []string{"+a", "+b", "a", "+c"}, get: "a", wantHit: true},
+		{name: "expired", ops: []string{"+a"}, elapsed: time.Minute, get: "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			c := NewLRUResultCache(2, time.Minute)
+			c.now = func() time.Time { return now }
+
+			for _, op := range tt.ops {
+				if key, ok := strings.CutPrefix(op, "+"); ok {
+					if err := c.Set(ctx, key, []byte(key)); err != nil {
+						t.Fatalf("Set failed: %v", err)
+					}
+				} else if _, _, err := c.Get(ctx, op); err != nil {
+					t.Fatalf("Get failed: %v", err)
+				}
+			}
+			now = now.Add(tt.elapsed)
+
+			got, hit, err := c.Get(ctx, tt.get)
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			if hit != tt.wantHit {
+				t.Fatalf("expected hit %v, got %v", tt.wantHit, hit)
+			}
+			if hit && string(got) != tt.get {
+				t.Errorf("expected %q, got %q", tt.get, got)
+			}
+		})
+	}
+}
+
+func Test_resultCacheKey(t *testing.T) {
+	parse := func(payload string) *TaskPayload {
+		p, err := ParseTaskPayload([]byte(payload))
+		if err != nil {
+			t.Fatalf("failed to parse payload: %v", err)
+		}
+		return p
+	}
+	base := resultCacheKey(parse(`{"schema_version":1,"prompt":"What is 2+2?"}`), payloadEncodingJSON, &resultCacheScope{})
+
+	tests := []struct {
+		name     string
+		payload  string
+		encoding string
+		scope    resultCacheScope
+		wantSame bool
+	}{
+		{name: "identical", payload: `{"schema_version":1,"prompt":"What is 2+2?"}`, encoding: payloadEncodingJSON, wantSame: true},
+		{name: "prompt", payload: `{"schema_version":1,"prompt":"What is 3+3?"}`, encoding: payloadEncodingJSON},
+		{name: "parameters", payload: `{"schema_version":1,"prompt":"What is 2+2?","temperature":0.7}`, encoding: payloadEncodingJSON},
+		{name: "encoding", payload: `{"schema_version":1,"prompt":"What is 2+2?"}`, encoding: payloadEncodingCBOR},
+		{name: "examples", payload: `{"schema_version":1,"prompt":"What is 2+2?"}`, encoding: payloadEncodingJSON, scope: resultCacheScope{ExamplesHash: "0xabc"}},
+		{name: "system prompt", payload: `{"schema_version":1,"prompt":"What is 2+2?"}`, encoding: payloadEncodingJSON, scope: resultCacheScope{SystemPromptHash: "0xabc"}},
+		{name: "template", payload: `{"schema_version":1,"prompt":"What is 2+2?"}`, encoding: payloadEncodingJSON, scope: resultCacheScope{Template: "qa@1", TemplateHash: "0xabc"}},
+		{name: "model settings", payload: `{"schema_version":1,"prompt":"What is 2+2?"}`, encoding: payloadEncodingJSON, scope: resultCacheScope{ModelSettings: "0xabc"}},
+		{name: "deterministic", payload: `{"schema_version":1,"prompt":"What is 2+2?"}`, encoding: payloadEncodingJSON, scope: resultCacheScope{Deterministic: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := resultCacheKey(parse(tt.payload), tt.encoding, &tt.scope)
+			if same := key == base; same != tt.wantSame {
+				t.Errorf("expected same key %v, got %v", tt.wantSame, same)
+			}
+		})
+	}
+}
+
+func Test_modelSettingsHash(t *testing.T) {
+	t.Setenv("OPENAI_MODEL", "gpt-4o-mini")
+	base := modelSettingsHash()
+	t.Setenv("OPENAI_MODEL", "gpt-4o")
+	if modelSettingsHash() == base {
+		t.Errorf("expected a model change to change the hash")
+	}
+	t.Setenv("OPENAI_MODEL", "gpt-4o-mini")
+	t.Setenv("RESULT_CACHE_TTL", "1h")
+	if modelSettingsHash() != base {
+		t.Errorf("expected other settings to leave the hash unchanged")
+	}
+}
+
+func Test_resultCacheFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantNil bool
+		wantTTL time.Duration
+		wantErr bool
+	}{
+		{name: "unset", wantNil: true},
+		{name: "default ttl", env: map[string]string{"RESULT_CACHE_SIZE": "100"}, wantTTL: defaultResultCacheTTL},
+		{name: "ttl", env: map[string]string{"RESULT_CACHE_SIZE": "100", "RESULT_CACHE_TTL": "1h"}, wantTTL: time.Hour},
+		{name: "redis", env: map[string]string{"RESULT_CACHE_REDIS_URL": "redis://localhost:6379/1"}},
+		{name: "invalid redis url", env: map[string]string{"RESULT_CACHE_REDIS_URL": "http://localhost"}, wantErr: true},
+		{name: "negative size", env: map[string]string{"RESULT_CACHE_SIZE": "-1"}, wantErr: true},
+		{name: "invalid ttl", env: map[string]string{"RESULT_CACHE_SIZE": "100", "RESULT_CACHE_TTL": "soon"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"RESULT_CACHE_SIZE", "RESULT_CACHE_TTL", "RESULT_CACHE_REDIS_URL"} {
+				t.Setenv(key, tt.env[key])
+			}
+			c, err := resultCacheFromEnv(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (c == nil) != tt.wantNil {
+				t.Fatalf("expected nil cache %v, got %+v", tt.wantNil, c)
+			}
+			if lru, ok := c.(*LRUResultCache); ok && lru.ttl != tt.wantTTL {
+				t.Errorf("expected ttl %s, got %s", tt.wantTTL, lru.ttl)
+			}
+		})
+	}
+}
+
+func Test_HandleTaskResultCache(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	provider := &gatedProvider{release: release}
+	tw := NewTaskWorker(zap.NewNop(), provider, nil)
+	tw.SetResultCache(NewLRUResultCache(10, time.Minute))
+
+	tasks := []struct {
+		id      string
+		payload string
+	}{
+		{id: "task-1", payload: "What is 2+2?"},
+		{id: "task-2", payload: "What is 2+2?"},
+		{id: "task-3", payload: "What is 3+3?"},
+	}
+	results := map[string][]byte{}
+	for _, task := range tasks {
+		resp, err := tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte(task.id), Payload: []byte(task.payload)})
+		if err != nil {
+			t.Fatalf("HandleTask failed: %v", err)
+		}
+		if string(resp.TaskId) != task.id {
+			t.Errorf("expected task id %s, got %s", task.id, resp.TaskId)
+		}
+		results[task.id] = resp.Result
+	}
+
+	if got := provider.calls.Load(); got != 2 {
+		t.Errorf("expected 2 provider calls, got %d", got)
+	}
+	if !bytes.Equal(results["task-1"], results["task-2"]) {
+		t.Errorf("expected the cached result %s, got %s", results["task-1"], results["task-2"])
+	}
+	if bytes.Equal(results["task-1"], results["task-3"]) {
+		t.Errorf("expected a different result for a different prompt")
+	}
+}
+
+func Test_HandleTaskResultCacheSystemPromptReload(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	provider := &gatedProvider{release: release}
+	systemPrompt := NewSystemPromptProvider(provider, "Answer briefly.")
+	tw := NewTaskWorker(zap.NewNop(), systemPrompt, nil)
+	tw.SetResultCache(NewLRUResultCache(10, time.Minute))
+
+	task := &performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")}
+	if _, err := tw.HandleTask(task); err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+	systemPrompt.SetPrompt("Answer in French.")
+	if _, err := tw.HandleTask(task); err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+	if got := provider.calls.Load(); got != 2 {
+		t.Errorf("expected the changed system prompt to bypass the cached result, got %d provider calls", got)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func Test_canonicalJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{
+			name:  "sorted keys",
+			value: map[string]interface{}{"b": 1, "a": map[string]interface{}{"z": true, "y": nil}},
+			want:  `{"a":{"y":null,"z":true},"b":1}`,
+		},
+		{
+			name: "struct fields",
+			value: struct {
+				Zeta  string `json:"zeta"`
+				Alpha []int  `json:"alpha"`
+			}{Zeta: "z", Alpha: []int{3, 1}},
+			want: `{"alpha":[3,1],"zeta":"z"}`,
+		},
+		{
+			name:  "raw numbers",
+			value: json.RawMessage(`{"a": 1.50, "b": 15e-1, "c": -0, "d": 1E3, "e": 0.0000001, "f": 1e21}`),
+			want:  `{"a":1.5,"b":1.5,"c":0,"d":1000,"e":1e-7,"f":1e+21}`,
+		},
+		{
+			name:  "float values",
+			value: map[string]interface{}{"score": 1 / float64(3), "ratio": float32(0.5)},
+			want:  `{"ratio":0.5,"score":0.3333333333333333}`,
+		},
+		{
+			name:  "strings",
+			value: map[string]interface{}{"html": "<a & b>", "quote": "say \"hi\"\n", "unicode": "héllo"},
+			want:  `{"html":"<a & b>","quote":"say \"hi\"\n","unicode":"héllo"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := canonicalJSON(tt.value)
+			if err != nil {
+				t.Fatalf("canonicalJSON failed: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_canonicalJSONIdempotent(t *testing.T) {
+	first, err := canonicalJSON(json.RawMessage(`{ "b" : [1.0, {"d":2, "c":"x"}], "a" : 0.25 }`))
+	if err != nil {
+		t.Fatalf("canonicalJSON failed: %v", err)
+	}
+	second, err := canonicalJSON(json.RawMessage(first))
+	if err != nil {
+		t.Fatalf("canonicalJSON failed: %v", err)
+	}
+	if string(first) != string(second) {
+		t.Errorf("expected %s, got %s", first, second)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"sort"
+)
+
+// resultCompressedField lists the fields of a result whose values were
+// replaced by their base64-encoded gzip compression to fit large outputs,
+// such as long summaries or generated code, in the result size limit.
+const resultCompressedField = "compressed_fields"
+
+// compressResultFields compresses the top-level string fields of result
+// longer than threshold bytes, when that makes them shorter, and lists them
+// in compressed_fields.
+func compressResultFields(result map[string]interface{}, threshold int) error {
+	var compressed []string
+	for key, value := range result {
+		s, ok := value.(string)
+		if !ok || len(s) <= threshold || key == resultDigestField {
+			continue
+		}
+		encoded, err := gzipBase64(s)
+		if err != nil {
+			return fmt.Errorf("failed to compress %s: %w", key, err)
+		}
+		if len(encoded) < len(s) {
+			result[key] = encoded
+			compressed = append(compressed, key)
+		}
+	}
+	if len(compressed) > 0 {
+		sort.Strings(compressed)
+		result[resultCompressedField] = compressed
+	}
+	return nil
+}
+
+// gzipBase64 returns the base64-encoded gzip compression of s. The gzip
+// header carries no name or modification time, so equal inputs compress to
+// equal outputs.
+func gzipBase64(s string) (string, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := zw.Write([]byte(s)); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// compressedFields returns the fields listed in the compressed_fields of a
+// result.
+func compressedFields(result map[string]interface{}) map[string]bool {
+	fields := map[string]bool{}
+	switch list := result[resultCompressedField].(type) {
+	case []string:
+		for _, key := range list {
+			fields[key] = true
+		}
+	case []interface{}:
+		for _, key := range list {
+			if s, ok := key.(string); ok {
+				fields[s] = true
+			}
+		}
+	}
+	return fields
+}
+
+// validateCompressedFields checks that compressed_fields names string fields
+// holding base64-encoded gzip data.
+func validateCompressedFields(result map[string]interface{}) error {
+	list, ok := result[resultCompressedField].([]interface{})
+	if !ok {
+		return fmt.Errorf("%s field must be an array", resultCompressedField)
+	}
+	for _, key := range list {
+		name, ok := key.(string)
+		if !ok {
+			return fmt.Errorf("%s field must only contain strings", resultCompressedField)
+		}
+		value, ok := result[name].(string)
+		if !ok {
+			return fmt.Errorf("compressed field %s must be a string", name)
+		}
+		data, err := base64.StdEncoding.DecodeString(value)
+		if err != nil || !bytes.HasPrefix(data, gzipMagic) {
+			return fmt.Errorf("compressed field %s must be base64-encoded gzip", name)
+		}
+	}
+	return nil
+}
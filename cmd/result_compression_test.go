@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+// gunzipBase64 reverses gzipBase64.
+func gunzipBase64(t *testing.T, s string) string {
+	t.Helper()
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid base64: %v", err)
+	}
+	out, err := decompressPayload(data, 1<<20)
+	if err != nil {
+		t.Fatalf("decompressPayload failed: %v", err)
+	}
+	return string(out)
+}
+
+func Test_compressResultFields(t *testing.T) {
+	long := strings.Repeat("the quick brown fox ", 100)
+	result := map[string]interface{}{
+		"llm_output":    long,
+		"short":         "hello",
+		"source_hash":   "0x5120c4e879e3f6b09496776240f68963f6b6a223fdf9ddddf23f34eb4451091c",
+		"result_digest": strings.Repeat("0", 200),
+	}
+	if err := compressResultFields(result, 64); err != nil {
+		t.Fatalf("compressResultFields failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(result[resultCompressedField], []string{"llm_output"}) {
+		t.Errorf("unexpected compressed fields: %v", result[resultCompressedField])
+	}
+	if got := gunzipBase64(t, result["llm_output"].(string)); got != long {
+		t.Errorf("compressed output does not round-trip")
+	}
+	if result["short"] != "hello" || result["result_digest"] != strings.Repeat("0", 200) {
+		t.Errorf("unexpected result: %v", result)
+	}
+
+	// Compression is deterministic, so operators agree on the result digest.
+	again := map[string]interface{}{"llm_output": long}
+	if err := compressResultFields(again, 64); err != nil {
+		t.Fatalf("compressResultFields failed: %v", err)
+	}
+	if again["llm_output"] != result["llm_output"] {
+		t.Errorf("expected equal outputs to compress equally")
+	}
+}
+
+func Test_HandleTaskCompressedResult(t *testing.T) {
+	output := "valid " + strings.Repeat("lorem ipsum dolor sit amet ", 200)
+	limits := defaultLimitsConfig()
+	limits.MaxResultSize = 1024
+	limits.CompressResultsOver = 512
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: output}, nil)
+	tw.SetLimits(limits)
+
+	resp, err := tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")})
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if _, truncated := result["truncated"]; truncated || !reflect.DeepEqual(result[resultCompressedField], []interface{}{"llm_output"}) {
+		t.Fatalf("unexpected result: %s", resp.Result)
+	}
+	if got := gunzipBase64(t, result["llm_output"].(string)); got != output {
+		t.Errorf("compressed output does not round-trip")
+	}
+}
+
+func Test_validateCompressedFields(t *testing.T) {
+	compressed, err := gzipBase64("hello")
+	if err != nil {
+		t.Fatalf("gzipBase64 failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		result  map[string]interface{}
+		wantErr bool
+	}{
+		{name: "valid", result: map[string]interface{}{"llm_output": compressed, "compressed_fields": []interface{}{"llm_output"}}},
+		{name: "not gzip", result: map[string]interface{}{"llm_output": "aGVsbG8=", "compressed_fields": []interface{}{"llm_output"}}, wantErr: true},
+		{name: "missing field", result: map[string]interface{}{"compressed_fields": []interface{}{"llm_output"}}, wantErr: true},
+		{name: "not an array", result: map[string]interface{}{"compressed_fields": "llm_output"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateCompressedFields(tt.result); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
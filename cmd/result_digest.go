@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// resultDigestField holds the keccak256 digest of the canonical JSON of the
+// rest of the result. Aggregators sign and compare the digest, a stable
+// commitment, rather than the raw result bytes.
+const resultDigestField = "result_digest"
+
+// resultDigest returns the keccak256 digest of the canonical JSON of result,
+// leaving out any result_digest field.
+func resultDigest(result map[string]interface{}) (string, error) {
+	committed := make(map[string]interface{}, len(result))
+	for key, value := range result {
+		if key != resultDigestField {
+			committed[key] = value
+		}
+	}
+	canonical, err := canonicalJSON(committed)
+	if err != nil {
+		return "", err
+	}
+	return crypto.Keccak256Hash(canonical).Hex(), nil
+}
+
+// addResultDigest sets the result_digest field of result and returns it.
+func addResultDigest(result map[string]interface{}) (string, error) {
+	digest, err := resultDigest(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute result digest: %w", err)
+	}
+	result[resultDigestField] = digest
+	return digest, nil
+}
+
+// verifyResultDigest checks that the result_digest field, when present,
+// commits to the rest of the result.
+func verifyResultDigest(result map[string]interface{}) error {
+	v, exists := result[resultDigestField]
+	if !exists {
+		return nil
+	}
+	digest, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("%s field must be a string", resultDigestField)
+	}
+	want, err := resultDigest(result)
+	if err != nil {
+		return err
+	}
+	if digest != want {
+		return fmt.Errorf("%s %s does not match result, expected %s", resultDigestField, digest, want)
+	}
+	return nil
+}
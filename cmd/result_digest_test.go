@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+)
+
+func Test_resultDigest(t *testing.T) {
+	result := map[string]interface{}{"verified": true, "llm_output": "4", "score": 1.50}
+	digest, err := addResultDigest(result)
+	if err != nil {
+		t.Fatalf("addResultDigest failed: %v", err)
+	}
+	if want := crypto.Keccak256Hash([]byte(`{"llm_output":"4","score":1.5,"verified":true}`)).Hex(); digest != want {
+		t.Errorf("expected digest %s, got %s", want, digest)
+	}
+	if result[resultDigestField] != digest {
+		t.Errorf("expected result_digest field %s, got %v", digest, result[resultDigestField])
+	}
+
+	// Recomputing the digest ignores the digest field itself.
+	again, err := resultDigest(result)
+	if err != nil {
+		t.Fatalf("resultDigest failed: %v", err)
+	}
+	if again != digest {
+		t.Errorf("expected digest %s, got %s", digest, again)
+	}
+}
+
+func Test_verifyResultDigest(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  string
+		wantErr bool
+	}{
+		{name: "absent", result: `{"verified":true}`},
+		{name: "matching", result: `{"result_digest":"0xa0cfbf47cec99878ef3e7f5940f613106f28db49e630ba29343e45192a9d584d","verified":true}`},
+		{name: "tampered", result: `{"result_digest":"0xa0cfbf47cec99878ef3e7f5940f613106f28db49e630ba29343e45192a9d584d","verified":false}`, wantErr: true},
+		{name: "not a string", result: `{"result_digest":1,"verified":true}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.result), &result); err != nil {
+				t.Fatalf("invalid test result: %v", err)
+			}
+			if err := verifyResultDigest(result); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_HandleTaskResultDigest(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "valid"}, nil)
+	resp, err := tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")})
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if _, ok := result[resultDigestField].(string); !ok {
+		t.Fatalf("result has no %s: %s", resultDigestField, resp.Result)
+	}
+	if err := verifyResultDigest(result); err != nil {
+		t.Errorf("verifyResultDigest failed: %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Result schema versions, requested by the payload's result_version field.
+// Version 2 adds execution metadata so aggregators and dispute resolvers can
+// audit how each operator produced its answer:
+//
+//	{
+//	  "result_version":    2,
+//	  "model":             "gpt-4o-mini",
+//	  "provider":          "openai",
+//	  "prompt_hash":       "0x…", // keccak256 of the task's prompt messages
+//	  "tokens_in":         42,
+//	  "tokens_out":        7,
+//	  "latency_ms":        830,
+//	  "performer_version": "v1.2.0",
+//	  ...
+//	}
+const (
+	resultVersion1 = 1
+	resultVersion2 = 2
+
+	maxResultVersion = resultVersion2
+)
+
+// taskUsage accumulates the token usage of the provider calls of one task,
+// and keeps the attestation of the last one.
+type taskUsage struct {
+	mu        sync.Mutex
+	tokensIn  int
+	tokensOut int
+	// overhead is the usage of the calls checking the task rather than
+	// producing its output, see withOverheadTracking.
+	overhead int
+	// coalesced is the part of tokensIn and tokensOut reported by coalesced
+	// responses, which another task was charged for.
+	coalesced   int
+	attestation *Attestation
+}
+
+type taskUsageKey struct{}
+
+// withTaskUsage returns a context that records the token usage of the
+// provider calls made with it.
+func withTaskUsage(ctx context.Context) (context.Context, *taskUsage) {
+	u := &taskUsage{}
+	return context.WithValue(ctx, taskUsageKey{}, u), u
+}
+
+func (u *taskUsage) add(tokensIn, tokensOut int, coalesced bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.tokensIn += tokensIn
+	u.tokensOut += tokensOut
+	if coalesced {
+		u.coalesced += tokensIn + tokensOut
+	}
+}
+
+func (u *taskUsage) addOverhead(tokens int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.overhead += tokens
+}
+
+// totals returns the usage of the calls producing the output of the task.
+func (u *taskUsage) totals() (int, int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.tokensIn, u.tokensOut
+}
+
+// spent returns the tokens of every provider call of the task, overhead
+// included, which are charged to the token budget. Coalesced responses are
+// left out: the task that made the call is charged for it.
+func (u *taskUsage) spent() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.tokensIn + u.tokensOut + u.overhead - u.coalesced
+}
+
+func (u *taskUsage) attest(attestation *Attestation) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.attestation = attestation
+}
+
+// lastAttestation returns a copy of the attestation of the last provider
+// call of the task, or nil when there was none.
+func (u *taskUsage) lastAttestation() *Attestation {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.attestation == nil {
+		return nil
+	}
+	attestation := *u.attestation
+	return &attestation
+}
+
+// usageProvider records the token usage and attestation of completions in
+// the task usage of the request context. Usage not reported by the provider
+// is estimated.
+type usageProvider struct {
+	Provider
+	// overhead records usage as overhead, without an attestation.
+	overhead bool
+}
+
+func withUsageTracking(p Provider) Provider {
+	return &usageProvider{Provider: p}
+}
+
+// withOverheadTracking records the usage of the provider of a verifier,
+// safety check or injection classifier as overhead of the task: it is charged
+// to the token budget but neither reported as the usage of the result nor
+// attested.
+func withOverheadTracking(p Provider) Provider {
+	return &usageProvider{Provider: p, overhead: true}
+}
+
+func (p *usageProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	resp, err := p.Provider.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if u, ok := ctx.Value(taskUsageKey{}).(*taskUsage); ok {
+		tokensIn, tokensOut := resp.TokensIn, resp.TokensOut
+		if tokensIn == 0 {
+			tokensIn = estimateTokens(req)
+		}
+		if tokensOut == 0 {
+			tokensOut = estimateTextTokens(resp.Output)
+		}
+		if p.overhead {
+			if !resp.Coalesced {
+				u.addOverhead(tokensIn + tokensOut)
+			}
+			return resp, nil
+		}
+		u.add(tokensIn, tokensOut, resp.Coalesced)
+		if attestation := newAttestation(p, resp); attestation != nil {
+			u.attest(attestation)
+		}
+	}
+	return resp, nil
+}
+
+// promptHash returns the keccak256 hash of the prompt messages of a payload,
+// before the operator's system prompt and examples are added; those are
+// reported by their own hashes.
+func promptHash(p *TaskPayload) string {
+	messages, _ := json.Marshal(p.CompletionRequest().Messages)
+	return crypto.Keccak256Hash(messages).Hex()
+}
+
+// addExecutionMetadata adds the version 2 result fields to a result.
+func addExecutionMetadata(result map[string]interface{}, p *TaskPayload, usage *taskUsage, latency time.Duration) {
+	result["result_version"] = resultVersion2
+	if metadata, ok := result["metadata"].(map[string]interface{}); ok {
+		result["provider"] = metadata["provider"]
+		result["model"] = metadata["model"]
+	}
+	result["prompt_hash"] = promptHash(p)
+	result["tokens_in"], result["tokens_out"] = usage.totals()
+	result["latency_ms"] = latency.Milliseconds()
+	result["performer_version"] = performerVersion
+}
+
+// validateExecutionMetadata checks the version 2 result fields.
+func validateExecutionMetadata(result map[string]interface{}) error {
+	for _, field := range []string{"provider", "model", "prompt_hash", "performer_version"} {
+		if _, ok := result[field].(string); !ok {
+			return fmt.Errorf("%s field must be a string", field)
+		}
+	}
+	for _, field := range []string{"tokens_in", "tokens_out", "latency_ms"} {
+		if v, ok := result[field].(float64); !ok || v < 0 {
+			return fmt.Errorf("%s field must be a non-negative number", field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+// usageStubProvider returns a fixed output and token usage.
+type usageStubProvider struct {
+	output    string
+	tokensIn  int
+	tokensOut int
+}
+
+func (p *usageStubProvider) Name() string {
+	return "usage-stub"
+}
+
+func (p *usageStubProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	return &CompletionResponse{Output: p.output, Model: "stub-model", TokensIn: p.tokensIn, TokensOut: p.tokensOut}, nil
+}
+
+func Test_usageProvider(t *testing.T) {
+	tests := []struct {
+		name          string
+		provider      Provider
+		wantTokensIn  int
+		wantTokensOut int
+	}{
+		{name: "reported", provider: &usageStubProvider{output: "valid", tokensIn: 10, tokensOut: 3}, wantTokensIn: 20, wantTokensOut: 6},
+		{name: "estimated", provider: &usageStubProvider{output: "12345678"}, wantTokensIn: 2, wantTokensOut: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := withUsageTracking(tt.provider)
+			ctx, usage := withTaskUsage(context.Background())
+			req := &CompletionRequest{Messages: []ChatMessage{{Role: "user", Content: "abcd"}}}
+			for i := 0; i < 2; i++ {
+				if _, err := p.Complete(ctx, req); err != nil {
+					t.Fatalf("Complete failed: %v", err)
+				}
+			}
+			if in, out := usage.totals(); in != tt.wantTokensIn || out != tt.wantTokensOut {
+				t.Errorf("expected %d/%d tokens, got %d/%d", tt.wantTokensIn, tt.wantTokensOut, in, out)
+			}
+		})
+	}
+}
+
+func Test_HandleTaskResultVersion2(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &usageStubProvider{output: "valid", tokensIn: 10, tokensOut: 3}, nil)
+
+	payload := `{"prompt":"What is 2+2?","result_version":2}`
+	resp, err := tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte(payload)})
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+
+	var result struct {
+		ResultVersion    int    `json:"result_version"`
+		Model            string `json:"model"`
+		Provider         string `json:"provider"`
+		PromptHash       string `json:"prompt_hash"`
+		TokensIn         int    `json:"tokens_in"`
+		TokensOut        int    `json:"tokens_out"`
+		LatencyMs        *int64 `json:"latency_ms"`
+		PerformerVersion string `json:"performer_version"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	parsed, _ := ParseTaskPayload([]byte(payload))
+	if result.ResultVersion != 2 || result.Model != "stub-model" || result.Provider != "usage-stub" ||
+		result.PromptHash != promptHash(parsed) || result.TokensIn != 10 || result.TokensOut != 3 ||
+		result.LatencyMs == nil || result.PerformerVersion != performerVersion {
+		t.Errorf("unexpected result: %s", resp.Result)
+	}
+
+	// Version 1 results carry no execution metadata.
+	resp, err = tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte("task-2"), Payload: []byte(`{"prompt":"What is 2+2?"}`)})
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+	var v1 map[string]interface{}
+	if err := json.Unmarshal(resp.Result, &v1); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if _, ok := v1["prompt_hash"]; ok {
+		t.Errorf("unexpected execution metadata in version 1 result: %s", resp.Result)
+	}
+}
+
+func Test_ValidateResultVersion2(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{}, nil)
+	handler, err := tw.tasks.Handler(taskTypeCompletion)
+	if err != nil {
+		t.Fatalf("Handler failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		result  string
+		wantErr bool
+	}{
+		{
+			name:   "complete",
+			result: `{"llm_output":"valid","verified":{"passed":true,"confidence":1,"checks":["contains_valid"],"reasons":[]},"result_version":2,"model":"m","provider":"p","prompt_hash":"0x00","tokens_in":1,"tokens_out":1,"latency_ms":5,"performer_version":"dev"}`,
+		},
+		{
+			name:    "missing prompt hash",
+			result:  `{"llm_output":"valid","verified":{"passed":true,"confidence":1,"checks":["contains_valid"],"reasons":[]},"result_version":2,"model":"m","provider":"p","tokens_in":1,"tokens_out":1,"latency_ms":5,"performer_version":"dev"}`,
+			wantErr: true,
+		},
+		{
+			name:    "negative latency",
+			result:  `{"llm_output":"valid","verified":{"passed":true,"confidence":1,"checks":["contains_valid"],"reasons":[]},"result_version":2,"model":"m","provider":"p","prompt_hash":"0x00","tokens_in":1,"tokens_out":1,"latency_ms":-5,"performer_version":"dev"}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown version",
+			result:  `{"llm_output":"valid","verified":{"passed":true,"confidence":1,"checks":["contains_valid"],"reasons":[]},"result_version":3}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tw.ValidateResult(handler, []byte(tt.result)); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// resultTruncatedField is set to true on results whose output was shortened
+// to fit the result size limit.
+const resultTruncatedField = "truncated"
+
+// truncateLongestString shortens the longest top-level string field of result
+// by at least excess bytes, cutting at a UTF-8 boundary. Compressed fields are
+// left alone. It reports false when there is no non-empty string field left
+// to shorten.
+func truncateLongestString(result map[string]interface{}, excess int) bool {
+	keys := make([]string, 0, len(result))
+	for key := range result {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	compressed := compressedFields(result)
+	longest := ""
+	for _, key := range keys {
+		if key == resultDigestField || compressed[key] {
+			continue
+		}
+		if s, ok := result[key].(string); ok && len(s) > 0 && (longest == "" || len(s) > len(result[longest].(string))) {
+			longest = key
+		}
+	}
+	if longest == "" {
+		return false
+	}
+
+	result[longest] = truncateUTF8(result[longest].(string), len(result[longest].(string))-excess)
+	return true
+}
+
+// truncateUTF8 returns the longest prefix of s of at most n bytes that does
+// not split a multi-byte character.
+func truncateUTF8(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if n >= len(s) {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
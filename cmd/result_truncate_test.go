@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_truncateUTF8(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		n    int
+		want string
+	}{
+		{name: "ascii", s: "hello", n: 3, want: "hel"},
+		{name: "longer than string", s: "hello", n: 10, want: "hello"},
+		{name: "negative", s: "hello", n: -1, want: ""},
+		{name: "inside multi-byte character", s: "héllo", n: 2, want: "h"},
+		{name: "after multi-byte character", s: "héllo", n: 3, want: "hé"},
+		{name: "inside emoji", s: "ok👍", n: 4, want: "ok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateUTF8(tt.s, tt.n); got != tt.want {
+				t.Errorf("truncateUTF8(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_HandleTaskTruncation(t *testing.T) {
+	output := "valid " + strings.Repeat("é", 2000)
+
+	tests := []struct {
+		name          string
+		truncate      bool
+		wantErr       bool
+		wantTruncated bool
+	}{
+		{name: "truncate", truncate: true, wantTruncated: true},
+		{name: "fail", truncate: false, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limits := defaultLimitsConfig()
+			limits.MaxResultSize = 1024
+			limits.TruncateResults = tt.truncate
+			tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: output}, nil)
+			tw.SetLimits(limits)
+
+			resp, err := tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(resp.Result) > limits.MaxResultSize {
+				t.Errorf("result size %d exceeds %d", len(resp.Result), limits.MaxResultSize)
+			}
+			var result map[string]interface{}
+			if err := json.Unmarshal(resp.Result, &result); err != nil {
+				t.Fatalf("failed to decode result: %v", err)
+			}
+			llmOutput, _ := result["llm_output"].(string)
+			if result["truncated"] != tt.wantTruncated || !strings.HasPrefix(output, llmOutput) || !utf8.ValidString(llmOutput) {
+				t.Errorf("unexpected result: %s", resp.Result)
+			}
+			if err := verifyResultDigest(result); err != nil {
+				t.Errorf("verifyResultDigest failed: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Passage is a piece of evidence returned by a Retriever.
+type Passage struct {
+	ID     string  `json:"id"`
+	Text   string  `json:"text"`
+	Source string  `json:"source,omitempty"`
+	Score  float64 `json:"score"`
+}
+
+// Retriever finds the passages most relevant to a query.
+type Retriever interface {
+	Name() string
+	Retrieve(ctx context.Context, query string, k int) ([]Passage, error)
+}
+
+// retrieverFromEnv builds the retriever of the fact-check verifier: the
+// search API at FACT_CHECK_SEARCH_URL, or the corpus in the JSON file named
+// by FACT_CHECK_CORPUS_FILE.
+func retrieverFromEnv(ctx context.Context) (Retriever, error) {
+	url, corpus := getenv("FACT_CHECK_SEARCH_URL"), getenv("FACT_CHECK_CORPUS_FILE")
+	switch {
+	case url != "" && corpus != "":
+		return nil, fmt.Errorf("only one of FACT_CHECK_SEARCH_URL and FACT_CHECK_CORPUS_FILE can be set")
+	case url != "":
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			return nil, fmt.Errorf("FACT_CHECK_SEARCH_URL must be an http:// or https:// URL")
+		}
+		apiKey, err := secretFromEnv(ctx, "FACT_CHECK_SEARCH_KEY")
+		if err != nil {
+			return nil, err
+		}
+		return &searchRetriever{url: url, apiKey: apiKey, client: newHTTPClient()}, nil
+	case corpus != "":
+		data, err := os.ReadFile(corpus)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fact-check corpus: %w", err)
+		}
+		return newCorpusRetriever(data)
+	default:
+		return nil, fmt.Errorf("FACT_CHECK_SEARCH_URL or FACT_CHECK_CORPUS_FILE must be set for the fact_check verifier")
+	}
+}
+
+// corpusRetriever ranks the passages of a local corpus by the share of the
+// query's terms they contain.
+type corpusRetriever struct {
+	passages []Passage
+	terms    []map[string]bool
+}
+
+// newCorpusRetriever loads a corpus given as a JSON array of passages:
+//
+//	[{"id": "paris", "text": "Paris is the capital of France.", "source": "..."}]
+func newCorpusRetriever(data []byte) (*corpusRetriever, error) {
+	var passages []Passage
+	if err := json.Unmarshal(data, &passages); err != nil {
+		return nil, fmt.Errorf("invalid fact-check corpus: %w", err)
+	}
+	if len(passages) == 0 {
+		return nil, fmt.Errorf("invalid fact-check corpus: no passages")
+	}
+
+	r := &corpusRetriever{passages: passages, terms: make([]map[string]bool, len(passages))}
+	for i, p := range passages {
+		if strings.TrimSpace(p.Text) == "" {
+			return nil, fmt.Errorf("passage %d of the fact-check corpus has no text", i)
+		}
+		if p.ID == "" {
+			r.passages[i].ID = fmt.Sprint(i)
+		}
+		r.terms[i] = map[string]bool{}
+		for _, term := range searchTerms(p.Text) {
+			r.terms[i][term] = true
+		}
+	}
+	return r, nil
+}
+
+func (r *corpusRetriever) Name() string {
+	return "corpus"
+}
+
+func (r *corpusRetriever) Retrieve(ctx context.Context, query string, k int) ([]Passage, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	var matches []Passage
+	for i, passage := range r.passages {
+		found := 0
+		for _, term := range terms {
+			if r.terms[i][term] {
+				found++
+			}
+		}
+		if found > 0 {
+			passage.Score = float64(found) / float64(len(terms))
+			matches = append(matches, passage)
+		}
+	}
+	// Stable, so equally relevant passages keep corpus order.
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+// searchTerms returns the distinct lower-cased words of s, leaving out words
+// of fewer than three letters.
+func searchTerms(s string) []string {
+	seen := map[string]bool{}
+	var terms []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(w)) >= 3 && !seen[w] {
+			seen[w] = true
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
+
+// searchRetriever queries a search API. The API receives
+// {"query": "...", "top_k": 3} and answers {"results": [<passage>, ...]}.
+type searchRetriever struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+func (r *searchRetriever) Name() string {
+	return "search"
+}
+
+func (r *searchRetriever) Retrieve(ctx context.Context, query string, k int) ([]Passage, error) {
+	headers := map[string]string{}
+	if r.apiKey != "" {
+		headers["Authorization"] = "Bearer " + r.apiKey
+	}
+
+	var resp struct {
+		Results []Passage `json:"results"`
+	}
+	err := doJSONRequest(ctx, r.client, r.Name(), r.url, headers, map[string]interface{}{"query": query, "top_k": k}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Results) > k {
+		resp.Results = resp.Results[:k]
+	}
+	return resp.Results, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testFactCheckCorpus = `[
+	{"id": "paris", "text": "Paris is the capital and largest city of France.", "source": "encyclopedia"},
+	{"id": "lyon", "text": "Lyon is the third-largest city of France."},
+	{"id": "berlin", "text": "Berlin is the capital of Germany."}
+]`
+
+func Test_corpusRetriever(t *testing.T) {
+	r, err := newCorpusRetriever([]byte(testFactCheckCorpus))
+	if err != nil {
+		t.Fatalf("newCorpusRetriever failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		k       int
+		wantIDs []string
+	}{
+		{name: "best match first", query: "The capital of France is Paris", k: 3, wantIDs: []string{"paris", "lyon", "berlin"}},
+		{name: "limited", query: "The capital of France is Paris", k: 1, wantIDs: []string{"paris"}},
+		{name: "no match", query: "Bananas are yellow", k: 3},
+		{name: "only short words", query: "is it so", k: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			passages, err := r.Retrieve(context.Background(), tt.query, tt.k)
+			if err != nil {
+				t.Fatalf("Retrieve failed: %v", err)
+			}
+			if len(passages) != len(tt.wantIDs) {
+				t.Fatalf("expected passages %v, got %+v", tt.wantIDs, passages)
+			}
+			for i, p := range passages {
+				if p.ID != tt.wantIDs[i] {
+					t.Errorf("expected passages %v, got %+v", tt.wantIDs, passages)
+				}
+			}
+		})
+	}
+
+	for _, corpus := range []string{`[]`, `{"text": "x"}`, `[{"id": "empty", "text": " "}]`} {
+		if _, err := newCorpusRetriever([]byte(corpus)); err == nil {
+			t.Errorf("expected error for corpus %s", corpus)
+		}
+	}
+}
+
+func Test_searchRetriever(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query string `json:"query"`
+			TopK  int    `json:"top_k"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Query != "capital of France" || req.TopK != 1 {
+			t.Errorf("unexpected search request: %+v, %v", req, err)
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("unexpected authorization header %q", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"results": [{"id": "1", "text": "Paris is the capital of France.", "score": 0.9}, {"id": "2", "text": "France is in Europe."}]}`))
+	}))
+	defer server.Close()
+
+	r := &searchRetriever{url: server.URL, apiKey: "secret", client: server.Client()}
+	passages, err := r.Retrieve(context.Background(), "capital of France", 1)
+	if err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if len(passages) != 1 || passages[0].ID != "1" || passages[0].Score != 0.9 {
+		t.Errorf("unexpected passages: %+v", passages)
+	}
+}
+
+func Test_retrieverFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		corpus   string
+		wantName string
+		wantErr  bool
+	}{
+		{name: "search", url: "https://search.example.com/v1/search", wantName: "search"},
+		{name: "missing corpus", corpus: "/nonexistent/corpus.json", wantErr: true},
+		{name: "both", url: "https://search.example.com", corpus: "corpus.json", wantErr: true},
+		{name: "invalid url", url: "search.example.com", wantErr: true},
+		{name: "none", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FACT_CHECK_SEARCH_URL", tt.url)
+			t.Setenv("FACT_CHECK_CORPUS_FILE", tt.corpus)
+			r, err := retrieverFromEnv(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && r.Name() != tt.wantName {
+				t.Errorf("expected retriever %s, got %s", tt.wantName, r.Name())
+			}
+		})
+	}
+}
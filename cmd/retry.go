@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBaseDelay   = 500 * time.Millisecond
+	defaultRetryMaxDelay    = 10 * time.Second
+)
+
+// RetryConfig configures retries of failed provider calls.
+type RetryConfig struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry. It doubles on every
+	// further retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff between two attempts.
+	MaxDelay time.Duration
+}
+
+func retryConfigFromEnv() (*RetryConfig, error) {
+	cfg := &RetryConfig{
+		MaxAttempts: defaultRetryMaxAttempts,
+		BaseDelay:   defaultRetryBaseDelay,
+		MaxDelay:    defaultRetryMaxDelay,
+	}
+
+	attempts, err := envInt("RETRY_MAX_ATTEMPTS")
+	if err != nil {
+		return nil, err
+	}
+	if attempts > 0 {
+		cfg.MaxAttempts = attempts
+	}
+
+	base, err := envDuration("RETRY_BASE_DELAY")
+	if err != nil {
+		return nil, err
+	}
+	if base > 0 {
+		cfg.BaseDelay = base
+	}
+
+	maxDelay, err := envDuration("RETRY_MAX_DELAY")
+	if err != nil {
+		return nil, err
+	}
+	if maxDelay > 0 {
+		cfg.MaxDelay = maxDelay
+	}
+	return cfg, nil
+}
+
+// RetryProvider wraps a Provider and retries rate-limited and failed calls with
+// jittered exponential backoff. A Retry-After header sent by the provider takes
+// precedence over the computed backoff.
+type RetryProvider struct {
+	Provider
+	config *RetryConfig
+	logger *zap.Logger
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+func NewRetryProvider(logger *zap.Logger, cfg *RetryConfig, p Provider) *RetryProvider {
+	return &RetryProvider{
+		Provider: p,
+		config:   cfg,
+		logger:   logger,
+		sleep:    sleepContext,
+	}
+}
+
+func (p *RetryProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	var err error
+	for attempt := 0; attempt < p.config.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			delay := p.backoff(attempt, err)
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+				// Waiting would outlive the task, so report the last error.
+				return nil, err
+			}
+
+			correlatedLogger(ctx, p.logger).Sugar().Infow("Retrying provider request",
+				zap.String("provider", p.Name()),
+				zap.Int("attempt", attempt+1),
+				zap.Duration("delay", delay),
+				zap.Error(err),
+			)
+			providerRetries.WithLabelValues(p.Name()).Inc()
+			if sleepErr := p.sleep(ctx, delay); sleepErr != nil {
+				return nil, err
+			}
+		}
+
+		var resp *CompletionResponse
+		resp, err = p.Provider.Complete(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil || !isProviderFault(err) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// backoff returns the delay before the given retry attempt.
+func (p *RetryProvider) backoff(attempt int, err error) time.Duration {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) && providerErr.RetryAfter > 0 {
+		return providerErr.RetryAfter
+	}
+
+	delay := p.config.BaseDelay
+	for i := 1; i < attempt && delay < p.config.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.config.MaxDelay {
+		delay = p.config.MaxDelay
+	}
+	// Spread retries between delay/2 and delay so operators hitting the same
+	// provider don't retry in lockstep.
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
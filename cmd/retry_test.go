@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// sequenceProvider returns the queued errors in order and succeeds once they
+// are exhausted.
+type sequenceProvider struct {
+	errs  []error
+	calls int
+}
+
+func (p *sequenceProvider) Name() string {
+	return "sequence"
+}
+
+func (p *sequenceProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	p.calls++
+	if len(p.errs) > 0 {
+		err := p.errs[0]
+		p.errs = p.errs[1:]
+		return nil, err
+	}
+	return &CompletionResponse{Output: "ok"}, nil
+}
+
+func Test_RetryProvider(t *testing.T) {
+	tests := []struct {
+		name      string
+		errs      []error
+		wantErr   bool
+		wantCalls int
+		wantDelay time.Duration
+	}{
+		{
+			name:      "success",
+			wantCalls: 1,
+		},
+		{
+			name:      "retries server errors",
+			errs:      []error{&ProviderError{StatusCode: 500}, &ProviderError{StatusCode: 502}},
+			wantCalls: 3,
+		},
+		{
+			name:      "honors Retry-After",
+			errs:      []error{&ProviderError{StatusCode: 429, RetryAfter: 7 * time.Second}},
+			wantCalls: 2,
+			wantDelay: 7 * time.Second,
+		},
+		{
+			name:      "does not retry client errors",
+			errs:      []error{&ProviderError{StatusCode: 400}},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:      "gives up after max attempts",
+			errs:      []error{&ProviderError{StatusCode: 503}, &ProviderError{StatusCode: 503}, &ProviderError{StatusCode: 503}},
+			wantErr:   true,
+			wantCalls: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &sequenceProvider{errs: tt.errs}
+			r := NewRetryProvider(zap.NewNop(), &RetryConfig{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: 10 * time.Second}, p)
+			var delays []time.Duration
+			r.sleep = func(ctx context.Context, d time.Duration) error {
+				delays = append(delays, d)
+				return nil
+			}
+
+			_, err := r.Complete(context.Background(), &CompletionRequest{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if p.calls != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, p.calls)
+			}
+			if tt.wantDelay > 0 && (len(delays) != 1 || delays[0] != tt.wantDelay) {
+				t.Errorf("expected delay %s, got %v", tt.wantDelay, delays)
+			}
+		})
+	}
+}
+
+func Test_RetryBackoff(t *testing.T) {
+	r := NewRetryProvider(zap.NewNop(), &RetryConfig{MaxAttempts: 10, BaseDelay: time.Second, MaxDelay: 5 * time.Second}, &stubProvider{})
+	err := &ProviderError{StatusCode: 503}
+
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 8: 5 * time.Second} {
+		got := r.backoff(attempt, err)
+		if got < want/2 || got > want {
+			t.Errorf("attempt %d: expected delay between %s and %s, got %s", attempt, want/2, want, got)
+		}
+	}
+}
+
+func Test_ParseRetryAfter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	err := doJSONRequest(context.Background(), srv.Client(), "test", srv.URL, nil, struct{}{}, &struct{}{})
+	providerErr, ok := err.(*ProviderError)
+	if !ok {
+		t.Fatalf("expected ProviderError, got %v", err)
+	}
+	if providerErr.RetryAfter != 3*time.Second {
+		t.Errorf("expected Retry-After of 3s, got %s", providerErr.RetryAfter)
+	}
+
+	if got := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)); got <= 0 || got > time.Minute {
+		t.Errorf("expected HTTP date to parse, got %s", got)
+	}
+	if got := parseRetryAfter("soon"); got != 0 {
+		t.Errorf("expected malformed header to be ignored, got %s", got)
+	}
+}
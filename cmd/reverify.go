@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// reverifyTimeout bounds a re-verification, which may call judge or
+	// embedding models but never generates the output again.
+	reverifyTimeout = 30 * time.Second
+
+	maxReverifyRequestSize = 1 << 20
+
+	// defaultReverifyHost keeps the re-verification endpoint, which spends
+	// LLM budget, off the network unless REVERIFY_HOST says otherwise.
+	defaultReverifyHost = "127.0.0.1"
+
+	// reverifyTaskID stands in for the task ID when a re-verification
+	// payload is validated.
+	reverifyTaskID = "reverify"
+)
+
+// ErrReverifyUnsupported is returned when the configured verification would
+// generate new completions, which re-verification never pays for.
+var ErrReverifyUnsupported = errors.New("re-verification not supported")
+
+// reverifyRequest is the body of a POST /verify request. Payload is the task
+// payload as sent on-chain, either a JSON object or a string; output is the
+// text output of the result being checked. Metadata is the task metadata,
+// needed when task signatures are required.
+//
+//	{"payload": {"prompt": "What is the capital of France?"}, "output": "Paris"}
+type reverifyRequest struct {
+	Payload  json.RawMessage `json:"payload"`
+	Output   string          `json:"output"`
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+}
+
+type reverifyResponse struct {
+	TaskType string        `json:"task_type"`
+	Verified *Verification `json:"verified"`
+}
+
+// reverifyAddrFromEnv returns the address of the re-verification server from
+// REVERIFY_PORT and REVERIFY_HOST (127.0.0.1 by default), or "" when
+// REVERIFY_PORT is unset.
+func reverifyAddrFromEnv() (string, error) {
+	port, err := envInt("REVERIFY_PORT")
+	if err != nil {
+		return "", err
+	}
+	if port < 0 {
+		return "", fmt.Errorf("REVERIFY_PORT must not be negative")
+	}
+	if port == 0 {
+		return "", nil
+	}
+	host := getenv("REVERIFY_HOST")
+	if host == "" {
+		host = defaultReverifyHost
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port)), nil
+}
+
+// Reverify runs the verification of a task type against a payload and an
+// output produced earlier, so challengers can check an operator's result
+// without paying for generation again. Completions are checked by the
+// completion verifier; the task type's verification pipeline runs as well.
+//
+// Payloads are validated like tasks, and re-verifications are subject to the
+// sender rate limit and the token budget. Verifications that would sample the
+// prompt again, see consistencyVerifier, are refused.
+func (tw *TaskWorker) Reverify(ctx context.Context, raw, metadata []byte, output string) (string, *Verification, error) {
+	if err := tw.validateTask(ctx, &performerV1.TaskRequest{TaskId: []byte(reverifyTaskID), Payload: raw, Metadata: metadata}); err != nil {
+		return "", nil, err
+	}
+	if tw.rateLimiter != nil {
+		if err := tw.rateLimiter.Allow(taskSender(metadata)); err != nil {
+			return "", nil, err
+		}
+	}
+	if tw.budget != nil {
+		if err := tw.budget.Check(); err != nil {
+			return "", nil, err
+		}
+	}
+
+	data, _, err := tw.decodePayload(ctx, raw)
+	if err != nil {
+		return "", nil, err
+	}
+	payload, err := ParseTaskPayload(data)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := tw.templates.Load().Render(payload); err != nil {
+		return "", nil, err
+	}
+	taskType := payload.TaskType
+	if taskType == "" {
+		taskType = taskTypeCompletion
+	}
+	payload.Examples = tw.examples.Load().Examples(taskType)
+
+	var verifiers []Verifier
+	if h, ok := tw.tasks.handlers[taskType].(*CompletionHandler); ok && taskType == taskTypeCompletion {
+		verifiers = append(verifiers, h.verifier)
+	}
+	if pipeline := tw.pipelines[taskType]; pipeline != nil {
+		verifiers = append(verifiers, pipeline)
+	}
+	if len(verifiers) == 0 {
+		return "", nil, fmt.Errorf("no verification configured for task type %q", taskType)
+	}
+	for _, v := range verifiers {
+		if generatesCompletions(v) {
+			return "", nil, fmt.Errorf("%w: the %s verification of task type %q generates new completions", ErrReverifyUnsupported, v.Name(), taskType)
+		}
+	}
+
+	ctx, usage := withTaskUsage(ctx)
+	if tw.budget != nil {
+		defer func() { tw.budget.Record(usage.spent()) }()
+	}
+	verification := newVerification()
+	for _, v := range verifiers {
+		if err := v.Verify(ctx, payload, output, verification); err != nil {
+			return "", nil, err
+		}
+	}
+	return taskType, verification, nil
+}
+
+// generatesCompletions reports whether v, or any verifier it runs, samples
+// the prompt again.
+func generatesCompletions(v Verifier) bool {
+	switch v := v.(type) {
+	case *consistencyVerifier:
+		return true
+	case verifierChain:
+		for _, verifier := range v {
+			if generatesCompletions(verifier) {
+				return true
+			}
+		}
+	case *VerificationPipeline:
+		for _, verifier := range v.verifiers {
+			if generatesCompletions(verifier) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reverifyHandler serves POST /verify, to the callers auth accepts when it is
+// not nil.
+func (tw *TaskWorker) reverifyHandler(auth *callerAuth) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if auth != nil {
+			if err := auth.authenticateHTTP(r); err != nil {
+				tw.logger.Sugar().Warnw("Rejected unauthenticated re-verification", zap.Error(err))
+				code := http.StatusForbidden
+				if status.Code(err) == codes.Unauthenticated {
+					code = http.StatusUnauthorized
+				}
+				http.Error(w, status.Convert(err).Message(), code)
+				return
+			}
+		}
+
+		var req reverifyRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxReverifyRequestSize)).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+		if len(req.Payload) == 0 {
+			http.Error(w, "invalid request: payload is required", http.StatusBadRequest)
+			return
+		}
+		// String payloads are verified as the raw bytes they carry.
+		raw := []byte(req.Payload)
+		var s string
+		if json.Unmarshal(req.Payload, &s) == nil {
+			raw = []byte(s)
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), reverifyTimeout)
+		defer cancel()
+		taskType, verification, err := tw.Reverify(ctx, raw, req.Metadata, req.Output)
+		if err != nil {
+			tw.logger.Sugar().Warnw("Re-verification failed", zap.Error(err))
+			code := http.StatusBadRequest
+			var providerErr *ProviderError
+			switch {
+			case errors.Is(err, ErrSenderRateLimited), errors.Is(err, ErrTokenBudgetExhausted):
+				code = http.StatusTooManyRequests
+			case errors.Is(err, context.DeadlineExceeded), errors.As(err, &providerErr):
+				code = http.StatusBadGateway
+			}
+			http.Error(w, err.Error(), code)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&reverifyResponse{TaskType: taskType, Verified: verification})
+	})
+}
+
+// startReverifyServer serves the re-verification endpoint POST /verify on
+// addr until ctx is cancelled, with the TLS configuration and caller
+// authentication of the performer server.
+func startReverifyServer(ctx context.Context, addr string, tw *TaskWorker, tlsCfg *tls.Config, auth *callerAuth, logger *zap.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("/verify", tw.reverifyHandler(auth))
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		TLSConfig:         tlsCfg,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		logger.Sugar().Infow("Starting re-verification server",
+			zap.String("addr", addr),
+			zap.Bool("tls", tlsCfg != nil),
+			zap.Bool("callerAuth", auth != nil),
+		)
+		var err error
+		if tlsCfg != nil {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Sugar().Errorw("Re-verification server failed", zap.Error(err))
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+}
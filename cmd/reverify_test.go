@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func Test_reverifyHandler(t *testing.T) {
+	// The provider must not be called: re-verification never generates.
+	tw := NewTaskWorker(zap.NewNop(), &erroringProvider{err: errors.New("unexpected generation")}, nil)
+	tw.SetVerifier(jsonVerifier{})
+	pipeline, err := NewVerificationPipeline(pipelinePolicyAll, 0, containsVerifier{substring: "Paris"})
+	if err != nil {
+		t.Fatalf("NewVerificationPipeline failed: %v", err)
+	}
+	tw.SetVerificationPipelines(map[string]*VerificationPipeline{taskTypeSummarize: pipeline})
+
+	tests := []struct {
+		name         string
+		method       string
+		body         string
+		wantStatus   int
+		wantTaskType string
+		wantChecks   []string
+		wantPassed   bool
+	}{
+		{
+			name:         "completion",
+			body:         `{"payload": {"prompt": "Answer in JSON"}, "output": "{\"answer\": 4}"}`,
+			wantStatus:   http.StatusOK,
+			wantTaskType: "completion",
+			wantChecks:   []string{"json"},
+			wantPassed:   true,
+		},
+		{
+			name:         "raw string payload",
+			body:         `{"payload": "What is 2+2?", "output": "four"}`,
+			wantStatus:   http.StatusOK,
+			wantTaskType: "completion",
+			wantChecks:   []string{"json"},
+		},
+		{
+			name:         "pipeline",
+			body:         `{"payload": {"task_type": "summarize", "document": "..."}, "output": "Paris is in France."}`,
+			wantStatus:   http.StatusOK,
+			wantTaskType: "summarize",
+			wantChecks:   []string{"pipeline"},
+			wantPassed:   true,
+		},
+		{name: "invalid payload", body: `{"payload": {"schema_version": 1, "prompt": "hi", "max_tokens": -1}, "output": "4"}`, wantStatus: http.StatusBadRequest},
+		{name: "no verification", body: `{"payload": {"task_type": "translate"}, "output": "Bonjour"}`, wantStatus: http.StatusBadRequest},
+		{name: "missing payload", body: `{"output": "Paris"}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid json", body: `{"payload":`, wantStatus: http.StatusBadRequest},
+		{name: "wrong method", method: http.MethodGet, wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			rec := httptest.NewRecorder()
+			tw.reverifyHandler(nil).ServeHTTP(rec, httptest.NewRequest(method, "/verify", strings.NewReader(tt.body)))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp struct {
+				TaskType string       `json:"task_type"`
+				Verified Verification `json:"verified"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.TaskType != tt.wantTaskType || resp.Verified.Passed != tt.wantPassed || !reflect.DeepEqual(resp.Verified.Checks, tt.wantChecks) {
+				t.Errorf("unexpected response: %s", rec.Body)
+			}
+		})
+	}
+}
+
+func Test_reverifyHandlerLimits(t *testing.T) {
+	consistency := &consistencyVerifier{provider: &erroringProvider{err: errors.New("unexpected generation")}, samples: 3, threshold: 0.5}
+	spent := NewTokenBudget(10, 0)
+	spent.Record(10)
+
+	tests := []struct {
+		name       string
+		verifier   Verifier
+		budget     *TokenBudget
+		wantStatus int
+	}{
+		{name: "within limits", verifier: jsonVerifier{}, budget: NewTokenBudget(10, 0), wantStatus: http.StatusOK},
+		{name: "budget exhausted", verifier: jsonVerifier{}, budget: spent, wantStatus: http.StatusTooManyRequests},
+		{name: "self consistency", verifier: consistency, wantStatus: http.StatusBadRequest},
+		{name: "self consistency in a chain", verifier: verifierChain{jsonVerifier{}, consistency}, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tw := NewTaskWorker(zap.NewNop(), &erroringProvider{err: errors.New("unexpected generation")}, nil)
+			tw.SetVerifier(tt.verifier)
+			tw.SetTokenBudget(tt.budget)
+
+			rec := httptest.NewRecorder()
+			body := `{"payload": {"prompt": "Answer in JSON"}, "output": "{\"answer\": 4}"}`
+			tw.reverifyHandler(nil).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader(body)))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func Test_reverifyHandlerAuth(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &erroringProvider{err: errors.New("unexpected generation")}, nil)
+	tw.SetVerifier(jsonVerifier{})
+	auth := &callerAuth{logger: zap.NewNop(), token: "secret"}
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "valid token", authorization: "Bearer secret", wantStatus: http.StatusOK},
+		{name: "invalid token", authorization: "Bearer guess", wantStatus: http.StatusUnauthorized},
+		{name: "missing token", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader(`{"payload": "What is 2+2?", "output": "4"}`))
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			tw.reverifyHandler(auth).ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func Test_reverifyAddrFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		port     string
+		host     string
+		wantAddr string
+		wantErr  bool
+	}{
+		{name: "unset"},
+		{name: "loopback by default", port: "8090", wantAddr: "127.0.0.1:8090"},
+		{name: "host", port: "8090", host: "0.0.0.0", wantAddr: "0.0.0.0:8090"},
+		{name: "negative", port: "-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("REVERIFY_PORT", tt.port)
+			t.Setenv("REVERIFY_HOST", tt.host)
+			addr, err := reverifyAddrFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if addr != tt.wantAddr {
+				t.Errorf("expected address %q, got %q", tt.wantAddr, addr)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// providerGeneration is one build of the provider stack, with the context
+// that runs its background work such as circuit breaker health checks.
+type providerGeneration struct {
+	provider Provider
+	cancel   context.CancelFunc
+}
+
+// RotatingProvider serves requests with the latest build of a provider stack.
+// It checks the secret store at an interval and, when a secret has been
+// rotated, builds the stack again with the new credentials and swaps it in.
+// Requests already running finish on the build they started with, so keys can
+// be rotated without restarting the performer or dropping tasks. A build that
+// fails, e.g. because the new key is not ready yet, keeps the current one.
+type RotatingProvider struct {
+	logger   *zap.Logger
+	store    *SecretStore
+	interval time.Duration
+	build    func(ctx context.Context) (Provider, error)
+
+	current atomic.Pointer[providerGeneration]
+	// pending is set when a rotated secret could not be applied yet, so the
+	// rebuild is retried at the next check. It is only used by watch.
+	pending bool
+}
+
+// NewRotatingProvider builds the provider and watches the store until ctx is
+// cancelled.
+func NewRotatingProvider(ctx context.Context, logger *zap.Logger, store *SecretStore, interval time.Duration, build func(ctx context.Context) (Provider, error)) (*RotatingProvider, error) {
+	rp := &RotatingProvider{logger: logger, store: store, interval: interval, build: build}
+	gen, err := rp.newGeneration(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rp.current.Store(gen)
+
+	go rp.watch(ctx)
+	return rp, nil
+}
+
+func (rp *RotatingProvider) Name() string {
+	return rp.current.Load().provider.Name()
+}
+
+func (rp *RotatingProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	return rp.current.Load().provider.Complete(ctx, req)
+}
+
+func (rp *RotatingProvider) newGeneration(ctx context.Context) (*providerGeneration, error) {
+	genCtx, cancel := context.WithCancel(ctx)
+	p, err := rp.build(genCtx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &providerGeneration{provider: p, cancel: cancel}, nil
+}
+
+func (rp *RotatingProvider) watch(ctx context.Context) {
+	ticker := time.NewTicker(rp.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rp.refresh(ctx)
+		}
+	}
+}
+
+// refresh rebuilds the provider when a secret has changed since the last
+// check, or when the last rebuild failed.
+func (rp *RotatingProvider) refresh(ctx context.Context) {
+	changed, err := rp.store.Refresh(ctx)
+	if err != nil {
+		rp.logger.Sugar().Warnw("Failed to refresh secrets, keeping current credentials", zap.Error(err))
+	}
+	if !changed && !rp.pending {
+		return
+	}
+
+	gen, err := rp.newGeneration(ctx)
+	if err != nil {
+		rp.pending = true
+		rp.logger.Sugar().Errorw("Failed to rebuild provider with rotated secrets, keeping current credentials", zap.Error(err))
+		return
+	}
+	rp.pending = false
+	old := rp.current.Swap(gen)
+	old.cancel()
+	rp.logger.Sugar().Infow("Rotated provider credentials", zap.String("provider", gen.provider.Name()))
+}
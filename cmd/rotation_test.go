@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func Test_RotatingProvider(t *testing.T) {
+	source := &countingSource{values: map[string]string{"key": "key-1"}}
+	// A zero TTL makes every check fetch the secret again.
+	store := NewSecretStore(0, func(ctx context.Context, scheme string) (SecretSource, error) {
+		return source, nil
+	})
+
+	failBuild := false
+	var builds []context.Context
+	rp, err := NewRotatingProvider(context.Background(), zap.NewNop(), store, time.Hour, func(ctx context.Context) (Provider, error) {
+		if failBuild {
+			return nil, errors.New("probe failed")
+		}
+		key, err := store.Resolve(ctx, "vault:key")
+		if err != nil {
+			return nil, err
+		}
+		builds = append(builds, ctx)
+		return &stubProvider{output: key}, nil
+	})
+	if err != nil {
+		t.Fatalf("NewRotatingProvider failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		secret     string
+		failBuild  bool
+		wantOutput string
+		wantBuilds int
+	}{
+		{name: "unchanged", secret: "key-1", wantOutput: "key-1", wantBuilds: 1},
+		{name: "rotated", secret: "key-2", wantOutput: "key-2", wantBuilds: 2},
+		{name: "rebuild fails", secret: "key-3", failBuild: true, wantOutput: "key-2", wantBuilds: 2},
+		{name: "rebuild retried", secret: "key-3", wantOutput: "key-3", wantBuilds: 3},
+		{name: "unchanged after retry", secret: "key-3", wantOutput: "key-3", wantBuilds: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source.values["key"] = tt.secret
+			failBuild = tt.failBuild
+			rp.refresh(context.Background())
+
+			resp, err := rp.Complete(context.Background(), &CompletionRequest{})
+			if err != nil {
+				t.Fatalf("Complete failed: %v", err)
+			}
+			if resp.Output != tt.wantOutput || len(builds) != tt.wantBuilds {
+				t.Errorf("expected %q after %d builds, got %q after %d", tt.wantOutput, tt.wantBuilds, resp.Output, len(builds))
+			}
+			// Only the current build keeps running its background work.
+			for i, ctx := range builds {
+				if current := i == len(builds)-1; (ctx.Err() == nil) != current {
+					t.Errorf("expected build %d running %v", i, current)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// Route is one entry of the cost table used by the RouterProvider.
+type Route struct {
+	Provider Provider
+	// CostPer1KTokens is the blended price of the route's model per 1000 tokens.
+	CostPer1KTokens float64
+	// MaxPromptTokens is the largest estimated prompt the route should serve.
+	// Zero means unlimited.
+	MaxPromptTokens int
+}
+
+// RouteSpec is the unresolved form of a Route as read from configuration.
+type RouteSpec struct {
+	Provider        string
+	CostPer1KTokens float64
+	MaxPromptTokens int
+}
+
+// parseRouteSpecs parses a comma-separated list of provider:cost_per_1k:max_prompt_tokens
+// entries, e.g. "groq:0.05:1000,openai:0.60:0".
+func parseRouteSpecs(s string) ([]RouteSpec, error) {
+	var specs []RouteSpec
+	for _, entry := range strings.Split(s, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid route %q, expected provider:cost_per_1k:max_prompt_tokens", entry)
+		}
+		cost, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || cost < 0 {
+			return nil, fmt.Errorf("invalid cost in route %q", entry)
+		}
+		maxTokens, err := strconv.Atoi(parts[2])
+		if err != nil || maxTokens < 0 {
+			return nil, fmt.Errorf("invalid max prompt tokens in route %q", entry)
+		}
+		specs = append(specs, RouteSpec{
+			Provider:        parts[0],
+			CostPer1KTokens: cost,
+			MaxPromptTokens: maxTokens,
+		})
+	}
+	return specs, nil
+}
+
+// RouterProvider picks a provider per request from a cost table: the cheapest
+// route able to take the estimated prompt size serves ordinary requests, while
+// requests flagged as critical always go to the most expensive (premium) route.
+type RouterProvider struct {
+	// routes is replaced as a whole when the cost table is reloaded.
+	routes atomic.Pointer[[]Route]
+	logger *zap.Logger
+}
+
+// currentRouter is the router tasks are sent through, if LLM_ROUTES is set,
+// whose cost table is updated when the configuration is reloaded.
+var currentRouter atomic.Pointer[RouterProvider]
+
+func NewRouterProvider(logger *zap.Logger, routes ...Route) (*RouterProvider, error) {
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("router requires at least one route")
+	}
+	p := &RouterProvider{logger: logger}
+	p.routes.Store(&routes)
+	return p, nil
+}
+
+// SetRouteSpecs replaces the costs and prompt limits of the routes. The specs
+// must name the providers of the routes in the same order, since providers
+// are only built at startup.
+func (p *RouterProvider) SetRouteSpecs(specs []RouteSpec) error {
+	current := *p.routes.Load()
+	if len(specs) != len(current) {
+		return fmt.Errorf("routes cannot be added or removed without a restart")
+	}
+	routes := make([]Route, len(current))
+	for i, spec := range specs {
+		if name := current[i].Provider.Name(); !strings.EqualFold(strings.TrimSpace(spec.Provider), name) {
+			return fmt.Errorf("route %d is served by %s, its provider cannot be changed without a restart", i+1, name)
+		}
+		routes[i] = Route{Provider: current[i].Provider, CostPer1KTokens: spec.CostPer1KTokens, MaxPromptTokens: spec.MaxPromptTokens}
+	}
+	p.routes.Store(&routes)
+	return nil
+}
+
+func (p *RouterProvider) Name() string {
+	routes := *p.routes.Load()
+	names := make([]string, 0, len(routes))
+	for _, route := range routes {
+		names = append(names, route.Provider.Name())
+	}
+	return "router(" + strings.Join(names, ",") + ")"
+}
+
+// estimateTokens approximates the token count of the request using the common
+// heuristic of four characters per token.
+func estimateTokens(req *CompletionRequest) int {
+	chars := 0
+	for _, m := range req.Messages {
+		chars += len(m.Content)
+	}
+	return (chars + 3) / 4
+}
+
+// selectRoute returns the route that should serve a prompt of the given size.
+func (p *RouterProvider) selectRoute(promptTokens int, critical bool) Route {
+	routes := *p.routes.Load()
+	if critical {
+		premium := routes[0]
+		for _, route := range routes[1:] {
+			if route.CostPer1KTokens > premium.CostPer1KTokens {
+				premium = route
+			}
+		}
+		return premium
+	}
+
+	var selected *Route
+	for i, route := range routes {
+		if route.MaxPromptTokens != 0 && promptTokens > route.MaxPromptTokens {
+			continue
+		}
+		if selected == nil || route.CostPer1KTokens < selected.CostPer1KTokens {
+			selected = &routes[i]
+		}
+	}
+	if selected != nil {
+		return *selected
+	}
+
+	// No route accepts a prompt this large, fall back to the route with the
+	// largest limit.
+	largest := routes[0]
+	for _, route := range routes[1:] {
+		if route.MaxPromptTokens > largest.MaxPromptTokens {
+			largest = route
+		}
+	}
+	return largest
+}
+
+func (p *RouterProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	promptTokens := estimateTokens(req)
+	route := p.selectRoute(promptTokens, req.Critical)
+
+	correlatedLogger(ctx, p.logger).Sugar().Infow("Routing completion",
+		zap.String("provider", route.Provider.Name()),
+		zap.Int("estimatedPromptTokens", promptTokens),
+		zap.Float64("estimatedCost", route.CostPer1KTokens*float64(promptTokens+req.MaxTokens)/1000),
+		zap.Bool("critical", req.Critical),
+	)
+
+	resp, err := route.Provider.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Provider == "" {
+		resp.Provider = route.Provider.Name()
+	}
+	return resp, nil
+}
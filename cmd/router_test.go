@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// namedStubProvider is a stubProvider that reports a custom name.
+type namedStubProvider struct {
+	stubProvider
+	name string
+}
+
+func (p *namedStubProvider) Name() string {
+	return p.name
+}
+
+func Test_RouterProvider(t *testing.T) {
+	routes := []Route{
+		{Provider: &namedStubProvider{name: "premium"}, CostPer1KTokens: 5, MaxPromptTokens: 0},
+		{Provider: &namedStubProvider{name: "cheap"}, CostPer1KTokens: 0.1, MaxPromptTokens: 100},
+		{Provider: &namedStubProvider{name: "mid"}, CostPer1KTokens: 1, MaxPromptTokens: 1000},
+	}
+
+	tests := []struct {
+		name         string
+		prompt       string
+		critical     bool
+		wantProvider string
+	}{
+		{name: "short prompt", prompt: "hi", wantProvider: "cheap"},
+		{name: "medium prompt", prompt: strings.Repeat("word ", 200), wantProvider: "mid"},
+		{name: "long prompt", prompt: strings.Repeat("word ", 2000), wantProvider: "premium"},
+		{name: "critical prompt", prompt: "hi", critical: true, wantProvider: "premium"},
+	}
+
+	p, err := NewRouterProvider(zap.NewNop(), routes...)
+	if err != nil {
+		t.Fatalf("NewRouterProvider failed: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := p.Complete(context.Background(), &CompletionRequest{
+				Messages: []ChatMessage{{Role: "user", Content: tt.prompt}},
+				Critical: tt.critical,
+			})
+			if err != nil {
+				t.Fatalf("Complete failed: %v", err)
+			}
+			if resp.Provider != tt.wantProvider {
+				t.Errorf("expected provider %s, got %s", tt.wantProvider, resp.Provider)
+			}
+		})
+	}
+}
+
+func Test_ParseRouteSpecs(t *testing.T) {
+	specs, err := parseRouteSpecs("groq:0.05:1000, openai:0.6:0")
+	if err != nil {
+		t.Fatalf("parseRouteSpecs failed: %v", err)
+	}
+	if len(specs) != 2 || specs[0].Provider != "groq" || specs[1].CostPer1KTokens != 0.6 || specs[0].MaxPromptTokens != 1000 {
+		t.Errorf("unexpected specs: %+v", specs)
+	}
+
+	for _, invalid := range []string{"groq", "groq:cheap:10", "groq:0.1:-1"} {
+		if _, err := parseRouteSpecs(invalid); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Safety policies, see safetyCheckFromEnv.
+const (
+	safetyPolicyFlag  = "flag"
+	safetyPolicyBlock = "block"
+)
+
+// SafetyResult is the safety classification of a completion output, reported
+// in the result's safety field.
+type SafetyResult struct {
+	Flagged bool `json:"flagged"`
+	// Categories maps each category to a score between 0 and 1.
+	Categories        map[string]float64 `json:"categories"`
+	FlaggedCategories []string           `json:"flagged_categories"`
+	// Blocked is set when the output was withheld from the result.
+	Blocked  bool   `json:"blocked"`
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+}
+
+// SafetyCheck classifies every completion output with a moderator after
+// generation. Flagged outputs fail verification; under the block policy they
+// are also withheld and the result is reported as refused.
+type SafetyCheck struct {
+	moderator Moderator
+	policy    string
+	threshold float64
+	// categories restricts the categories that flag an output; nil means all.
+	categories map[string]bool
+}
+
+// safetyCheckFromEnv builds the check from SAFETY_POLICY ("flag" or "block"),
+// or returns nil when it is unset. Outputs are classified by the
+// MODERATION_PROVIDER, or by the completion provider without one. An output
+// is flagged when the score of one of SAFETY_CATEGORIES (comma-separated, all
+// by default) reaches SAFETY_THRESHOLD.
+func safetyCheckFromEnv(ctx context.Context, provider Provider) (*SafetyCheck, error) {
+	policy := getenv("SAFETY_POLICY")
+	switch policy {
+	case "":
+		return nil, nil
+	case safetyPolicyFlag, safetyPolicyBlock:
+	default:
+		return nil, fmt.Errorf("unsupported SAFETY_POLICY %q, expected %s or %s", policy, safetyPolicyFlag, safetyPolicyBlock)
+	}
+
+	threshold, err := envFloat("SAFETY_THRESHOLD")
+	if err != nil {
+		return nil, err
+	}
+	if threshold < 0 || threshold > 1 {
+		return nil, fmt.Errorf("SAFETY_THRESHOLD must be between 0 and 1")
+	}
+	if threshold == 0 {
+		threshold = moderationFlagThreshold
+	}
+
+	var categories map[string]bool
+	if list := getenv("SAFETY_CATEGORIES"); list != "" {
+		categories = map[string]bool{}
+		for _, category := range strings.Split(list, ",") {
+			categories[strings.TrimSpace(category)] = true
+		}
+	}
+
+	moderator, err := newModeratorFromEnv(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if moderator == nil {
+		moderator = &completionModerator{provider: withOverheadTracking(provider)}
+	}
+	return &SafetyCheck{moderator: moderator, policy: policy, threshold: threshold, categories: categories}, nil
+}
+
+// Check classifies output. An error means the output could not be
+// classified.
+func (s *SafetyCheck) Check(ctx context.Context, output string) (*SafetyResult, error) {
+	resp, err := s.moderator.Moderate(ctx, &ModerationRequest{Input: output})
+	if err != nil {
+		return nil, fmt.Errorf("%s safety check failed: %w", s.moderator.Name(), err)
+	}
+
+	result := &SafetyResult{
+		Categories:        resp.Scores,
+		FlaggedCategories: []string{},
+		Provider:          s.moderator.Name(),
+		Model:             resp.Model,
+	}
+	for category, score := range resp.Scores {
+		if math.IsNaN(score) || score < 0 || score > 1 {
+			return nil, fmt.Errorf("%s returned invalid score %v for category %q", s.moderator.Name(), score, category)
+		}
+		if score >= s.threshold && (s.categories == nil || s.categories[category]) {
+			result.FlaggedCategories = append(result.FlaggedCategories, category)
+		}
+	}
+	sort.Strings(result.FlaggedCategories)
+
+	result.Flagged = len(result.FlaggedCategories) > 0
+	result.Blocked = result.Flagged && s.policy == safetyPolicyBlock
+	return result, nil
+}
+
+// validateSafety checks the safety field of a decoded result.
+func validateSafety(value interface{}) error {
+	safety, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("safety field must be an object")
+	}
+	for _, field := range []string{"flagged", "blocked"} {
+		if _, ok := safety[field].(bool); !ok {
+			return fmt.Errorf("safety.%s field must be a boolean", field)
+		}
+	}
+	categories, ok := safety["categories"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("safety.categories field must be an object")
+	}
+	for category, v := range categories {
+		if score, ok := v.(float64); !ok || score < 0 || score > 1 {
+			return fmt.Errorf("safety score of category %q must be a number between 0 and 1", category)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_SafetyCheck(t *testing.T) {
+	scores := map[string]float64{"hate": 0.1, "violence": 0.8, "sexual": 0.6}
+
+	tests := []struct {
+		name        string
+		policy      string
+		threshold   float64
+		categories  map[string]bool
+		wantFlagged []string
+		wantBlocked bool
+	}{
+		{name: "flag", policy: safetyPolicyFlag, threshold: 0.5, wantFlagged: []string{"sexual", "violence"}},
+		{name: "block", policy: safetyPolicyBlock, threshold: 0.5, wantFlagged: []string{"sexual", "violence"}, wantBlocked: true},
+		{name: "higher threshold", policy: safetyPolicyBlock, threshold: 0.9, wantFlagged: []string{}},
+		{name: "selected categories", policy: safetyPolicyBlock, threshold: 0.5, categories: map[string]bool{"hate": true, "sexual": true}, wantFlagged: []string{"sexual"}, wantBlocked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := &SafetyCheck{moderator: &stubModerator{scores: scores}, policy: tt.policy, threshold: tt.threshold, categories: tt.categories}
+			result, err := check.Check(context.Background(), "some output")
+			if err != nil {
+				t.Fatalf("Check failed: %v", err)
+			}
+			if !reflect.DeepEqual(result.FlaggedCategories, tt.wantFlagged) || result.Flagged != (len(tt.wantFlagged) > 0) || result.Blocked != tt.wantBlocked {
+				t.Errorf("unexpected safety result: %+v", result)
+			}
+		})
+	}
+}
+
+func Test_safetyCheckFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "disabled", wantNil: true},
+		{name: "block", env: map[string]string{"SAFETY_POLICY": "block", "SAFETY_CATEGORIES": "hate, violence"}},
+		{name: "unknown policy", env: map[string]string{"SAFETY_POLICY": "warn"}, wantErr: true},
+		{name: "threshold out of range", env: map[string]string{"SAFETY_POLICY": "flag", "SAFETY_THRESHOLD": "2"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"SAFETY_POLICY", "SAFETY_THRESHOLD", "SAFETY_CATEGORIES", "MODERATION_PROVIDER"} {
+				t.Setenv(key, tt.env[key])
+			}
+			check, err := safetyCheckFromEnv(context.Background(), &stubProvider{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && (check == nil) != tt.wantNil {
+				t.Errorf("unexpected safety check: %+v", check)
+			}
+		})
+	}
+}
+
+func Test_HandleTaskSafetyCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     string
+		scores     map[string]float64
+		wantOutput string
+		wantPassed bool
+	}{
+		{name: "safe", policy: safetyPolicyBlock, scores: map[string]float64{"violence": 0.1}, wantOutput: "valid", wantPassed: true},
+		{name: "flagged", policy: safetyPolicyFlag, scores: map[string]float64{"violence": 0.9}, wantOutput: "valid"},
+		{name: "blocked", policy: safetyPolicyBlock, scores: map[string]float64{"violence": 0.9}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "valid"}, nil)
+			tw.SetSafetyCheck(&SafetyCheck{moderator: &stubModerator{scores: tt.scores}, policy: tt.policy, threshold: moderationFlagThreshold})
+
+			resp, err := tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")})
+			if err != nil {
+				t.Fatalf("HandleTask failed: %v", err)
+			}
+			var result struct {
+				LLMOutput string        `json:"llm_output"`
+				Refused   bool          `json:"refused"`
+				Verified  Verification  `json:"verified"`
+				Safety    *SafetyResult `json:"safety"`
+			}
+			if err := json.Unmarshal(resp.Result, &result); err != nil {
+				t.Fatalf("failed to decode result: %v", err)
+			}
+			if result.LLMOutput != tt.wantOutput || result.Refused != (tt.wantOutput == "") || result.Verified.Passed != tt.wantPassed {
+				t.Errorf("unexpected result: %s", resp.Result)
+			}
+			if result.Safety == nil || !reflect.DeepEqual(result.Safety.Categories, tt.scores) {
+				t.Errorf("expected the category scores in the result, got %s", resp.Result)
+			}
+		})
+	}
+}
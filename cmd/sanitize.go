@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Kinds of content the output sanitizer removes.
+const (
+	sanitizeHTML    = "html"
+	sanitizeControl = "control"
+	sanitizeSecrets = "secrets"
+)
+
+// HTML handling modes of the output sanitizer.
+const (
+	sanitizeHTMLStrip  = "strip"
+	sanitizeHTMLEscape = "escape"
+)
+
+// redactedSecret replaces secrets-looking strings in outputs and logs.
+const redactedSecret = "[REDACTED]"
+
+var (
+	// htmlBlockPattern matches script and style elements, whose content is
+	// stripped along with the tags.
+	htmlBlockPattern = regexp.MustCompile(`(?is)<(script|style)\b.*?</(script|style)\s*>`)
+	// htmlTagPattern matches tags and comments but not a lone "<" as in
+	// "a < b".
+	htmlTagPattern = regexp.MustCompile(`(?s)<!--.*?-->|</?[A-Za-z][A-Za-z0-9-]*(\s[^<>]*)?/?>`)
+)
+
+// OutputSanitizer cleans model outputs before they are returned and possibly
+// posted on-chain: HTML is stripped or escaped, control characters other than
+// tabs and newlines are removed, including bidirectional overrides that
+// disguise text, and strings that look like secrets are redacted.
+type OutputSanitizer struct {
+	kinds map[string]bool
+	// htmlMode is sanitizeHTMLStrip or sanitizeHTMLEscape.
+	htmlMode string
+}
+
+// outputSanitizerFromEnv configures the sanitizer from OUTPUT_SANITIZE, a
+// comma-separated list of what to sanitize (html, control, secrets) or "all",
+// and returns nil when it is unset. OUTPUT_SANITIZE_HTML chooses whether HTML
+// is stripped ("strip", the default) or escaped ("escape").
+func outputSanitizerFromEnv() (*OutputSanitizer, error) {
+	v := strings.TrimSpace(getenv("OUTPUT_SANITIZE"))
+	if v == "" {
+		return nil, nil
+	}
+	s := &OutputSanitizer{kinds: map[string]bool{}, htmlMode: sanitizeHTMLStrip}
+	for _, kind := range strings.Split(v, ",") {
+		switch kind = strings.ToLower(strings.TrimSpace(kind)); kind {
+		case "all":
+			s.kinds[sanitizeHTML], s.kinds[sanitizeControl], s.kinds[sanitizeSecrets] = true, true, true
+		case sanitizeHTML, sanitizeControl, sanitizeSecrets:
+			s.kinds[kind] = true
+		default:
+			return nil, fmt.Errorf("unsupported OUTPUT_SANITIZE kind %q, expected one of: all, %s, %s, %s", kind, sanitizeHTML, sanitizeControl, sanitizeSecrets)
+		}
+	}
+	switch mode := getenv("OUTPUT_SANITIZE_HTML"); mode {
+	case "", sanitizeHTMLStrip:
+	case sanitizeHTMLEscape:
+		s.htmlMode = mode
+	default:
+		return nil, fmt.Errorf("unsupported OUTPUT_SANITIZE_HTML %q, expected %s or %s", mode, sanitizeHTMLStrip, sanitizeHTMLEscape)
+	}
+	return s, nil
+}
+
+// Sanitize returns the cleaned text and the number of changes of each kind.
+func (s *OutputSanitizer) Sanitize(text string) (string, map[string]int) {
+	changes := map[string]int{}
+	if s.kinds[sanitizeControl] {
+		text = strings.Map(func(r rune) rune {
+			if isUnsafeControl(r) {
+				changes[sanitizeControl]++
+				return -1
+			}
+			return r
+		}, text)
+	}
+	// Secrets go before HTML, so that escaping cannot split a key.
+	if s.kinds[sanitizeSecrets] {
+		text = secretKeyPattern.ReplaceAllStringFunc(text, func(string) string {
+			changes[sanitizeSecrets]++
+			return redactedSecret
+		})
+	}
+	if s.kinds[sanitizeHTML] {
+		if s.htmlMode == sanitizeHTMLEscape {
+			if escaped := html.EscapeString(text); escaped != text {
+				changes[sanitizeHTML]++
+				text = escaped
+			}
+		} else {
+			strip := func(string) string {
+				changes[sanitizeHTML]++
+				return ""
+			}
+			text = htmlBlockPattern.ReplaceAllStringFunc(text, strip)
+			text = htmlTagPattern.ReplaceAllStringFunc(text, strip)
+		}
+	}
+	return text, changes
+}
+
+// isUnsafeControl reports whether r is a control character other than tab and
+// newline, or an invisible formatting character that reorders or hides text.
+func isUnsafeControl(r rune) bool {
+	switch r {
+	case '\t', '\n':
+		return false
+	case '\u200b', '\u200c', '\u200d', '\u200e', '\u200f', '\u2060', '\ufeff':
+		return true
+	}
+	// U+202A-U+202E and U+2066-U+2069 are the bidirectional embeddings,
+	// overrides and isolates.
+	return unicode.IsControl(r) || (r >= '\u202a' && r <= '\u202e') || (r >= '\u2066' && r <= '\u2069')
+}
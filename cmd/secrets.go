@@ -0,0 +1,458 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// Secret reference schemes, see secretFromEnv.
+const (
+	secretSchemeFile  = "file"
+	secretSchemeVault = "vault"
+	secretSchemeAWS   = "aws-sm"
+	secretSchemeAzure = "azure-kv"
+)
+
+const (
+	defaultSecretCacheTTL = 5 * time.Minute
+
+	azureKeyVaultAPIVersion = "7.4"
+	azureKeyVaultScope      = "https://vault.azure.net/.default"
+)
+
+// SecretSource fetches secrets from a secret store. The reference is the part
+// of a secret reference after the scheme.
+type SecretSource interface {
+	Name() string
+	Fetch(ctx context.Context, ref string) (string, error)
+}
+
+// LeasedSecretSource is implemented by sources whose secrets carry a lease,
+// such as Vault. A secret is fetched again once its lease ends, even when the
+// cache TTL is longer.
+type LeasedSecretSource interface {
+	SecretSource
+	FetchLeased(ctx context.Context, ref string) (string, time.Duration, error)
+}
+
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+// SecretStore resolves secret references through the source of their scheme
+// and caches the values, so that providers built repeatedly do not query the
+// secret store each time.
+type SecretStore struct {
+	ttl time.Duration
+	// newSource builds the source of a scheme on first use, so that stores
+	// which are not referenced need no configuration.
+	newSource func(ctx context.Context, scheme string) (SecretSource, error)
+	now       func() time.Time
+
+	mu      sync.Mutex
+	sources map[string]SecretSource
+	cache   map[string]cachedSecret
+}
+
+func NewSecretStore(ttl time.Duration, newSource func(ctx context.Context, scheme string) (SecretSource, error)) *SecretStore {
+	return &SecretStore{
+		ttl:       ttl,
+		newSource: newSource,
+		now:       time.Now,
+		sources:   map[string]SecretSource{},
+		cache:     map[string]cachedSecret{},
+	}
+}
+
+// defaultSecretStore is the store used by secretFromEnv, with the cache TTL
+// set by SECRETS_CACHE_TTL.
+var defaultSecretStore = sync.OnceValues(func() (*SecretStore, error) {
+	ttl, err := envDuration("SECRETS_CACHE_TTL")
+	if err != nil {
+		return nil, err
+	}
+	if ttl == 0 {
+		ttl = defaultSecretCacheTTL
+	}
+	return NewSecretStore(ttl, secretSourceFromEnv), nil
+})
+
+// secretFromEnv returns the secret configured by the environment variable
+// key, so that credentials need not be kept in the environment. The variable
+// holds either the secret itself or a reference to it:
+//
+//	file:/run/secrets/azure_openai_key
+//	vault:secret/data/performer#azure_openai_key         (VAULT_ADDR, VAULT_TOKEN)
+//	aws-sm:prod/performer#azure_openai_key               (AWS default credentials)
+//	azure-kv:https://myvault.vault.azure.net/secrets/key (AZURE_TENANT_ID, AZURE_CLIENT_ID, AZURE_CLIENT_SECRET)
+//
+// The part after # selects a field of a secret holding several values.
+// Without the variable, the file named by key_FILE is read if set, as with
+// Docker and Kubernetes secrets.
+func secretFromEnv(ctx context.Context, key string) (string, error) {
+	value := getenv(key)
+	if value == "" {
+		path := getenv(key + "_FILE")
+		if path == "" {
+			return "", nil
+		}
+		value = secretSchemeFile + ":" + path
+	}
+
+	store, err := defaultSecretStore()
+	if err != nil {
+		return "", err
+	}
+	secret, err := store.Resolve(ctx, value)
+	if err != nil {
+		return "", fmt.Errorf("failed to load %s: %w", key, err)
+	}
+	return secret, nil
+}
+
+// parseSecretRef splits a secret reference into its scheme and reference. It
+// reports false for values that are not references.
+func parseSecretRef(value string) (string, string, bool) {
+	scheme, ref, ok := strings.Cut(value, ":")
+	if !ok {
+		return "", "", false
+	}
+	switch scheme {
+	case secretSchemeFile, secretSchemeVault, secretSchemeAWS, secretSchemeAzure:
+		if ref != "" {
+			return scheme, ref, true
+		}
+	}
+	return "", "", false
+}
+
+// Resolve returns the secret a value refers to, or the value itself when it
+// is not a secret reference.
+func (s *SecretStore) Resolve(ctx context.Context, value string) (string, error) {
+	scheme, ref, ok := parseSecretRef(value)
+	if !ok {
+		return value, nil
+	}
+
+	s.mu.Lock()
+	cached, hit := s.cache[value]
+	s.mu.Unlock()
+	if hit && s.now().Before(cached.expires) {
+		return cached.value, nil
+	}
+	secret, _, err := s.fetch(ctx, value, scheme, ref)
+	return secret, err
+}
+
+// Refresh fetches again every cached secret whose TTL or lease has ended and
+// reports whether any of them changed. Secrets that cannot be fetched keep
+// their previous value.
+func (s *SecretStore) Refresh(ctx context.Context) (bool, error) {
+	now := s.now()
+	var due []string
+	s.mu.Lock()
+	for value, cached := range s.cache {
+		if !now.Before(cached.expires) {
+			due = append(due, value)
+		}
+	}
+	s.mu.Unlock()
+	sort.Strings(due)
+
+	changed := false
+	var errs []error
+	for _, value := range due {
+		scheme, ref, _ := parseSecretRef(value)
+		_, rotated, err := s.fetch(ctx, value, scheme, ref)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		changed = changed || rotated
+	}
+	return changed, errors.Join(errs...)
+}
+
+// fetch reads a secret from the source of its scheme and caches it, reporting
+// whether it differs from the value cached before.
+func (s *SecretStore) fetch(ctx context.Context, value, scheme, ref string) (string, bool, error) {
+	s.mu.Lock()
+	source := s.sources[scheme]
+	s.mu.Unlock()
+	if source == nil {
+		var err error
+		if source, err = s.newSource(ctx, scheme); err != nil {
+			return "", false, err
+		}
+	}
+
+	var secret string
+	var lease time.Duration
+	var err error
+	if leased, ok := source.(LeasedSecretSource); ok {
+		secret, lease, err = leased.FetchLeased(ctx, ref)
+	} else {
+		secret, err = source.Fetch(ctx, ref)
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("%s: %w", source.Name(), err)
+	}
+	if secret == "" {
+		return "", false, fmt.Errorf("%s: secret %s is empty", source.Name(), ref)
+	}
+	ttl := s.ttl
+	if lease > 0 && lease < ttl {
+		ttl = lease
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, hit := s.cache[value]
+	s.sources[scheme] = source
+	s.cache[value] = cachedSecret{value: secret, expires: s.now().Add(ttl)}
+	return secret, hit && previous.value != secret, nil
+}
+
+// secretSourceFromEnv builds the source of a scheme from its environment.
+func secretSourceFromEnv(ctx context.Context, scheme string) (SecretSource, error) {
+	switch scheme {
+	case secretSchemeFile:
+		return fileSecretSource{}, nil
+	case secretSchemeVault:
+		return vaultSecretSourceFromEnv()
+	case secretSchemeAWS:
+		return awsSecretSourceFromEnv(ctx)
+	case secretSchemeAzure:
+		return azureKeyVaultSourceFromEnv(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported secret scheme %q", scheme)
+	}
+}
+
+// fileSecretSource reads secrets from mounted files. Trailing newlines are
+// dropped.
+type fileSecretSource struct{}
+
+func (fileSecretSource) Name() string {
+	return "file"
+}
+
+func (fileSecretSource) Fetch(ctx context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// vaultSecretSource reads secrets from HashiCorp Vault's KV secrets engine,
+// version 1 or 2. References have the form <path>#<field>; the field defaults
+// to "value".
+type vaultSecretSource struct {
+	addr      string
+	token     string
+	namespace string
+	client    *http.Client
+}
+
+// vaultSecretSourceFromEnv configures Vault from VAULT_ADDR, VAULT_TOKEN (or
+// VAULT_TOKEN_FILE) and the optional VAULT_NAMESPACE.
+func vaultSecretSourceFromEnv() (*vaultSecretSource, error) {
+	addr := strings.TrimSuffix(getenv("VAULT_ADDR"), "/")
+	if addr == "" {
+		return nil, fmt.Errorf("VAULT_ADDR must be set for vault: secrets")
+	}
+	token := getenv("VAULT_TOKEN")
+	if path := getenv("VAULT_TOKEN_FILE"); token == "" && path != "" {
+		var err error
+		if token, err = (fileSecretSource{}).Fetch(context.Background(), path); err != nil {
+			return nil, fmt.Errorf("failed to read VAULT_TOKEN_FILE: %w", err)
+		}
+	}
+	if token == "" {
+		return nil, fmt.Errorf("VAULT_TOKEN or VAULT_TOKEN_FILE must be set for vault: secrets")
+	}
+	return &vaultSecretSource{addr: addr, token: token, namespace: getenv("VAULT_NAMESPACE"), client: newHTTPClient()}, nil
+}
+
+func (v *vaultSecretSource) Name() string {
+	return "vault"
+}
+
+func (v *vaultSecretSource) Fetch(ctx context.Context, ref string) (string, error) {
+	secret, _, err := v.FetchLeased(ctx, ref)
+	return secret, err
+}
+
+func (v *vaultSecretSource) FetchLeased(ctx context.Context, ref string) (string, time.Duration, error) {
+	path, field, _ := strings.Cut(ref, "#")
+	if field == "" {
+		field = "value"
+	}
+	headers := map[string]string{"X-Vault-Token": v.token}
+	if v.namespace != "" {
+		headers["X-Vault-Namespace"] = v.namespace
+	}
+
+	var resp struct {
+		LeaseDuration int                    `json:"lease_duration"`
+		Data          map[string]interface{} `json:"data"`
+	}
+	if err := getJSON(ctx, v.client, v.Name(), v.addr+"/v1/"+strings.TrimPrefix(path, "/"), headers, &resp); err != nil {
+		return "", 0, err
+	}
+	// KV version 2 nests the secret's fields under data.data.
+	data := resp.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+	value, ok := data[field].(string)
+	if !ok {
+		return "", 0, fmt.Errorf("secret %s has no string field %q", path, field)
+	}
+	return value, time.Duration(resp.LeaseDuration) * time.Second, nil
+}
+
+// awsSecretsManagerAPI is the subset of the Secrets Manager client used by
+// the source, extracted so tests can substitute a fake.
+type awsSecretsManagerAPI interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// awsSecretSource reads secrets from AWS Secrets Manager. References have the
+// form <secret id>#<field>; with a field the secret must hold a JSON object.
+type awsSecretSource struct {
+	client awsSecretsManagerAPI
+}
+
+// awsSecretSourceFromEnv uses the default AWS credential chain and region.
+func awsSecretSourceFromEnv(ctx context.Context) (*awsSecretSource, error) {
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithHTTPClient(newHTTPClient()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	if awsCfg.Region == "" {
+		return nil, fmt.Errorf("AWS region not set for aws-sm: secrets")
+	}
+	return &awsSecretSource{client: secretsmanager.NewFromConfig(awsCfg)}, nil
+}
+
+func (a *awsSecretSource) Name() string {
+	return "aws-secrets-manager"
+}
+
+func (a *awsSecretSource) Fetch(ctx context.Context, ref string) (string, error) {
+	id, field, _ := strings.Cut(ref, "#")
+	out, err := a.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(id)})
+	if err != nil {
+		return "", err
+	}
+	value := aws.ToString(out.SecretString)
+	if field == "" {
+		return value, nil
+	}
+	return jsonSecretField(id, value, field)
+}
+
+// azureKeyVaultSource reads secrets from Azure Key Vault. References are
+// secret URLs, https://<vault>.vault.azure.net/secrets/<name>[/<version>].
+type azureKeyVaultSource struct {
+	client *http.Client
+}
+
+// azureKeyVaultSourceFromEnv authenticates as the service principal given by
+// AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET.
+func azureKeyVaultSourceFromEnv(ctx context.Context) (*azureKeyVaultSource, error) {
+	tenant, clientID, clientSecret := getenv("AZURE_TENANT_ID"), getenv("AZURE_CLIENT_ID"), getenv("AZURE_CLIENT_SECRET")
+	if tenant == "" || clientID == "" || clientSecret == "" {
+		return nil, fmt.Errorf("AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET must be set for azure-kv: secrets")
+	}
+	cfg := &clientcredentials.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     "https://login.microsoftonline.com/" + url.PathEscape(tenant) + "/oauth2/v2.0/token",
+		Scopes:       []string{azureKeyVaultScope},
+	}
+	// Both the token requests and the Key Vault calls go through the shared
+	// transport.
+	client := cfg.Client(context.WithValue(ctx, oauth2.HTTPClient, newHTTPClient()))
+	client.Timeout = defaultProviderTimeout
+	return &azureKeyVaultSource{client: client}, nil
+}
+
+func (a *azureKeyVaultSource) Name() string {
+	return "azure-key-vault"
+}
+
+func (a *azureKeyVaultSource) Fetch(ctx context.Context, ref string) (string, error) {
+	if !strings.HasPrefix(ref, "https://") || !strings.Contains(ref, "/secrets/") {
+		return "", fmt.Errorf("Key Vault secret %q must be an https:// secret URL", ref)
+	}
+	var resp struct {
+		Value string `json:"value"`
+	}
+	if err := getJSON(ctx, a.client, a.Name(), ref+"?api-version="+azureKeyVaultAPIVersion, nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.Value, nil
+}
+
+// jsonSecretField returns a string field of a secret holding a JSON object.
+func jsonSecretField(id, value, field string) (string, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object", id)
+	}
+	s, ok := fields[field].(string)
+	if !ok {
+		return "", fmt.Errorf("secret %s has no string field %q", id, field)
+	}
+	return s, nil
+}
+
+// getJSON GETs url and decodes the JSON response into out. Non-2xx responses
+// are returned as a ProviderError.
+func getJSON(ctx context.Context, client *http.Client, provider, url string, headers map[string]string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &ProviderError{Provider: provider, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", provider, err)
+	}
+	return nil
+}
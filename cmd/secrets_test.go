@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// countingSource returns the value of its reference and counts the fetches.
+type countingSource struct {
+	values  map[string]string
+	fetches int
+}
+
+func (c *countingSource) Name() string {
+	return "counting"
+}
+
+func (c *countingSource) Fetch(ctx context.Context, ref string) (string, error) {
+	c.fetches++
+	value, ok := c.values[ref]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return value, nil
+}
+
+func Test_parseSecretRef(t *testing.T) {
+	tests := []struct {
+		value      string
+		wantScheme string
+		wantRef    string
+		wantOK     bool
+	}{
+		{value: "file:/run/secrets/key", wantScheme: "file", wantRef: "/run/secrets/key", wantOK: true},
+		{value: "vault:secret/data/performer#key", wantScheme: "vault", wantRef: "secret/data/performer#key", wantOK: true},
+		{value: "aws-sm:prod/performer", wantScheme: "aws-sm", wantRef: "prod/performer", wantOK: true},
+		{value: "azure-kv:https://v.vault.azure.net/secrets/key", wantScheme: "azure-kv", wantRef: "https://v.vault.azure.net/secrets/key", wantOK: true},
+		{value: "sk-abc123"},
+		{value: "https://example.com"},
+		{value: "file:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			scheme, ref, ok := parseSecretRef(tt.value)
+			if scheme != tt.wantScheme || ref != tt.wantRef || ok != tt.wantOK {
+				t.Errorf("expected (%q, %q, %v), got (%q, %q, %v)", tt.wantScheme, tt.wantRef, tt.wantOK, scheme, ref, ok)
+			}
+		})
+	}
+}
+
+func Test_SecretStoreCache(t *testing.T) {
+	source := &countingSource{values: map[string]string{"performer#key": "s3cret"}}
+	store := NewSecretStore(time.Minute, func(ctx context.Context, scheme string) (SecretSource, error) {
+		return source, nil
+	})
+	now := time.Unix(0, 0)
+	store.now = func() time.Time { return now }
+
+	tests := []struct {
+		name        string
+		advance     time.Duration
+		value       string
+		want        string
+		wantFetches int
+		wantErr     bool
+	}{
+		{name: "literal", value: "sk-abc123", want: "sk-abc123"},
+		{name: "first fetch", value: "vault:performer#key", want: "s3cret", wantFetches: 1},
+		{name: "cached", advance: 30 * time.Second, value: "vault:performer#key", want: "s3cret", wantFetches: 1},
+		{name: "expired", advance: time.Minute, value: "vault:performer#key", want: "s3cret", wantFetches: 2},
+		{name: "missing", value: "vault:performer#other", wantFetches: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.advance)
+			got, err := store.Resolve(context.Background(), tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want || source.fetches != tt.wantFetches {
+				t.Errorf("expected %q after %d fetches, got %q after %d", tt.want, tt.wantFetches, got, source.fetches)
+			}
+		})
+	}
+}
+
+func Test_secretFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "key")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatalf("failed to write secret: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    string
+		wantErr bool
+	}{
+		{name: "unset"},
+		{name: "literal", env: map[string]string{"TEST_API_KEY": "sk-abc123"}, want: "sk-abc123"},
+		{name: "file reference", env: map[string]string{"TEST_API_KEY": "file:" + path}, want: "from-file"},
+		{name: "file variable", env: map[string]string{"TEST_API_KEY_FILE": path}, want: "from-file"},
+		{name: "missing file", env: map[string]string{"TEST_API_KEY_FILE": filepath.Join(dir, "missing")}, wantErr: true},
+		{name: "vault without address", env: map[string]string{"TEST_API_KEY": "vault:secret/key", "VAULT_ADDR": ""}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			got, err := secretFromEnv(context.Background(), "TEST_API_KEY")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_vaultSecretSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/performer":
+			_, _ = w.Write([]byte(`{"data": {"data": {"api_key": "kv2-key"}, "metadata": {"version": 3}}}`))
+		case "/v1/kv/performer":
+			_, _ = w.Write([]byte(`{"data": {"value": "kv1-key"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		token   string
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{name: "kv version 2", token: "root", ref: "secret/data/performer#api_key", want: "kv2-key"},
+		{name: "kv version 1 default field", token: "root", ref: "kv/performer", want: "kv1-key"},
+		{name: "missing field", token: "root", ref: "secret/data/performer#other", wantErr: true},
+		{name: "missing secret", token: "root", ref: "secret/data/other#api_key", wantErr: true},
+		{name: "forbidden", token: "wrong", ref: "secret/data/performer#api_key", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &vaultSecretSource{addr: srv.URL, token: tt.token, client: srv.Client()}
+			got, err := source.Fetch(context.Background(), tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+type fakeSecretsManager struct {
+	secrets map[string]string
+}
+
+func (f *fakeSecretsManager) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	value, ok := f.secrets[aws.ToString(params.SecretId)]
+	if !ok {
+		return nil, errors.New("ResourceNotFoundException")
+	}
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(value)}, nil
+}
+
+func Test_awsSecretSource(t *testing.T) {
+	source := &awsSecretSource{client: &fakeSecretsManager{secrets: map[string]string{
+		"plain":     "sk-plain",
+		"performer": `{"azure_openai_key": "sk-json"}`,
+	}}}
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{ref: "plain", want: "sk-plain"},
+		{ref: "performer#azure_openai_key", want: "sk-json"},
+		{ref: "performer#other", wantErr: true},
+		{ref: "plain#azure_openai_key", wantErr: true},
+		{ref: "missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := source.Fetch(context.Background(), tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_azureKeyVaultSource(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/secrets/azure-openai-key" || r.URL.Query().Get("api-version") != azureKeyVaultAPIVersion {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"value": "sk-kv", "id": "https://v.vault.azure.net/secrets/azure-openai-key/1"}`))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{name: "secret", ref: srv.URL + "/secrets/azure-openai-key", want: "sk-kv"},
+		{name: "missing secret", ref: srv.URL + "/secrets/other", wantErr: true},
+		{name: "not a secret url", ref: "azure-openai-key", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &azureKeyVaultSource{client: srv.Client()}
+			got, err := source.Fetch(context.Background(), tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// leasedSource returns secrets with a fixed lease.
+type leasedSource struct {
+	countingSource
+	lease time.Duration
+}
+
+func (l *leasedSource) FetchLeased(ctx context.Context, ref string) (string, time.Duration, error) {
+	value, err := l.Fetch(ctx, ref)
+	return value, l.lease, err
+}
+
+func Test_SecretStoreRefresh(t *testing.T) {
+	source := &leasedSource{countingSource: countingSource{values: map[string]string{"key": "v1"}}}
+	store := NewSecretStore(time.Minute, func(ctx context.Context, scheme string) (SecretSource, error) {
+		return source, nil
+	})
+	now := time.Unix(0, 0)
+	store.now = func() time.Time { return now }
+	if _, err := store.Resolve(context.Background(), "vault:key"); err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		lease       time.Duration
+		advance     time.Duration
+		secret      string
+		wantChanged bool
+		wantFetches int
+		wantErr     bool
+	}{
+		{name: "not expired", advance: 30 * time.Second, secret: "v2", wantFetches: 1},
+		{name: "expired and rotated", advance: 30 * time.Second, secret: "v2", wantChanged: true, wantFetches: 2},
+		{name: "expired and unchanged", lease: 10 * time.Second, advance: time.Minute, secret: "v2", wantFetches: 3},
+		{name: "lease ended before ttl", advance: 10 * time.Second, secret: "v3", wantChanged: true, wantFetches: 4},
+		{name: "fetch fails", advance: time.Minute, wantFetches: 5, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source.lease = tt.lease
+			if tt.secret == "" {
+				delete(source.values, "key")
+			} else {
+				source.values["key"] = tt.secret
+			}
+			now = now.Add(tt.advance)
+
+			changed, err := store.Refresh(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if changed != tt.wantChanged || source.fetches != tt.wantFetches {
+				t.Errorf("expected changed %v after %d fetches, got %v after %d", tt.wantChanged, tt.wantFetches, changed, source.fetches)
+			}
+		})
+	}
+}
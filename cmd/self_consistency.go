@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultConsistencySamples   = 3
+	defaultConsistencyThreshold = 0.6
+	maxConsistencySamples       = 10
+)
+
+// Consistency records how many samples of a prompt agreed with the output.
+type Consistency struct {
+	Samples   int     `json:"samples"`
+	Agreeing  int     `json:"agreeing"`
+	Agreement float64 `json:"agreement"`
+}
+
+// consistencyVerifier samples the prompt again and verifies the output only
+// when enough samples agree with it, a guard against hallucinated answers
+// that a model does not reproduce. The output counts as the first sample.
+type consistencyVerifier struct {
+	provider Provider
+	// samples is the total number of samples including the output.
+	samples int
+	// temperatures are used in turn for the extra samples. Empty keeps the
+	// request's temperature.
+	temperatures []float64
+	// similarity selects the agreement test as EnsembleConfig does.
+	similarity float64
+	threshold  float64
+}
+
+// consistencyVerifierFromEnv builds the verifier from SELF_CONSISTENCY_SAMPLES,
+// SELF_CONSISTENCY_TEMPERATURES (comma-separated), SELF_CONSISTENCY_SIMILARITY
+// and SELF_CONSISTENCY_THRESHOLD, the share of samples that must agree.
+func consistencyVerifierFromEnv(provider Provider) (*consistencyVerifier, error) {
+	samples, err := envInt("SELF_CONSISTENCY_SAMPLES")
+	if err != nil {
+		return nil, err
+	}
+	if samples == 0 {
+		samples = defaultConsistencySamples
+	}
+	if samples < 2 || samples > maxConsistencySamples {
+		return nil, fmt.Errorf("SELF_CONSISTENCY_SAMPLES must be between 2 and %d", maxConsistencySamples)
+	}
+
+	var temperatures []float64
+	if list := getenv("SELF_CONSISTENCY_TEMPERATURES"); list != "" {
+		for _, s := range strings.Split(list, ",") {
+			t, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil || t < 0 || t > maxTemperature {
+				return nil, fmt.Errorf("invalid value for SELF_CONSISTENCY_TEMPERATURES: temperatures must be between 0 and %g", maxTemperature)
+			}
+			temperatures = append(temperatures, t)
+		}
+	}
+
+	similarity, err := envFloat("SELF_CONSISTENCY_SIMILARITY")
+	if err != nil {
+		return nil, err
+	}
+	if similarity < 0 || similarity > 1 {
+		return nil, fmt.Errorf("SELF_CONSISTENCY_SIMILARITY must be between 0 and 1")
+	}
+
+	threshold, err := envFloat("SELF_CONSISTENCY_THRESHOLD")
+	if err != nil {
+		return nil, err
+	}
+	if threshold < 0 || threshold > 1 {
+		return nil, fmt.Errorf("SELF_CONSISTENCY_THRESHOLD must be between 0 and 1")
+	}
+	if threshold == 0 {
+		threshold = defaultConsistencyThreshold
+	}
+
+	return &consistencyVerifier{
+		provider:     withOverheadTracking(provider),
+		samples:      samples,
+		temperatures: temperatures,
+		similarity:   similarity,
+		threshold:    threshold,
+	}, nil
+}
+
+func (c *consistencyVerifier) Name() string {
+	return "self_consistency"
+}
+
+func (c *consistencyVerifier) Verify(ctx context.Context, p *TaskPayload, output string, v *Verification) error {
+	outputs := make([]string, c.samples-1)
+	errs := make([]error, c.samples-1)
+
+	var wg sync.WaitGroup
+	for i := range outputs {
+		req := p.CompletionRequest()
+		if len(c.temperatures) > 0 {
+			req.Temperature = c.temperatures[i%len(c.temperatures)]
+		}
+		wg.Add(1)
+		go func(i int, req *CompletionRequest) {
+			defer wg.Done()
+			resp, err := c.provider.Complete(ctx, req)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			outputs[i] = resp.Output
+		}(i, req)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%s sampling failed: %w", c.provider.Name(), err)
+	}
+
+	agreeing := 1
+	for _, sample := range outputs {
+		if outputsAgree(output, sample, c.similarity) {
+			agreeing++
+		}
+	}
+	consistency := &Consistency{
+		Samples:   c.samples,
+		Agreeing:  agreeing,
+		Agreement: float64(agreeing) / float64(c.samples),
+	}
+	v.Consistency = consistency
+	v.Check("self_consistency", consistency.Agreement >= c.threshold, "samples_disagree")
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// temperatureProvider answers with the output registered for the request's
+// temperature and records the temperatures it was called with.
+type temperatureProvider struct {
+	outputs      map[float64]string
+	err          error
+	mu           sync.Mutex
+	temperatures []float64
+}
+
+func (p *temperatureProvider) Name() string {
+	return "temperature"
+}
+
+func (p *temperatureProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	p.mu.Lock()
+	p.temperatures = append(p.temperatures, req.Temperature)
+	p.mu.Unlock()
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &CompletionResponse{Output: p.outputs[req.Temperature], Model: "temperature-model"}, nil
+}
+
+func Test_consistencyVerifier(t *testing.T) {
+	outputs := map[float64]string{0.2: "Paris", 0.7: "paris ", 1.0: "Lyon"}
+
+	tests := []struct {
+		name             string
+		samples          int
+		temperatures     []float64
+		similarity       float64
+		output           string
+		wantPassed       bool
+		wantAgreeing     int
+		wantTemperatures []float64
+	}{
+		{name: "request temperature", samples: 3, output: "Paris", wantPassed: true, wantAgreeing: 3, wantTemperatures: []float64{0.2, 0.2}},
+		{name: "varied temperatures", samples: 3, temperatures: []float64{0.7, 1.0}, output: "Paris", wantPassed: true, wantAgreeing: 2, wantTemperatures: []float64{0.7, 1.0}},
+		{name: "disagreement", samples: 5, temperatures: []float64{1.0}, output: "Paris", wantAgreeing: 1, wantTemperatures: []float64{1.0, 1.0, 1.0, 1.0}},
+		{name: "similar enough", samples: 2, temperatures: []float64{0.2}, similarity: 0.5, output: "Paris indeed", wantPassed: true, wantAgreeing: 2, wantTemperatures: []float64{0.2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &temperatureProvider{outputs: outputs}
+			verifier := &consistencyVerifier{
+				provider:     provider,
+				samples:      tt.samples,
+				temperatures: tt.temperatures,
+				similarity:   tt.similarity,
+				threshold:    defaultConsistencyThreshold,
+			}
+			payload, err := ParseTaskPayload([]byte("What is the capital of France?"))
+			if err != nil {
+				t.Fatalf("ParseTaskPayload failed: %v", err)
+			}
+
+			v := newVerification()
+			if err := verifier.Verify(context.Background(), payload, tt.output, v); err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if v.Passed != tt.wantPassed || v.Consistency == nil || v.Consistency.Agreeing != tt.wantAgreeing || v.Consistency.Samples != tt.samples {
+				t.Errorf("unexpected verification: %+v, consistency %+v", v, v.Consistency)
+			}
+
+			sort.Float64s(provider.temperatures)
+			if len(provider.temperatures) != len(tt.wantTemperatures) {
+				t.Fatalf("expected temperatures %v, got %v", tt.wantTemperatures, provider.temperatures)
+			}
+			for i := range provider.temperatures {
+				if provider.temperatures[i] != tt.wantTemperatures[i] {
+					t.Errorf("expected temperatures %v, got %v", tt.wantTemperatures, provider.temperatures)
+				}
+			}
+		})
+	}
+}
+
+func Test_consistencyVerifierSamplingError(t *testing.T) {
+	verifier := &consistencyVerifier{provider: &temperatureProvider{err: errors.New("boom")}, samples: 3, threshold: 0.6}
+	if err := verifier.Verify(context.Background(), &TaskPayload{Prompt: "hi"}, "hello", newVerification()); err == nil {
+		t.Errorf("expected error when sampling fails")
+	}
+}
+
+func Test_consistencyVerifierFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{name: "defaults"},
+		{name: "configured", env: map[string]string{"SELF_CONSISTENCY_SAMPLES": "5", "SELF_CONSISTENCY_TEMPERATURES": "0.2, 0.8", "SELF_CONSISTENCY_THRESHOLD": "0.8"}},
+		{name: "one sample", env: map[string]string{"SELF_CONSISTENCY_SAMPLES": "1"}, wantErr: true},
+		{name: "invalid temperature", env: map[string]string{"SELF_CONSISTENCY_TEMPERATURES": "0.2,hot"}, wantErr: true},
+		{name: "temperature out of range", env: map[string]string{"SELF_CONSISTENCY_TEMPERATURES": "3"}, wantErr: true},
+		{name: "threshold out of range", env: map[string]string{"SELF_CONSISTENCY_THRESHOLD": "2"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"SELF_CONSISTENCY_SAMPLES", "SELF_CONSISTENCY_TEMPERATURES", "SELF_CONSISTENCY_SIMILARITY", "SELF_CONSISTENCY_THRESHOLD"} {
+				t.Setenv(key, tt.env[key])
+			}
+			v, err := consistencyVerifierFromEnv(&stubProvider{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.name == "defaults" && (v.samples != defaultConsistencySamples || v.threshold != defaultConsistencyThreshold) {
+				t.Errorf("unexpected defaults: %+v", v)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultShutdownGracePeriod is how long in-flight tasks are given to finish
+// on shutdown when SHUTDOWN_GRACE_PERIOD is unset.
+const defaultShutdownGracePeriod = 30 * time.Second
+
+// ErrShuttingDown is returned for tasks received once the performer started
+// shutting down. The aggregator may send them to the performer again once it
+// restarted, or to another operator.
+var ErrShuttingDown = errors.New("performer is shutting down")
+
+// shutdownGracePeriodFromEnv returns how long in-flight tasks are given to
+// finish on SIGTERM or SIGINT, from SHUTDOWN_GRACE_PERIOD.
+func shutdownGracePeriodFromEnv() (time.Duration, error) {
+	grace, err := envDuration("SHUTDOWN_GRACE_PERIOD")
+	if err != nil {
+		return 0, err
+	}
+	if grace < 0 {
+		return 0, fmt.Errorf("SHUTDOWN_GRACE_PERIOD must not be negative")
+	}
+	if grace == 0 {
+		grace = defaultShutdownGracePeriod
+	}
+	return grace, nil
+}
+
+// taskDrain tracks the tasks in flight so that shutdown can stop taking new
+// tasks and wait for the ones already started.
+type taskDrain struct {
+	mu       sync.RWMutex
+	draining bool
+	tasks    sync.WaitGroup
+	inFlight atomic.Int64
+}
+
+// begin registers a task, and fails with ErrShuttingDown once draining
+// started. Tasks that began must call end.
+func (d *taskDrain) begin() error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.draining {
+		return ErrShuttingDown
+	}
+	d.tasks.Add(1)
+	d.inFlight.Add(1)
+	return nil
+}
+
+func (d *taskDrain) isDraining() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.draining
+}
+
+func (d *taskDrain) end() {
+	d.inFlight.Add(-1)
+	d.tasks.Done()
+}
+
+// drain stops taking new tasks and waits for the tasks in flight to finish,
+// until ctx is done.
+func (d *taskDrain) drain(ctx context.Context) error {
+	d.mu.Lock()
+	d.draining = true
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.tasks.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d task(s) still in flight: %w", d.inFlight.Load(), ctx.Err())
+	}
+}
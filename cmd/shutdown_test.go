@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_shutdownGracePeriodFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default", want: defaultShutdownGracePeriod},
+		{name: "set", env: "1m", want: time.Minute},
+		{name: "negative", env: "-1s", wantErr: true},
+		{name: "invalid", env: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SHUTDOWN_GRACE_PERIOD", tt.env)
+			got, err := shutdownGracePeriodFromEnv()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_taskDrain(t *testing.T) {
+	tests := []struct {
+		name    string
+		finish  bool
+		wantErr bool
+	}{
+		{name: "waits for tasks in flight", finish: true},
+		{name: "gives up after the grace period", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d taskDrain
+			require.NoError(t, d.begin())
+			if tt.finish {
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					d.end()
+				}()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			err := d.drain(ctx)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.ErrorIs(t, d.begin(), ErrShuttingDown)
+		})
+	}
+}
+
+func Test_TaskWorkerDrain(t *testing.T) {
+	w := NewTaskWorker(zap.NewNop(), &stubProvider{output: "hello"}, nil)
+	task := &performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("say hello")}
+	_, err := w.HandleTask(task)
+	require.NoError(t, err)
+
+	require.NoError(t, w.Drain(context.Background()))
+	_, err = w.HandleTask(task)
+	assert.ErrorIs(t, err, ErrShuttingDown)
+}
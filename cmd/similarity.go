@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+const defaultSimilarityThreshold = 0.5
+
+// similarityVerifier embeds the output and compares it to a reference
+// embedding, catching off-topic or garbage outputs. The reference is the
+// payload's reference_embedding or, without one, the embedding of the prompt:
+//
+//	{"prompt": "...", "reference_embedding": [0.12, -0.03, ...]}
+//
+// The output passes when the cosine similarity reaches the threshold.
+type similarityVerifier struct {
+	embedder  Embedder
+	threshold float64
+}
+
+// similarityVerifierFromEnv builds the verifier from the embedding provider
+// named by EMBEDDING_PROVIDER and SIMILARITY_THRESHOLD.
+func similarityVerifierFromEnv(ctx context.Context) (*similarityVerifier, error) {
+	embedder, err := newEmbedderFromEnv(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if embedder == nil {
+		return nil, fmt.Errorf("EMBEDDING_PROVIDER must be set for the similarity verifier")
+	}
+
+	threshold, err := envFloat("SIMILARITY_THRESHOLD")
+	if err != nil {
+		return nil, err
+	}
+	if threshold < 0 || threshold > 1 {
+		return nil, fmt.Errorf("SIMILARITY_THRESHOLD must be between 0 and 1")
+	}
+	if threshold == 0 {
+		threshold = defaultSimilarityThreshold
+	}
+	return &similarityVerifier{embedder: embedder, threshold: threshold}, nil
+}
+
+func (s *similarityVerifier) Name() string {
+	return "similarity"
+}
+
+func (s *similarityVerifier) Verify(ctx context.Context, p *TaskPayload, output string, v *Verification) error {
+	reference, err := s.reference(ctx, p)
+	if err != nil {
+		return err
+	}
+	resp, err := s.embedder.Embed(ctx, &EmbeddingRequest{Input: output})
+	if err != nil {
+		return fmt.Errorf("%s embedding failed: %w", s.embedder.Name(), err)
+	}
+
+	similarity, ok := cosineSimilarity(reference, resp.Embedding)
+	if !ok {
+		v.Check("similarity", false, "reference_mismatch")
+		return nil
+	}
+	v.Similarity = &similarity
+	v.Check("similarity", similarity >= s.threshold, "low_similarity")
+	return nil
+}
+
+// reference returns the payload's reference embedding, or embeds the prompt
+// when the payload has none.
+func (s *similarityVerifier) reference(ctx context.Context, p *TaskPayload) ([]float64, error) {
+	if len(p.raw) > 0 {
+		var params struct {
+			ReferenceEmbedding []float64 `json:"reference_embedding"`
+		}
+		if err := p.Decode(&params); err != nil {
+			return nil, err
+		}
+		if len(params.ReferenceEmbedding) > 0 {
+			return params.ReferenceEmbedding, nil
+		}
+	}
+
+	prompt := p.Prompt
+	if prompt == "" && len(p.Messages) > 0 {
+		prompt = p.Messages[len(p.Messages)-1].Content
+	}
+	resp, err := s.embedder.Embed(ctx, &EmbeddingRequest{Input: prompt})
+	if err != nil {
+		return nil, fmt.Errorf("%s embedding failed: %w", s.embedder.Name(), err)
+	}
+	return resp.Embedding, nil
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors. It reports
+// false when they differ in length or either has no direction.
+func cosineSimilarity(a, b []float64) (float64, bool) {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0, false
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0, false
+	}
+	similarity := dot / (math.Sqrt(normA) * math.Sqrt(normB))
+	if math.IsNaN(similarity) {
+		return 0, false
+	}
+	// Rounding can push parallel vectors just past 1.
+	return math.Max(-1, math.Min(1, similarity)), true
+}
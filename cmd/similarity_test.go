@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// textEmbedder embeds each input as the vector registered for it.
+type textEmbedder struct {
+	vectors map[string][]float64
+	inputs  []string
+}
+
+func (e *textEmbedder) Name() string {
+	return "text"
+}
+
+func (e *textEmbedder) Embed(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	e.inputs = append(e.inputs, req.Input)
+	return &EmbeddingResponse{Embedding: e.vectors[req.Input], Model: "text-embedding"}, nil
+}
+
+func Test_cosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name   string
+		a, b   []float64
+		want   float64
+		wantOK bool
+	}{
+		{name: "parallel", a: []float64{1, 2}, b: []float64{2, 4}, want: 1, wantOK: true},
+		{name: "orthogonal", a: []float64{1, 0}, b: []float64{0, 3}, want: 0, wantOK: true},
+		{name: "opposite", a: []float64{1, 1}, b: []float64{-1, -1}, want: -1, wantOK: true},
+		{name: "length mismatch", a: []float64{1, 0}, b: []float64{1, 0, 0}},
+		{name: "zero vector", a: []float64{0, 0}, b: []float64{1, 0}},
+		{name: "empty", a: nil, b: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := cosineSimilarity(tt.a, tt.b)
+			if ok != tt.wantOK || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("expected %v, %v, got %v, %v", tt.want, tt.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func Test_similarityVerifier(t *testing.T) {
+	embedder := &textEmbedder{vectors: map[string][]float64{
+		"What is the capital of France?": {1, 0, 0},
+		"Paris":                          {0.9, 0.1, 0},
+		"Bananas are yellow":             {0, 0, 1},
+		"Short":                          {1, 0},
+	}}
+
+	tests := []struct {
+		name           string
+		payload        string
+		output         string
+		wantReasons    []string
+		wantSimilarity bool
+		wantInputs     []string
+	}{
+		{
+			name:           "similar to the prompt",
+			payload:        "What is the capital of France?",
+			output:         "Paris",
+			wantReasons:    []string{},
+			wantSimilarity: true,
+			wantInputs:     []string{"What is the capital of France?", "Paris"},
+		},
+		{
+			name:           "off topic",
+			payload:        `{"prompt":"What is the capital of France?"}`,
+			output:         "Bananas are yellow",
+			wantReasons:    []string{"low_similarity"},
+			wantSimilarity: true,
+			wantInputs:     []string{"What is the capital of France?", "Bananas are yellow"},
+		},
+		{
+			name:           "reference embedding",
+			payload:        `{"prompt":"What is the capital of France?","reference_embedding":[0,0,2]}`,
+			output:         "Bananas are yellow",
+			wantReasons:    []string{},
+			wantSimilarity: true,
+			wantInputs:     []string{"Bananas are yellow"},
+		},
+		{
+			name:        "reference of another size",
+			payload:     `{"prompt":"What is the capital of France?"}`,
+			output:      "Short",
+			wantReasons: []string{"reference_mismatch"},
+			wantInputs:  []string{"What is the capital of France?", "Short"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embedder.inputs = nil
+			verifier := &similarityVerifier{embedder: embedder, threshold: 0.8}
+			payload, err := ParseTaskPayload([]byte(tt.payload))
+			if err != nil {
+				t.Fatalf("ParseTaskPayload failed: %v", err)
+			}
+
+			v := newVerification()
+			if err := verifier.Verify(context.Background(), payload, tt.output, v); err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if !reflect.DeepEqual(v.Reasons, tt.wantReasons) || (v.Similarity != nil) != tt.wantSimilarity {
+				t.Errorf("unexpected verification: %+v", v)
+			}
+			if !reflect.DeepEqual(embedder.inputs, tt.wantInputs) {
+				t.Errorf("expected embeddings of %q, got %q", tt.wantInputs, embedder.inputs)
+			}
+		})
+	}
+}
+
+func Test_similarityVerifierFromEnv(t *testing.T) {
+	t.Setenv("EMBEDDING_PROVIDER", "")
+	if _, err := similarityVerifierFromEnv(context.Background()); err == nil {
+		t.Errorf("expected error without EMBEDDING_PROVIDER")
+	}
+
+	t.Setenv("EMBEDDING_PROVIDER", "ollama")
+	verifier, err := similarityVerifierFromEnv(context.Background())
+	if err != nil {
+		t.Fatalf("similarityVerifierFromEnv failed: %v", err)
+	}
+	if verifier.threshold != defaultSimilarityThreshold {
+		t.Errorf("expected default threshold, got %v", verifier.threshold)
+	}
+
+	t.Setenv("SIMILARITY_THRESHOLD", "1.5")
+	if _, err := similarityVerifierFromEnv(context.Background()); err == nil {
+		t.Errorf("expected error for SIMILARITY_THRESHOLD above 1")
+	}
+}
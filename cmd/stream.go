@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// chatCompletionChunk is a single server-sent event of a streamed OpenAI chat
+// completion.
+type chatCompletionChunk struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *chatCompletionUsage `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// streamChat posts body, a chat completion request with streaming enabled, and
+// assembles the output from the server-sent events as they arrive. Servers
+// that ignore the stream flag and answer with a plain JSON body are handled as
+// well.
+func streamChat(ctx context.Context, client *http.Client, provider, url string, headers map[string]string, body interface{}) (*CompletionResponse, error) {
+	start := time.Now()
+	resp, err := postJSON(ctx, client, provider, url, headers, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		var body chatCompletionResponse
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return nil, fmt.Errorf("failed to decode %s response: %w", provider, err)
+		}
+		return body.toCompletionResponse(), nil
+	}
+
+	var (
+		output    strings.Builder
+		respModel string
+		usage     chatCompletionUsage
+		firstSeen bool
+	)
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			// Comments, event names and the blank lines separating events.
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk chatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("failed to decode %s stream event: %w", provider, err)
+		}
+		if chunk.Error != nil {
+			return nil, fmt.Errorf("%s stream failed: %s", provider, chunk.Error.Message)
+		}
+		if chunk.Model != "" {
+			respModel = chunk.Model
+		}
+		if chunk.Usage != nil {
+			usage = *chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			if !firstSeen {
+				firstSeen = true
+				providerTimeToFirstToken.WithLabelValues(provider).Observe(time.Since(start).Seconds())
+			}
+			output.WriteString(choice.Delta.Content)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to read %s stream: %w", provider, err)
+	}
+
+	return &CompletionResponse{
+		Output:    output.String(),
+		Model:     respModel,
+		TokensIn:  usage.PromptTokens,
+		TokensOut: usage.CompletionTokens,
+	}, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_StreamChat(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+		wantUsage   chatCompletionUsage
+		wantErr     bool
+	}{
+		{
+			name:        "event stream",
+			contentType: "text/event-stream",
+			body: ": keep-alive\n\n" +
+				`data: {"model":"gpt-4o-mini","choices":[{"delta":{"role":"assistant"}}]}` + "\n\n" +
+				`data: {"model":"gpt-4o-mini","choices":[{"delta":{"content":"hel"}}]}` + "\n\n" +
+				`data: {"model":"gpt-4o-mini","choices":[{"delta":{"content":"lo"}}]}` + "\n\n" +
+				`data: {"model":"gpt-4o-mini","choices":[],"usage":{"prompt_tokens":8,"completion_tokens":2}}` + "\n\n" +
+				"data: [DONE]\n\n",
+			want:      "hello",
+			wantUsage: chatCompletionUsage{PromptTokens: 8, CompletionTokens: 2},
+		},
+		{
+			name:        "stream flag ignored",
+			contentType: "application/json",
+			body:        `{"model":"gpt-4o-mini","choices":[{"message":{"content":"hello"}}]}`,
+			want:        "hello",
+		},
+		{
+			name:        "error event",
+			contentType: "text/event-stream",
+			body:        `data: {"error":{"message":"overloaded"}}` + "\n\n",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body chatCompletionRequest
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+				}
+				if !body.Stream || body.StreamOptions == nil || !body.StreamOptions.IncludeUsage {
+					t.Errorf("expected stream with usage to be requested")
+				}
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+
+			resp, err := completeChat(context.Background(), srv.Client(), "test", srv.URL, nil, "gpt-4o-mini", &CompletionRequest{Stream: true})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if resp.Output != tt.want || resp.Model != "gpt-4o-mini" ||
+				resp.TokensIn != tt.wantUsage.PromptTokens || resp.TokensOut != tt.wantUsage.CompletionTokens {
+				t.Errorf("unexpected response: %+v", resp)
+			}
+		})
+	}
+}
+
+func Test_StreamChatCancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, `data: {"choices":[{"delta":{"content":"partial"}}]}`+"\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := completeChat(ctx, srv.Client(), "test", srv.URL, nil, "", &CompletionRequest{Stream: true})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// systemPromptFromEnv returns the operator's system prompt, read from
+// OPERATOR_SYSTEM_PROMPT or from the file named by OPERATOR_SYSTEM_PROMPT_FILE.
+// It returns "" when none is configured.
+func systemPromptFromEnv() (string, error) {
+	prompt := getenv("OPERATOR_SYSTEM_PROMPT")
+	if path := getenv("OPERATOR_SYSTEM_PROMPT_FILE"); path != "" {
+		if prompt != "" {
+			return "", fmt.Errorf("OPERATOR_SYSTEM_PROMPT and OPERATOR_SYSTEM_PROMPT_FILE are mutually exclusive")
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read operator system prompt: %w", err)
+		}
+		prompt = string(data)
+	}
+	return strings.TrimSpace(prompt), nil
+}
+
+// SystemPromptProvider prepends the operator's system prompt to every request,
+// e.g. to pin the output format or refuse off-policy requests. The prompt's
+// hash is reported in results so task creators can check which policy an
+// operator applied.
+type SystemPromptProvider struct {
+	Provider
+	prompt atomic.Pointer[systemPrompt]
+}
+
+// systemPrompt is a system prompt and its hash, replaced together.
+type systemPrompt struct {
+	text string
+	hash string
+}
+
+func NewSystemPromptProvider(p Provider, prompt string) *SystemPromptProvider {
+	sp := &SystemPromptProvider{Provider: p}
+	sp.SetPrompt(prompt)
+	return sp
+}
+
+// SetPrompt replaces the system prompt.
+func (p *SystemPromptProvider) SetPrompt(prompt string) {
+	p.prompt.Store(&systemPrompt{text: prompt, hash: crypto.Keccak256Hash([]byte(prompt)).Hex()})
+}
+
+// Hash returns the keccak256 hash of the system prompt.
+func (p *SystemPromptProvider) Hash() string {
+	return p.prompt.Load().hash
+}
+
+func (p *SystemPromptProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	withPrompt := *req
+	withPrompt.Messages = append([]ChatMessage{{Role: "system", Content: p.prompt.Load().text}}, req.Messages...)
+	return p.Provider.Complete(ctx, &withPrompt)
+}
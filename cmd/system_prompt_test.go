@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_systemPromptFromEnv(t *testing.T) {
+	t.Setenv("OPERATOR_SYSTEM_PROMPT", "  Answer in English.\n")
+	prompt, err := systemPromptFromEnv()
+	if err != nil || prompt != "Answer in English." {
+		t.Errorf("expected prompt from env, got %q (%v)", prompt, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "system.txt")
+	if err := os.WriteFile(path, []byte("Refuse off-topic requests.\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	t.Setenv("OPERATOR_SYSTEM_PROMPT_FILE", path)
+	if _, err := systemPromptFromEnv(); err == nil {
+		t.Errorf("expected error when both variables are set")
+	}
+
+	t.Setenv("OPERATOR_SYSTEM_PROMPT", "")
+	prompt, err = systemPromptFromEnv()
+	if err != nil || prompt != "Refuse off-topic requests." {
+		t.Errorf("expected prompt from file, got %q (%v)", prompt, err)
+	}
+}
+
+func Test_SystemPromptProvider(t *testing.T) {
+	inner := &recordingProvider{}
+	p := NewSystemPromptProvider(inner, "Answer in English.")
+
+	req := &CompletionRequest{Messages: []ChatMessage{{Role: "system", Content: "be brief"}, {Role: "user", Content: "hi"}}}
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	want := []ChatMessage{{Role: "system", Content: "Answer in English."}, {Role: "system", Content: "be brief"}, {Role: "user", Content: "hi"}}
+	if !reflect.DeepEqual(inner.last.Messages, want) {
+		t.Errorf("expected %+v, got %+v", want, inner.last.Messages)
+	}
+	if len(req.Messages) != 2 {
+		t.Errorf("caller's request was modified: %+v", req.Messages)
+	}
+}
+
+func Test_HandleTaskReportsSystemPromptHash(t *testing.T) {
+	p := NewSystemPromptProvider(&stubProvider{output: "valid"}, "Answer in English.")
+	tw := NewTaskWorker(zap.NewNop(), p, nil)
+
+	resp, err := tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")})
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+
+	var result struct {
+		Metadata struct {
+			SystemPromptHash string `json:"system_prompt_hash"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if result.Metadata.SystemPromptHash != p.Hash() {
+		t.Errorf("expected system_prompt_hash %s, got %s", p.Hash(), result.Metadata.SystemPromptHash)
+	}
+}
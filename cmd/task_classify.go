@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	taskTypeClassify = "classify"
+
+	maxClassifyLabels = 50
+)
+
+// classifyParams are the classify task fields of a payload:
+//
+//	{"task_type": "classify", "text": "...", "labels": ["positive", "negative", "neutral"]}
+type classifyParams struct {
+	Text   string   `json:"text"`
+	Labels []string `json:"labels"`
+}
+
+// ClassifyHandler assigns one of a fixed set of candidate labels to a text.
+// A result is verified when the selected label is one of the candidates.
+type ClassifyHandler struct {
+	provider Provider
+}
+
+func NewClassifyHandler(provider Provider) *ClassifyHandler {
+	return &ClassifyHandler{provider: provider}
+}
+
+func (h *ClassifyHandler) params(p *TaskPayload) (*classifyParams, error) {
+	var params classifyParams
+	if err := p.Decode(&params); err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
+
+func (h *ClassifyHandler) Validate(p *TaskPayload) error {
+	params, err := h.params(p)
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(params.Text)) == 0 {
+		return fmt.Errorf("text cannot be empty or whitespace only")
+	}
+	if len(params.Labels) < 2 || len(params.Labels) > maxClassifyLabels {
+		return fmt.Errorf("labels must contain between 2 and %d entries", maxClassifyLabels)
+	}
+	seen := map[string]bool{}
+	for _, label := range params.Labels {
+		key := strings.ToLower(strings.TrimSpace(label))
+		if key == "" {
+			return fmt.Errorf("labels cannot be empty")
+		}
+		if seen[key] {
+			return fmt.Errorf("duplicate label %q", label)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+func (h *ClassifyHandler) Handle(ctx context.Context, p *TaskPayload) (map[string]interface{}, error) {
+	params, err := h.params(p)
+	if err != nil {
+		return nil, err
+	}
+
+	labelsJSON, err := json.Marshal(params.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	req := p.CompletionRequest()
+	req.Messages = []ChatMessage{
+		{
+			Role: "system",
+			Content: "Classify the user's text into exactly one of these labels: " + string(labelsJSON) + ". " +
+				`Reply with JSON only, in the form {"label": "<label>", "confidence": <number between 0 and 1>}.`,
+		},
+		{Role: "user", Content: params.Text},
+	}
+	if p.Temperature == nil {
+		// Classification should be deterministic unless the task asks otherwise.
+		req.Temperature = 0
+	}
+
+	completion, err := h.provider.Complete(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("%s classification failed: %w", h.provider.Name(), err)
+	}
+
+	label, confidence := parseClassification(completion.Output, params.Labels)
+	// Cross-model agreement is a better confidence signal than the model's own
+	// estimate.
+	if completion.Agreement != nil && completion.Agreement.Members > 0 {
+		confidence = float64(completion.Agreement.Votes) / float64(completion.Agreement.Members)
+	}
+
+	return map[string]interface{}{
+		"label":      label,
+		"confidence": confidence,
+		"verified":   newVerification().Check("label_in_set", label != "", "label_not_in_set"),
+		"metadata":   completionMetadata(h.provider, completion),
+	}, nil
+}
+
+func (h *ClassifyHandler) ValidateResult(result map[string]interface{}) error {
+	if _, ok := result["label"].(string); !ok {
+		return fmt.Errorf("label field must be a string")
+	}
+	confidence, ok := result["confidence"].(float64)
+	if !ok {
+		return fmt.Errorf("confidence field must be a number")
+	}
+	if confidence < 0 || confidence > 1 {
+		return fmt.Errorf("confidence must be between 0 and 1")
+	}
+	return nil
+}
+
+// parseClassification extracts the label and confidence from the model output.
+// The label is mapped to its spelling in labels, or left empty when the model
+// answered with a label outside the allowed set. Models that ignore the JSON
+// format are matched on their plain-text answer with zero confidence.
+func parseClassification(output string, labels []string) (string, float64) {
+	var answer struct {
+		Label      string  `json:"label"`
+		Confidence float64 `json:"confidence"`
+	}
+	start, end := strings.Index(output, "{"), strings.LastIndex(output, "}")
+	if start < 0 || end < start || json.Unmarshal([]byte(output[start:end+1]), &answer) != nil {
+		answer.Label = output
+		answer.Confidence = 0
+	}
+
+	if answer.Confidence < 0 {
+		answer.Confidence = 0
+	} else if answer.Confidence > 1 {
+		answer.Confidence = 1
+	}
+
+	got := strings.ToLower(strings.Trim(strings.TrimSpace(answer.Label), `"'.`))
+	for _, label := range labels {
+		if strings.ToLower(strings.TrimSpace(label)) == got {
+			return label, answer.Confidence
+		}
+	}
+	return "", 0
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_ClassifyHandler(t *testing.T) {
+	labels := `["positive","negative","neutral"]`
+
+	tests := []struct {
+		name           string
+		output         string
+		wantLabel      string
+		wantConfidence float64
+		wantVerified   bool
+	}{
+		{name: "json answer", output: `{"label": "Positive", "confidence": 0.9}`, wantLabel: "positive", wantConfidence: 0.9, wantVerified: true},
+		{name: "json in prose", output: "Sure! {\"label\":\"neutral\",\"confidence\":0.6}", wantLabel: "neutral", wantConfidence: 0.6, wantVerified: true},
+		{name: "plain label", output: "negative.", wantLabel: "negative", wantVerified: true},
+		{name: "label outside set", output: `{"label":"angry","confidence":0.8}`, wantLabel: "", wantVerified: false},
+		{name: "confidence clamped", output: `{"label":"neutral","confidence":7}`, wantLabel: "neutral", wantConfidence: 1, wantVerified: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewClassifyHandler(&stubProvider{output: tt.output})
+			payload, err := ParseTaskPayload([]byte(`{"task_type":"classify","text":"I love it","labels":` + labels + `}`))
+			if err != nil {
+				t.Fatalf("ParseTaskPayload failed: %v", err)
+			}
+			if err := h.Validate(payload); err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+
+			result, err := h.Handle(context.Background(), payload)
+			if err != nil {
+				t.Fatalf("Handle failed: %v", err)
+			}
+			if err := h.ValidateResult(result); err != nil {
+				t.Errorf("ValidateResult failed: %v", err)
+			}
+			if result["label"] != tt.wantLabel || result["confidence"] != tt.wantConfidence || result["verified"].(*Verification).Passed != tt.wantVerified {
+				t.Errorf("unexpected result: %v", result)
+			}
+		})
+	}
+}
+
+func Test_ClassifyHandlerValidate(t *testing.T) {
+	h := NewClassifyHandler(&stubProvider{})
+	for _, raw := range []string{
+		`{"task_type":"classify","labels":["a","b"]}`,
+		`{"task_type":"classify","text":"hi","labels":["a"]}`,
+		`{"task_type":"classify","text":"hi","labels":["a","A"]}`,
+		`{"task_type":"classify","text":"hi","labels":["a",""]}`,
+	} {
+		payload, err := ParseTaskPayload([]byte(raw))
+		if err != nil {
+			t.Fatalf("ParseTaskPayload failed: %v", err)
+		}
+		if err := h.Validate(payload); err == nil {
+			t.Errorf("expected error for %s", raw)
+		}
+	}
+}
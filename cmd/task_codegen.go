@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+const taskTypeCodegen = "codegen"
+
+// syntaxCheckers parse generated code per language and return the first
+// syntax error. Only languages with a parser in the Go toolchain or standard
+// library are supported so the performer stays a static binary.
+var syntaxCheckers = map[string]func(code string) error{
+	"go":   checkGoSyntax,
+	"json": checkJSONSyntax,
+}
+
+// codegenParams are the codegen task fields of a payload:
+//
+//	{"task_type": "codegen", "prompt": "a function that reverses a string", "language": "go"}
+type codegenParams struct {
+	Language string `json:"language"`
+}
+
+// CodegenHandler generates code for a prompt and verifies it objectively: a
+// result is verified when the returned code parses in the requested language.
+type CodegenHandler struct {
+	provider Provider
+}
+
+func NewCodegenHandler(provider Provider) *CodegenHandler {
+	return &CodegenHandler{provider: provider}
+}
+
+func (h *CodegenHandler) params(p *TaskPayload) (*codegenParams, error) {
+	var params codegenParams
+	if err := p.Decode(&params); err != nil {
+		return nil, err
+	}
+	params.Language = strings.ToLower(strings.TrimSpace(params.Language))
+	return &params, nil
+}
+
+func (h *CodegenHandler) Validate(p *TaskPayload) error {
+	params, err := h.params(p)
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(p.Prompt)) == 0 {
+		return fmt.Errorf("task prompt cannot be empty or whitespace only")
+	}
+	if _, ok := syntaxCheckers[params.Language]; !ok {
+		languages := make([]string, 0, len(syntaxCheckers))
+		for l := range syntaxCheckers {
+			languages = append(languages, l)
+		}
+		sort.Strings(languages)
+		return fmt.Errorf("unsupported language %q, expected one of: %s", params.Language, strings.Join(languages, ", "))
+	}
+	return nil
+}
+
+func (h *CodegenHandler) Handle(ctx context.Context, p *TaskPayload) (map[string]interface{}, error) {
+	params, err := h.params(p)
+	if err != nil {
+		return nil, err
+	}
+
+	req := p.CompletionRequest()
+	req.Messages = append([]ChatMessage{{
+		Role:    "system",
+		Content: fmt.Sprintf("Write %s code for the user's request. Reply with the code only, without explanations.", params.Language),
+	}}, req.Messages...)
+	if p.MaxTokens == 0 {
+		req.MaxTokens = maxTaskMaxTokens
+	}
+
+	completion, err := h.provider.Complete(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("%s code generation failed: %w", h.provider.Name(), err)
+	}
+
+	code := extractCode(completion.Output)
+	syntaxErr := syntaxCheckers[params.Language](code)
+	result := map[string]interface{}{
+		"code":     code,
+		"language": params.Language,
+		"verified": newVerification().Check("syntax", syntaxErr == nil, "syntax_error"),
+		"metadata": completionMetadata(h.provider, completion),
+	}
+	if syntaxErr != nil {
+		result["syntax_error"] = syntaxErr.Error()
+	}
+	return result, nil
+}
+
+func (h *CodegenHandler) ValidateResult(result map[string]interface{}) error {
+	code, ok := result["code"].(string)
+	if !ok {
+		return fmt.Errorf("code field must be a string")
+	}
+	if len(strings.TrimSpace(code)) == 0 {
+		return fmt.Errorf("code cannot be empty or whitespace only")
+	}
+	if _, ok := result["language"].(string); !ok {
+		return fmt.Errorf("language field must be a string")
+	}
+	return nil
+}
+
+// extractCode returns the contents of the first fenced code block of a model
+// answer, or the whole answer when it has none.
+func extractCode(output string) string {
+	start := strings.Index(output, "```")
+	if start < 0 {
+		return strings.TrimSpace(output)
+	}
+	body := output[start+3:]
+	// Skip the info string, e.g. "go".
+	if nl := strings.Index(body, "\n"); nl >= 0 {
+		body = body[nl+1:]
+	}
+	if end := strings.Index(body, "```"); end >= 0 {
+		body = body[:end]
+	}
+	return strings.TrimSpace(body)
+}
+
+// checkGoSyntax parses code as a Go file. Snippets without a package clause
+// are parsed as declarations of package main, and failing that, as statements
+// of a function body.
+func checkGoSyntax(code string) error {
+	fset := token.NewFileSet()
+	_, err := parser.ParseFile(fset, "generated.go", code, parser.AllErrors)
+	if err == nil || strings.HasPrefix(strings.TrimSpace(code), "package ") {
+		return err
+	}
+
+	_, err = parser.ParseFile(fset, "generated.go", "package main\n"+code, parser.AllErrors)
+	if err == nil {
+		return nil
+	}
+	if _, stmtErr := parser.ParseFile(fset, "generated.go", "package main\nfunc _() {\n"+code+"\n}", parser.AllErrors); stmtErr == nil {
+		return nil
+	}
+	// Report the declaration error, whose positions match the snippet more
+	// closely.
+	return err
+}
+
+func checkJSONSyntax(code string) error {
+	var v interface{}
+	return json.Unmarshal([]byte(code), &v)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_CodegenHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		payload      string
+		output       string
+		wantCode     string
+		wantVerified bool
+	}{
+		{
+			name:         "fenced go function",
+			payload:      `{"task_type":"codegen","prompt":"reverse a string","language":"go"}`,
+			output:       "Here you go:\n```go\nfunc reverse(s string) string {\n\treturn s\n}\n```\nDone.",
+			wantCode:     "func reverse(s string) string {\n\treturn s\n}",
+			wantVerified: true,
+		},
+		{
+			name:         "invalid go",
+			payload:      `{"task_type":"codegen","prompt":"reverse a string","language":"Go"}`,
+			output:       "func reverse(s string) string {\n\treturn s",
+			wantCode:     "func reverse(s string) string {\n\treturn s",
+			wantVerified: false,
+		},
+		{
+			name:         "json",
+			payload:      `{"task_type":"codegen","prompt":"a config with a port","language":"json"}`,
+			output:       `{"port": 8080}`,
+			wantCode:     `{"port": 8080}`,
+			wantVerified: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewCodegenHandler(&stubProvider{output: tt.output})
+			payload, err := ParseTaskPayload([]byte(tt.payload))
+			if err != nil {
+				t.Fatalf("ParseTaskPayload failed: %v", err)
+			}
+			if err := h.Validate(payload); err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+
+			result, err := h.Handle(context.Background(), payload)
+			if err != nil {
+				t.Fatalf("Handle failed: %v", err)
+			}
+			if err := h.ValidateResult(result); err != nil {
+				t.Errorf("ValidateResult failed: %v", err)
+			}
+			if result["code"] != tt.wantCode || result["verified"].(*Verification).Passed != tt.wantVerified {
+				t.Errorf("unexpected result: %v", result)
+			}
+			if _, ok := result["syntax_error"]; ok == tt.wantVerified {
+				t.Errorf("syntax_error present = %v, want %v", ok, !tt.wantVerified)
+			}
+		})
+	}
+}
+
+func Test_CodegenHandlerValidate(t *testing.T) {
+	h := NewCodegenHandler(&stubProvider{})
+	for _, raw := range []string{
+		`{"task_type":"codegen","prompt":"hello","language":"cobol"}`,
+		`{"task_type":"codegen","prompt":"hello"}`,
+		`{"task_type":"codegen","prompt":"  ","language":"go"}`,
+	} {
+		payload, err := ParseTaskPayload([]byte(raw))
+		if err != nil {
+			t.Fatalf("ParseTaskPayload failed: %v", err)
+		}
+		if err := h.Validate(payload); err == nil {
+			t.Errorf("Validate(%s) = nil, want error", raw)
+		}
+	}
+}
+
+func Test_checkGoSyntax(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		wantErr bool
+	}{
+		{name: "file", code: "package foo\n\nfunc F() {}"},
+		{name: "declarations", code: "import \"fmt\"\n\nfunc F() { fmt.Println() }"},
+		{name: "statements", code: "x := 1\nx++"},
+		{name: "broken file", code: "package foo\n\nfunc F( {}", wantErr: true},
+		{name: "broken snippet", code: "func F() { if }", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkGoSyntax(tt.code); (err != nil) != tt.wantErr {
+				t.Errorf("checkGoSyntax() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
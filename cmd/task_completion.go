@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// CompletionHandler serves the default task type: the prompt is sent to the
+// LLM provider and its answer returned as llm_output, verified by the
+// handler's verifier. When the payload carries an output_schema, the model is
+// asked for JSON matching it and re-prompted up to maxSchemaRetries times
+// while its output does not validate. With a safety check, every output is
+// classified after generation as well, and with an output sanitizer the
+// returned output is cleaned of HTML, control characters and secrets.
+type CompletionHandler struct {
+	provider Provider
+	verifier Verifier
+	// safety classifies outputs after generation; nil disables it.
+	safety *SafetyCheck
+	// sanitizer cleans the returned output; nil disables it.
+	sanitizer *OutputSanitizer
+	// verifyTimeout bounds the verification of an output; 0 disables it.
+	verifyTimeout time.Duration
+}
+
+func NewCompletionHandler(provider Provider) *CompletionHandler {
+	return &CompletionHandler{provider: provider, verifier: nonEmptyVerifier{}}
+}
+
+// SetVerifier replaces the verifier of completion outputs.
+func (h *CompletionHandler) SetVerifier(verifier Verifier) {
+	h.verifier = verifier
+}
+
+// SetVerificationTimeout bounds the verification of completion outputs; 0
+// leaves it bounded by the task's deadline only.
+func (h *CompletionHandler) SetVerificationTimeout(timeout time.Duration) {
+	h.verifyTimeout = timeout
+}
+
+// SetSafetyCheck sets the safety check of completion outputs.
+func (h *CompletionHandler) SetSafetyCheck(safety *SafetyCheck) {
+	h.safety = safety
+}
+
+// SetOutputSanitizer sets the sanitizer of completion outputs.
+func (h *CompletionHandler) SetOutputSanitizer(sanitizer *OutputSanitizer) {
+	h.sanitizer = sanitizer
+}
+
+func (h *CompletionHandler) Validate(p *TaskPayload) error {
+	if !p.HasPrompt() {
+		return fmt.Errorf("task prompt cannot be empty or whitespace only")
+	}
+	return nil
+}
+
+func (h *CompletionHandler) Handle(ctx context.Context, p *TaskPayload) (map[string]interface{}, error) {
+	req := p.CompletionRequest()
+	req.Stream = true
+
+	var schema *jsonschema.Schema
+	if len(p.OutputSchema) > 0 {
+		var err error
+		if schema, err = compileOutputSchema(p.OutputSchema); err != nil {
+			return nil, err
+		}
+		req.Messages = append([]ChatMessage{{Role: "system", Content: outputSchemaInstruction(p.OutputSchema)}}, req.Messages...)
+	}
+
+	completion, err := h.provider.Complete(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("%s completion failed: %w", h.provider.Name(), err)
+	}
+
+	verification := newVerification()
+	attempts := 1
+	if schema != nil {
+		schemaErr := validateOutput(schema, completion.Output)
+		for ; schemaErr != nil && attempts <= maxSchemaRetries && !completion.Refused; attempts++ {
+			req.Messages = append(req.Messages,
+				ChatMessage{Role: "assistant", Content: completion.Output},
+				ChatMessage{Role: "user", Content: schemaRetryPrompt(schemaErr)},
+			)
+			if completion, err = h.provider.Complete(ctx, req); err != nil {
+				return nil, fmt.Errorf("%s completion failed: %w", h.provider.Name(), err)
+			}
+			schemaErr = validateOutput(schema, completion.Output)
+		}
+		verification.Check("output_schema", schemaErr == nil, "schema_violation")
+	}
+	verifyCtx, cancel := withVerificationTimeout(ctx, h.verifyTimeout)
+	verifyCtx, span := startSpan(verifyCtx, "Verify")
+	err = h.verifier.Verify(verifyCtx, p, completion.Output, verification)
+	endSpan(span, err)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	// When running an ensemble, cross-model agreement is checked as well and
+	// is the better confidence signal.
+	if completion.Agreement != nil {
+		verification.Check("model_agreement", completion.Agreement.Agreed, "models_disagree")
+		if completion.Agreement.Members > 0 {
+			verification.Confidence = float64(completion.Agreement.Votes) / float64(completion.Agreement.Members)
+		}
+	}
+
+	var safety *SafetyResult
+	if h.safety != nil && !completion.Refused {
+		if safety, err = h.safety.Check(ctx, completion.Output); err != nil {
+			return nil, err
+		}
+		verification.Check("safety", !safety.Flagged, "unsafe_output")
+	}
+
+	metadata := completionMetadata(h.provider, completion)
+	if schema != nil {
+		metadata["schema_attempts"] = attempts
+	}
+	result := map[string]interface{}{
+		"llm_output": completion.Output,
+		"verified":   verification,
+		"metadata":   metadata,
+	}
+	if completion.Refused {
+		result["refused"] = true
+		result["refusal_reason"] = completion.RefusalReason
+	}
+	if safety != nil {
+		result["safety"] = safety
+		// Blocked outputs are withheld and reported like a provider refusal.
+		if safety.Blocked {
+			result["llm_output"] = ""
+			result["refused"] = true
+			result["refusal_reason"] = "unsafe_output"
+		}
+	}
+	// Outputs are sanitized after verification, which checks what the model
+	// actually answered.
+	if h.sanitizer != nil {
+		output, changes := h.sanitizer.Sanitize(result["llm_output"].(string))
+		result["llm_output"] = output
+		if len(changes) > 0 {
+			metadata["sanitized"] = changes
+		}
+	}
+	return result, nil
+}
+
+func (h *CompletionHandler) ValidateResult(result map[string]interface{}) error {
+	// Validate llm_output is a string
+	llmOutput, ok := result["llm_output"].(string)
+	if !ok {
+		return fmt.Errorf("llm_output field must be a string")
+	}
+
+	// Validate llm_output is not empty, unless the provider refused to answer
+	if refused, _ := result["refused"].(bool); !refused && len(strings.TrimSpace(llmOutput)) == 0 {
+		return fmt.Errorf("llm_output cannot be empty or whitespace only")
+	}
+	if safety, ok := result["safety"]; ok {
+		return validateSafety(safety)
+	}
+	return nil
+}
+
+// completionMetadata records which provider and model produced a completion so
+// the aggregator can audit operators' answers.
+func completionMetadata(provider Provider, completion *CompletionResponse) map[string]interface{} {
+	servedBy := completion.Provider
+	if servedBy == "" {
+		servedBy = provider.Name()
+	}
+
+	metadata := map[string]interface{}{
+		"provider": servedBy,
+		"model":    completion.Model,
+	}
+	if completion.Agreement != nil {
+		metadata["agreement"] = completion.Agreement
+	}
+	// Token usage as reported by the provider, for cost reconciliation.
+	if completion.TokensIn > 0 || completion.TokensOut > 0 {
+		metadata["usage"] = map[string]interface{}{
+			"prompt_tokens":     completion.TokensIn,
+			"completion_tokens": completion.TokensOut,
+		}
+	}
+	return metadata
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// slowVerifier blocks until its context is done.
+type slowVerifier struct{}
+
+func (slowVerifier) Name() string {
+	return "slow"
+}
+
+func (slowVerifier) Verify(ctx context.Context, p *TaskPayload, output string, v *Verification) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func Test_CompletionHandlerValidateResult(t *testing.T) {
+	h := NewCompletionHandler(&stubProvider{})
+
+	tests := []struct {
+		name    string
+		result  map[string]interface{}
+		wantErr bool
+	}{
+		{name: "output", result: map[string]interface{}{"llm_output": "Paris", "verified": true}},
+		{name: "empty output", result: map[string]interface{}{"llm_output": " ", "verified": false}, wantErr: true},
+		{name: "refused", result: map[string]interface{}{"llm_output": "", "verified": false, "refused": true}},
+		{name: "missing output", result: map[string]interface{}{"verified": false}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := h.ValidateResult(tt.result); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_completionMetadataUsage(t *testing.T) {
+	metadata := completionMetadata(&stubProvider{}, &CompletionResponse{Model: "m", TokensIn: 12, TokensOut: 4})
+	usage, ok := metadata["usage"].(map[string]interface{})
+	if !ok || usage["prompt_tokens"] != 12 || usage["completion_tokens"] != 4 {
+		t.Errorf("unexpected metadata: %v", metadata)
+	}
+
+	if metadata := completionMetadata(&stubProvider{}, &CompletionResponse{Model: "m"}); metadata["usage"] != nil {
+		t.Errorf("expected no usage when the provider reports none, got %v", metadata)
+	}
+}
+
+func Test_CompletionHandlerVerificationTimeout(t *testing.T) {
+	h := NewCompletionHandler(&stubProvider{output: "Paris"})
+	h.SetVerifier(slowVerifier{})
+	h.SetVerificationTimeout(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := h.Handle(ctx, &TaskPayload{Prompt: "What is the capital of France?"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the verification to time out, got %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Errorf("expected the task deadline to be left untouched")
+	}
+}
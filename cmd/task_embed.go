@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	taskTypeEmbed = "embed"
+
+	maxEmbeddingDimensions = 4096
+)
+
+// Embedding encodings. Float vectors are only practical for small dimensions
+// given the result size limit, so int8 is the default.
+const (
+	embeddingEncodingFloat  = "float"
+	embeddingEncodingBase64 = "base64"
+	embeddingEncodingInt8   = "int8"
+)
+
+// embedParams are the embed task fields of a payload:
+//
+//	{"task_type": "embed", "input": "...", "encoding": "int8", "dimensions": 256}
+type embedParams struct {
+	Input string `json:"input"`
+	// Encoding is "float" (JSON array), "base64" (little-endian float32) or
+	// "int8" (base64 of the vector quantized to int8, the default).
+	Encoding string `json:"encoding"`
+	// Dimensions shortens the vector on models that support it. Zero, or
+	// leaving it out, keeps the model's dimensions.
+	Dimensions int `json:"dimensions"`
+}
+
+// EmbedHandler returns the embedding vector of the input text together with
+// its model, dimension and the keccak256 hash of the input.
+type EmbedHandler struct {
+	embedder Embedder
+}
+
+func NewEmbedHandler(embedder Embedder) *EmbedHandler {
+	return &EmbedHandler{embedder: embedder}
+}
+
+func (h *EmbedHandler) params(p *TaskPayload) (*embedParams, error) {
+	var params embedParams
+	if err := p.Decode(&params); err != nil {
+		return nil, err
+	}
+	if params.Encoding == "" {
+		params.Encoding = embeddingEncodingInt8
+	}
+	return &params, nil
+}
+
+func (h *EmbedHandler) Validate(p *TaskPayload) error {
+	params, err := h.params(p)
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(params.Input)) == 0 {
+		return fmt.Errorf("input cannot be empty or whitespace only")
+	}
+	switch params.Encoding {
+	case embeddingEncodingFloat, embeddingEncodingBase64, embeddingEncodingInt8:
+	default:
+		return fmt.Errorf("unsupported embedding encoding %q", params.Encoding)
+	}
+	if params.Dimensions < 0 || params.Dimensions > maxEmbeddingDimensions {
+		return fmt.Errorf("dimensions must be between 1 and %d, or 0 for the model's dimensions", maxEmbeddingDimensions)
+	}
+	return nil
+}
+
+func (h *EmbedHandler) Handle(ctx context.Context, p *TaskPayload) (map[string]interface{}, error) {
+	params, err := h.params(p)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.embedder.Embed(ctx, &EmbeddingRequest{Input: params.Input, Dimensions: params.Dimensions})
+	if err != nil {
+		return nil, fmt.Errorf("%s embedding failed: %w", h.embedder.Name(), err)
+	}
+	vector := resp.Embedding
+
+	// A vector is verified when it has the requested size and holds only
+	// finite, not all-zero values.
+	finite, nonZero := true, false
+	for _, v := range vector {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			finite = false
+		}
+		if v != 0 {
+			nonZero = true
+		}
+	}
+	verification := newVerification().
+		Check("dimensions", len(vector) > 0 && (params.Dimensions == 0 || len(vector) == params.Dimensions), "dimension_mismatch").
+		Check("finite", finite, "non_finite_value").
+		Check("non_zero", nonZero, "zero_vector")
+
+	result := map[string]interface{}{
+		"encoding":   params.Encoding,
+		"dimensions": len(vector),
+		"input_hash": crypto.Keccak256Hash([]byte(params.Input)).Hex(),
+		"verified":   verification,
+		"metadata": map[string]interface{}{
+			"provider": h.embedder.Name(),
+			"model":    resp.Model,
+		},
+	}
+	switch params.Encoding {
+	case embeddingEncodingFloat:
+		result["embedding"] = vector
+	case embeddingEncodingBase64:
+		result["embedding"] = encodeFloat32(vector)
+	case embeddingEncodingInt8:
+		quantized, scale := quantizeInt8(vector)
+		result["embedding"] = base64.StdEncoding.EncodeToString(quantized)
+		result["scale"] = scale
+	}
+	return result, nil
+}
+
+func (h *EmbedHandler) ValidateResult(result map[string]interface{}) error {
+	dimensions, ok := result["dimensions"].(float64)
+	if !ok || dimensions < 1 {
+		return fmt.Errorf("dimensions field must be a positive number")
+	}
+
+	switch result["encoding"] {
+	case embeddingEncodingFloat:
+		vector, ok := result["embedding"].([]interface{})
+		if !ok || len(vector) != int(dimensions) {
+			return fmt.Errorf("embedding field must be an array of %d numbers", int(dimensions))
+		}
+	case embeddingEncodingBase64, embeddingEncodingInt8:
+		encoded, ok := result["embedding"].(string)
+		if !ok {
+			return fmt.Errorf("embedding field must be a base64 string")
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("embedding field must be a base64 string: %w", err)
+		}
+		width := 4
+		if result["encoding"] == embeddingEncodingInt8 {
+			width = 1
+			if _, ok := result["scale"].(float64); !ok {
+				return fmt.Errorf("scale field must be a number")
+			}
+		}
+		if len(raw) != int(dimensions)*width {
+			return fmt.Errorf("embedding has %d bytes, expected %d", len(raw), int(dimensions)*width)
+		}
+	default:
+		return fmt.Errorf("unsupported embedding encoding %v", result["encoding"])
+	}
+	return nil
+}
+
+// encodeFloat32 returns the base64 encoding of the vector as little-endian
+// float32 values, the layout used by the OpenAI API for base64 embeddings.
+func encodeFloat32(vector []float64) string {
+	var buf bytes.Buffer
+	for _, v := range vector {
+		_ = binary.Write(&buf, binary.LittleEndian, float32(v))
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// quantizeInt8 scales the vector symmetrically into int8. Each value is
+// recovered as int8(q) * scale.
+func quantizeInt8(vector []float64) ([]byte, float64) {
+	maxAbs := 0.0
+	for _, v := range vector {
+		maxAbs = math.Max(maxAbs, math.Abs(v))
+	}
+	scale := maxAbs / 127
+	quantized := make([]byte, len(vector))
+	if scale == 0 {
+		return quantized, 0
+	}
+	for i, v := range vector {
+		quantized[i] = byte(int8(math.Round(v / scale)))
+	}
+	return quantized, scale
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+// stubEmbedder returns a fixed vector.
+type stubEmbedder struct {
+	vector []float64
+}
+
+func (e *stubEmbedder) Name() string {
+	return "stub"
+}
+
+func (e *stubEmbedder) Embed(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	return &EmbeddingResponse{Embedding: e.vector, Model: "stub-embedding"}, nil
+}
+
+func Test_EmbedHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		payload      string
+		vector       []float64
+		wantVerified bool
+	}{
+		{name: "int8", payload: `{"task_type":"embed","input":"hello"}`, vector: []float64{0.5, -0.25, 1}, wantVerified: true},
+		{name: "float", payload: `{"task_type":"embed","input":"hello","encoding":"float"}`, vector: []float64{0.5, -0.25, 1}, wantVerified: true},
+		{name: "base64", payload: `{"task_type":"embed","input":"hello","encoding":"base64"}`, vector: []float64{0.5, -0.25, 1}, wantVerified: true},
+		{name: "wrong dimensions", payload: `{"task_type":"embed","input":"hello","dimensions":2}`, vector: []float64{0.5, -0.25, 1}, wantVerified: false},
+		{name: "zero vector", payload: `{"task_type":"embed","input":"hello"}`, vector: []float64{0, 0, 0}, wantVerified: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewEmbedHandler(&stubEmbedder{vector: tt.vector})
+			payload, err := ParseTaskPayload([]byte(tt.payload))
+			if err != nil {
+				t.Fatalf("ParseTaskPayload failed: %v", err)
+			}
+			if err := h.Validate(payload); err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+
+			result, err := h.Handle(context.Background(), payload)
+			if err != nil {
+				t.Fatalf("Handle failed: %v", err)
+			}
+			if result["verified"].(*Verification).Passed != tt.wantVerified {
+				t.Errorf("expected verified %v, got %+v", tt.wantVerified, result["verified"])
+			}
+
+			// Results are validated after a JSON round trip.
+			resultBytes, _ := json.Marshal(result)
+			var decoded map[string]interface{}
+			_ = json.Unmarshal(resultBytes, &decoded)
+			if err := h.ValidateResult(decoded); err != nil {
+				t.Errorf("ValidateResult failed: %v", err)
+			}
+		})
+	}
+}
+
+func Test_QuantizeInt8(t *testing.T) {
+	vector := []float64{0.5, -0.25, 1, 0}
+	quantized, scale := quantizeInt8(vector)
+	for i, q := range quantized {
+		if got := float64(int8(q)) * scale; math.Abs(got-vector[i]) > scale {
+			t.Errorf("value %d: expected %f, got %f", i, vector[i], got)
+		}
+	}
+
+	raw, _ := base64.StdEncoding.DecodeString(encodeFloat32(vector))
+	if len(raw) != 4*len(vector) {
+		t.Errorf("expected %d bytes, got %d", 4*len(vector), len(raw))
+	}
+}
+
+func Test_EmbedHandlerValidate(t *testing.T) {
+	h := NewEmbedHandler(&stubEmbedder{})
+	for _, raw := range []string{
+		`{"task_type":"embed"}`,
+		`{"task_type":"embed","input":"hi","encoding":"hex"}`,
+		`{"task_type":"embed","input":"hi","dimensions":100000}`,
+		`{"task_type":"embed","input":"hi","dimensions":-1}`,
+	} {
+		payload, err := ParseTaskPayload([]byte(raw))
+		if err != nil {
+			t.Fatalf("ParseTaskPayload failed: %v", err)
+		}
+		if err := h.Validate(payload); err == nil {
+			t.Errorf("expected error for %s", raw)
+		}
+	}
+
+	// Zero dimensions keep the model's dimensions.
+	payload, err := ParseTaskPayload([]byte(`{"task_type":"embed","input":"hi","dimensions":0}`))
+	if err != nil {
+		t.Fatalf("ParseTaskPayload failed: %v", err)
+	}
+	if err := h.Validate(payload); err != nil {
+		t.Errorf("expected zero dimensions to be accepted, got %v", err)
+	}
+}
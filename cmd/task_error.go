@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Error codes of failed task results. Codes prefixed with provider_ report a
+// fault of the upstream provider; the others a fault of the operator or the
+// task itself.
+const (
+	errorCodeProviderUnavailable = "provider_unavailable"
+	errorCodeProviderTimeout     = "provider_timeout"
+	errorCodeTaskCancelled       = "task_cancelled"
+	errorCodeProviderRateLimited = "provider_rate_limited"
+	errorCodeProviderError       = "provider_error"
+	errorCodeAuthFailed          = "auth_failed"
+	errorCodeRequestRejected     = "request_rejected"
+	errorCodeInjectionDetected   = "injection_detected"
+	errorCodeRateLimited         = "rate_limited"
+	errorCodeOverloaded          = "overloaded"
+	errorCodeShuttingDown        = "shutting_down"
+	errorCodeBudgetExhausted     = "budget_exhausted"
+	errorCodeCostCapExceeded     = "cost_cap_exceeded"
+	errorCodeParseError          = "parse_error"
+	errorCodeVerificationFailed  = "verification_failed"
+	errorCodeInvalidTask         = "invalid_task"
+	errorCodeTaskFailed          = "task_failed"
+)
+
+// Categories of task failures. Unlike error codes, which grow with the
+// performer's features, categories are a stable enum for dashboards and
+// executors: they are reported as error_category in results, in the gRPC
+// status details and in the task_failures_total metric.
+const (
+	errorCategoryValidation   = "validation"
+	errorCategoryProvider4xx  = "provider_4xx"
+	errorCategoryProvider5xx  = "provider_5xx"
+	errorCategoryTimeout      = "timeout"
+	errorCategoryParse        = "parse_error"
+	errorCategoryVerification = "verification_failed"
+	errorCategoryRejected     = "rejected"
+	errorCategoryInternal     = "internal"
+)
+
+// errorCategories maps error codes to their category; codes missing from it
+// are internal failures.
+var errorCategories = map[string]string{
+	errorCodeInvalidTask:         errorCategoryValidation,
+	errorCodeAuthFailed:          errorCategoryProvider4xx,
+	errorCodeRequestRejected:     errorCategoryProvider4xx,
+	errorCodeProviderRateLimited: errorCategoryProvider4xx,
+	errorCodeProviderError:       errorCategoryProvider5xx,
+	errorCodeProviderUnavailable: errorCategoryProvider5xx,
+	errorCodeProviderTimeout:     errorCategoryTimeout,
+	errorCodeTaskCancelled:       errorCategoryTimeout,
+	errorCodeParseError:          errorCategoryParse,
+	errorCodeVerificationFailed:  errorCategoryVerification,
+	errorCodeInjectionDetected:   errorCategoryRejected,
+	errorCodeRateLimited:         errorCategoryRejected,
+	errorCodeOverloaded:          errorCategoryRejected,
+	errorCodeShuttingDown:        errorCategoryRejected,
+	errorCodeBudgetExhausted:     errorCategoryRejected,
+	errorCodeCostCapExceeded:     errorCategoryRejected,
+}
+
+// errorCategory returns the category of an error code.
+func errorCategory(code string) string {
+	if category, ok := errorCategories[code]; ok {
+		return category
+	}
+	return errorCategoryInternal
+}
+
+// ErrVerificationFailed is returned when a verifier could not check a result.
+// Results it checked and rejected carry a failed verification instead.
+var ErrVerificationFailed = errors.New("verification failed")
+
+// retryAfterError is implemented by errors of tasks that the performer may
+// accept again after a delay, reported as retry_after_seconds.
+type retryAfterError interface {
+	error
+	retryAfter() time.Duration
+}
+
+// classifyTaskError maps a task handler error to the error code reported in
+// the result, and whether the aggregator may reschedule the task.
+func classifyTaskError(err error) (code string, retryable bool) {
+	var providerErr *ProviderError
+	var netErr net.Error
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, ErrProviderUnavailable):
+		return errorCodeProviderUnavailable, true
+	case errors.Is(err, context.DeadlineExceeded):
+		return errorCodeProviderTimeout, true
+	case errors.Is(err, context.Canceled):
+		// The caller gave up on the task; it may send it again.
+		return errorCodeTaskCancelled, true
+	case errors.Is(err, ErrModelOverrideUnsupported):
+		return errorCodeRequestRejected, false
+	case errors.As(err, &providerErr):
+		switch {
+		case providerErr.StatusCode == http.StatusTooManyRequests:
+			return errorCodeProviderRateLimited, true
+		case providerErr.StatusCode >= 500:
+			return errorCodeProviderError, true
+		case providerErr.StatusCode == http.StatusUnauthorized || providerErr.StatusCode == http.StatusForbidden:
+			// The operator's credentials are missing or invalid.
+			return errorCodeAuthFailed, false
+		default:
+			return errorCodeRequestRejected, false
+		}
+	case errors.As(err, &netErr):
+		return errorCodeProviderError, true
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		// Provider responses and model outputs that fail to parse may parse
+		// when the task is tried again.
+		return errorCodeParseError, true
+	case errors.Is(err, ErrVerificationFailed):
+		return errorCodeVerificationFailed, false
+	case errors.Is(err, ErrInjectionDetected):
+		return errorCodeInjectionDetected, false
+	case errors.Is(err, ErrSenderRateLimited):
+		return errorCodeRateLimited, true
+	case errors.Is(err, ErrWorkerPoolFull), errors.Is(err, ErrLoadShed):
+		return errorCodeOverloaded, true
+	case errors.Is(err, ErrShuttingDown):
+		return errorCodeShuttingDown, true
+	case errors.Is(err, ErrTokenBudgetExhausted):
+		return errorCodeBudgetExhausted, true
+	case errors.Is(err, ErrCostCapExceeded):
+		return errorCodeCostCapExceeded, true
+	default:
+		return errorCodeTaskFailed, false
+	}
+}
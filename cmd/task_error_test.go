@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+// erroringProvider fails every completion with err.
+type erroringProvider struct {
+	err error
+}
+
+func (p *erroringProvider) Name() string {
+	return "erroring"
+}
+
+func (p *erroringProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	return nil, p.err
+}
+
+func Test_classifyTaskError(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantCode      string
+		wantRetryable bool
+	}{
+		{name: "circuit open", err: fmt.Errorf("openai: %w", ErrProviderUnavailable), wantCode: errorCodeProviderUnavailable, wantRetryable: true},
+		{name: "timeout", err: fmt.Errorf("request: %w", context.DeadlineExceeded), wantCode: errorCodeProviderTimeout, wantRetryable: true},
+		{name: "cancelled", err: fmt.Errorf("request: %w", context.Canceled), wantCode: errorCodeTaskCancelled, wantRetryable: true},
+		{name: "rate limited", err: &ProviderError{StatusCode: 429}, wantCode: errorCodeProviderRateLimited, wantRetryable: true},
+		{name: "server error", err: &ProviderError{StatusCode: 502}, wantCode: errorCodeProviderError, wantRetryable: true},
+		{name: "network error", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, wantCode: errorCodeProviderError, wantRetryable: true},
+		{name: "unauthorized", err: &ProviderError{StatusCode: 401}, wantCode: errorCodeAuthFailed},
+		{name: "bad request", err: &ProviderError{StatusCode: 400}, wantCode: errorCodeRequestRejected},
+		{name: "model override unsupported", err: fmt.Errorf("%w: model %q", ErrModelOverrideUnsupported, "gpt-4o"), wantCode: errorCodeRequestRejected},
+		{name: "prompt injection", err: fmt.Errorf("%w: score 0.90", ErrInjectionDetected), wantCode: errorCodeInjectionDetected},
+		{name: "sender rate limited", err: &RateLimitError{Sender: "dapp", Reason: rateLimitQPS, RetryAfter: time.Second}, wantCode: errorCodeRateLimited, wantRetryable: true},
+		{name: "worker pool full", err: fmt.Errorf("%w: 4 tasks running and 0 queued", ErrWorkerPoolFull), wantCode: errorCodeOverloaded, wantRetryable: true},
+		{name: "shutting down", err: ErrShuttingDown, wantCode: errorCodeShuttingDown, wantRetryable: true},
+		{name: "load shed", err: fmt.Errorf("%w: live heap of 2048 bytes exceeds the limit of 1024", ErrLoadShed), wantCode: errorCodeOverloaded, wantRetryable: true},
+		{name: "token budget exhausted", err: &BudgetExhaustedError{Window: "hourly", Limit: 1000, RetryAfter: time.Minute}, wantCode: errorCodeBudgetExhausted, wantRetryable: true},
+		{name: "cost cap exceeded", err: fmt.Errorf("openai completion failed: %w", &CostCapError{Spend: 51, Cap: 50, RetryAfter: time.Hour}), wantCode: errorCodeCostCapExceeded, wantRetryable: true},
+		{name: "malformed provider output", err: fmt.Errorf("failed to decode scores: %w", &json.SyntaxError{Offset: 1}), wantCode: errorCodeParseError, wantRetryable: true},
+		{name: "verification failed", err: fmt.Errorf("%w: schema verifier: missing field", ErrVerificationFailed), wantCode: errorCodeVerificationFailed},
+		{name: "other", err: errors.New("invalid moderation scores"), wantCode: errorCodeTaskFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, retryable := classifyTaskError(tt.err)
+			if code != tt.wantCode || retryable != tt.wantRetryable {
+				t.Errorf("expected %s/%v, got %s/%v", tt.wantCode, tt.wantRetryable, code, retryable)
+			}
+		})
+	}
+}
+
+func Test_errorCategory(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{code: errorCodeInvalidTask, want: errorCategoryValidation},
+		{code: errorCodeAuthFailed, want: errorCategoryProvider4xx},
+		{code: errorCodeProviderError, want: errorCategoryProvider5xx},
+		{code: errorCodeProviderTimeout, want: errorCategoryTimeout},
+		{code: errorCodeTaskCancelled, want: errorCategoryTimeout},
+		{code: errorCodeParseError, want: errorCategoryParse},
+		{code: errorCodeVerificationFailed, want: errorCategoryVerification},
+		{code: errorCodeOverloaded, want: errorCategoryRejected},
+		{code: errorCodeTaskFailed, want: errorCategoryInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := errorCategory(tt.code); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_HandleTaskErrorResult(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &erroringProvider{err: &ProviderError{Provider: "erroring", StatusCode: 401, Body: "invalid api key"}}, nil)
+
+	resp, err := tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")})
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+
+	var result struct {
+		Verified     Verification `json:"verified"`
+		ErrorCode    string       `json:"error_code"`
+		ErrorMessage string       `json:"error_message"`
+		Retryable    bool         `json:"retryable"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if result.ErrorCode != errorCodeAuthFailed || result.Retryable || result.Verified.Passed || result.ErrorMessage == "" {
+		t.Errorf("unexpected result: %s", resp.Result)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TaskHandler implements one kind of AI work served by the performer, selected
+// by the task_type field of the payload.
+type TaskHandler interface {
+	// Validate checks a payload of this task type before the task is accepted.
+	Validate(p *TaskPayload) error
+
+	// Handle runs the task and returns the fields of its result.
+	Handle(ctx context.Context, p *TaskPayload) (map[string]interface{}, error)
+
+	// ValidateResult checks a successful result against the task type's
+	// result schema.
+	ValidateResult(result map[string]interface{}) error
+}
+
+// TaskRegistry maps task types to their handlers and size limits.
+type TaskRegistry struct {
+	handlers map[string]TaskHandler
+	limits   map[string]TaskLimits
+}
+
+func NewTaskRegistry() *TaskRegistry {
+	r := &TaskRegistry{handlers: map[string]TaskHandler{}, limits: map[string]TaskLimits{}}
+	for taskType, limits := range defaultTaskLimits {
+		r.limits[taskType] = limits
+	}
+	return r
+}
+
+// newDefaultTaskRegistry returns a registry with the built-in task types.
+func newDefaultTaskRegistry(provider Provider) *TaskRegistry {
+	r := NewTaskRegistry()
+	r.Register(taskTypeCompletion, NewCompletionHandler(provider))
+	r.Register(taskTypeSummarize, NewSummarizeHandler(provider))
+	r.Register(taskTypeClassify, NewClassifyHandler(provider))
+	r.Register(taskTypeTranslate, NewTranslateHandler(provider))
+	r.Register(taskTypeCodegen, NewCodegenHandler(provider))
+	r.Register(taskTypeModerate, NewModerateHandler(&completionModerator{provider: provider}))
+	r.Register(taskTypeSentiment, NewSentimentHandler(provider))
+	return r
+}
+
+// Register adds or replaces the handler of a task type.
+func (r *TaskRegistry) Register(taskType string, h TaskHandler) {
+	r.handlers[taskType] = h
+}
+
+// Handler returns the handler of a task type. Payloads without a task type are
+// plain completions.
+func (r *TaskRegistry) Handler(taskType string) (TaskHandler, error) {
+	if taskType == "" {
+		taskType = taskTypeCompletion
+	}
+	h, ok := r.handlers[taskType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported task type %q, expected one of: %s", taskType, strings.Join(r.TaskTypes(), ", "))
+	}
+	return h, nil
+}
+
+// TaskTypes returns the registered task types in alphabetical order.
+func (r *TaskRegistry) TaskTypes() []string {
+	types := make([]string, 0, len(r.handlers))
+	for t := range r.handlers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+// echoHandler is a TaskHandler that returns the payload's text field.
+type echoHandler struct{}
+
+func (h *echoHandler) Validate(p *TaskPayload) error {
+	var fields struct {
+		Text string `json:"text"`
+	}
+	if err := p.Decode(&fields); err != nil {
+		return err
+	}
+	if fields.Text == "" {
+		return fmt.Errorf("text is required")
+	}
+	return nil
+}
+
+func (h *echoHandler) Handle(ctx context.Context, p *TaskPayload) (map[string]interface{}, error) {
+	var fields struct {
+		Text string `json:"text"`
+	}
+	if err := p.Decode(&fields); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"echo": fields.Text, "verified": newVerification().Check("echo", true, "")}, nil
+}
+
+func (h *echoHandler) ValidateResult(result map[string]interface{}) error {
+	if _, ok := result["echo"].(string); !ok {
+		return fmt.Errorf("echo field must be a string")
+	}
+	return nil
+}
+
+func Test_TaskRegistry(t *testing.T) {
+	r := newDefaultTaskRegistry(&stubProvider{})
+
+	if _, err := r.Handler(""); err != nil {
+		t.Errorf("expected payloads without task type to be completions: %v", err)
+	}
+	if _, err := r.Handler("dance"); err == nil {
+		t.Errorf("expected error for unknown task type")
+	}
+
+	r.Register("echo", &echoHandler{})
+	if _, err := r.Handler("echo"); err != nil {
+		t.Errorf("expected registered handler: %v", err)
+	}
+}
+
+func Test_TaskWorkerDispatch(t *testing.T) {
+	taskWorker := NewTaskWorker(zap.NewNop(), &stubProvider{output: "the prompt is valid"}, nil)
+	taskWorker.tasks.Register("echo", &echoHandler{})
+
+	tests := []struct {
+		name        string
+		payload     string
+		wantInvalid bool
+		want        string
+	}{
+		{name: "echo", payload: `{"task_type":"echo","text":"hello"}`, want: `{"echo":"hello","result_digest":"0x7b67412d07835da972a2880e327e73a1ed409a82d2cc2c9a00062d08bac87f0b","verified":{"checks":["echo"],"confidence":1,"passed":true,"reasons":[]}}`},
+		{name: "echo without text", payload: `{"task_type":"echo"}`, wantInvalid: true},
+		{name: "unknown task type", payload: `{"task_type":"dance","prompt":"hi"}`, wantInvalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &performerV1.TaskRequest{TaskId: []byte("test-task-id"), Payload: []byte(tt.payload)}
+
+			err := taskWorker.ValidateTask(task)
+			if (err != nil) != tt.wantInvalid {
+				t.Fatalf("expected invalid %v, got %v", tt.wantInvalid, err)
+			}
+			if tt.wantInvalid {
+				return
+			}
+
+			resp, err := taskWorker.HandleTask(task)
+			if err != nil {
+				t.Fatalf("HandleTask failed: %v", err)
+			}
+			var got, want interface{}
+			_ = json.Unmarshal(resp.Result, &got)
+			_ = json.Unmarshal([]byte(tt.want), &want)
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("expected %s, got %s", tt.want, resp.Result)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TaskLimits overrides the payload and result size limits of one task type.
+// Zero values fall back to the global LimitsConfig.
+type TaskLimits struct {
+	MaxPayloadSize int
+	MaxResultSize  int
+}
+
+// defaultTaskLimits are the built-in limits of task types whose results are
+// much larger or smaller than a completion's.
+var defaultTaskLimits = map[string]TaskLimits{
+	// Vectors of a few thousand dimensions do not fit the default 8KB.
+	taskTypeEmbed: {MaxResultSize: 64 * 1024},
+	// A label or a score needs little room; anything larger is a bug.
+	taskTypeClassify:  {MaxResultSize: 2 * 1024},
+	taskTypeSentiment: {MaxResultSize: 2 * 1024},
+}
+
+// SetLimits sets the size limits of a task type.
+func (r *TaskRegistry) SetLimits(taskType string, limits TaskLimits) {
+	r.limits[taskType] = limits
+}
+
+// Limits returns the size limits of a task type. Payloads without a task type
+// are plain completions.
+func (r *TaskRegistry) Limits(taskType string) TaskLimits {
+	if taskType == "" {
+		taskType = taskTypeCompletion
+	}
+	return r.limits[taskType]
+}
+
+// LimitsFromEnv reads the size limits of the registered task types from
+// MAX_PAYLOAD_SIZE_<TYPE> and MAX_RESULT_SIZE_<TYPE>, e.g.
+// MAX_RESULT_SIZE_EMBED.
+func (r *TaskRegistry) LimitsFromEnv() error {
+	for _, taskType := range r.TaskTypes() {
+		limits := r.limits[taskType]
+		suffix := strings.ToUpper(strings.ReplaceAll(taskType, "-", "_"))
+		for _, limit := range []struct {
+			key   string
+			value *int
+		}{
+			{"MAX_PAYLOAD_SIZE_" + suffix, &limits.MaxPayloadSize},
+			{"MAX_RESULT_SIZE_" + suffix, &limits.MaxResultSize},
+		} {
+			v, err := envInt(limit.key)
+			if err != nil {
+				return err
+			}
+			if v < 0 {
+				return fmt.Errorf("%s must not be negative", limit.key)
+			}
+			if v > 0 {
+				*limit.value = v
+			}
+		}
+		r.limits[taskType] = limits
+	}
+	return nil
+}
+
+// limitsFor returns the limits applying to tasks of a task type: the global
+// limits with the task type's overrides.
+func (tw *TaskWorker) limitsFor(taskType string) *LimitsConfig {
+	limits := *tw.limits.Load()
+	overrides := tw.tasks.Limits(taskType)
+	if overrides.MaxPayloadSize > 0 {
+		limits.MaxPayloadSize = overrides.MaxPayloadSize
+	}
+	if overrides.MaxResultSize > 0 {
+		limits.MaxResultSize = overrides.MaxResultSize
+	}
+	return &limits
+}
+
+// maxPayloadSize returns the largest payload size accepted by any task type,
+// which bounds payloads before their task type is known.
+func (tw *TaskWorker) maxPayloadSize() int {
+	size := tw.limits.Load().MaxPayloadSize
+	for _, taskType := range tw.tasks.TaskTypes() {
+		if limit := tw.tasks.Limits(taskType).MaxPayloadSize; limit > size {
+			size = limit
+		}
+	}
+	return size
+}
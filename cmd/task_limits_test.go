@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_TaskRegistryLimitsFromEnv(t *testing.T) {
+	r := newDefaultTaskRegistry(&stubProvider{})
+	t.Setenv("MAX_RESULT_SIZE_CLASSIFY", "512")
+	t.Setenv("MAX_PAYLOAD_SIZE_SUMMARIZE", "65536")
+
+	if err := r.LimitsFromEnv(); err != nil {
+		t.Fatalf("LimitsFromEnv failed: %v", err)
+	}
+	if got := r.Limits(taskTypeClassify); got.MaxResultSize != 512 {
+		t.Errorf("unexpected classify limits: %+v", got)
+	}
+	if got := r.Limits(taskTypeSummarize); got.MaxPayloadSize != 65536 || got.MaxResultSize != 0 {
+		t.Errorf("unexpected summarize limits: %+v", got)
+	}
+	if got := r.Limits(""); got != (TaskLimits{}) {
+		t.Errorf("unexpected completion limits: %+v", got)
+	}
+
+	t.Setenv("MAX_RESULT_SIZE_CLASSIFY", "small")
+	if err := r.LimitsFromEnv(); err == nil {
+		t.Errorf("expected error for invalid MAX_RESULT_SIZE_CLASSIFY")
+	}
+}
+
+func Test_limitsFor(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{}, nil)
+	tw.tasks.SetLimits(taskTypeSummarize, TaskLimits{MaxPayloadSize: 32 * 1024})
+
+	if got := tw.limitsFor(taskTypeSummarize); got.MaxPayloadSize != 32*1024 || got.MaxResultSize != defaultMaxResultSize {
+		t.Errorf("unexpected summarize limits: %+v", got)
+	}
+	if got := tw.limitsFor(taskTypeEmbed); got.MaxResultSize != 64*1024 || got.MaxPayloadSize != defaultMaxPayloadSize {
+		t.Errorf("unexpected embed limits: %+v", got)
+	}
+	if got := tw.maxPayloadSize(); got != 32*1024 {
+		t.Errorf("expected max payload size %d, got %d", 32*1024, got)
+	}
+}
+
+func Test_ValidateTaskTypeLimits(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "valid"}, nil)
+	tw.tasks.SetLimits(taskTypeCompletion, TaskLimits{MaxPayloadSize: 64})
+	tw.tasks.SetLimits(taskTypeSummarize, TaskLimits{MaxPayloadSize: 8 * 1024})
+
+	document := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 120)
+	tests := []struct {
+		name    string
+		payload string
+		wantErr bool
+	}{
+		{name: "completion within its limit", payload: `{"prompt":"What is 2+2?"}`},
+		{name: "completion over its limit", payload: `{"prompt":"` + strings.Repeat("a", 100) + `"}`, wantErr: true},
+		{name: "summarize over the global limit", payload: `{"task_type":"summarize","document":"` + document + `","max_words":50}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tw.ValidateTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte(tt.payload)})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_HandleTaskTypeResultLimit(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "valid " + strings.Repeat("a", 2000)}, nil)
+	tw.tasks.SetLimits(taskTypeCompletion, TaskLimits{MaxResultSize: 1024})
+
+	resp, err := tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")})
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if len(resp.Result) > 1024 || result["truncated"] != true {
+		t.Errorf("expected a result truncated to 1024 bytes, got %d bytes", len(resp.Result))
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const taskTypeModerate = "moderate"
+
+// moderateParams are the moderate task fields of a payload:
+//
+//	{"task_type": "moderate", "input": "..."}
+type moderateParams struct {
+	Input string `json:"input"`
+}
+
+// ModerateHandler scores the input against moderation categories so other
+// AVSs can use the network as a moderation oracle. A result is verified when
+// the moderator scored at least one category; scores outside [0, 1] fail the
+// task.
+type ModerateHandler struct {
+	moderator Moderator
+}
+
+func NewModerateHandler(moderator Moderator) *ModerateHandler {
+	return &ModerateHandler{moderator: moderator}
+}
+
+func (h *ModerateHandler) params(p *TaskPayload) (*moderateParams, error) {
+	var params moderateParams
+	if err := p.Decode(&params); err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
+
+func (h *ModerateHandler) Validate(p *TaskPayload) error {
+	params, err := h.params(p)
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(params.Input)) == 0 {
+		return fmt.Errorf("input cannot be empty or whitespace only")
+	}
+	return nil
+}
+
+func (h *ModerateHandler) Handle(ctx context.Context, p *TaskPayload) (map[string]interface{}, error) {
+	params, err := h.params(p)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.moderator.Moderate(ctx, &ModerationRequest{Input: params.Input})
+	if err != nil {
+		return nil, fmt.Errorf("%s moderation failed: %w", h.moderator.Name(), err)
+	}
+
+	flaggedCategories := []string{}
+	for category, score := range resp.Scores {
+		if math.IsNaN(score) || score < 0 || score > 1 {
+			return nil, fmt.Errorf("%s returned invalid score %v for category %q", h.moderator.Name(), score, category)
+		}
+		if score >= moderationFlagThreshold {
+			flaggedCategories = append(flaggedCategories, category)
+		}
+	}
+	sort.Strings(flaggedCategories)
+
+	return map[string]interface{}{
+		"flagged":            resp.Flagged,
+		"categories":         resp.Scores,
+		"flagged_categories": flaggedCategories,
+		"verified":           newVerification().Check("scored", len(resp.Scores) > 0, "no_scores"),
+		"metadata": map[string]interface{}{
+			"provider": h.moderator.Name(),
+			"model":    resp.Model,
+		},
+	}, nil
+}
+
+func (h *ModerateHandler) ValidateResult(result map[string]interface{}) error {
+	if _, ok := result["flagged"].(bool); !ok {
+		return fmt.Errorf("flagged field must be a boolean")
+	}
+	categories, ok := result["categories"].(map[string]interface{})
+	if !ok || len(categories) == 0 {
+		return fmt.Errorf("categories field must be a non-empty object")
+	}
+	for category, v := range categories {
+		score, ok := v.(float64)
+		if !ok || score < 0 || score > 1 {
+			return fmt.Errorf("score of category %q must be a number between 0 and 1", category)
+		}
+	}
+	return nil
+}
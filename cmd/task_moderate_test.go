@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// stubModerator returns fixed category scores.
+type stubModerator struct {
+	scores map[string]float64
+}
+
+func (m *stubModerator) Name() string {
+	return "stub"
+}
+
+func (m *stubModerator) Moderate(ctx context.Context, req *ModerationRequest) (*ModerationResponse, error) {
+	flagged := false
+	for _, score := range m.scores {
+		flagged = flagged || score >= moderationFlagThreshold
+	}
+	return &ModerationResponse{Flagged: flagged, Scores: m.scores, Model: "stub-moderation"}, nil
+}
+
+func Test_ModerateHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		scores       map[string]float64
+		wantFlagged  []string
+		wantVerified bool
+	}{
+		{name: "clean", scores: map[string]float64{"hate": 0.01, "violence": 0.02}, wantFlagged: []string{}, wantVerified: true},
+		{name: "flagged", scores: map[string]float64{"hate": 0.8, "violence": 0.6, "sexual": 0}, wantFlagged: []string{"hate", "violence"}, wantVerified: true},
+		{name: "no scores", scores: map[string]float64{}, wantFlagged: []string{}, wantVerified: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewModerateHandler(&stubModerator{scores: tt.scores})
+			payload, err := ParseTaskPayload([]byte(`{"task_type":"moderate","input":"some text"}`))
+			if err != nil {
+				t.Fatalf("ParseTaskPayload failed: %v", err)
+			}
+			if err := h.Validate(payload); err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+
+			result, err := h.Handle(context.Background(), payload)
+			if err != nil {
+				t.Fatalf("Handle failed: %v", err)
+			}
+			if result["verified"].(*Verification).Passed != tt.wantVerified || !reflect.DeepEqual(result["flagged_categories"], tt.wantFlagged) {
+				t.Errorf("unexpected result: %v", result)
+			}
+			if !tt.wantVerified {
+				return
+			}
+
+			// Results are validated after a JSON round trip.
+			resultBytes, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("failed to marshal result: %v", err)
+			}
+			var decoded map[string]interface{}
+			_ = json.Unmarshal(resultBytes, &decoded)
+			if err := h.ValidateResult(decoded); err != nil {
+				t.Errorf("ValidateResult failed: %v", err)
+			}
+		})
+	}
+}
+
+func Test_ModerateHandlerInvalidScore(t *testing.T) {
+	h := NewModerateHandler(&stubModerator{scores: map[string]float64{"hate": math.NaN()}})
+	payload, err := ParseTaskPayload([]byte(`{"task_type":"moderate","input":"some text"}`))
+	if err != nil {
+		t.Fatalf("ParseTaskPayload failed: %v", err)
+	}
+	if _, err := h.Handle(context.Background(), payload); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func Test_ModerateHandlerValidateResult(t *testing.T) {
+	h := NewModerateHandler(&stubModerator{})
+	tests := []struct {
+		name   string
+		result map[string]interface{}
+	}{
+		{name: "missing flagged", result: map[string]interface{}{"categories": map[string]interface{}{"hate": 0.1}}},
+		{name: "no categories", result: map[string]interface{}{"flagged": false, "categories": map[string]interface{}{}}},
+		{name: "score out of range", result: map[string]interface{}{"flagged": false, "categories": map[string]interface{}{"hate": 1.5}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := h.ValidateResult(tt.result); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const taskTypeSentiment = "sentiment"
+
+// Sentiment labels, derived from the score so that operators returning the
+// same score always agree on the label.
+const (
+	sentimentNegative = "negative"
+	sentimentNeutral  = "neutral"
+	sentimentPositive = "positive"
+
+	// sentimentNeutralBand is the distance from zero within which a score is
+	// labelled neutral.
+	sentimentNeutralBand = 0.25
+)
+
+// sentimentParams are the sentiment task fields of a payload:
+//
+//	{"task_type": "sentiment", "text": "..."}
+type sentimentParams struct {
+	Text string `json:"text"`
+}
+
+// SentimentHandler scores the sentiment of a text in [-1, 1]. Prompting is
+// deterministic (temperature 0) and scores are rounded to one decimal so that
+// independent operators converge on the same answer for aggregation.
+type SentimentHandler struct {
+	provider Provider
+}
+
+func NewSentimentHandler(provider Provider) *SentimentHandler {
+	return &SentimentHandler{provider: provider}
+}
+
+func (h *SentimentHandler) params(p *TaskPayload) (*sentimentParams, error) {
+	var params sentimentParams
+	if err := p.Decode(&params); err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
+
+func (h *SentimentHandler) Validate(p *TaskPayload) error {
+	params, err := h.params(p)
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(params.Text)) == 0 {
+		return fmt.Errorf("text cannot be empty or whitespace only")
+	}
+	return nil
+}
+
+func (h *SentimentHandler) Handle(ctx context.Context, p *TaskPayload) (map[string]interface{}, error) {
+	params, err := h.params(p)
+	if err != nil {
+		return nil, err
+	}
+
+	req := p.CompletionRequest()
+	req.Messages = []ChatMessage{
+		{
+			Role: "system",
+			Content: "Rate the sentiment of the user's text from -1 (very negative) through 0 (neutral) to 1 (very positive). " +
+				`Reply with JSON only, in the form {"score": <number>}.`,
+		},
+		{Role: "user", Content: params.Text},
+	}
+	req.Temperature = 0
+	req.MaxTokens = 32
+
+	completion, err := h.provider.Complete(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("%s sentiment analysis failed: %w", h.provider.Name(), err)
+	}
+
+	score, ok := parseSentimentScore(completion.Output)
+	return map[string]interface{}{
+		"score":    score,
+		"label":    sentimentLabel(score),
+		"verified": newVerification().Check("score_parsed", ok, "score_not_found"),
+		"metadata": completionMetadata(h.provider, completion),
+	}, nil
+}
+
+func (h *SentimentHandler) ValidateResult(result map[string]interface{}) error {
+	score, ok := result["score"].(float64)
+	if !ok {
+		return fmt.Errorf("score field must be a number")
+	}
+	if score < -1 || score > 1 {
+		return fmt.Errorf("score must be between -1 and 1")
+	}
+	label, ok := result["label"].(string)
+	if !ok {
+		return fmt.Errorf("label field must be a string")
+	}
+	if label != sentimentLabel(score) {
+		return fmt.Errorf("label %q does not match score %v", label, score)
+	}
+	return nil
+}
+
+// parseSentimentScore extracts the score from the model output, clamped to
+// [-1, 1] and rounded to one decimal. Models that answer with a bare label are
+// mapped to -1, 0 or 1. It reports false when no score could be found.
+func parseSentimentScore(output string) (float64, bool) {
+	var answer struct {
+		Score *float64 `json:"score"`
+	}
+	start, end := strings.Index(output, "{"), strings.LastIndex(output, "}")
+	if start >= 0 && end > start && json.Unmarshal([]byte(output[start:end+1]), &answer) == nil && answer.Score != nil {
+		score := math.Max(-1, math.Min(1, *answer.Score))
+		return math.Round(score*10) / 10, true
+	}
+
+	switch strings.ToLower(strings.Trim(strings.TrimSpace(output), `"'.`)) {
+	case sentimentNegative:
+		return -1, true
+	case sentimentNeutral:
+		return 0, true
+	case sentimentPositive:
+		return 1, true
+	}
+	return 0, false
+}
+
+func sentimentLabel(score float64) string {
+	switch {
+	case score <= -sentimentNeutralBand:
+		return sentimentNegative
+	case score >= sentimentNeutralBand:
+		return sentimentPositive
+	default:
+		return sentimentNeutral
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_SentimentHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		output       string
+		wantScore    float64
+		wantLabel    string
+		wantVerified bool
+	}{
+		{name: "positive", output: `{"score": 0.87}`, wantScore: 0.9, wantLabel: sentimentPositive, wantVerified: true},
+		{name: "negative", output: `Sure: {"score": -0.5}`, wantScore: -0.5, wantLabel: sentimentNegative, wantVerified: true},
+		{name: "neutral", output: `{"score": 0.12}`, wantScore: 0.1, wantLabel: sentimentNeutral, wantVerified: true},
+		{name: "clamped", output: `{"score": -3}`, wantScore: -1, wantLabel: sentimentNegative, wantVerified: true},
+		{name: "bare label", output: "Positive.", wantScore: 1, wantLabel: sentimentPositive, wantVerified: true},
+		{name: "unparseable", output: "I cannot tell", wantScore: 0, wantLabel: sentimentNeutral, wantVerified: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewSentimentHandler(&stubProvider{output: tt.output})
+			payload, err := ParseTaskPayload([]byte(`{"task_type":"sentiment","text":"I love this product"}`))
+			if err != nil {
+				t.Fatalf("ParseTaskPayload failed: %v", err)
+			}
+			if err := h.Validate(payload); err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+
+			result, err := h.Handle(context.Background(), payload)
+			if err != nil {
+				t.Fatalf("Handle failed: %v", err)
+			}
+			if err := h.ValidateResult(result); err != nil {
+				t.Errorf("ValidateResult failed: %v", err)
+			}
+			if result["score"] != tt.wantScore || result["label"] != tt.wantLabel || result["verified"].(*Verification).Passed != tt.wantVerified {
+				t.Errorf("unexpected result: %v", result)
+			}
+		})
+	}
+}
+
+func Test_SentimentHandlerValidateResult(t *testing.T) {
+	h := NewSentimentHandler(&stubProvider{})
+	tests := []struct {
+		name   string
+		result map[string]interface{}
+	}{
+		{name: "missing score", result: map[string]interface{}{"label": sentimentNeutral}},
+		{name: "score out of range", result: map[string]interface{}{"score": 1.5, "label": sentimentPositive}},
+		{name: "label mismatch", result: map[string]interface{}{"score": 0.8, "label": sentimentNegative}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := h.ValidateResult(tt.result); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// taskSignatureMetadata is the task metadata carrying the payload signature:
+//
+//	{"signature": "0x<r><s><v>"}
+type taskSignatureMetadata struct {
+	Signature string `json:"signature"`
+}
+
+// TaskSigners holds the addresses allowed to sign the payloads of each task
+// type. Tasks of a task type with signers must carry an ECDSA signature by
+// one of them; tasks of other task types are not checked.
+type TaskSigners struct {
+	signers map[string]map[common.Address]bool
+}
+
+// taskSignersFromEnv configures the signers of each task type from
+// TASK_SIGNERS, a comma-separated list of addresses applying to every task
+// type, and TASK_SIGNERS_<TASK_TYPE>, which replaces it for one task type
+// (e.g. TASK_SIGNERS_SUMMARIZE). It returns nil when no signers are set.
+func taskSignersFromEnv(taskTypes []string) (*TaskSigners, error) {
+	defaults, err := parseSignerAddresses("TASK_SIGNERS")
+	if err != nil {
+		return nil, err
+	}
+	s := &TaskSigners{signers: map[string]map[common.Address]bool{}}
+	for _, taskType := range taskTypes {
+		signers, err := parseSignerAddresses("TASK_SIGNERS_" + strings.ToUpper(strings.ReplaceAll(taskType, "-", "_")))
+		if err != nil {
+			return nil, err
+		}
+		if signers == nil {
+			signers = defaults
+		}
+		if signers != nil {
+			s.signers[taskType] = signers
+		}
+	}
+	if len(s.signers) == 0 {
+		return nil, nil
+	}
+	return s, nil
+}
+
+// parseSignerAddresses parses the comma-separated addresses of key, returning
+// nil when it is unset.
+func parseSignerAddresses(key string) (map[common.Address]bool, error) {
+	v := strings.TrimSpace(getenv(key))
+	if v == "" {
+		return nil, nil
+	}
+	signers := map[common.Address]bool{}
+	for _, addr := range strings.Split(v, ",") {
+		addr = strings.TrimSpace(addr)
+		if !common.IsHexAddress(addr) {
+			return nil, fmt.Errorf("invalid address %q in %s", addr, key)
+		}
+		signers[common.HexToAddress(addr)] = true
+	}
+	return signers, nil
+}
+
+// taskPayloadHash returns the digest signed for a payload: the EIP-191
+// personal message hash of the keccak256 hash of the raw payload bytes, as
+// produced by personal_sign or eth_sign of the payload hash.
+func taskPayloadHash(payload []byte) []byte {
+	return accounts.TextHash(crypto.Keccak256(payload))
+}
+
+// Verify checks the signature in the task metadata over the raw payload of a
+// task of the given type and returns the signer, or the zero address when
+// the task type needs no signature.
+func (s *TaskSigners) Verify(taskType string, payload, metadata []byte) (common.Address, error) {
+	if taskType == "" {
+		taskType = taskTypeCompletion
+	}
+	signers := s.signers[taskType]
+	if signers == nil {
+		return common.Address{}, nil
+	}
+
+	var md taskSignatureMetadata
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &md); err != nil {
+			return common.Address{}, fmt.Errorf("invalid task metadata: %w", err)
+		}
+	}
+	if md.Signature == "" {
+		return common.Address{}, fmt.Errorf("%s tasks must be signed", taskType)
+	}
+	sig, err := hexutil.Decode(md.Signature)
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("task signature must be %d hex-encoded bytes", crypto.SignatureLength)
+	}
+	// Wallets produce recovery IDs of 27 and 28.
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pub, err := crypto.SigToPub(taskPayloadHash(payload), sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid task signature: %w", err)
+	}
+	signer := crypto.PubkeyToAddress(*pub)
+	if !signers[signer] {
+		return common.Address{}, fmt.Errorf("task signed by %s, which is not an allowed signer of %s tasks", signer.Hex(), taskType)
+	}
+	return signer, nil
+}
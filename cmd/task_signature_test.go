@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+)
+
+// signTaskMetadata returns task metadata carrying the signature of payload
+// by key, with the recovery ID offset by 27 as wallets produce it when
+// walletV is set.
+func signTaskMetadata(t *testing.T, payload []byte, hexKey string, walletV bool) []byte {
+	t.Helper()
+	key, err := crypto.HexToECDSA(hexKey)
+	if err != nil {
+		t.Fatalf("invalid key: %v", err)
+	}
+	sig, err := crypto.Sign(taskPayloadHash(payload), key)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if walletV {
+		sig[crypto.RecoveryIDOffset] += 27
+	}
+	return []byte(fmt.Sprintf(`{"signature": %q}`, hexutil.Encode(sig)))
+}
+
+const (
+	testSignerKey = "b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291"
+	testOtherKey  = "8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a"
+)
+
+func testKeyAddress(t *testing.T, hexKey string) common.Address {
+	t.Helper()
+	key, err := crypto.HexToECDSA(hexKey)
+	if err != nil {
+		t.Fatalf("invalid key: %v", err)
+	}
+	return crypto.PubkeyToAddress(key.PublicKey)
+}
+
+func Test_taskSignersFromEnv(t *testing.T) {
+	signer, other := testKeyAddress(t, testSignerKey).Hex(), testKeyAddress(t, testOtherKey).Hex()
+
+	tests := []struct {
+		name      string
+		env       map[string]string
+		wantTypes map[string]int
+		wantNil   bool
+		wantErr   bool
+	}{
+		{name: "unset", wantNil: true},
+		{
+			name:      "all task types",
+			env:       map[string]string{"TASK_SIGNERS": signer + ", " + other},
+			wantTypes: map[string]int{"completion": 2, "summarize": 2},
+		},
+		{
+			name:      "one task type",
+			env:       map[string]string{"TASK_SIGNERS_SUMMARIZE": other},
+			wantTypes: map[string]int{"summarize": 1},
+		},
+		{
+			name:      "override",
+			env:       map[string]string{"TASK_SIGNERS": signer + "," + other, "TASK_SIGNERS_COMPLETION": signer},
+			wantTypes: map[string]int{"completion": 1, "summarize": 2},
+		},
+		{name: "invalid address", env: map[string]string{"TASK_SIGNERS": "0x1234"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"TASK_SIGNERS", "TASK_SIGNERS_COMPLETION", "TASK_SIGNERS_SUMMARIZE"} {
+				t.Setenv(key, tt.env[key])
+			}
+			signers, err := taskSignersFromEnv([]string{"completion", "summarize"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (signers == nil) != tt.wantNil {
+				t.Fatalf("expected nil signers %v, got %+v", tt.wantNil, signers)
+			}
+			if signers == nil {
+				return
+			}
+			if len(signers.signers) != len(tt.wantTypes) {
+				t.Errorf("expected signers for %v, got %v", tt.wantTypes, signers.signers)
+			}
+			for taskType, n := range tt.wantTypes {
+				if len(signers.signers[taskType]) != n {
+					t.Errorf("expected %d signers of %s tasks, got %d", n, taskType, len(signers.signers[taskType]))
+				}
+			}
+		})
+	}
+}
+
+func Test_TaskSignersVerify(t *testing.T) {
+	signer := testKeyAddress(t, testSignerKey)
+	signers := &TaskSigners{signers: map[string]map[common.Address]bool{taskTypeCompletion: {signer: true}}}
+	payload := []byte(`{"prompt": "What is 2+2?"}`)
+
+	tests := []struct {
+		name       string
+		taskType   string
+		payload    []byte
+		metadata   []byte
+		wantSigner common.Address
+		wantErr    bool
+	}{
+		{name: "signed", payload: payload, metadata: signTaskMetadata(t, payload, testSignerKey, false), wantSigner: signer},
+		{name: "wallet recovery id", payload: payload, metadata: signTaskMetadata(t, payload, testSignerKey, true), wantSigner: signer},
+		{name: "unchecked task type", taskType: taskTypeSummarize, payload: payload},
+		{name: "unsigned", payload: payload, wantErr: true},
+		{name: "tampered", payload: []byte(`{"prompt": "What is 2+3?"}`), metadata: signTaskMetadata(t, payload, testSignerKey, false), wantErr: true},
+		{name: "other signer", payload: payload, metadata: signTaskMetadata(t, payload, testOtherKey, false), wantErr: true},
+		{name: "short signature", payload: payload, metadata: []byte(`{"signature": "0x1234"}`), wantErr: true},
+		{name: "invalid metadata", payload: payload, metadata: []byte(`signature`), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := signers.Verify(tt.taskType, tt.payload, tt.metadata)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.wantSigner {
+				t.Errorf("expected signer %s, got %s", tt.wantSigner.Hex(), got.Hex())
+			}
+		})
+	}
+}
+
+func Test_ValidateTaskSignature(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "4"}, nil)
+	tw.SetTaskSigners(&TaskSigners{signers: map[string]map[common.Address]bool{
+		taskTypeCompletion: {testKeyAddress(t, testSignerKey): true},
+	}})
+	payload := []byte("What is 2+2?")
+
+	tests := []struct {
+		name     string
+		metadata []byte
+		wantErr  bool
+	}{
+		{name: "signed", metadata: signTaskMetadata(t, payload, testSignerKey, false)},
+		{name: "unsigned", wantErr: true},
+		{name: "wrong signer", metadata: signTaskMetadata(t, payload, testOtherKey, false), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tw.ValidateTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: payload, Metadata: tt.metadata})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	taskTypeSummarize = "summarize"
+
+	defaultSummaryMaxWords = 100
+	maxSummaryMaxWords     = 500
+	// summaryChunkSize is the number of bytes of a document summarized
+	// by a single provider call.
+	summaryChunkSize = 2000
+	// summaryWordTolerance is how far a summary may exceed max_words and
+	// still be considered verified.
+	summaryWordTolerance = 1.2
+	// defaultSummaryChunkConcurrency bounds the chunks of a document
+	// summarized at once when there is no worker pool.
+	defaultSummaryChunkConcurrency = 4
+)
+
+var summaryFormats = map[string]string{
+	"paragraph": "a single paragraph",
+	"bullets":   "a bulleted list, one point per line starting with \"- \"",
+}
+
+// summarizeParams are the summarize task fields of a payload:
+//
+//	{"task_type": "summarize", "document": "...", "max_words": 100, "format": "bullets"}
+type summarizeParams struct {
+	Document string `json:"document"`
+	MaxWords int    `json:"max_words"`
+	// Format is "paragraph" (default) or "bullets".
+	Format string `json:"format"`
+}
+
+// SummarizeHandler summarizes a document to a target length. Documents longer
+// than a chunk are summarized chunk by chunk and the partial summaries are
+// then combined. The keccak256 hash of the document is returned with the
+// summary so that consumers can check which source it was produced from.
+type SummarizeHandler struct {
+	provider Provider
+	// concurrency bounds the chunks summarized at once.
+	concurrency int
+}
+
+func NewSummarizeHandler(provider Provider) *SummarizeHandler {
+	return &SummarizeHandler{provider: provider, concurrency: defaultSummaryChunkConcurrency}
+}
+
+// SetChunkConcurrency sets how many chunks of a document are summarized at
+// once; values below 1 summarize them one at a time.
+func (h *SummarizeHandler) SetChunkConcurrency(n int) {
+	h.concurrency = max(n, 1)
+}
+
+func (h *SummarizeHandler) params(p *TaskPayload) (*summarizeParams, error) {
+	var params summarizeParams
+	if err := p.Decode(&params); err != nil {
+		return nil, err
+	}
+	if params.MaxWords == 0 {
+		params.MaxWords = defaultSummaryMaxWords
+	}
+	if params.Format == "" {
+		params.Format = "paragraph"
+	}
+	return &params, nil
+}
+
+func (h *SummarizeHandler) Validate(p *TaskPayload) error {
+	params, err := h.params(p)
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(params.Document)) == 0 {
+		return fmt.Errorf("document cannot be empty or whitespace only")
+	}
+	if params.MaxWords < 1 || params.MaxWords > maxSummaryMaxWords {
+		return fmt.Errorf("max_words must be between 1 and %d", maxSummaryMaxWords)
+	}
+	if _, ok := summaryFormats[params.Format]; !ok {
+		return fmt.Errorf("unsupported summary format %q", params.Format)
+	}
+	return nil
+}
+
+func (h *SummarizeHandler) Handle(ctx context.Context, p *TaskPayload) (map[string]interface{}, error) {
+	params, err := h.params(p)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := chunkText(params.Document, summaryChunkSize)
+	text := chunks[0]
+	if len(chunks) > 1 {
+		// Summarize every chunk to its share of the target length, then
+		// condense the partial summaries into the final one.
+		partials := make([]string, len(chunks))
+		errs := make([]error, len(chunks))
+		chunkWords := params.MaxWords/len(chunks) + 1
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, h.concurrency)
+		for i, chunk := range chunks {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int, chunk string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				resp, err := h.summarize(ctx, p, chunk, chunkWords, "paragraph")
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				partials[i] = resp.Output
+			}(i, chunk)
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		text = strings.Join(partials, "\n\n")
+	}
+
+	completion, err := h.summarize(ctx, p, text, params.MaxWords, params.Format)
+	if err != nil {
+		return nil, err
+	}
+	summary := strings.TrimSpace(completion.Output)
+	wordCount := len(strings.Fields(summary))
+	verification := newVerification().
+		Check("non_empty", wordCount > 0, "empty_summary").
+		Check("word_limit", float64(wordCount) <= float64(params.MaxWords)*summaryWordTolerance, "word_limit_exceeded")
+
+	metadata := completionMetadata(h.provider, completion)
+	metadata["chunks"] = len(chunks)
+
+	return map[string]interface{}{
+		"summary":     summary,
+		"format":      params.Format,
+		"word_count":  wordCount,
+		"source_hash": crypto.Keccak256Hash([]byte(params.Document)).Hex(),
+		"verified":    verification,
+		"metadata":    metadata,
+	}, nil
+}
+
+func (h *SummarizeHandler) summarize(ctx context.Context, p *TaskPayload, text string, maxWords int, format string) (*CompletionResponse, error) {
+	req := p.CompletionRequest()
+	req.Messages = []ChatMessage{
+		{
+			Role: "system",
+			Content: fmt.Sprintf("Summarize the user's text in at most %d words as %s. Reply with the summary only.",
+				maxWords, summaryFormats[format]),
+		},
+		{Role: "user", Content: text},
+	}
+	// Leave headroom over the word budget, words average more than one token.
+	req.MaxTokens = maxWords*2 + 16
+	if req.MaxTokens > maxTaskMaxTokens {
+		req.MaxTokens = maxTaskMaxTokens
+	}
+
+	resp, err := h.provider.Complete(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("%s summarization failed: %w", h.provider.Name(), err)
+	}
+	return resp, nil
+}
+
+func (h *SummarizeHandler) ValidateResult(result map[string]interface{}) error {
+	summary, ok := result["summary"].(string)
+	if !ok {
+		return fmt.Errorf("summary field must be a string")
+	}
+	if len(strings.TrimSpace(summary)) == 0 {
+		return fmt.Errorf("summary cannot be empty or whitespace only")
+	}
+	if hash, ok := result["source_hash"].(string); !ok || len(hash) != 66 || !strings.HasPrefix(hash, "0x") {
+		return fmt.Errorf("source_hash field must be a 0x-prefixed 32-byte hex string")
+	}
+	return nil
+}
+
+// chunkText splits text into chunks of at most size bytes, preferring to break
+// at paragraph, line or sentence boundaries, then at spaces.
+func chunkText(text string, size int) []string {
+	text = strings.TrimSpace(text)
+	var chunks []string
+	for len(text) > size {
+		cut := -1
+		for _, sep := range []string{"\n\n", "\n", ". ", " "} {
+			if i := strings.LastIndex(text[:size], sep); i > 0 {
+				cut = i + len(sep)
+				break
+			}
+		}
+		if cut <= 0 {
+			cut = size
+			// Don't split a multi-byte character.
+			for cut > 0 && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+		}
+		chunks = append(chunks, strings.TrimSpace(text[:cut]))
+		text = strings.TrimSpace(text[cut:])
+	}
+	if text != "" || len(chunks) == 0 {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
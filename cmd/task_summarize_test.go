@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingProvider returns a fixed output and counts the calls it received.
+type countingProvider struct {
+	output string
+	mu     sync.Mutex
+	calls  int
+}
+
+func (p *countingProvider) Name() string {
+	return "counting"
+}
+
+func (p *countingProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	p.mu.Lock()
+	p.calls++
+	p.mu.Unlock()
+	return &CompletionResponse{Output: p.output, Model: "counting-model"}, nil
+}
+
+func Test_SummarizeHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		document     string
+		maxWords     int
+		output       string
+		wantCalls    int
+		wantVerified bool
+	}{
+		{
+			name:         "short document",
+			document:     "Paris is the capital of France.",
+			maxWords:     10,
+			output:       "Paris is France's capital.",
+			wantCalls:    1,
+			wantVerified: true,
+		},
+		{
+			name:         "long document is chunked",
+			document:     strings.Repeat("A sentence about the topic. ", 150),
+			maxWords:     10,
+			output:       "The text repeats one sentence.",
+			wantCalls:    4,
+			wantVerified: true,
+		},
+		{
+			name:         "summary too long",
+			document:     "Paris is the capital of France.",
+			maxWords:     2,
+			output:       "Paris is the capital of France.",
+			wantCalls:    1,
+			wantVerified: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &countingProvider{output: tt.output}
+			h := NewSummarizeHandler(provider)
+			payload := &TaskPayload{TaskType: taskTypeSummarize}
+			payload.raw = []byte(`{"task_type":"summarize","document":"` + tt.document + `","max_words":` + strconv.Itoa(tt.maxWords) + `}`)
+
+			if err := h.Validate(payload); err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+			result, err := h.Handle(context.Background(), payload)
+			if err != nil {
+				t.Fatalf("Handle failed: %v", err)
+			}
+			if err := h.ValidateResult(result); err != nil {
+				t.Errorf("ValidateResult failed: %v", err)
+			}
+			if provider.calls != tt.wantCalls {
+				t.Errorf("expected %d provider calls, got %d", tt.wantCalls, provider.calls)
+			}
+			if result["verified"].(*Verification).Passed != tt.wantVerified {
+				t.Errorf("expected verified %v, got %+v", tt.wantVerified, result["verified"])
+			}
+			if result["source_hash"] == "" {
+				t.Errorf("expected source hash")
+			}
+		})
+	}
+}
+
+func Test_SummarizeHandlerValidate(t *testing.T) {
+	h := NewSummarizeHandler(&stubProvider{})
+	for _, raw := range []string{
+		`{"task_type":"summarize"}`,
+		`{"task_type":"summarize","document":"text","max_words":100000}`,
+		`{"task_type":"summarize","document":"text","format":"haiku"}`,
+	} {
+		payload, err := ParseTaskPayload([]byte(raw))
+		if err != nil {
+			t.Fatalf("ParseTaskPayload failed: %v", err)
+		}
+		if err := h.Validate(payload); err == nil {
+			t.Errorf("expected error for %s", raw)
+		}
+	}
+}
+
+func Test_ChunkText(t *testing.T) {
+	text := strings.Repeat("word ", 100)
+	chunks := chunkText(text, 64)
+	if len(chunks) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	for _, c := range chunks {
+		if len(c) > 64 {
+			t.Errorf("chunk exceeds size: %d", len(c))
+		}
+		if strings.HasPrefix(c, "ord") {
+			t.Errorf("chunk split a word: %q", c)
+		}
+	}
+	if strings.Join(chunks, " ") != strings.TrimSpace(text) {
+		t.Errorf("chunks do not reassemble the text")
+	}
+}
+
+// concurrencyProvider records the most calls it served at once.
+type concurrencyProvider struct {
+	active atomic.Int32
+	peak   atomic.Int32
+}
+
+func (p *concurrencyProvider) Name() string { return "concurrency" }
+
+func (p *concurrencyProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	n := p.active.Add(1)
+	defer p.active.Add(-1)
+	for {
+		peak := p.peak.Load()
+		if n <= peak || p.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return &CompletionResponse{Output: "a short summary", Model: "m"}, nil
+}
+
+func Test_SummarizeHandlerChunkConcurrency(t *testing.T) {
+	provider := &concurrencyProvider{}
+	h := NewSummarizeHandler(provider)
+	h.SetChunkConcurrency(2)
+	payload := &TaskPayload{TaskType: taskTypeSummarize}
+	payload.raw = []byte(`{"task_type":"summarize","document":"` + strings.Repeat("word ", summaryChunkSize) + `"}`)
+
+	if _, err := h.Handle(context.Background(), payload); err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+	if peak := provider.peak.Load(); peak < 1 || peak > 2 {
+		t.Errorf("expected at most 2 concurrent calls, got %d", peak)
+	}
+}
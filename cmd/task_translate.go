@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/abadojack/whatlanggo"
+)
+
+const (
+	taskTypeTranslate = "translate"
+
+	autoDetectLanguage = "auto"
+)
+
+// languagesByCode maps ISO 639-1 codes to the languages the detector knows.
+var languagesByCode = func() map[string]whatlanggo.Lang {
+	langs := map[string]whatlanggo.Lang{}
+	for lang := range whatlanggo.Langs {
+		if code := lang.Iso6391(); code != "" {
+			langs[code] = lang
+		}
+	}
+	return langs
+}()
+
+// translateParams are the translate task fields of a payload:
+//
+//	{"task_type": "translate", "text": "...", "source_language": "auto", "target_language": "fr"}
+//
+// Languages are ISO 639-1 codes; the source language defaults to "auto".
+type translateParams struct {
+	Text           string `json:"text"`
+	SourceLanguage string `json:"source_language"`
+	TargetLanguage string `json:"target_language"`
+}
+
+// TranslateHandler translates text into a target language. The source
+// language is detected when not given, and a translation is verified when the
+// language detected in the output is the requested target.
+type TranslateHandler struct {
+	provider Provider
+}
+
+func NewTranslateHandler(provider Provider) *TranslateHandler {
+	return &TranslateHandler{provider: provider}
+}
+
+func (h *TranslateHandler) params(p *TaskPayload) (*translateParams, error) {
+	var params translateParams
+	if err := p.Decode(&params); err != nil {
+		return nil, err
+	}
+	params.SourceLanguage = strings.ToLower(strings.TrimSpace(params.SourceLanguage))
+	params.TargetLanguage = strings.ToLower(strings.TrimSpace(params.TargetLanguage))
+	if params.SourceLanguage == "" {
+		params.SourceLanguage = autoDetectLanguage
+	}
+	return &params, nil
+}
+
+func (h *TranslateHandler) Validate(p *TaskPayload) error {
+	params, err := h.params(p)
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(params.Text)) == 0 {
+		return fmt.Errorf("text cannot be empty or whitespace only")
+	}
+	if _, ok := languagesByCode[params.TargetLanguage]; !ok {
+		return fmt.Errorf("unsupported target language %q, expected an ISO 639-1 code", params.TargetLanguage)
+	}
+	if _, ok := languagesByCode[params.SourceLanguage]; !ok && params.SourceLanguage != autoDetectLanguage {
+		return fmt.Errorf("unsupported source language %q, expected an ISO 639-1 code or %q", params.SourceLanguage, autoDetectLanguage)
+	}
+	return nil
+}
+
+func (h *TranslateHandler) Handle(ctx context.Context, p *TaskPayload) (map[string]interface{}, error) {
+	params, err := h.params(p)
+	if err != nil {
+		return nil, err
+	}
+
+	source := params.SourceLanguage
+	if source == autoDetectLanguage {
+		source = whatlanggo.DetectLang(params.Text).Iso6391()
+	}
+	target := languagesByCode[params.TargetLanguage]
+
+	instruction := fmt.Sprintf("Translate the user's text into %s.", whatlanggo.Langs[target])
+	if lang, ok := languagesByCode[source]; ok {
+		instruction = fmt.Sprintf("Translate the user's text from %s into %s.", whatlanggo.Langs[lang], whatlanggo.Langs[target])
+	}
+
+	req := p.CompletionRequest()
+	req.Messages = []ChatMessage{
+		{Role: "system", Content: instruction + " Reply with the translation only."},
+		{Role: "user", Content: params.Text},
+	}
+	// Translations are roughly as long as their source.
+	if p.MaxTokens == 0 {
+		req.MaxTokens = len(params.Text)/2 + 32
+		if req.MaxTokens > maxTaskMaxTokens {
+			req.MaxTokens = maxTaskMaxTokens
+		}
+	}
+
+	completion, err := h.provider.Complete(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("%s translation failed: %w", h.provider.Name(), err)
+	}
+
+	translation := strings.TrimSpace(completion.Output)
+	detected := whatlanggo.Detect(translation)
+
+	verification := newVerification().
+		Check("non_empty", translation != "", "empty_translation").
+		Check("target_language", detected.Lang == target, "language_mismatch")
+
+	return map[string]interface{}{
+		"translation":         translation,
+		"source_language":     source,
+		"target_language":     params.TargetLanguage,
+		"detected_language":   detected.Lang.Iso6391(),
+		"language_confidence": detected.Confidence,
+		"verified":            verification,
+		"metadata":            completionMetadata(h.provider, completion),
+	}, nil
+}
+
+func (h *TranslateHandler) ValidateResult(result map[string]interface{}) error {
+	translation, ok := result["translation"].(string)
+	if !ok {
+		return fmt.Errorf("translation field must be a string")
+	}
+	if len(strings.TrimSpace(translation)) == 0 {
+		return fmt.Errorf("translation cannot be empty or whitespace only")
+	}
+	if _, ok := result["target_language"].(string); !ok {
+		return fmt.Errorf("target_language field must be a string")
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_TranslateHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		payload      string
+		output       string
+		wantSource   string
+		wantVerified bool
+	}{
+		{
+			name:         "auto-detected source",
+			payload:      `{"task_type":"translate","text":"The weather is lovely today and we are going to the beach.","target_language":"fr"}`,
+			output:       "Il fait très beau aujourd'hui et nous allons à la plage.",
+			wantSource:   "en",
+			wantVerified: true,
+		},
+		{
+			name:         "explicit source",
+			payload:      `{"task_type":"translate","text":"Bonjour tout le monde","source_language":"fr","target_language":"de"}`,
+			output:       "Hallo zusammen, wie geht es euch heute allen?",
+			wantSource:   "fr",
+			wantVerified: true,
+		},
+		{
+			name:         "wrong output language",
+			payload:      `{"task_type":"translate","text":"The weather is lovely today and we are going to the beach.","target_language":"fr"}`,
+			output:       "The weather is lovely today and we are going to the beach.",
+			wantSource:   "en",
+			wantVerified: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewTranslateHandler(&stubProvider{output: tt.output})
+			payload, err := ParseTaskPayload([]byte(tt.payload))
+			if err != nil {
+				t.Fatalf("ParseTaskPayload failed: %v", err)
+			}
+			if err := h.Validate(payload); err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+
+			result, err := h.Handle(context.Background(), payload)
+			if err != nil {
+				t.Fatalf("Handle failed: %v", err)
+			}
+			if err := h.ValidateResult(result); err != nil {
+				t.Errorf("ValidateResult failed: %v", err)
+			}
+			if result["source_language"] != tt.wantSource || result["verified"].(*Verification).Passed != tt.wantVerified {
+				t.Errorf("unexpected result: %v", result)
+			}
+		})
+	}
+}
+
+func Test_TranslateHandlerValidate(t *testing.T) {
+	h := NewTranslateHandler(&stubProvider{})
+	for _, raw := range []string{
+		`{"task_type":"translate","target_language":"fr"}`,
+		`{"task_type":"translate","text":"hi"}`,
+		`{"task_type":"translate","text":"hi","target_language":"klingon"}`,
+		`{"task_type":"translate","text":"hi","source_language":"xx","target_language":"fr"}`,
+	} {
+		payload, err := ParseTaskPayload([]byte(raw))
+		if err != nil {
+			t.Fatalf("ParseTaskPayload failed: %v", err)
+		}
+		if err := h.Validate(payload); err == nil {
+			t.Errorf("expected error for %s", raw)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrTokenBudgetExhausted is matched by the BudgetExhaustedError of a task
+// refused because the operator's token budget is spent.
+var ErrTokenBudgetExhausted = errors.New("token budget exhausted")
+
+// BudgetExhaustedError reports the budget window that is spent and when it
+// resets.
+type BudgetExhaustedError struct {
+	Window     string
+	Limit      int
+	RetryAfter time.Duration
+}
+
+func (e *BudgetExhaustedError) Error() string {
+	return fmt.Sprintf("%s token budget of %d tokens is exhausted, resets in %s", e.Window, e.Limit, e.RetryAfter.Round(time.Second))
+}
+
+func (e *BudgetExhaustedError) Is(target error) bool {
+	return target == ErrTokenBudgetExhausted
+}
+
+func (e *BudgetExhaustedError) retryAfter() time.Duration {
+	return e.RetryAfter
+}
+
+// budgetWindow is the usage of one budget window, aligned to the UTC clock.
+type budgetWindow struct {
+	name   string
+	length time.Duration
+	limit  int
+	start  time.Time
+	used   int
+}
+
+// TokenBudget caps the prompt and completion tokens spent per hour and per
+// day, so a flood of tasks cannot run up the operator's LLM bill. Tasks are
+// refused once a window is spent; tasks already running when it runs out
+// still finish, so a window may be exceeded by their usage.
+type TokenBudget struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	windows []*budgetWindow
+}
+
+// tokenBudgetFromEnv configures the budget from TOKEN_BUDGET_HOURLY and
+// TOKEN_BUDGET_DAILY, and returns nil when neither is set.
+func tokenBudgetFromEnv() (*TokenBudget, error) {
+	hourly, err := envInt("TOKEN_BUDGET_HOURLY")
+	if err != nil {
+		return nil, err
+	}
+	daily, err := envInt("TOKEN_BUDGET_DAILY")
+	if err != nil {
+		return nil, err
+	}
+	if hourly < 0 || daily < 0 {
+		return nil, fmt.Errorf("TOKEN_BUDGET_HOURLY and TOKEN_BUDGET_DAILY must not be negative")
+	}
+	if hourly == 0 && daily == 0 {
+		return nil, nil
+	}
+	return NewTokenBudget(hourly, daily), nil
+}
+
+// NewTokenBudget returns a budget; a limit of 0 disables that window.
+func NewTokenBudget(hourly, daily int) *TokenBudget {
+	b := &TokenBudget{now: time.Now}
+	if hourly > 0 {
+		b.windows = append(b.windows, &budgetWindow{name: "hourly", length: time.Hour, limit: hourly})
+	}
+	if daily > 0 {
+		b.windows = append(b.windows, &budgetWindow{name: "daily", length: 24 * time.Hour, limit: daily})
+	}
+	return b
+}
+
+// Check returns a BudgetExhaustedError when a window is spent.
+func (b *TokenBudget) Check() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	for _, w := range b.windows {
+		b.roll(w, now)
+		if w.used >= w.limit {
+			return &BudgetExhaustedError{Window: w.name, Limit: w.limit, RetryAfter: w.start.Add(w.length).Sub(now)}
+		}
+	}
+	return nil
+}
+
+// Record adds the tokens spent by a task to every window.
+func (b *TokenBudget) Record(tokens int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	for _, w := range b.windows {
+		b.roll(w, now)
+		w.used += tokens
+		tokenBudgetRemaining.WithLabelValues(w.name).Set(float64(max(w.limit-w.used, 0)))
+	}
+}
+
+// roll starts a new window once the current one has passed.
+func (b *TokenBudget) roll(w *budgetWindow, now time.Time) {
+	if start := now.UTC().Truncate(w.length); !start.Equal(w.start) {
+		w.start, w.used = start, 0
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_TokenBudget(t *testing.T) {
+	start := time.Date(2024, 10, 15, 10, 30, 0, 0, time.UTC)
+
+	type step struct {
+		at         time.Duration
+		record     int
+		wantWindow string
+	}
+	tests := []struct {
+		name   string
+		hourly int
+		daily  int
+		steps  []step
+	}{
+		{
+			name:   "hourly window resets",
+			hourly: 100,
+			steps: []step{
+				{record: 60},
+				{at: time.Minute, record: 40},
+				{at: 2 * time.Minute, wantWindow: "hourly"},
+				{at: 30 * time.Minute},
+			},
+		},
+		{
+			name:   "daily window outlasts the hour",
+			hourly: 100, daily: 150,
+			steps: []step{
+				{record: 90},
+				{at: time.Hour, record: 90},
+				{at: 2 * time.Hour, wantWindow: "daily"},
+				{at: 14 * time.Hour},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewTokenBudget(tt.hourly, tt.daily)
+			for i, s := range tt.steps {
+				b.now = func() time.Time { return start.Add(s.at) }
+				err := b.Check()
+				var budgetErr *BudgetExhaustedError
+				switch {
+				case s.wantWindow == "" && err != nil:
+					t.Fatalf("step %d: unexpected error %v", i, err)
+				case s.wantWindow != "" && !errors.As(err, &budgetErr):
+					t.Fatalf("step %d: expected an exhausted budget, got %v", i, err)
+				case s.wantWindow != "" && (budgetErr.Window != s.wantWindow || budgetErr.RetryAfter <= 0):
+					t.Fatalf("step %d: unexpected error %+v", i, budgetErr)
+				}
+				b.Record(s.record)
+			}
+		})
+	}
+}
+
+func Test_tokenBudgetFromEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		hourly      string
+		daily       string
+		wantWindows int
+		wantNil     bool
+		wantErr     bool
+	}{
+		{name: "unset", wantNil: true},
+		{name: "hourly", hourly: "100000", wantWindows: 1},
+		{name: "both", hourly: "100000", daily: "1000000", wantWindows: 2},
+		{name: "negative", daily: "-1", wantErr: true},
+		{name: "invalid", hourly: "lots", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TOKEN_BUDGET_HOURLY", tt.hourly)
+			t.Setenv("TOKEN_BUDGET_DAILY", tt.daily)
+			b, err := tokenBudgetFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (b == nil) != tt.wantNil {
+				t.Fatalf("expected nil budget %v, got %+v", tt.wantNil, b)
+			}
+			if b != nil && len(b.windows) != tt.wantWindows {
+				t.Errorf("expected %d windows, got %d", tt.wantWindows, len(b.windows))
+			}
+		})
+	}
+}
+
+func Test_HandleTaskTokenBudget(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "4"}, nil)
+	tw.SetTokenBudget(NewTokenBudget(1, 0))
+	task := &performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")}
+
+	type result struct {
+		ErrorCode  string `json:"error_code"`
+		Retryable  bool   `json:"retryable"`
+		RetryAfter int    `json:"retry_after_seconds"`
+	}
+	var results []result
+	for i := 0; i < 2; i++ {
+		resp, err := tw.HandleTask(task)
+		if err != nil {
+			t.Fatalf("HandleTask failed: %v", err)
+		}
+		var r result
+		if err := json.Unmarshal(resp.Result, &r); err != nil {
+			t.Fatalf("failed to decode result: %v", err)
+		}
+		results = append(results, r)
+	}
+	if results[0].ErrorCode != "" {
+		t.Errorf("expected the first task to run, got %+v", results[0])
+	}
+	if r := results[1]; r.ErrorCode != errorCodeBudgetExhausted || !r.Retryable || r.RetryAfter <= 0 {
+		t.Errorf("expected a retryable budget exhausted result, got %+v", r)
+	}
+}
+
+func Test_HandleTaskTokenBudgetOverhead(t *testing.T) {
+	task := &performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte(`{"schema_version":1,"prompt":"What is 2+2?","result_version":2}`)}
+
+	spent := func(verifier Verifier) (int, string) {
+		tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "4"}, nil)
+		budget := NewTokenBudget(1000, 0)
+		tw.SetTokenBudget(budget)
+		if verifier != nil {
+			tw.SetVerifier(verifier)
+		}
+		resp, err := tw.HandleTask(task)
+		if err != nil {
+			t.Fatalf("HandleTask failed: %v", err)
+		}
+		var result struct {
+			TokensIn  int `json:"tokens_in"`
+			TokensOut int `json:"tokens_out"`
+		}
+		if err := json.Unmarshal(resp.Result, &result); err != nil {
+			t.Fatalf("failed to decode result: %v", err)
+		}
+		return budget.windows[0].used, fmt.Sprintf("%d/%d", result.TokensIn, result.TokensOut)
+	}
+
+	generated, wantUsage := spent(nil)
+	verified, gotUsage := spent(&consistencyVerifier{provider: withOverheadTracking(&stubProvider{output: "4"}), samples: 3, threshold: 0.5})
+	if verified <= generated {
+		t.Errorf("expected the verifier's usage to be charged, got %d tokens with and %d without it", verified, generated)
+	}
+	if gotUsage != wantUsage {
+		t.Errorf("expected the result to report the usage %s of the output only, got %s", wantUsage, gotUsage)
+	}
+}
+
+func Test_HandleTaskTokenBudgetCoalesced(t *testing.T) {
+	payload := []byte(`{"schema_version":1,"prompt":"What is 2+2?","result_version":2}`)
+
+	// run handles a task per ID at once through a coalescing provider and
+	// returns the tokens charged and the usage each result reports.
+	run := func(ids ...string) (int, []string) {
+		inner := &gatedProvider{release: make(chan struct{})}
+		coalescer := NewCoalescingProvider(zap.NewNop(), inner)
+		tw := NewTaskWorker(zap.NewNop(), coalescer, nil)
+		budget := NewTokenBudget(1000, 0)
+		tw.SetTokenBudget(budget)
+
+		var wg sync.WaitGroup
+		usages := make([]string, len(ids))
+		for i, id := range ids {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte(id), Payload: payload})
+				if err != nil {
+					t.Errorf("HandleTask failed: %v", err)
+					return
+				}
+				var result struct {
+					TokensIn  int `json:"tokens_in"`
+					TokensOut int `json:"tokens_out"`
+				}
+				if err := json.Unmarshal(resp.Result, &result); err != nil {
+					t.Errorf("failed to decode result: %v", err)
+					return
+				}
+				usages[i] = fmt.Sprintf("%d/%d", result.TokensIn, result.TokensOut)
+			}()
+		}
+		// Wait for every task to share the one call before answering.
+		for {
+			coalescer.mu.Lock()
+			waiting := 0
+			for _, call := range coalescer.inflight {
+				waiting += call.waiters + 1
+			}
+			coalescer.mu.Unlock()
+			if waiting == len(ids) {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		close(inner.release)
+		wg.Wait()
+
+		if got := inner.calls.Load(); got != 1 {
+			t.Fatalf("expected one provider call, got %d", got)
+		}
+		return budget.windows[0].used, usages
+	}
+
+	single, want := run("task-1")
+	coalesced, got := run("task-1", "task-2")
+	if coalesced != single {
+		t.Errorf("expected the coalesced tasks to be charged %d tokens once, got %d", single, coalesced)
+	}
+	for i, usage := range got {
+		if usage != want[0] {
+			t.Errorf("expected task %d to report the usage %s, got %s", i, want[0], usage)
+		}
+	}
+}
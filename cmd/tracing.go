@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	tracerName         = "github.com/Layr-Labs/hourglass-avs-template/cmd"
+	tracingServiceName = "performer"
+	// tracingShutdownTimeout bounds flushing the last spans on exit.
+	tracingShutdownTimeout = 5 * time.Second
+)
+
+// taskIDKey is the span attribute carrying the TaskId, set on every span of
+// a task so traces can be looked up by task.
+const taskIDKey = attribute.Key("task.id")
+
+type taskIDContextKey struct{}
+
+// tracingFromEnv exports spans over OTLP/HTTP when OTEL_EXPORTER_OTLP_ENDPOINT
+// or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is set. The exporter, sampler and
+// resource are configured by the standard OTEL_* variables. It returns the
+// function flushing the spans on shutdown, or nil when tracing is disabled.
+func tracingFromEnv(ctx context.Context, logger *zap.Logger) (func(context.Context) error, error) {
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return nil, nil
+	}
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", tracingServiceName)),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe tracing resource: %w", err)
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	logger.Sugar().Infow("Exporting traces over OTLP")
+	return tp.Shutdown, nil
+}
+
+// startSpan starts a span of the task in ctx, if any, tagged with its TaskId
+// and correlation ID.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if taskID, ok := ctx.Value(taskIDContextKey{}).(string); ok {
+		attrs = append(attrs, taskIDKey.String(taskID))
+	}
+	if id := correlationID(ctx); id != "" {
+		attrs = append(attrs, correlationIDKey.String(id))
+	}
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// startTaskSpan starts the root span of handling t and propagates its TaskId
+// to the spans started from the returned context.
+func startTaskSpan(ctx context.Context, name string, t *performerV1.TaskRequest) (context.Context, trace.Span) {
+	return startSpan(context.WithValue(ctx, taskIDContextKey{}, string(t.TaskId)), name)
+}
+
+// endSpan records err, if any, on span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// tracingTransport traces provider HTTP requests and propagates the trace
+// context to providers that take part in it.
+type tracingTransport struct {
+	base http.RoundTripper
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := startSpan(req.Context(), "HTTP "+req.Method,
+		attribute.String("http.request.method", req.Method),
+		attribute.String("server.address", req.URL.Hostname()),
+	)
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, resp.Status)
+		}
+	}
+	endSpan(span, err)
+	return resp, err
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+)
+
+// recordSpans records the spans ended during the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return recorder
+}
+
+// spanAttribute returns the value of the attribute key of the span named
+// name, if any.
+func spanAttribute(spans []sdktrace.ReadOnlySpan, name string, key attribute.Key) (string, bool) {
+	for _, span := range spans {
+		if span.Name() != name {
+			continue
+		}
+		for _, attr := range span.Attributes() {
+			if attr.Key == key {
+				return attr.Value.Emit(), true
+			}
+		}
+	}
+	return "", false
+}
+
+func Test_taskSpans(t *testing.T) {
+	recorder := recordSpans(t)
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "4"}, nil)
+	task := &performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")}
+
+	if err := tw.validateTask(context.Background(), task); err != nil {
+		t.Fatalf("validateTask failed: %v", err)
+	}
+	if _, err := tw.handleTask(context.Background(), task); err != nil {
+		t.Fatalf("handleTask failed: %v", err)
+	}
+
+	spans := recorder.Ended()
+	tests := []struct {
+		span string
+		key  attribute.Key
+		want string
+	}{
+		{span: "ValidateTask", key: taskIDKey, want: "task-1"},
+		{span: "HandleTask", key: taskIDKey, want: "task-1"},
+		{span: "HandleTask", key: "task.type", want: taskTypeCompletion},
+		{span: "Verify", key: taskIDKey, want: "task-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.span+" "+string(tt.key), func(t *testing.T) {
+			got, ok := spanAttribute(spans, tt.span, tt.key)
+			if !ok || got != tt.want {
+				t.Errorf("expected %s=%s, got %q (found %v)", tt.key, tt.want, got, ok)
+			}
+		})
+	}
+}
+
+func Test_tracingTransport(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantStatus string
+	}{
+		{name: "ok", status: http.StatusOK, wantStatus: "200"},
+		{name: "server error", status: http.StatusBadGateway, wantStatus: "502"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := recordSpans(t)
+			var traceparent string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				traceparent = r.Header.Get("traceparent")
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			ctx, span := startTaskSpan(context.Background(), "HandleTask", &performerV1.TaskRequest{TaskId: []byte("task-1")})
+			req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, nil)
+			client := &http.Client{Transport: &tracingTransport{base: http.DefaultTransport}}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+			span.End()
+
+			if traceparent == "" {
+				t.Errorf("expected the trace context to be propagated")
+			}
+			spans := recorder.Ended()
+			if got, _ := spanAttribute(spans, "HTTP POST", "http.response.status_code"); got != tt.wantStatus {
+				t.Errorf("expected status %s, got %q", tt.wantStatus, got)
+			}
+			if got, _ := spanAttribute(spans, "HTTP POST", taskIDKey); got != "task-1" {
+				t.Errorf("expected the provider call to carry the TaskId, got %q", got)
+			}
+		})
+	}
+}
+
+func Test_tracingFromEnv(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	shutdown, err := tracingFromEnv(context.Background(), zap.NewNop())
+	if err != nil {
+		t.Fatalf("tracingFromEnv failed: %v", err)
+	}
+	if shutdown != nil {
+		t.Errorf("expected tracing to be disabled")
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 32
+	defaultIdleConnTimeout     = 90 * time.Second
+	defaultKeepAlive           = 30 * time.Second
+)
+
+// TransportConfig tunes the HTTP transport shared by all providers.
+type TransportConfig struct {
+	// MaxIdleConns caps idle keep-alive connections across all hosts.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost caps idle keep-alive connections per provider host.
+	// Go's default of 2 makes bursts of tasks open new TLS connections.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout closes idle connections after this long.
+	IdleConnTimeout time.Duration
+	// KeepAlive is the TCP keep-alive period of provider connections.
+	KeepAlive time.Duration
+	// HTTP2 negotiates HTTP/2 with providers that support it.
+	HTTP2 bool
+}
+
+func defaultTransportConfig() *TransportConfig {
+	return &TransportConfig{
+		MaxIdleConns:        defaultMaxIdleConns,
+		MaxIdleConnsPerHost: defaultMaxIdleConnsPerHost,
+		IdleConnTimeout:     defaultIdleConnTimeout,
+		KeepAlive:           defaultKeepAlive,
+		HTTP2:               true,
+	}
+}
+
+func transportConfigFromEnv() (*TransportConfig, error) {
+	cfg := defaultTransportConfig()
+
+	maxIdle, err := envInt("HTTP_MAX_IDLE_CONNS")
+	if err != nil {
+		return nil, err
+	}
+	if maxIdle > 0 {
+		cfg.MaxIdleConns = maxIdle
+	}
+
+	maxIdlePerHost, err := envInt("HTTP_MAX_IDLE_CONNS_PER_HOST")
+	if err != nil {
+		return nil, err
+	}
+	if maxIdlePerHost > 0 {
+		cfg.MaxIdleConnsPerHost = maxIdlePerHost
+	}
+
+	idleTimeout, err := envDuration("HTTP_IDLE_CONN_TIMEOUT")
+	if err != nil {
+		return nil, err
+	}
+	if idleTimeout > 0 {
+		cfg.IdleConnTimeout = idleTimeout
+	}
+
+	keepAlive, err := envDuration("HTTP_KEEPALIVE")
+	if err != nil {
+		return nil, err
+	}
+	if keepAlive > 0 {
+		cfg.KeepAlive = keepAlive
+	}
+
+	if v := getenv("HTTP2_ENABLED"); v != "" {
+		if cfg.HTTP2, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid value for HTTP2_ENABLED: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+// providerTransport is shared by every provider's HTTP client so connections
+// (and their TLS sessions) are reused across tasks. It is replaced by
+// NewProviderFromEnv with the configured transport.
+var providerTransport http.RoundTripper = newHTTPTransport(defaultTransportConfig())
+
+// providerTimeout bounds each provider request. It is replaced by
+// NewProviderFromEnv with PROVIDER_TIMEOUT.
+var providerTimeout = defaultProviderTimeout
+
+func newHTTPTransport(cfg *TransportConfig) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   defaultProviderTimeout,
+		KeepAlive: cfg.KeepAlive,
+	}
+	t := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     cfg.HTTP2,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		TLSHandshakeTimeout:   defaultProviderTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+	if !cfg.HTTP2 {
+		// A non-nil, empty map disables the automatic HTTP/2 upgrade.
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return t
+}
+
+// newHTTPClient returns a client for provider requests that uses the shared
+// transport.
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout:   providerTimeout,
+		Transport: providerTransport,
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_TransportConfigFromEnv(t *testing.T) {
+	t.Setenv("HTTP_MAX_IDLE_CONNS_PER_HOST", "64")
+	t.Setenv("HTTP_IDLE_CONN_TIMEOUT", "2m")
+	t.Setenv("HTTP2_ENABLED", "false")
+
+	cfg, err := transportConfigFromEnv()
+	if err != nil {
+		t.Fatalf("transportConfigFromEnv failed: %v", err)
+	}
+	if cfg.MaxIdleConns != defaultMaxIdleConns || cfg.MaxIdleConnsPerHost != 64 || cfg.IdleConnTimeout != 2*time.Minute || cfg.HTTP2 {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	transport := newHTTPTransport(cfg)
+	if transport.MaxIdleConnsPerHost != 64 || transport.ForceAttemptHTTP2 || transport.TLSNextProto == nil {
+		t.Errorf("transport does not reflect config: %+v", transport)
+	}
+
+	t.Setenv("HTTP2_ENABLED", "sometimes")
+	if _, err := transportConfigFromEnv(); err == nil {
+		t.Errorf("expected error for invalid HTTP2_ENABLED")
+	}
+}
+
+func Test_NewHTTPClientSharesTransport(t *testing.T) {
+	if newHTTPClient().Transport != newHTTPClient().Transport {
+		t.Errorf("expected provider clients to share a transport")
+	}
+}
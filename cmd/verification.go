@@ -0,0 +1,107 @@
+package main
+
+import "fmt"
+
+// Verification records how a result was verified, so consumers know why it
+// was or wasn't verified:
+//
+//	"verified": {
+//	  "passed":      false,
+//	  "confidence":  0.5,                                    // share of checks passed
+//	  "checks":      ["non_empty", "word_limit"],            // checks run
+//	  "reasons":     ["word_limit_exceeded"],                // reason codes of failed checks
+//	  "judge":       {"verdict": "pass", "score": 0.9, ...}, // when judged by a second model
+//	  "similarity":  0.82,                                   // when compared to a reference embedding
+//	  "consistency": {"samples": 3, "agreeing": 3, ...},     // when the prompt was sampled again
+//	  "fact_check":  {"claims": [...], ...},                 // when the output's claims were checked
+//	  "pipeline":    {"policy": "any", "stages": [...]}      // when checked by a verification pipeline
+//	}
+//
+// A result passes when every check passed; one without checks never does.
+type Verification struct {
+	Passed     bool     `json:"passed"`
+	Confidence float64  `json:"confidence"`
+	Checks     []string `json:"checks"`
+	Reasons    []string `json:"reasons"`
+	// Judge is the verdict of the judge model, see judgeVerifier.
+	Judge *JudgeVerdict `json:"judge,omitempty"`
+	// Similarity is the cosine similarity of the output to its reference,
+	// see similarityVerifier.
+	Similarity *float64 `json:"similarity,omitempty"`
+	// Consistency is the agreement between samples of the prompt, see
+	// consistencyVerifier.
+	Consistency *Consistency `json:"consistency,omitempty"`
+	// FactCheck is the evidence found for the output's claims, see
+	// factCheckVerifier.
+	FactCheck *FactCheck `json:"fact_check,omitempty"`
+	// Pipeline is the outcome of each stage of the task type's verification
+	// pipeline, see VerificationPipeline.
+	Pipeline *PipelineResult `json:"pipeline,omitempty"`
+}
+
+func newVerification() *Verification {
+	return &Verification{Checks: []string{}, Reasons: []string{}}
+}
+
+// failedVerification returns the verification of a result that could not be
+// checked, with reason as its only reason code.
+func failedVerification(reason string) *Verification {
+	v := newVerification()
+	v.Reasons = append(v.Reasons, reason)
+	return v
+}
+
+// Check records the outcome of the named check and the reason code reported
+// when it failed.
+func (v *Verification) Check(name string, passed bool, reason string) *Verification {
+	v.Checks = append(v.Checks, name)
+	if !passed {
+		v.Reasons = append(v.Reasons, reason)
+	}
+	v.Passed = len(v.Reasons) == 0
+	v.Confidence = float64(len(v.Checks)-len(v.Reasons)) / float64(len(v.Checks))
+	return v
+}
+
+// mergeDetails copies the verifier details recorded in other, such as the
+// judge's verdict, without its checks.
+func (v *Verification) mergeDetails(other *Verification) {
+	if other.Judge != nil {
+		v.Judge = other.Judge
+	}
+	if other.Similarity != nil {
+		v.Similarity = other.Similarity
+	}
+	if other.Consistency != nil {
+		v.Consistency = other.Consistency
+	}
+	if other.FactCheck != nil {
+		v.FactCheck = other.FactCheck
+	}
+}
+
+// validateVerification checks the verified field of a decoded result.
+func validateVerification(value interface{}) error {
+	v, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("verified field must be an object")
+	}
+	if _, ok := v["passed"].(bool); !ok {
+		return fmt.Errorf("verified.passed field must be a boolean")
+	}
+	if confidence, ok := v["confidence"].(float64); !ok || confidence < 0 || confidence > 1 {
+		return fmt.Errorf("verified.confidence field must be a number between 0 and 1")
+	}
+	for _, field := range []string{"checks", "reasons"} {
+		list, ok := v[field].([]interface{})
+		if !ok {
+			return fmt.Errorf("verified.%s field must be an array", field)
+		}
+		for _, item := range list {
+			if _, ok := item.(string); !ok {
+				return fmt.Errorf("verified.%s field must only contain strings", field)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Combination policies of a verification pipeline.
+const (
+	pipelinePolicyAll       = "all"
+	pipelinePolicyAny       = "any"
+	pipelinePolicyThreshold = "threshold"
+)
+
+// taskOutputFields names the result field holding the text output of each
+// task type that verification pipelines can check.
+var taskOutputFields = map[string]string{
+	taskTypeCompletion: "llm_output",
+	taskTypeSummarize:  "summary",
+	taskTypeTranslate:  "translation",
+	taskTypeCodegen:    "code",
+	taskTypeClassify:   "label",
+	taskTypeSentiment:  "label",
+}
+
+// PipelineResult records the outcome of each stage of a verification
+// pipeline and the policy that combined them.
+type PipelineResult struct {
+	Policy string          `json:"policy"`
+	Stages []PipelineStage `json:"stages"`
+}
+
+// PipelineStage is the outcome of one verifier of a pipeline.
+type PipelineStage struct {
+	Verifier string   `json:"verifier"`
+	Passed   bool     `json:"passed"`
+	Reasons  []string `json:"reasons"`
+}
+
+// VerificationPipeline runs verifiers in order, each on its own, and combines
+// their outcomes with a policy: all must pass, any must pass, or the share of
+// passing verifiers must reach the threshold. The pipeline is recorded as a
+// single "pipeline" check with the stages under verified.pipeline.
+type VerificationPipeline struct {
+	verifiers []Verifier
+	policy    string
+	threshold float64
+}
+
+// pipelineConfig is the declaration of a pipeline in the pipelines file.
+type pipelineConfig struct {
+	Policy    string   `yaml:"policy"`
+	Threshold float64  `yaml:"threshold"`
+	Verifiers []string `yaml:"verifiers"`
+}
+
+// verificationPipelinesFromEnv loads the pipelines per task type from the
+// YAML file named by VERIFICATION_PIPELINES_FILE, or returns nil when none is
+// configured. Verifiers are named as in VERIFIERS:
+//
+//	completion:
+//	  policy: any
+//	  verifiers: [json, judge]
+//	summarize:
+//	  policy: threshold
+//	  threshold: 0.6
+//	  verifiers: [non_empty, rules, similarity]
+func verificationPipelinesFromEnv(ctx context.Context, provider Provider) (map[string]*VerificationPipeline, error) {
+	path := getenv("VERIFICATION_PIPELINES_FILE")
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read verification pipelines: %w", err)
+	}
+
+	var configs map[string]pipelineConfig
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&configs); err != nil {
+		return nil, fmt.Errorf("invalid verification pipelines: %w", err)
+	}
+
+	pipelines := map[string]*VerificationPipeline{}
+	for taskType, cfg := range configs {
+		if _, ok := taskOutputFields[taskType]; !ok {
+			return nil, fmt.Errorf("verification pipelines are not supported for task type %q", taskType)
+		}
+		var verifiers []Verifier
+		for _, name := range cfg.Verifiers {
+			v, err := newVerifierFromEnv(ctx, provider, name)
+			if err != nil {
+				return nil, fmt.Errorf("%s verification pipeline: %w", taskType, err)
+			}
+			verifiers = append(verifiers, v)
+		}
+		p, err := NewVerificationPipeline(cfg.Policy, cfg.Threshold, verifiers...)
+		if err != nil {
+			return nil, fmt.Errorf("%s verification pipeline: %w", taskType, err)
+		}
+		pipelines[taskType] = p
+	}
+	return pipelines, nil
+}
+
+// NewVerificationPipeline combines verifiers with a policy. The threshold is
+// only used by the threshold policy; an empty policy means all.
+func NewVerificationPipeline(policy string, threshold float64, verifiers ...Verifier) (*VerificationPipeline, error) {
+	if len(verifiers) == 0 {
+		return nil, fmt.Errorf("a verification pipeline needs at least one verifier")
+	}
+	switch policy {
+	case "":
+		policy = pipelinePolicyAll
+	case pipelinePolicyAll, pipelinePolicyAny:
+	case pipelinePolicyThreshold:
+		if threshold <= 0 || threshold > 1 {
+			return nil, fmt.Errorf("the threshold policy needs a threshold above 0 and at most 1")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported policy %q, expected one of: %s", policy,
+			strings.Join([]string{pipelinePolicyAll, pipelinePolicyAny, pipelinePolicyThreshold}, ", "))
+	}
+	return &VerificationPipeline{verifiers: verifiers, policy: policy, threshold: threshold}, nil
+}
+
+func (vp *VerificationPipeline) Name() string {
+	return "pipeline"
+}
+
+func (vp *VerificationPipeline) Verify(ctx context.Context, p *TaskPayload, output string, v *Verification) error {
+	result := &PipelineResult{Policy: vp.policy, Stages: make([]PipelineStage, len(vp.verifiers))}
+	passed := 0
+	for i, verifier := range vp.verifiers {
+		stage := newVerification()
+		if err := verifier.Verify(ctx, p, output, stage); err != nil {
+			return fmt.Errorf("%w: %s verifier: %w", ErrVerificationFailed, verifier.Name(), err)
+		}
+		v.mergeDetails(stage)
+		result.Stages[i] = PipelineStage{Verifier: verifier.Name(), Passed: stage.Passed, Reasons: stage.Reasons}
+		if stage.Passed {
+			passed++
+		}
+	}
+
+	var ok bool
+	switch vp.policy {
+	case pipelinePolicyAll:
+		ok = passed == len(vp.verifiers)
+	case pipelinePolicyAny:
+		ok = passed > 0
+	case pipelinePolicyThreshold:
+		ok = float64(passed)/float64(len(vp.verifiers)) >= vp.threshold
+	}
+	v.Pipeline = result
+	v.Check("pipeline", ok, "pipeline_policy_failed")
+	return nil
+}
+
+// verifyPipeline runs the verification pipeline of the task type, if any, on
+// the text output of a successful result and records it in the result's
+// verification.
+func (tw *TaskWorker) verifyPipeline(ctx context.Context, taskType string, p *TaskPayload, result map[string]interface{}) error {
+	pipeline := tw.pipelines[taskType]
+	if pipeline == nil {
+		return nil
+	}
+	if refused, _ := result["refused"].(bool); refused {
+		return nil
+	}
+	output, ok := result[taskOutputFields[taskType]].(string)
+	if !ok {
+		return nil
+	}
+	verification, ok := result["verified"].(*Verification)
+	if !ok {
+		return fmt.Errorf("%s results carry no verification", taskType)
+	}
+	ctx, cancel := withVerificationTimeout(ctx, tw.verifyTimeout)
+	defer cancel()
+	ctx, span := startSpan(ctx, "VerifyPipeline")
+	err := pipeline.Verify(ctx, p, output, verification)
+	endSpan(span, err)
+	return err
+}
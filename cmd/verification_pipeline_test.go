@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_VerificationPipeline(t *testing.T) {
+	// For the output "four", non_empty passes while json and contains fail.
+	verifiers := []Verifier{nonEmptyVerifier{}, jsonVerifier{}, containsVerifier{substring: "4"}}
+
+	tests := []struct {
+		name       string
+		policy     string
+		threshold  float64
+		wantPassed bool
+	}{
+		{name: "all", policy: pipelinePolicyAll},
+		{name: "default is all", policy: ""},
+		{name: "any", policy: pipelinePolicyAny, wantPassed: true},
+		{name: "threshold met", policy: pipelinePolicyThreshold, threshold: 0.3, wantPassed: true},
+		{name: "threshold not met", policy: pipelinePolicyThreshold, threshold: 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline, err := NewVerificationPipeline(tt.policy, tt.threshold, verifiers...)
+			if err != nil {
+				t.Fatalf("NewVerificationPipeline failed: %v", err)
+			}
+
+			v := newVerification()
+			if err := pipeline.Verify(context.Background(), &TaskPayload{}, "four", v); err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if v.Passed != tt.wantPassed || !reflect.DeepEqual(v.Checks, []string{"pipeline"}) {
+				t.Errorf("unexpected verification: %+v", v)
+			}
+			want := []PipelineStage{
+				{Verifier: "non_empty", Passed: true, Reasons: []string{}},
+				{Verifier: "json", Reasons: []string{"invalid_json"}},
+				{Verifier: "contains", Reasons: []string{"substring_not_found"}},
+			}
+			if !reflect.DeepEqual(v.Pipeline.Stages, want) {
+				t.Errorf("unexpected stages: %+v", v.Pipeline.Stages)
+			}
+		})
+	}
+}
+
+func Test_NewVerificationPipelineErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    string
+		threshold float64
+		verifiers []Verifier
+	}{
+		{name: "no verifiers", policy: pipelinePolicyAll},
+		{name: "unknown policy", policy: "majority", verifiers: []Verifier{nonEmptyVerifier{}}},
+		{name: "threshold without value", policy: pipelinePolicyThreshold, verifiers: []Verifier{nonEmptyVerifier{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewVerificationPipeline(tt.policy, tt.threshold, tt.verifiers...); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func Test_verificationPipelinesFromEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		wantTypes []string
+		wantErr   bool
+	}{
+		{
+			name:      "valid",
+			config:    "completion:\n  policy: any\n  verifiers: [json, non_empty]\nsummarize:\n  verifiers: [non_empty]\n",
+			wantTypes: []string{"completion", "summarize"},
+		},
+		{name: "unsupported task type", config: "embed:\n  verifiers: [non_empty]\n", wantErr: true},
+		{name: "unknown verifier", config: "completion:\n  verifiers: [oracle]\n", wantErr: true},
+		{name: "unknown field", config: "completion:\n  verifier: [json]\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "pipelines.yaml")
+			if err := os.WriteFile(path, []byte(tt.config), 0o600); err != nil {
+				t.Fatalf("failed to write pipelines: %v", err)
+			}
+			t.Setenv("VERIFICATION_PIPELINES_FILE", path)
+
+			pipelines, err := verificationPipelinesFromEnv(context.Background(), &stubProvider{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			for _, taskType := range tt.wantTypes {
+				if pipelines[taskType] == nil {
+					t.Errorf("expected a pipeline for %s", taskType)
+				}
+			}
+		})
+	}
+}
+
+func Test_HandleTaskVerificationPipeline(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "valid"}, nil)
+	pipeline, err := NewVerificationPipeline(pipelinePolicyAny, 0, jsonVerifier{}, containsVerifier{substring: "valid"})
+	if err != nil {
+		t.Fatalf("NewVerificationPipeline failed: %v", err)
+	}
+	tw.SetVerificationPipelines(map[string]*VerificationPipeline{taskTypeCompletion: pipeline})
+
+	resp, err := tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")})
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+	var result struct {
+		Verified Verification `json:"verified"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if !result.Verified.Passed || !reflect.DeepEqual(result.Verified.Checks, []string{"non_empty", "pipeline"}) || len(result.Verified.Pipeline.Stages) != 2 {
+		t.Errorf("unexpected verification: %+v", result.Verified)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func Test_VerificationCheck(t *testing.T) {
+	v := newVerification()
+	if v.Passed {
+		t.Errorf("expected a verification without checks not to pass")
+	}
+
+	v.Check("non_empty", true, "empty").Check("word_limit", false, "word_limit_exceeded")
+	if v.Passed || v.Confidence != 0.5 {
+		t.Errorf("unexpected verification: %+v", v)
+	}
+	if !reflect.DeepEqual(v.Checks, []string{"non_empty", "word_limit"}) || !reflect.DeepEqual(v.Reasons, []string{"word_limit_exceeded"}) {
+		t.Errorf("unexpected checks or reasons: %+v", v)
+	}
+
+	if v := newVerification().Check("syntax", true, "syntax_error"); !v.Passed || v.Confidence != 1 || len(v.Reasons) != 0 {
+		t.Errorf("unexpected verification: %+v", v)
+	}
+
+	if v := failedVerification(errorCodeProviderTimeout); v.Passed || !reflect.DeepEqual(v.Reasons, []string{errorCodeProviderTimeout}) {
+		t.Errorf("unexpected verification: %+v", v)
+	}
+}
+
+func Test_validateVerification(t *testing.T) {
+	tests := []struct {
+		name     string
+		verified string
+		wantErr  bool
+	}{
+		{name: "passed", verified: `{"passed":true,"confidence":1,"checks":["syntax"],"reasons":[]}`},
+		{name: "failed", verified: `{"passed":false,"confidence":0,"checks":["syntax"],"reasons":["syntax_error"]}`},
+		{name: "boolean", verified: `true`, wantErr: true},
+		{name: "confidence out of range", verified: `{"passed":true,"confidence":2,"checks":[],"reasons":[]}`, wantErr: true},
+		{name: "missing reasons", verified: `{"passed":true,"confidence":1,"checks":[]}`, wantErr: true},
+		{name: "non-string check", verified: `{"passed":true,"confidence":1,"checks":[1],"reasons":[]}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.verified), &value); err != nil {
+				t.Fatalf("invalid test value: %v", err)
+			}
+			if err := validateVerification(value); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
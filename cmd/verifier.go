@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Verifier checks the output of a completion task after the LLM call and
+// records the checks it ran in the result's verification.
+type Verifier interface {
+	Name() string
+	// Verify records its checks of output in v. An error means the output
+	// could not be checked, not that it failed a check.
+	Verify(ctx context.Context, p *TaskPayload, output string, v *Verification) error
+}
+
+// verifierFromEnv builds the verifiers listed in VERIFIERS, comma-separated
+// and run in order. Without VERIFIERS outputs are only checked to be
+// non-empty. provider is the completion provider, used by verifiers that
+// sample the prompt again.
+//
+//	non_empty         the output is not empty or whitespace only
+//	contains          the output contains VERIFIER_SUBSTRING
+//	pattern           the output matches the regular expression VERIFIER_PATTERN
+//	json              the output is valid JSON
+//	rules             the rules of VERIFIER_RULES_FILE, see ruleVerifierFromEnv
+//	similarity        the output is close to a reference embedding, see similarityVerifier
+//	judge             a second model grades the output, see judgeVerifierFromEnv
+//	fact_check        the output's claims are supported by evidence, see factCheckVerifierFromEnv
+//	self_consistency  samples of the prompt agree with the output, see consistencyVerifierFromEnv
+func verifierFromEnv(ctx context.Context, provider Provider) (Verifier, error) {
+	names := getenv("VERIFIERS")
+	if names == "" {
+		return nonEmptyVerifier{}, nil
+	}
+
+	var chain verifierChain
+	for _, name := range strings.Split(names, ",") {
+		v, err := newVerifierFromEnv(ctx, provider, strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, v)
+	}
+	if len(chain) == 1 {
+		return chain[0], nil
+	}
+	return chain, nil
+}
+
+// newVerifierFromEnv builds a single named verifier, see verifierFromEnv.
+func newVerifierFromEnv(ctx context.Context, provider Provider, name string) (Verifier, error) {
+	switch name {
+	case "non_empty":
+		return nonEmptyVerifier{}, nil
+	case "contains":
+		substring := getenv("VERIFIER_SUBSTRING")
+		if substring == "" {
+			return nil, fmt.Errorf("VERIFIER_SUBSTRING must be set for the contains verifier")
+		}
+		return containsVerifier{substring: substring}, nil
+	case "pattern":
+		pattern := getenv("VERIFIER_PATTERN")
+		if pattern == "" {
+			return nil, fmt.Errorf("VERIFIER_PATTERN must be set for the pattern verifier")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for VERIFIER_PATTERN: %w", err)
+		}
+		return patternVerifier{re: re}, nil
+	case "json":
+		return jsonVerifier{}, nil
+	case "rules":
+		return ruleVerifierFromEnv()
+	case "similarity":
+		return similarityVerifierFromEnv(ctx)
+	case "judge":
+		return judgeVerifierFromEnv(ctx)
+	case "fact_check":
+		return factCheckVerifierFromEnv(ctx, provider)
+	case "self_consistency":
+		return consistencyVerifierFromEnv(provider)
+	default:
+		return nil, fmt.Errorf("unsupported verifier %q", name)
+	}
+}
+
+// withVerificationTimeout returns ctx bounded by timeout, or ctx itself when
+// timeout is 0.
+func withVerificationTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// verifierChain runs several verifiers in order.
+type verifierChain []Verifier
+
+func (c verifierChain) Name() string {
+	names := make([]string, len(c))
+	for i, v := range c {
+		names[i] = v.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (c verifierChain) Verify(ctx context.Context, p *TaskPayload, output string, v *Verification) error {
+	for _, verifier := range c {
+		if err := verifier.Verify(ctx, p, output, v); err != nil {
+			return fmt.Errorf("%w: %s verifier: %w", ErrVerificationFailed, verifier.Name(), err)
+		}
+	}
+	return nil
+}
+
+type nonEmptyVerifier struct{}
+
+func (nonEmptyVerifier) Name() string {
+	return "non_empty"
+}
+
+func (nonEmptyVerifier) Verify(ctx context.Context, p *TaskPayload, output string, v *Verification) error {
+	v.Check("non_empty", strings.TrimSpace(output) != "", "empty_output")
+	return nil
+}
+
+type containsVerifier struct {
+	substring string
+}
+
+func (containsVerifier) Name() string {
+	return "contains"
+}
+
+func (c containsVerifier) Verify(ctx context.Context, p *TaskPayload, output string, v *Verification) error {
+	v.Check("contains", strings.Contains(output, c.substring), "substring_not_found")
+	return nil
+}
+
+type patternVerifier struct {
+	re *regexp.Regexp
+}
+
+func (patternVerifier) Name() string {
+	return "pattern"
+}
+
+func (pv patternVerifier) Verify(ctx context.Context, p *TaskPayload, output string, v *Verification) error {
+	v.Check("pattern", pv.re.MatchString(output), "pattern_mismatch")
+	return nil
+}
+
+type jsonVerifier struct{}
+
+func (jsonVerifier) Name() string {
+	return "json"
+}
+
+func (jsonVerifier) Verify(ctx context.Context, p *TaskPayload, output string, v *Verification) error {
+	v.Check("json", json.Valid([]byte(strings.TrimSpace(output))), "invalid_json")
+	return nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rule types supported in a verification rules file.
+const (
+	ruleRegex     = "regex"
+	ruleContains  = "contains"
+	ruleRange     = "range"
+	ruleMaxLength = "max_length"
+)
+
+// numberPattern finds the number checked by range rules.
+var numberPattern = regexp.MustCompile(`[-+]?\d+(?:\.\d+)?`)
+
+// VerificationRule is one check of a rules file. Which fields apply depends
+// on the type:
+//
+//	regex       the output matches pattern
+//	contains    the output contains every one of substrings
+//	range       the first number in the output lies within min and max
+//	max_length  the output has at most max characters
+type VerificationRule struct {
+	Name       string   `yaml:"name"`
+	Type       string   `yaml:"type"`
+	Pattern    string   `yaml:"pattern"`
+	Substrings []string `yaml:"substrings"`
+	Min        *float64 `yaml:"min"`
+	Max        *float64 `yaml:"max"`
+
+	re *regexp.Regexp
+}
+
+// ruleVerifier checks outputs against the operator's rules without a second
+// LLM call.
+type ruleVerifier struct {
+	rules []VerificationRule
+}
+
+// ruleVerifierFromEnv loads the rules from the YAML file named by
+// VERIFIER_RULES_FILE, for example:
+//
+//	rules:
+//	  - name: mentions_city
+//	    type: regex
+//	    pattern: "(?i)paris|london"
+//	  - type: range
+//	    min: 0
+//	    max: 100
+//	  - type: max_length
+//	    max: 280
+func ruleVerifierFromEnv() (*ruleVerifier, error) {
+	path := getenv("VERIFIER_RULES_FILE")
+	if path == "" {
+		return nil, fmt.Errorf("VERIFIER_RULES_FILE must be set for the rules verifier")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read verification rules: %w", err)
+	}
+	return newRuleVerifier(data)
+}
+
+func newRuleVerifier(data []byte) (*ruleVerifier, error) {
+	var file struct {
+		Rules []VerificationRule `yaml:"rules"`
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid verification rules: %w", err)
+	}
+	if len(file.Rules) == 0 {
+		return nil, fmt.Errorf("invalid verification rules: no rules defined")
+	}
+
+	for i := range file.Rules {
+		r := &file.Rules[i]
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("%s_%d", r.Type, i)
+		}
+		if err := r.compile(); err != nil {
+			return nil, fmt.Errorf("verification rule %s: %w", r.Name, err)
+		}
+	}
+	return &ruleVerifier{rules: file.Rules}, nil
+}
+
+// compile checks the rule's fields and compiles its pattern.
+func (r *VerificationRule) compile() error {
+	switch r.Type {
+	case ruleRegex:
+		if r.Pattern == "" {
+			return fmt.Errorf("regex rules need a pattern")
+		}
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+		r.re = re
+	case ruleContains:
+		if len(r.Substrings) == 0 {
+			return fmt.Errorf("contains rules need substrings")
+		}
+	case ruleRange:
+		if r.Min == nil && r.Max == nil {
+			return fmt.Errorf("range rules need a min or max")
+		}
+		if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+			return fmt.Errorf("min must not be greater than max")
+		}
+	case ruleMaxLength:
+		if r.Max == nil || *r.Max < 0 {
+			return fmt.Errorf("max_length rules need a max of at least 0")
+		}
+	default:
+		return fmt.Errorf("unsupported rule type %q, expected one of: %s", r.Type,
+			strings.Join([]string{ruleRegex, ruleContains, ruleRange, ruleMaxLength}, ", "))
+	}
+	return nil
+}
+
+// check evaluates the rule against output and returns the reason it failed.
+func (r *VerificationRule) check(output string) (bool, string) {
+	switch r.Type {
+	case ruleRegex:
+		return r.re.MatchString(output), "pattern_mismatch"
+	case ruleContains:
+		for _, s := range r.Substrings {
+			if !strings.Contains(output, s) {
+				return false, "substring_not_found"
+			}
+		}
+		return true, ""
+	case ruleRange:
+		n, err := strconv.ParseFloat(numberPattern.FindString(output), 64)
+		if err != nil {
+			return false, "number_not_found"
+		}
+		inRange := (r.Min == nil || n >= *r.Min) && (r.Max == nil || n <= *r.Max)
+		return inRange, "out_of_range"
+	case ruleMaxLength:
+		return float64(utf8.RuneCountInString(output)) <= *r.Max, "too_long"
+	}
+	return false, "unsupported_rule"
+}
+
+func (*ruleVerifier) Name() string {
+	return "rules"
+}
+
+func (rv *ruleVerifier) Verify(ctx context.Context, p *TaskPayload, output string, v *Verification) error {
+	for i := range rv.rules {
+		passed, reason := rv.rules[i].check(output)
+		v.Check(rv.rules[i].Name, passed, reason)
+	}
+	return nil
+}
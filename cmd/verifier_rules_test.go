@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testVerificationRules = `
+rules:
+  - name: mentions_answer
+    type: regex
+    pattern: "(?i)answer"
+  - type: contains
+    substrings: ["is", "."]
+  - type: range
+    min: 0
+    max: 100
+  - type: max_length
+    max: 40
+`
+
+func Test_newRuleVerifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		wantErr bool
+	}{
+		{name: "valid", rules: testVerificationRules},
+		{name: "no rules", rules: "rules: []", wantErr: true},
+		{name: "unknown type", rules: "rules: [{type: oracle}]", wantErr: true},
+		{name: "unknown field", rules: "rules: [{type: regex, pattern: a, regexp: b}]", wantErr: true},
+		{name: "invalid pattern", rules: "rules: [{type: regex, pattern: '('}]", wantErr: true},
+		{name: "contains without substrings", rules: "rules: [{type: contains}]", wantErr: true},
+		{name: "range without bounds", rules: "rules: [{type: range}]", wantErr: true},
+		{name: "inverted range", rules: "rules: [{type: range, min: 5, max: 1}]", wantErr: true},
+		{name: "max_length without max", rules: "rules: [{type: max_length}]", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newRuleVerifier([]byte(tt.rules)); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_ruleVerifier(t *testing.T) {
+	verifier, err := newRuleVerifier([]byte(testVerificationRules))
+	if err != nil {
+		t.Fatalf("newRuleVerifier failed: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		output      string
+		wantReasons []string
+	}{
+		{name: "passes", output: "The answer is 42.", wantReasons: []string{}},
+		{name: "no match", output: "It is 42.", wantReasons: []string{"pattern_mismatch"}},
+		{name: "out of range", output: "The answer is 420.", wantReasons: []string{"out_of_range"}},
+		{name: "no number", output: "The answer is many.", wantReasons: []string{"number_not_found"}},
+		{name: "too long", output: "The answer, after a long deliberation, is 42.", wantReasons: []string{"too_long"}},
+		{name: "several failures", output: "Answer: 420", wantReasons: []string{"substring_not_found", "out_of_range"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newVerification()
+			if err := verifier.Verify(context.Background(), &TaskPayload{}, tt.output, v); err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if !reflect.DeepEqual(v.Reasons, tt.wantReasons) || v.Passed != (len(tt.wantReasons) == 0) {
+				t.Errorf("unexpected verification: %+v", v)
+			}
+			if !reflect.DeepEqual(v.Checks, []string{"mentions_answer", "contains_1", "range_2", "max_length_3"}) {
+				t.Errorf("unexpected checks: %v", v.Checks)
+			}
+		})
+	}
+}
+
+func Test_ruleVerifierFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte(testVerificationRules), 0o600); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+	t.Setenv("VERIFIERS", "non_empty,rules")
+	t.Setenv("VERIFIER_RULES_FILE", path)
+
+	v, err := verifierFromEnv(context.Background(), &stubProvider{})
+	if err != nil {
+		t.Fatalf("verifierFromEnv failed: %v", err)
+	}
+	if v.Name() != "non_empty,rules" {
+		t.Errorf("unexpected verifier: %s", v.Name())
+	}
+
+	t.Setenv("VERIFIER_RULES_FILE", "")
+	if _, err := verifierFromEnv(context.Background(), &stubProvider{}); err == nil {
+		t.Errorf("expected error without VERIFIER_RULES_FILE")
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_verifierFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		wantName string
+		wantErr  bool
+	}{
+		{name: "default", wantName: "non_empty"},
+		{name: "single", env: map[string]string{"VERIFIERS": "json"}, wantName: "json"},
+		{name: "chain", env: map[string]string{"VERIFIERS": "non_empty, contains", "VERIFIER_SUBSTRING": "42"}, wantName: "non_empty,contains"},
+		{name: "contains without substring", env: map[string]string{"VERIFIERS": "contains"}, wantErr: true},
+		{name: "invalid pattern", env: map[string]string{"VERIFIERS": "pattern", "VERIFIER_PATTERN": "("}, wantErr: true},
+		{name: "unknown", env: map[string]string{"VERIFIERS": "oracle"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"VERIFIERS", "VERIFIER_SUBSTRING", "VERIFIER_PATTERN"} {
+				t.Setenv(key, tt.env[key])
+			}
+			v, err := verifierFromEnv(context.Background(), &stubProvider{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && v.Name() != tt.wantName {
+				t.Errorf("expected verifier %s, got %s", tt.wantName, v.Name())
+			}
+		})
+	}
+}
+
+func Test_Verifiers(t *testing.T) {
+	tests := []struct {
+		name        string
+		verifier    Verifier
+		output      string
+		wantReasons []string
+	}{
+		{name: "non-empty", verifier: nonEmptyVerifier{}, output: "Paris", wantReasons: []string{}},
+		{name: "empty", verifier: nonEmptyVerifier{}, output: "  ", wantReasons: []string{"empty_output"}},
+		{name: "contains", verifier: containsVerifier{substring: "42"}, output: "The answer is 42.", wantReasons: []string{}},
+		{name: "does not contain", verifier: containsVerifier{substring: "42"}, output: "The answer is 41.", wantReasons: []string{"substring_not_found"}},
+		{name: "json", verifier: jsonVerifier{}, output: ` {"answer": 4} `, wantReasons: []string{}},
+		{name: "not json", verifier: jsonVerifier{}, output: "four", wantReasons: []string{"invalid_json"}},
+		{
+			name:        "chain",
+			verifier:    verifierChain{nonEmptyVerifier{}, jsonVerifier{}},
+			output:      "four",
+			wantReasons: []string{"invalid_json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newVerification()
+			if err := tt.verifier.Verify(context.Background(), &TaskPayload{}, tt.output, v); err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if !reflect.DeepEqual(v.Reasons, tt.wantReasons) || v.Passed != (len(tt.wantReasons) == 0) {
+				t.Errorf("unexpected verification: %+v", v)
+			}
+		})
+	}
+}
+
+func Test_HandleTaskUsesVerifier(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "valid"}, nil)
+	tw.SetVerifier(jsonVerifier{})
+
+	resp, err := tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")})
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+	var result struct {
+		Verified Verification `json:"verified"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if result.Verified.Passed || !reflect.DeepEqual(result.Verified.Checks, []string{"json"}) {
+		t.Errorf("unexpected verification: %+v", result.Verified)
+	}
+}
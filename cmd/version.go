@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// The build of the performer, set at build time with
+//
+//	-ldflags "-X main.performerVersion=<version> -X main.gitCommit=<sha> -X main.buildTime=<RFC 3339 time>"
+//
+// The commit and build time default to the VCS information Go embeds in
+// binaries built from a checkout.
+var (
+	performerVersion = "dev"
+	gitCommit        = ""
+	buildTime        = ""
+)
+
+// errVersionRequested is returned by loadConfig when -version was passed and
+// the version was printed.
+var errVersionRequested = errors.New("version requested")
+
+// BuildInfo identifies the build of the performer, so aggregators can detect
+// operators running stale or divergent builds.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// currentBuildInfo returns the build of the running performer.
+func currentBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:   performerVersion,
+		Commit:    gitCommit,
+		BuildTime: buildTime,
+		GoVersion: runtime.Version(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		modified := false
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = s.Value
+				}
+			case "vcs.modified":
+				modified = s.Value == "true"
+			}
+		}
+		if modified && gitCommit == "" && info.Commit != "" {
+			info.Commit += "-dirty"
+		}
+	}
+	return info
+}
+
+func (b BuildInfo) String() string {
+	s := "performer " + b.Version
+	if b.Commit != "" {
+		s += " (commit " + b.Commit
+		if b.BuildTime != "" {
+			s += ", built " + b.BuildTime
+		}
+		s += ")"
+	}
+	return fmt.Sprintf("%s %s", s, b.GoVersion)
+}
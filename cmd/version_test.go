@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"runtime"
+	"testing"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+func Test_currentBuildInfo(t *testing.T) {
+	saved := [3]string{performerVersion, gitCommit, buildTime}
+	t.Cleanup(func() { performerVersion, gitCommit, buildTime = saved[0], saved[1], saved[2] })
+	performerVersion, gitCommit, buildTime = "v1.2.0", "abc123", "2025-05-16T16:05:57Z"
+
+	got := currentBuildInfo()
+	want := BuildInfo{Version: "v1.2.0", Commit: "abc123", BuildTime: "2025-05-16T16:05:57Z", GoVersion: runtime.Version()}
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func Test_BuildInfoString(t *testing.T) {
+	tests := []struct {
+		name string
+		info BuildInfo
+		want string
+	}{
+		{
+			name: "full",
+			info: BuildInfo{Version: "v1.2.0", Commit: "abc123", BuildTime: "2025-05-16T16:05:57Z", GoVersion: "go1.23.6"},
+			want: "performer v1.2.0 (commit abc123, built 2025-05-16T16:05:57Z) go1.23.6",
+		},
+		{
+			name: "without build time",
+			info: BuildInfo{Version: "v1.2.0", Commit: "abc123", GoVersion: "go1.23.6"},
+			want: "performer v1.2.0 (commit abc123) go1.23.6",
+		},
+		{
+			name: "without commit",
+			info: BuildInfo{Version: "dev", GoVersion: "go1.23.6"},
+			want: "performer dev go1.23.6",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.info.String(); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_loadConfigVersion(t *testing.T) {
+	withConfigSettings(t)
+	if err := loadConfig([]string{"-version"}); !errors.Is(err, errVersionRequested) {
+		t.Errorf("expected errVersionRequested, got %v", err)
+	}
+}
+
+func Test_HandleTaskPerformerVersion(t *testing.T) {
+	tw := NewTaskWorker(zap.NewNop(), &stubProvider{output: "4"}, nil)
+
+	resp, err := tw.HandleTask(&performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")})
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+	var result struct {
+		Metadata map[string]interface{} `json:"metadata"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if result.Metadata["performer_version"] != performerVersion {
+		t.Errorf("expected the performer version in the result metadata, got %v", result.Metadata)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// taskDurationWeight is the weight of the latest task in the moving average
+// of task durations.
+const taskDurationWeight = 0.2
+
+// ErrWorkerPoolFull is returned for tasks the worker pool has no room for.
+var ErrWorkerPoolFull = errors.New("performer is at capacity")
+
+// WorkerPool bounds the tasks handled at once. Tasks beyond the concurrency
+// wait in a queue of bounded depth, and are rejected when it is full or their
+// deadline passes while waiting, so a burst of tasks cannot start unbounded
+// concurrent provider calls.
+//
+// Admission is deadline-aware: the pool keeps a moving average of how long
+// tasks take, and a task that would not finish before its deadline behind
+// the tasks queued ahead of it is rejected at once instead of waiting only to
+// time out. When the provider slows down, the queue sheds the tasks it cannot
+// serve in time and the rest still finish. Tasks wait at most maxWait.
+type WorkerPool struct {
+	// admitted holds a token for every running or queued task.
+	admitted chan struct{}
+	// running holds a token for every running task.
+	running chan struct{}
+	// maxWait bounds the time a task waits for a worker; 0 waits until its
+	// deadline.
+	maxWait time.Duration
+	now     func() time.Time
+
+	mu sync.Mutex
+	// avgDuration is the moving average of task durations.
+	avgDuration time.Duration
+}
+
+// workerPoolFromEnv configures the pool from WORKER_POOL_SIZE, the tasks
+// handled at once, WORKER_POOL_QUEUE, the tasks waiting for a worker beyond
+// them (none by default), and WORKER_POOL_MAX_WAIT, the longest a task waits.
+// It returns nil when the size is unset.
+func workerPoolFromEnv() (*WorkerPool, error) {
+	size, err := envInt("WORKER_POOL_SIZE")
+	if err != nil {
+		return nil, err
+	}
+	queue, err := envInt("WORKER_POOL_QUEUE")
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 || queue < 0 {
+		return nil, fmt.Errorf("WORKER_POOL_SIZE and WORKER_POOL_QUEUE must not be negative")
+	}
+	if size == 0 {
+		return nil, nil
+	}
+	p := NewWorkerPool(size, queue)
+	if p.maxWait, err = envDuration("WORKER_POOL_MAX_WAIT"); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func NewWorkerPool(size, queue int) *WorkerPool {
+	return &WorkerPool{admitted: make(chan struct{}, size+queue), running: make(chan struct{}, size), now: time.Now}
+}
+
+// Size returns the number of tasks handled at once.
+func (p *WorkerPool) Size() int {
+	return cap(p.running)
+}
+
+// Acquire waits for a worker and returns the function releasing it. It fails
+// at once when the queue is full or the task would not finish before the
+// deadline of ctx, and when the wait exceeds maxWait or ctx ends.
+func (p *WorkerPool) Acquire(ctx context.Context) (func(), error) {
+	select {
+	case p.admitted <- struct{}{}:
+	default:
+		workerPoolRejected.Inc()
+		return nil, fmt.Errorf("%w: %d tasks running and %d queued", ErrWorkerPoolFull, cap(p.running), cap(p.admitted)-cap(p.running))
+	}
+	if err := p.admit(ctx, len(p.admitted)-1); err != nil {
+		<-p.admitted
+		workerPoolRejected.Inc()
+		return nil, err
+	}
+	workerPoolQueued.Inc()
+	defer workerPoolQueued.Dec()
+
+	waitCtx := ctx
+	if p.maxWait > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, p.maxWait)
+		defer cancel()
+	}
+	queued := p.now()
+	select {
+	case p.running <- struct{}{}:
+	case <-waitCtx.Done():
+		<-p.admitted
+		workerPoolRejected.Inc()
+		return nil, fmt.Errorf("%w: no worker became free in time", ErrWorkerPoolFull)
+	}
+	started := p.now()
+	workerPoolWait.Observe(started.Sub(queued).Seconds())
+	workerPoolActive.Inc()
+	return func() {
+		p.observe(p.now().Sub(started))
+		workerPoolActive.Dec()
+		<-p.running
+		<-p.admitted
+	}, nil
+}
+
+// admit rejects a task that, behind the given number of tasks, is not
+// expected to finish before the deadline of ctx. Tasks are admitted while
+// there is no estimate yet.
+func (p *WorkerPool) admit(ctx context.Context, ahead int) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	p.mu.Lock()
+	avg := p.avgDuration
+	p.mu.Unlock()
+	if avg == 0 {
+		return nil
+	}
+
+	// Every worker frees up once per average task duration, so the task
+	// starts after the rounds needed to serve the tasks queued ahead of it.
+	// Tasks that start at once are always admitted, which keeps the average
+	// current even when the provider is slower than the deadline.
+	size := cap(p.running)
+	if ahead < size {
+		return nil
+	}
+	rounds := (ahead-size)/size + 1
+	if late := p.now().Add(time.Duration(rounds+1) * avg).Sub(deadline); late > 0 {
+		return fmt.Errorf("%w: %d tasks ahead, expected to miss the deadline by %s", ErrWorkerPoolFull, ahead, late.Round(time.Millisecond))
+	}
+	return nil
+}
+
+// observe adds the duration of a finished task to the moving average.
+func (p *WorkerPool) observe(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.avgDuration == 0 {
+		p.avgDuration = d
+		return
+	}
+	p.avgDuration = time.Duration(taskDurationWeight*float64(d) + (1-taskDurationWeight)*float64(p.avgDuration))
+}
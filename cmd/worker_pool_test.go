@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	performerV1 "github.com/Layr-Labs/protocol-apis/gen/protos/eigenlayer/hourglass/v1/performer"
+	"go.uber.org/zap"
+)
+
+// blockingProvider answers once release is closed, and reports each started
+// completion on started.
+type blockingProvider struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingProvider) Name() string { return "blocking" }
+
+func (p *blockingProvider) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	p.started <- struct{}{}
+	<-p.release
+	return &CompletionResponse{Output: "4"}, nil
+}
+
+func Test_WorkerPoolAcquire(t *testing.T) {
+	p := NewWorkerPool(1, 1)
+	ctx := context.Background()
+
+	release, err := p.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("first task rejected: %v", err)
+	}
+
+	// The second task waits in the queue until the first is done.
+	acquired := make(chan func())
+	go func() {
+		r, err := p.Acquire(ctx)
+		if err != nil {
+			t.Errorf("queued task rejected: %v", err)
+		}
+		acquired <- r
+	}()
+	for len(p.admitted) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := p.Acquire(ctx); !errors.Is(err, ErrWorkerPoolFull) {
+		t.Errorf("expected the third task to be rejected, got %v", err)
+	}
+	release()
+	(<-acquired)()
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	release, _ = p.Acquire(ctx)
+	if _, err := p.Acquire(timeoutCtx); !errors.Is(err, ErrWorkerPoolFull) {
+		t.Errorf("expected the queued task to time out, got %v", err)
+	}
+	release()
+	if len(p.admitted) != 0 || len(p.running) != 0 {
+		t.Errorf("expected an idle pool, got %d admitted and %d running", len(p.admitted), len(p.running))
+	}
+}
+
+func Test_WorkerPoolDeadlineAdmission(t *testing.T) {
+	now := time.Date(2024, 10, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		avg      time.Duration
+		running  int
+		deadline time.Duration
+		wantErr  bool
+	}{
+		{name: "no estimate yet", running: 1, deadline: time.Second},
+		{name: "worker free", avg: time.Minute, deadline: time.Second},
+		{name: "finishes in time", avg: time.Second, running: 1, deadline: 3 * time.Second},
+		{name: "would miss the deadline", avg: 2 * time.Second, running: 1, deadline: 3 * time.Second, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewWorkerPool(1, 1)
+			p.now = func() time.Time { return now }
+			p.avgDuration = tt.avg
+			for i := 0; i < tt.running; i++ {
+				p.admitted <- struct{}{}
+				p.running <- struct{}{}
+			}
+
+			ctx, cancel := context.WithDeadline(context.Background(), now.Add(tt.deadline))
+			defer cancel()
+			err := p.admit(ctx, tt.running)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrWorkerPoolFull) {
+				t.Errorf("expected ErrWorkerPoolFull, got %v", err)
+			}
+		})
+	}
+}
+
+func Test_WorkerPoolMaxWait(t *testing.T) {
+	p := NewWorkerPool(1, 1)
+	p.maxWait = 10 * time.Millisecond
+	release, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("first task rejected: %v", err)
+	}
+	defer release()
+
+	if _, err := p.Acquire(context.Background()); !errors.Is(err, ErrWorkerPoolFull) {
+		t.Errorf("expected the queued task to give up after the max wait, got %v", err)
+	}
+}
+
+func Test_WorkerPoolObserve(t *testing.T) {
+	p := NewWorkerPool(1, 0)
+	p.observe(time.Second)
+	p.observe(2 * time.Second)
+	if want := 1200 * time.Millisecond; p.avgDuration != want {
+		t.Errorf("expected an average of %s, got %s", want, p.avgDuration)
+	}
+}
+
+func Test_workerPoolFromEnv(t *testing.T) {
+	tests := []struct {
+		name         string
+		size         string
+		queue        string
+		maxWait      string
+		wantSize     int
+		wantAdmitted int
+		wantMaxWait  time.Duration
+		wantNil      bool
+		wantErr      bool
+	}{
+		{name: "unset", wantNil: true},
+		{name: "size", size: "4", wantSize: 4, wantAdmitted: 4},
+		{name: "size and queue", size: "4", queue: "16", wantSize: 4, wantAdmitted: 20},
+		{name: "max wait", size: "4", queue: "16", maxWait: "5s", wantSize: 4, wantAdmitted: 20, wantMaxWait: 5 * time.Second},
+		{name: "invalid max wait", size: "4", maxWait: "soon", wantErr: true},
+		{name: "negative", size: "-1", wantErr: true},
+		{name: "invalid", size: "many", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WORKER_POOL_SIZE", tt.size)
+			t.Setenv("WORKER_POOL_QUEUE", tt.queue)
+			t.Setenv("WORKER_POOL_MAX_WAIT", tt.maxWait)
+			p, err := workerPoolFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (p == nil) != tt.wantNil {
+				t.Fatalf("expected nil pool %v, got %+v", tt.wantNil, p)
+			}
+			if p != nil && (cap(p.running) != tt.wantSize || cap(p.admitted) != tt.wantAdmitted || p.maxWait != tt.wantMaxWait) {
+				t.Errorf("unexpected pool %d/%d, max wait %s", cap(p.running), cap(p.admitted), p.maxWait)
+			}
+		})
+	}
+}
+
+func Test_HandleTaskWorkerPoolFull(t *testing.T) {
+	provider := &blockingProvider{started: make(chan struct{}), release: make(chan struct{})}
+	tw := NewTaskWorker(zap.NewNop(), provider, nil)
+	tw.SetInjectionDetector(nil)
+	tw.SetWorkerPool(NewWorkerPool(1, 0))
+	task := &performerV1.TaskRequest{TaskId: []byte("task-1"), Payload: []byte("What is 2+2?")}
+
+	done := make(chan error)
+	go func() {
+		_, err := tw.HandleTask(task)
+		done <- err
+	}()
+	<-provider.started
+
+	resp, err := tw.HandleTask(task)
+	if err != nil {
+		t.Fatalf("HandleTask failed: %v", err)
+	}
+	var result struct {
+		ErrorCode string `json:"error_code"`
+		Retryable bool   `json:"retryable"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if result.ErrorCode != errorCodeOverloaded || !result.Retryable {
+		t.Errorf("expected a retryable overloaded result, got %s", resp.Result)
+	}
+
+	close(provider.release)
+	if err := <-done; err != nil {
+		t.Errorf("first task failed: %v", err)
+	}
+}
@@ -0,0 +1,261 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: avs/v1/task/task.proto
+
+package task
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// TaskPayload is the protobuf form of the performer's JSON task payload. Set
+// PAYLOAD_ENCODING=protobuf on the performer to accept it.
+type TaskPayload struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The user prompt. Required for completion tasks.
+	Prompt string `protobuf:"bytes,1,opt,name=prompt,proto3" json:"prompt,omitempty"`
+	// Optional system prompt.
+	System string `protobuf:"bytes,2,opt,name=system,proto3" json:"system,omitempty"`
+	// The task type, "completion" when empty.
+	TaskType string            `protobuf:"bytes,3,opt,name=task_type,json=taskType,proto3" json:"task_type,omitempty"`
+	Params   *GenerationParams `protobuf:"bytes,4,opt,name=params,proto3" json:"params,omitempty"`
+	// Requested provider and model, honoured only when the operator's
+	// allowlist permits them.
+	Provider string `protobuf:"bytes,5,opt,name=provider,proto3" json:"provider,omitempty"`
+	Model    string `protobuf:"bytes,6,opt,name=model,proto3" json:"model,omitempty"`
+	// Free-form metadata, e.g. {"critical": true}.
+	Metadata *structpb.Struct `protobuf:"bytes,7,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// Fields specific to the task type, e.g. {"document": "..."} for summarize
+	// tasks.
+	Fields        *structpb.Struct `protobuf:"bytes,8,opt,name=fields,proto3" json:"fields,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskPayload) Reset() {
+	*x = TaskPayload{}
+	mi := &file_avs_v1_task_task_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskPayload) ProtoMessage() {}
+
+func (x *TaskPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_avs_v1_task_task_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskPayload.ProtoReflect.Descriptor instead.
+func (*TaskPayload) Descriptor() ([]byte, []int) {
+	return file_avs_v1_task_task_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TaskPayload) GetPrompt() string {
+	if x != nil {
+		return x.Prompt
+	}
+	return ""
+}
+
+func (x *TaskPayload) GetSystem() string {
+	if x != nil {
+		return x.System
+	}
+	return ""
+}
+
+func (x *TaskPayload) GetTaskType() string {
+	if x != nil {
+		return x.TaskType
+	}
+	return ""
+}
+
+func (x *TaskPayload) GetParams() *GenerationParams {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *TaskPayload) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *TaskPayload) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *TaskPayload) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *TaskPayload) GetFields() *structpb.Struct {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+type GenerationParams struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 1..1024, the performer's default when zero.
+	MaxTokens uint32 `protobuf:"varint,1,opt,name=max_tokens,json=maxTokens,proto3" json:"max_tokens,omitempty"`
+	// 0..2, the performer's default when unset.
+	Temperature   *float64 `protobuf:"fixed64,2,opt,name=temperature,proto3,oneof" json:"temperature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerationParams) Reset() {
+	*x = GenerationParams{}
+	mi := &file_avs_v1_task_task_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerationParams) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerationParams) ProtoMessage() {}
+
+func (x *GenerationParams) ProtoReflect() protoreflect.Message {
+	mi := &file_avs_v1_task_task_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerationParams.ProtoReflect.Descriptor instead.
+func (*GenerationParams) Descriptor() ([]byte, []int) {
+	return file_avs_v1_task_task_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GenerationParams) GetMaxTokens() uint32 {
+	if x != nil {
+		return x.MaxTokens
+	}
+	return 0
+}
+
+func (x *GenerationParams) GetTemperature() float64 {
+	if x != nil && x.Temperature != nil {
+		return *x.Temperature
+	}
+	return 0
+}
+
+var File_avs_v1_task_task_proto protoreflect.FileDescriptor
+
+const file_avs_v1_task_task_proto_rawDesc = "" +
+	"\n" +
+	"\x16avs/v1/task/task.proto\x12\vavs.v1.task\x1a\x1cgoogle/protobuf/struct.proto\"\xa9\x02\n" +
+	"\vTaskPayload\x12\x16\n" +
+	"\x06prompt\x18\x01 \x01(\tR\x06prompt\x12\x16\n" +
+	"\x06system\x18\x02 \x01(\tR\x06system\x12\x1b\n" +
+	"\ttask_type\x18\x03 \x01(\tR\btaskType\x125\n" +
+	"\x06params\x18\x04 \x01(\v2\x1d.avs.v1.task.GenerationParamsR\x06params\x12\x1a\n" +
+	"\bprovider\x18\x05 \x01(\tR\bprovider\x12\x14\n" +
+	"\x05model\x18\x06 \x01(\tR\x05model\x123\n" +
+	"\bmetadata\x18\a \x01(\v2\x17.google.protobuf.StructR\bmetadata\x12/\n" +
+	"\x06fields\x18\b \x01(\v2\x17.google.protobuf.StructR\x06fields\"h\n" +
+	"\x10GenerationParams\x12\x1d\n" +
+	"\n" +
+	"max_tokens\x18\x01 \x01(\rR\tmaxTokens\x12%\n" +
+	"\vtemperature\x18\x02 \x01(\x01H\x00R\vtemperature\x88\x01\x01B\x0e\n" +
+	"\f_temperatureBDZBgithub.com/Layr-Labs/hourglass-avs-template/gen/protos/avs/v1/taskb\x06proto3"
+
+var (
+	file_avs_v1_task_task_proto_rawDescOnce sync.Once
+	file_avs_v1_task_task_proto_rawDescData []byte
+)
+
+func file_avs_v1_task_task_proto_rawDescGZIP() []byte {
+	file_avs_v1_task_task_proto_rawDescOnce.Do(func() {
+		file_avs_v1_task_task_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_avs_v1_task_task_proto_rawDesc), len(file_avs_v1_task_task_proto_rawDesc)))
+	})
+	return file_avs_v1_task_task_proto_rawDescData
+}
+
+var file_avs_v1_task_task_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_avs_v1_task_task_proto_goTypes = []any{
+	(*TaskPayload)(nil),      // 0: avs.v1.task.TaskPayload
+	(*GenerationParams)(nil), // 1: avs.v1.task.GenerationParams
+	(*structpb.Struct)(nil),  // 2: google.protobuf.Struct
+}
+var file_avs_v1_task_task_proto_depIdxs = []int32{
+	1, // 0: avs.v1.task.TaskPayload.params:type_name -> avs.v1.task.GenerationParams
+	2, // 1: avs.v1.task.TaskPayload.metadata:type_name -> google.protobuf.Struct
+	2, // 2: avs.v1.task.TaskPayload.fields:type_name -> google.protobuf.Struct
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_avs_v1_task_task_proto_init() }
+func file_avs_v1_task_task_proto_init() {
+	if File_avs_v1_task_task_proto != nil {
+		return
+	}
+	file_avs_v1_task_task_proto_msgTypes[1].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_avs_v1_task_task_proto_rawDesc), len(file_avs_v1_task_task_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_avs_v1_task_task_proto_goTypes,
+		DependencyIndexes: file_avs_v1_task_task_proto_depIdxs,
+		MessageInfos:      file_avs_v1_task_task_proto_msgTypes,
+	}.Build()
+	File_avs_v1_task_task_proto = out.File
+	file_avs_v1_task_task_proto_goTypes = nil
+	file_avs_v1_task_task_proto_depIdxs = nil
+}